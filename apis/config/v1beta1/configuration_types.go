@@ -41,6 +41,8 @@ type Configuration struct {
 	// jobs created before starting the kueue controller.
 	// Defaults to false; therefore, those jobs are not managed and if they are created
 	// unsuspended, they will start immediately.
+	// This setting doesn't apply to plain Pods: the Pod webhooks only intercept
+	// the Pods that have the kueue.x-k8s.io/queue-name label.
 	ManageJobsWithoutQueueName bool `json:"manageJobsWithoutQueueName"`
 
 	// InternalCertManagement is configuration for internalCertManagement
@@ -59,6 +61,18 @@ type Configuration struct {
 	// Integrations provide configuration options for AI/ML/Batch frameworks
 	// integrations (including K8S job).
 	Integrations *Integrations `json:"integrations,omitempty"`
+
+	// FairSharing controls the fair sharing semantics across the cluster.
+	FairSharing *FairSharing `json:"fairSharing,omitempty"`
+
+	// Visibility controls the endpoints that list the pending workloads of
+	// the ClusterQueues and LocalQueues.
+	Visibility *Visibility `json:"visibility,omitempty"`
+
+	// MultiKueue controls the dispatching of the Workloads to worker
+	// clusters by the AdmissionChecks with the kueue.x-k8s.io/multikueue
+	// controllerName.
+	MultiKueue *MultiKueue `json:"multiKueue,omitempty"`
 }
 
 type WaitForPodsReady struct {
@@ -74,6 +88,34 @@ type WaitForPodsReady struct {
 	// is cancelled and requeued in the same cluster queue. Defaults to 5min.
 	// +optional
 	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// RequeuingStrategy defines how the workloads evicted because of
+	// exceeding the Timeout are requeued.
+	// +optional
+	RequeuingStrategy *RequeuingStrategy `json:"requeuingStrategy,omitempty"`
+}
+
+type RequeuingStrategy struct {
+	// BackoffLimitCount defines the maximum number of times a workload is
+	// requeued after being evicted. When the count is exceeded, the workload
+	// is deactivated and it's not queued for admission again.
+	// Defaults to null, meaning that the workload is requeued indefinitely.
+	// +optional
+	BackoffLimitCount *int32 `json:"backoffLimitCount,omitempty"`
+
+	// BackoffBaseSeconds defines the base for the exponential backoff
+	// applied before requeuing an evicted workload. The workload is requeued
+	// after waiting BackoffBaseSeconds*2^(n-1) seconds, where n is the number
+	// of times it was evicted.
+	// Defaults to null, meaning that the workload is requeued immediately.
+	// +optional
+	BackoffBaseSeconds *int32 `json:"backoffBaseSeconds,omitempty"`
+
+	// BackoffMaxSeconds defines the maximum waiting time before requeuing an
+	// evicted workload.
+	// Defaults to null, meaning that the waiting time is not capped.
+	// +optional
+	BackoffMaxSeconds *int32 `json:"backoffMaxSeconds,omitempty"`
 }
 
 type InternalCertManagement struct {
@@ -106,5 +148,31 @@ type Integrations struct {
 	// Possible options:
 	//  - "batch/job"
 	//  - "kubeflow.org/mpijob"
+	//  - "jobset.x-k8s.io/jobset"
+	//  - "pod"
 	Frameworks []string `json:"frameworks,omitempty"`
 }
+
+type FairSharing struct {
+	// Enable indicates whether to enable fair sharing for all cohorts.
+	// When enabled, the scheduler admits the workloads from the ClusterQueue
+	// with the lowest dominant resource share of the borrowed capacity first.
+	// Defaults to false.
+	Enable bool `json:"enable"`
+}
+
+type Visibility struct {
+	// Enable indicates whether to serve the pending workloads of the
+	// ClusterQueues and LocalQueues, with their positions in the queues, as
+	// the aggregated API visibility.kueue.x-k8s.io/v1alpha1 on port 8082.
+	// Defaults to false.
+	Enable bool `json:"enable"`
+}
+
+type MultiKueue struct {
+	// Enable indicates whether to run the controller of the MultiKueue
+	// AdmissionChecks. The kubeconfigs of the worker clusters are read from
+	// Secrets in the namespace in which Kueue is deployed.
+	// Defaults to false.
+	Enable bool `json:"enable"`
+}
@@ -316,6 +316,37 @@ func TestSetDefaults_Configuration(t *testing.T) {
 				Integrations:     defaultIntegrations,
 			},
 		},
+		"respecting provided waitForPodsReady.requeuingStrategy": {
+			original: &Configuration{
+				WaitForPodsReady: &WaitForPodsReady{
+					Enable: true,
+					RequeuingStrategy: &RequeuingStrategy{
+						BackoffLimitCount:  pointer.Int32(5),
+						BackoffBaseSeconds: pointer.Int32(10),
+					},
+				},
+				InternalCertManagement: &InternalCertManagement{
+					Enable: pointer.Bool(false),
+				},
+			},
+			want: &Configuration{
+				WaitForPodsReady: &WaitForPodsReady{
+					Enable:  true,
+					Timeout: &podsReadyTimeoutTimeout,
+					RequeuingStrategy: &RequeuingStrategy{
+						BackoffLimitCount:  pointer.Int32(5),
+						BackoffBaseSeconds: pointer.Int32(10),
+					},
+				},
+				Namespace:                          pointer.String(DefaultNamespace),
+				ControllerManagerConfigurationSpec: defaultCtrlManagerConfigurationSpec,
+				InternalCertManagement: &InternalCertManagement{
+					Enable: pointer.Bool(false),
+				},
+				ClientConnection: defaultClientConnection,
+				Integrations:     defaultIntegrations,
+			},
+		},
 		"integrations": {
 			original: &Configuration{
 				InternalCertManagement: &InternalCertManagement{
@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckState is the state of an admission check for a Workload.
+// +kubebuilder:validation:Enum=Pending;Ready;Retry;Rejected
+type CheckState string
+
+const (
+	// CheckStatePending means that the check is still being evaluated.
+	CheckStatePending CheckState = "Pending"
+
+	// CheckStateReady means that the check passed.
+	CheckStateReady CheckState = "Ready"
+
+	// CheckStateRetry means that the check can't pass at the moment. The
+	// quota reservation of the Workload is released and the Workload is
+	// requeued.
+	CheckStateRetry CheckState = "Retry"
+
+	// CheckStateRejected means that the check will not pass in the near
+	// future. The quota reservation of the Workload is released.
+	CheckStateRejected CheckState = "Rejected"
+)
+
+// AdmissionCheckSpec defines the desired state of AdmissionCheck
+type AdmissionCheckSpec struct {
+	// controllerName is the name of the controller that evaluates the
+	// AdmissionCheck for the Workloads, and that maintains its Active
+	// condition. It is not necessarily a Kubernetes Pod or Deployment name.
+	// controllerName cannot be changed.
+	// +kubebuilder:validation:MinLength=1
+	ControllerName string `json:"controllerName"`
+
+	// parameters identifies an object with additional parameters for the
+	// check, interpreted by its controller.
+	// +optional
+	Parameters *AdmissionCheckParametersReference `json:"parameters,omitempty"`
+}
+
+type AdmissionCheckParametersReference struct {
+	// apiGroup is the group of the object being referenced.
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern="^$|^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
+	APIGroup string `json:"apiGroup"`
+
+	// kind is the kind of the object being referenced.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern="^(?i)[a-z]([-a-z0-9]*[a-z0-9])?$"
+	Kind string `json:"kind"`
+
+	// name is the name of the object being referenced.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	Name string `json:"name"`
+}
+
+// AdmissionCheckStatus defines the observed state of AdmissionCheck
+type AdmissionCheckStatus struct {
+	// conditions hold the latest available observations of the
+	// AdmissionCheck current state.
+	//
+	// The type of the condition could be:
+	//
+	// - Active: the controller of the check is running and the check can be
+	// used by ClusterQueues.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// AdmissionCheckActive indicates that the controller of the admission
+	// check is ready to evaluate it for the Workloads.
+	AdmissionCheckActive = "Active"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:storageversion
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Controller",JSONPath=".spec.controllerName",type=string,description="Controller that evaluates the AdmissionCheck"
+
+// AdmissionCheck is the Schema for the admissionchecks API. It represents
+// a condition, evaluated by an external controller, that a Workload needs
+// to meet after reserving quota and before it is admitted.
+type AdmissionCheck struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AdmissionCheckSpec   `json:"spec,omitempty"`
+	Status AdmissionCheckStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// AdmissionCheckList contains a list of AdmissionCheck
+type AdmissionCheckList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AdmissionCheck `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AdmissionCheck{}, &AdmissionCheckList{})
+}
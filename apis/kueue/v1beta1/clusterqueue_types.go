@@ -88,6 +88,99 @@ type ClusterQueueSpec struct {
 	// preempt to accomomdate the pending Workload, preempting Workloads with
 	// lower priority first.
 	Preemption *ClusterQueuePreemption `json:"preemption,omitempty"`
+
+	// fairSharing defines the properties of the ClusterQueue when participating
+	// in fair sharing. The values are only relevant if fair sharing is enabled
+	// in the Kueue configuration.
+	// +optional
+	FairSharing *FairSharing `json:"fairSharing,omitempty"`
+
+	// stopPolicy allows to stop the ClusterQueue, for example during a
+	// maintenance. A stopped ClusterQueue is inactive: it doesn't admit new
+	// workloads and its quota can't be borrowed by other ClusterQueues in the
+	// cohort. The possible values are:
+	//
+	// - None: the ClusterQueue is not stopped.
+	// - Hold: the pending workloads stay queued and the admitted workloads
+	//   keep running until they finish.
+	// - HoldAndDrain: the pending workloads stay queued and the admitted
+	//   workloads are evicted.
+	//
+	// +kubebuilder:default=None
+	// +kubebuilder:validation:Enum=None;Hold;HoldAndDrain
+	// +optional
+	StopPolicy *StopPolicy `json:"stopPolicy,omitempty"`
+
+	// flavorFungibility defines whether a workload should try the next flavor
+	// before borrowing or preempting in the flavor being evaluated.
+	// +optional
+	FlavorFungibility *FlavorFungibility `json:"flavorFungibility,omitempty"`
+
+	// admissionChecks lists the AdmissionChecks that the workloads admitted
+	// through this ClusterQueue need to pass after reserving quota. A
+	// workload is only admitted, and its job started, when all of them are
+	// Ready.
+	//
+	// The ClusterQueue is inactive while any of the AdmissionChecks doesn't
+	// exist or is not Active.
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=8
+	// +optional
+	AdmissionChecks []string `json:"admissionChecks,omitempty"`
+}
+
+type StopPolicy string
+
+const (
+	// None means that the ClusterQueue is not stopped.
+	None StopPolicy = "None"
+
+	// Hold means that the ClusterQueue doesn't admit new workloads, but the
+	// admitted workloads keep running.
+	Hold StopPolicy = "Hold"
+
+	// HoldAndDrain means that the ClusterQueue doesn't admit new workloads and
+	// the admitted workloads are evicted.
+	HoldAndDrain StopPolicy = "HoldAndDrain"
+)
+
+type FlavorFungibilityPolicy string
+
+const (
+	Borrow        FlavorFungibilityPolicy = "Borrow"
+	Preempt       FlavorFungibilityPolicy = "Preempt"
+	TryNextFlavor FlavorFungibilityPolicy = "TryNextFlavor"
+)
+
+// FlavorFungibility determines whether a workload should try the next flavor
+// before borrowing or preempting in the current flavor. The flavors are
+// evaluated in the order in which they are listed in the resource group.
+type FlavorFungibility struct {
+	// whenCanBorrow determines whether a workload should try the next flavor
+	// before borrowing in the current flavor. The possible values are:
+	//
+	// - `Borrow` (default): allocate in the current flavor if borrowing
+	//   is possible.
+	// - `TryNextFlavor`: try the next flavor, even if the current
+	//   flavor has enough resources to borrow. The first flavor in which the
+	//   workload fits by borrowing is used if no flavor fits without borrowing.
+	//
+	// +kubebuilder:default=Borrow
+	// +kubebuilder:validation:Enum=Borrow;TryNextFlavor
+	WhenCanBorrow FlavorFungibilityPolicy `json:"whenCanBorrow,omitempty"`
+
+	// whenCanPreempt determines whether a workload should try the next flavor
+	// before preempting in the current flavor. The possible values are:
+	//
+	// - `Preempt`: allocate in the current flavor if it's possible to preempt
+	//   some workloads.
+	// - `TryNextFlavor` (default): try the next flavor, even if there are enough
+	//   candidates for preemption in the current flavor. The first flavor in
+	//   which preemption is possible is used if no flavor fits.
+	//
+	// +kubebuilder:default=TryNextFlavor
+	// +kubebuilder:validation:Enum=Preempt;TryNextFlavor
+	WhenCanPreempt FlavorFungibilityPolicy `json:"whenCanPreempt,omitempty"`
 }
 
 type QueueingStrategy string
@@ -108,6 +201,10 @@ type ResourceGroup struct {
 	// coveredResources is the list of resources covered by the flavors in this
 	// group.
 	// Examples: cpu, memory, vendor.com/gpu.
+	// The synthetic resources pods and workloads can be used to limit the
+	// number of pods and workloads admitted by the ClusterQueue. Workloads
+	// request as many pods as the sum of the counts of their podSets, and one
+	// workload.
 	// The list cannot be empty and it can contain up to 16 resources.
 	// +kubebuilder:validation:MinItems=1
 	// +kubebuilder:validation:MaxItems=16
@@ -158,8 +255,23 @@ type ResourceQuota struct {
 	// If the ClusterQueue belongs to a cohort, the sum of the quotas for each
 	// (flavor, resource) combination defines the maximum quantity that can be
 	// allocated by a ClusterQueue in the cohort.
+	//
+	// nominalQuota must be zero if nominalQuotaPercent is set.
+	// +optional
 	NominalQuota resource.Quantity `json:"nominalQuota"`
 
+	// nominalQuotaPercent is the share, in percent, of the capacity of the
+	// Nodes of the flavor that is available for Workloads admitted by this
+	// ClusterQueue. The ResourceFlavor must set capacityFromNodes; otherwise,
+	// the nominal quota is zero.
+	// The nominal quota follows the capacity of the Nodes as they are added,
+	// removed or change their allocatable resources.
+	// nominalQuotaPercent can't be used together with schedules.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	NominalQuotaPercent *int32 `json:"nominalQuotaPercent,omitempty"`
+
 	// borrowingLimit is the maximum amount of quota for the [flavor, resource]
 	// combination that this ClusterQueue is allowed to borrow from the unused
 	// quota of other ClusterQueues in the same cohort.
@@ -171,6 +283,60 @@ type ResourceQuota struct {
 	// borrowingLimit must be null if spec.cohort is empty.
 	// +optional
 	BorrowingLimit *resource.Quantity `json:"borrowingLimit,omitempty"`
+
+	// lendingLimit is the maximum amount of unused quota for the [flavor,
+	// resource] combination that this ClusterQueue can lend to other
+	// ClusterQueues in the same cohort.
+	// In total, at a given time, ClusterQueue reserves for its exclusive use
+	// a quantity of quota equal to nominalQuota - lendingLimit.
+	// If null, it means that there is no lending limit, meaning that
+	// all the nominalQuota can be borrowed by other clusterQueues in the cohort.
+	// If not null, it must be non-negative and less than or equal to
+	// nominalQuota.
+	// lendingLimit must be null if spec.cohort is empty.
+	// +optional
+	LendingLimit *resource.Quantity `json:"lendingLimit,omitempty"`
+
+	// schedules is a list of time windows in which the nominalQuota and
+	// borrowingLimit of the schedule replace the ones of this resource.
+	// Outside of all the windows, nominalQuota and borrowingLimit apply.
+	// If the windows of multiple schedules overlap, the first schedule in the
+	// list takes precedence.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=8
+	// +optional
+	Schedules []QuotaSchedule `json:"schedules,omitempty"`
+}
+
+type QuotaSchedule struct {
+	// name of this schedule.
+	Name string `json:"name"`
+
+	// schedule is a cron expression of five fields (minute, hour, day of
+	// month, month and day of week) that defines when the time window starts.
+	// Macros such as @daily or @weekly are also accepted.
+	Schedule string `json:"schedule"`
+
+	// timeZone is the name of the time zone, from the IANA time zone database,
+	// in which the schedule is evaluated. Defaults to UTC.
+	// +optional
+	TimeZone *string `json:"timeZone,omitempty"`
+
+	// duration is the length of the time window. It must be positive.
+	Duration metav1.Duration `json:"duration"`
+
+	// nominalQuota is the quantity of this resource that is available for
+	// Workloads admitted by this ClusterQueue while the window is active.
+	// The nominalQuota must be non-negative.
+	NominalQuota resource.Quantity `json:"nominalQuota"`
+
+	// borrowingLimit is the maximum amount of quota that this ClusterQueue is
+	// allowed to borrow while the window is active.
+	// If null, it means that there is no borrowing limit.
+	// borrowingLimit must be null if spec.cohort is empty.
+	// +optional
+	BorrowingLimit *resource.Quantity `json:"borrowingLimit,omitempty"`
 }
 
 // ResourceFlavorReference is the name of the ResourceFlavor.
@@ -202,6 +368,12 @@ type ClusterQueueStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// fairSharing contains the information about the current status of fair
+	// sharing. It is only populated if fair sharing is enabled in the Kueue
+	// configuration.
+	// +optional
+	FairSharing *FairSharingStatus `json:"fairSharing,omitempty"`
 }
 
 type FlavorUsage struct {
@@ -232,6 +404,11 @@ const (
 	// ClusterQueueActive indicates that the ClusterQueue can admit new workloads and its quota
 	// can be borrowed by other ClusterQueues in the same cohort.
 	ClusterQueueActive string = "Active"
+
+	// ClusterQueueQuotaScheduleActive indicates that the quotas of some
+	// resources are defined by a quota schedule whose time window is active.
+	// The condition is only set for ClusterQueues with quota schedules.
+	ClusterQueueQuotaScheduleActive string = "QuotaScheduleActive"
 )
 
 type PreemptionPolicy string
@@ -240,6 +417,7 @@ const (
 	PreemptionPolicyNever         PreemptionPolicy = "Never"
 	PreemptionPolicyAny           PreemptionPolicy = "Any"
 	PreemptionPolicyLowerPriority PreemptionPolicy = "LowerPriority"
+	PreemptionPolicyFairSharing   PreemptionPolicy = "FairSharing"
 )
 
 // ClusterQueuePreemption contains policies to preempt Workloads from this
@@ -256,9 +434,16 @@ type ClusterQueuePreemption struct {
 	// - `Any`: if the pending Workload fits within the nominal quota of its
 	//   ClusterQueue, preempt any Workload in the cohort, irrespective of
 	//   priority.
+	// - `FairSharing`: if the pending Workload fits within the quota of the
+	//   cohort, preempt Workloads from the ClusterQueues with the highest
+	//   weighted share of borrowed resources first. A Workload is only
+	//   preempted if, after the preemption, the share of its ClusterQueue is
+	//   still greater than or equal to the share of this ClusterQueue
+	//   including the pending Workload.
+	//   This policy requires fair sharing to be enabled.
 	//
 	// +kubebuilder:default=Never
-	// +kubebuilder:validation:Enum=Never;LowerPriority;Any
+	// +kubebuilder:validation:Enum=Never;LowerPriority;Any;FairSharing
 	ReclaimWithinCohort PreemptionPolicy `json:"reclaimWithinCohort,omitempty"`
 
 	// withinClusterQueue determines whether a pending Workload that doesn't fit
@@ -272,6 +457,45 @@ type ClusterQueuePreemption struct {
 	// +kubebuilder:default=Never
 	// +kubebuilder:validation:Enum=Never;LowerPriority
 	WithinClusterQueue PreemptionPolicy `json:"withinClusterQueue,omitempty"`
+
+	// borrowWithinCohort determines whether a pending Workload can borrow
+	// quota from the cohort while preempting Workloads from other
+	// ClusterQueues in the cohort. It can't be used with the reclaimWithinCohort
+	// policies Never and FairSharing.
+	// +optional
+	BorrowWithinCohort *BorrowWithinCohort `json:"borrowWithinCohort,omitempty"`
+}
+
+type BorrowWithinCohortPolicy string
+
+const (
+	BorrowWithinCohortPolicyNever         BorrowWithinCohortPolicy = "Never"
+	BorrowWithinCohortPolicyLowerPriority BorrowWithinCohortPolicy = "LowerPriority"
+)
+
+// BorrowWithinCohort contains the configuration of the preemption of
+// Workloads from other ClusterQueues in the cohort by a pending Workload that
+// needs to borrow quota.
+type BorrowWithinCohort struct {
+	// policy determines whether a pending Workload that needs to borrow quota
+	// can preempt Workloads from other ClusterQueues in the cohort. The
+	// possible values are:
+	//
+	// - `Never` (default): do not preempt Workloads in the cohort when
+	//   borrowing.
+	// - `LowerPriority`: when borrowing, only preempt Workloads in the cohort
+	//   that have lower priority than the pending Workload.
+	//
+	// +kubebuilder:default=Never
+	// +kubebuilder:validation:Enum=Never;LowerPriority
+	Policy BorrowWithinCohortPolicy `json:"policy,omitempty"`
+
+	// maxPriorityThreshold restricts the Workloads that can be preempted by a
+	// borrowing Workload to the ones with priority less than or equal to the
+	// threshold. When not specified, any Workload with lower priority than
+	// the pending Workload can be preempted.
+	// +optional
+	MaxPriorityThreshold *int32 `json:"maxPriorityThreshold,omitempty"`
 }
 
 //+kubebuilder:object:root=true
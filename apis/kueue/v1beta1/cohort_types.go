@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CohortSpec defines the desired state of Cohort
+type CohortSpec struct {
+	// parent is the name of the cohort that this cohort belongs to.
+	// Cohorts that share a parent form a larger cohort: unused quota of the
+	// ClusterQueues in any of them can be borrowed by ClusterQueues in the
+	// others. Unused quota flows up to the nearest ancestor first: a
+	// ClusterQueue borrows from its own cohort, and only borrows from the
+	// parent the part of the request that the cohort can't serve. When
+	// workloads compete for the same unused quota, the ones that borrow from
+	// a nearer cohort are admitted first.
+	//
+	// The parent doesn't need to be referenced by any ClusterQueue.
+	// A cohort can't be its own ancestor; a Cohort whose parent chain forms
+	// a cycle is treated as having no parent.
+	//
+	// If empty, the cohort is the root of its tree.
+	//
+	// Validation of a parent name is equivalent to that of object names:
+	// subdomain in DNS (RFC 1123).
+	// +optional
+	Parent string `json:"parent,omitempty"`
+
+	// resourceGroups describes the quota that the cohort provides on its own,
+	// in addition to the quota of its ClusterQueues. This quota isn't owned by
+	// any ClusterQueue: it can only be borrowed by the ClusterQueues of the
+	// cohort and of its descendants, which use it before borrowing from the
+	// ancestor cohorts.
+	//
+	// Only nominalQuota can be set for the resources of a cohort.
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	ResourceGroups []ResourceGroup `json:"resourceGroups,omitempty"`
+}
+
+// CohortStatus defines the observed state of Cohort
+type CohortStatus struct {
+	// clusterQueues is the number of ClusterQueues in the cohort and
+	// in its descendants.
+	// +optional
+	ClusterQueues int32 `json:"clusterQueues"`
+
+	// flavorsResources lists, for each flavor and resource, the quota that
+	// can be borrowed in the cohort, and how much of it is used.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	FlavorsResources []CohortFlavorResources `json:"flavorsResources,omitempty"`
+}
+
+type CohortFlavorResources struct {
+	// name of the flavor.
+	Name ResourceFlavorReference `json:"name"`
+
+	// resources lists the quota and usage for the resources in the flavor.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=16
+	Resources []CohortResourceStatus `json:"resources"`
+}
+
+type CohortResourceStatus struct {
+	// name of the resource.
+	Name corev1.ResourceName `json:"name"`
+
+	// requestable is the quota available in the cohort: the quota that the
+	// ClusterQueues of the cohort and its descendants lend, plus the quota
+	// of the cohort. The quota of the descendant cohorts is only available
+	// to their own subtrees, so it isn't included.
+	Requestable resource.Quantity `json:"requestable,omitempty"`
+
+	// usage is the part of the requestable quota that is used by the
+	// admitted workloads. The usage of the ClusterQueues within the quota
+	// that they don't lend, and the usage of the descendant cohorts within
+	// their own quota, are not accounted.
+	Usage resource.Quantity `json:"usage,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:storageversion
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Parent",JSONPath=".spec.parent",type=string,description="Cohort that this Cohort belongs to"
+//+kubebuilder:printcolumn:name="ClusterQueues",JSONPath=".status.clusterQueues",type=integer,description="Number of ClusterQueues in the Cohort and its descendants"
+
+// Cohort is the Schema for the cohorts API. It allows to organize the
+// cohorts referenced by ClusterQueues into a tree.
+type Cohort struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CohortSpec   `json:"spec,omitempty"`
+	Status CohortStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CohortList contains a list of Cohort
+type CohortList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Cohort `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Cohort{}, &CohortList{})
+}
@@ -16,8 +16,30 @@ limitations under the License.
 
 package v1beta1
 
+import corev1 "k8s.io/api/core/v1"
+
 const (
 	ResourceInUseFinalizerName = "kueue.x-k8s.io/resource-in-use"
 
 	DefaultPodSetName = "main"
+
+	// ResourcePods is a synthetic resource that counts the pods of a
+	// workload, as given by the count of its podSets.
+	ResourcePods corev1.ResourceName = corev1.ResourcePods
+
+	// ResourceWorkloads is a synthetic resource that counts 1 per workload.
+	ResourceWorkloads corev1.ResourceName = "workloads"
+
+	// WorkloadPriorityClassSource indicates that the priorityClassName of a
+	// Workload refers to a WorkloadPriorityClass.
+	WorkloadPriorityClassSource = "kueue.x-k8s.io/workloadpriorityclass"
+
+	// PodPriorityClassSource indicates that the priorityClassName of a
+	// Workload refers to a pod PriorityClass.
+	PodPriorityClassSource = "scheduling.k8s.io/priorityclass"
+
+	// PodSetRequiredTopologyAnnotation is the annotation in the template of a
+	// podSet that indicates the topology level, given by its node label,
+	// within which all the pods of the podSet must be placed.
+	PodSetRequiredTopologyAnnotation = "kueue.x-k8s.io/podset-required-topology"
 )
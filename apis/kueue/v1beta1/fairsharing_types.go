@@ -0,0 +1,49 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import "k8s.io/apimachinery/pkg/api/resource"
+
+// FairSharing contains the properties of the ClusterQueue when participating
+// in fair sharing.
+type FairSharing struct {
+	// weight gives a comparative advantage to this ClusterQueue when competing
+	// for unused resources in the cohort against other ClusterQueues.
+	// The share of a ClusterQueue is based on the dominant resource usage
+	// above nominal quotas for each resource, divided by the weight.
+	// Admission prioritizes scheduling workloads from ClusterQueues with the
+	// lowest share.
+	// A zero weight implies infinite share value, meaning that this
+	// ClusterQueue will always be at disadvantage against other ClusterQueues.
+	// Defaults to 1.
+	// +optional
+	Weight *resource.Quantity `json:"weight,omitempty"`
+}
+
+// FairSharingStatus contains the information about the current status of
+// fair sharing.
+type FairSharingStatus struct {
+	// weightedShare represents the maximum of the ratios of usage above the
+	// nominal quota to the lendable resources in the cohort, among all the
+	// resources provided by the ClusterQueue, divided by the weight. The
+	// ratios are expressed in per-mille units.
+	// If zero, it means that the usage of the ClusterQueue is below the
+	// nominal quota.
+	// If the ClusterQueue has a weight of zero, this is
+	// 9223372036854775807, the maximum possible share value.
+	WeightedShare int64 `json:"weightedShare"`
+}
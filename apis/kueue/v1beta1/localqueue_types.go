@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -24,6 +26,49 @@ import (
 type LocalQueueSpec struct {
 	// clusterQueue is a reference to a clusterQueue that backs this localQueue.
 	ClusterQueue ClusterQueueReference `json:"clusterQueue,omitempty"`
+
+	// limits caps the quota of the ClusterQueue that the workloads submitted
+	// to this LocalQueue can use. Workloads that would exceed the limits stay
+	// pending, even if the ClusterQueue has enough unused quota.
+	// +optional
+	Limits *LocalQueueLimits `json:"limits,omitempty"`
+}
+
+type LocalQueueLimits struct {
+	// flavors is the list of limits per flavor.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Flavors []LocalQueueFlavorLimits `json:"flavors,omitempty"`
+
+	// maxAdmittedWorkloads is the maximum number of workloads from this
+	// LocalQueue that can be admitted at the same time.
+	// If null, the number of admitted workloads is not limited.
+	// +optional
+	MaxAdmittedWorkloads *int32 `json:"maxAdmittedWorkloads,omitempty"`
+}
+
+type LocalQueueFlavorLimits struct {
+	// name of the flavor.
+	Name ResourceFlavorReference `json:"name"`
+
+	// resources is the list of limits for this flavor per resource.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	Resources []LocalQueueResourceLimit `json:"resources"`
+}
+
+type LocalQueueResourceLimit struct {
+	// name of the resource.
+	Name corev1.ResourceName `json:"name"`
+
+	// maxUsage is the maximum quantity of the resource in the flavor that the
+	// admitted workloads from this LocalQueue can use at a point in time.
+	// It must be non-negative.
+	MaxUsage resource.Quantity `json:"maxUsage"`
 }
 
 // ClusterQueueReference is the name of the ClusterQueue.
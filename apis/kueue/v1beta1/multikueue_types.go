@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MultiKueueControllerName is the controllerName of the AdmissionChecks
+	// that dispatch the Workloads to worker clusters. Their parameters
+	// reference a MultiKueueConfig.
+	MultiKueueControllerName = "kueue.x-k8s.io/multikueue"
+)
+
+// KubeconfigLocationType is the type of the location of a kubeconfig.
+// +kubebuilder:validation:Enum=Secret
+type KubeconfigLocationType string
+
+const (
+	// SecretLocationType means that the kubeconfig is stored in the
+	// "kubeconfig" key of a Secret in the namespace in which Kueue is
+	// deployed.
+	SecretLocationType KubeconfigLocationType = "Secret"
+)
+
+// KubeconfigRef is a reference to the kubeconfig of a worker cluster.
+type KubeconfigRef struct {
+	// locationType is the type of the location of the kubeconfig.
+	// +kubebuilder:default=Secret
+	LocationType KubeconfigLocationType `json:"locationType"`
+
+	// location of the kubeconfig. For the Secret location type, it is the
+	// name of the Secret.
+	// +kubebuilder:validation:MinLength=1
+	Location string `json:"location"`
+}
+
+// MultiKueueCluster is a worker cluster to which Workloads can be
+// dispatched.
+type MultiKueueCluster struct {
+	// name of the worker cluster.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	Name string `json:"name"`
+
+	// kubeconfigRef is the reference to the kubeconfig used to connect to
+	// the worker cluster.
+	KubeconfigRef KubeconfigRef `json:"kubeconfigRef"`
+}
+
+// MultiKueueConfigSpec defines the desired state of MultiKueueConfig
+type MultiKueueConfigSpec struct {
+	// clusters lists the worker clusters to which the Workloads are
+	// dispatched. A Workload is copied to all of them and runs in the first
+	// one that reserves quota for it.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=100
+	Clusters []MultiKueueCluster `json:"clusters"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:storageversion
+//+kubebuilder:resource:scope=Cluster
+
+// MultiKueueConfig is the Schema for the multikueueconfigs API. It holds
+// the worker clusters used by the AdmissionChecks with the
+// kueue.x-k8s.io/multikueue controllerName.
+type MultiKueueConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MultiKueueConfigSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MultiKueueConfigList contains a list of MultiKueueConfig
+type MultiKueueConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MultiKueueConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MultiKueueConfig{}, &MultiKueueConfigList{})
+}
@@ -64,6 +64,37 @@ type ResourceFlavorSpec struct {
 	// +listType=atomic
 	// +kubebuilder:validation:MaxItems=8
 	NodeTaints []corev1.Taint `json:"nodeTaints,omitempty"`
+
+	// topologyLevels are the levels of the topology of the Nodes associated
+	// with this ResourceFlavor, ordered from the highest to the lowest level,
+	// for example, block and rack.
+	// A podSet that requires a topology level, with the
+	// kueue.x-k8s.io/podset-required-topology annotation in its template, can
+	// only get assigned this ResourceFlavor if all its pods fit in a single
+	// domain of that level, given the capacity of the Nodes.
+	//
+	// topologyLevels can be up to 8 elements.
+	//
+	// +optional
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=8
+	TopologyLevels []TopologyLevel `json:"topologyLevels,omitempty"`
+
+	// capacityFromNodes indicates whether Kueue computes the capacity of this
+	// ResourceFlavor from the Ready and schedulable Nodes that match the
+	// nodeLabels. The capacity is the sum of the allocatable resources of the
+	// Nodes, minus the requests of the running Pods that don't belong to
+	// Workloads admitted by Kueue.
+	// ClusterQueues can define their nominal quota for this ResourceFlavor as
+	// a share of the capacity with nominalQuotaPercent.
+	// +optional
+	CapacityFromNodes bool `json:"capacityFromNodes,omitempty"`
+}
+
+type TopologyLevel struct {
+	// nodeLabel is the label of the Nodes whose value identifies the domain
+	// of this level that a Node belongs to.
+	NodeLabel string `json:"nodeLabel"`
 }
 
 //+kubebuilder:object:root=true
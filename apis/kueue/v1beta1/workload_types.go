@@ -42,7 +42,8 @@ type WorkloadSpec struct {
 	// "system-node-critical" and "system-cluster-critical" are two special
 	// keywords which indicate the highest priorities with the former being
 	// the highest priority. Any other name must be defined by creating a
-	// PriorityClass object with that name. If not specified, the workload
+	// PriorityClass or a WorkloadPriorityClass object with that name,
+	// as indicated by priorityClassSource. If not specified, the workload
 	// priority will be default or zero if there is no default.
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 
@@ -52,6 +53,25 @@ type WorkloadSpec struct {
 	// The higher the value, the higher the priority.
 	// If priorityClassName is specified, priority must not be null.
 	Priority *int32 `json:"priority,omitempty"`
+
+	// priorityClassSource determines whether the priorityClassName field
+	// refers to a pod PriorityClass (scheduling.k8s.io/priorityclass) or a
+	// WorkloadPriorityClass (kueue.x-k8s.io/workloadpriorityclass).
+	// priorityClassSource and priorityClassName cannot be changed while
+	// .status.admission is not null.
+	// +optional
+	// +kubebuilder:default=""
+	// +kubebuilder:validation:Enum=kueue.x-k8s.io/workloadpriorityclass;scheduling.k8s.io/priorityclass;""
+	PriorityClassSource string `json:"priorityClassSource,omitempty"`
+
+	// active determines whether the workload can be admitted.
+	// When set to false, the workload is evicted if it's admitted, it's
+	// removed from the queues and the owning job is suspended. When set back
+	// to true, the workload is queued for admission again.
+	// Defaults to true.
+	// +optional
+	// +kubebuilder:default=true
+	Active *bool `json:"active,omitempty"`
 }
 
 type Admission struct {
@@ -77,6 +97,33 @@ type PodSetAssignment struct {
 	// Beside what is provided in podSet's specs, this calculation takes into account
 	// the LimitRange defaults and RuntimeClass overheads at the moment of admission.
 	ResourceUsage corev1.ResourceList `json:"resourceUsage,omitempty"`
+
+	// count is the number of pods admitted for the podSet. It's only set when
+	// the workload was partially admitted with fewer pods than
+	// .spec.podSets[*].count; otherwise, all the pods of the podSet were
+	// admitted.
+	// +optional
+	Count *int32 `json:"count,omitempty"`
+
+	// topologyAssignment is the topology domain that the pods of the podSet
+	// are assigned to. It's only set when the podSet requires a topology
+	// level with the kueue.x-k8s.io/podset-required-topology annotation.
+	// +optional
+	TopologyAssignment *TopologyAssignment `json:"topologyAssignment,omitempty"`
+}
+
+type TopologyAssignment struct {
+	// levels are the node labels of the topology levels of the assigned
+	// flavor, from the highest level down to the level of the domain.
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=8
+	Levels []string `json:"levels"`
+
+	// values are the values of the node labels in levels that identify the
+	// domain.
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=8
+	Values []string `json:"values"`
 }
 
 type PodSet struct {
@@ -101,6 +148,16 @@ type PodSet struct {
 	// count is the number of pods for the spec.
 	// +kubebuilder:validation:Minimum=1
 	Count int32 `json:"count"`
+
+	// minCount is the minimum number of pods for the spec acceptable if the
+	// workload supports partial admission. When the workload doesn't fit with
+	// count pods, it can be admitted with the largest number of pods, not
+	// lower than minCount, that fits.
+	//
+	// If not provided, partial admission for the podSet is not enabled.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinCount *int32 `json:"minCount,omitempty"`
 }
 
 // WorkloadStatus defines the observed state of Workload
@@ -115,19 +172,101 @@ type WorkloadStatus struct {
 	//
 	// The type of the condition could be:
 	//
-	// - Admitted: the Workload was admitted through a ClusterQueue.
+	// - QuotaReserved: the Workload reserved quota in a ClusterQueue.
+	// - Admitted: the Workload reserved quota and all the admission checks
+	// of the ClusterQueue are Ready.
 	// - Finished: the associated workload finished running (failed or succeeded).
 	// - PodsReady: at least `.spec.podSets[*].count` Pods are ready or have
 	// succeeded.
+	// - Deactivated: the workload is not active and won't be queued for
+	// admission until it's reactivated.
+	// - Evicted: the workload released its quota reservation before
+	// finishing. The reason is one of Preempted, PodsReadyTimeout,
+	// ClusterQueueStopped or InactiveWorkload.
 	//
 	// +optional
 	// +listType=map
 	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// admissionChecks list the states of the admission checks of the
+	// ClusterQueue where the Workload reserved quota. The entries are reset
+	// to Pending when the quota reservation is released.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=8
+	AdmissionChecks []AdmissionCheckState `json:"admissionChecks,omitempty"`
+
+	// reclaimablePods keeps track of the number of pods within a podset for which
+	// the resource reservation is no longer needed, for example, because they
+	// already succeeded.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=8
+	ReclaimablePods []ReclaimablePod `json:"reclaimablePods,omitempty"`
+
+	// requeueState holds the state of the requeuing of a workload that was
+	// evicted because its pods didn't become ready within the PodsReady
+	// timeout.
+	// +optional
+	RequeueState *RequeueState `json:"requeueState,omitempty"`
+}
+
+type RequeueState struct {
+	// count is the number of times the workload was evicted and requeued
+	// because its pods didn't become ready within the PodsReady timeout.
+	// When the count exceeds the backoffLimitCount of the requeuing
+	// strategy, the workload is deactivated.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Count *int32 `json:"count,omitempty"`
+
+	// requeueAt is the time after which the workload is eligible to be
+	// queued for admission again. Until then, the workload is kept out of the
+	// ClusterQueue's queue.
+	// +optional
+	RequeueAt *metav1.Time `json:"requeueAt,omitempty"`
+}
+
+type ReclaimablePod struct {
+	// name is the PodSet name.
+	Name string `json:"name"`
+
+	// count is the number of pods for which the requested resources are no longer needed.
+	// +kubebuilder:validation:Minimum=0
+	Count int32 `json:"count"`
+}
+
+type AdmissionCheckState struct {
+	// name identifies the admission check.
+	// +kubebuilder:validation:MaxLength=316
+	Name string `json:"name"`
+
+	// state of the admission check, one of Pending, Ready, Retry or Rejected.
+	State CheckState `json:"state"`
+
+	// lastTransitionTime is the last time the state transitioned from one
+	// value to another.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// message is a human readable message indicating details about the
+	// state.
+	// +kubebuilder:validation:MaxLength=32768
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 const (
-	// WorkloadAdmitted means that the Workload was admitted by a ClusterQueue.
+	// WorkloadQuotaReserved means that the Workload reserved quota in a
+	// ClusterQueue.
+	WorkloadQuotaReserved = "QuotaReserved"
+
+	// WorkloadAdmitted means that the Workload reserved quota in a
+	// ClusterQueue and passed all its admission checks.
 	WorkloadAdmitted = "Admitted"
 
 	// WorkloadFinished means that the workload associated to the
@@ -137,6 +276,57 @@ const (
 	// WorkloadPodsReady means that at least `.spec.podSets[*].count` Pods are
 	// ready or have succeeded.
 	WorkloadPodsReady = "PodsReady"
+
+	// WorkloadDeactivated means that the Workload has .spec.active set to
+	// false, so it won't be queued for admission until it's reactivated.
+	WorkloadDeactivated = "Deactivated"
+
+	// WorkloadEvicted means that the Workload released its quota reservation
+	// before finishing. The reason of the condition indicates the cause of
+	// the eviction. The condition is set to False when the Workload reserves
+	// quota again.
+	WorkloadEvicted = "Evicted"
+)
+
+const (
+	// WorkloadEvictedByPreemption indicates that the workload was preempted
+	// to accommodate a higher priority workload in its ClusterQueue, or a
+	// workload of another ClusterQueue reclaiming its quota in the cohort.
+	WorkloadEvictedByPreemption = "Preempted"
+
+	// WorkloadEvictedByPodsReadyTimeout indicates that the workload was
+	// evicted because its pods didn't become ready within the PodsReady
+	// timeout.
+	WorkloadEvictedByPodsReadyTimeout = "PodsReadyTimeout"
+
+	// WorkloadEvictedByClusterQueueStopped indicates that the workload was
+	// evicted because its ClusterQueue was stopped with the HoldAndDrain
+	// policy.
+	WorkloadEvictedByClusterQueueStopped = "ClusterQueueStopped"
+
+	// WorkloadEvictedByDeactivation indicates that the workload was evicted
+	// because it had .spec.active set to false.
+	WorkloadEvictedByDeactivation = "InactiveWorkload"
+)
+
+const (
+	// WorkloadInactive is the reason of the Deactivated condition for the
+	// workloads that were deactivated by setting .spec.active to false.
+	WorkloadInactive = "InactiveWorkload"
+
+	// WorkloadRequeuingLimitExceeded is the reason of the Deactivated
+	// condition for the workloads that Kueue deactivated because they
+	// exceeded the backoffLimitCount of the requeuing strategy.
+	WorkloadRequeuingLimitExceeded = "RequeuingLimitExceeded"
+
+	// WorkloadAdmissionCheckRejected is the reason of the Deactivated
+	// condition for the workloads that Kueue deactivated because one of
+	// their admission checks is Rejected.
+	WorkloadAdmissionCheckRejected = "AdmissionCheckRejected"
+
+	// WorkloadReactivated is the reason of the Deactivated condition with
+	// status False for the workloads that had .spec.active set back to true.
+	WorkloadReactivated = "Reactivated"
 )
 
 // +kubebuilder:object:root=true
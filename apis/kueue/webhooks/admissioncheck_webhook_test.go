@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+)
+
+func TestValidateAdmissionCheckUpdate(t *testing.T) {
+	controllerNamePath := field.NewPath("spec", "controllerName")
+	testcases := []struct {
+		name    string
+		newAc   *kueue.AdmissionCheck
+		oldAc   *kueue.AdmissionCheck
+		wantErr field.ErrorList
+	}{
+		{
+			name:  "same controller",
+			newAc: utiltesting.MakeAdmissionCheck("budget", "budget-controller").Active("True").Obj(),
+			oldAc: utiltesting.MakeAdmissionCheck("budget", "budget-controller").Obj(),
+		},
+		{
+			name:  "controller changed",
+			newAc: utiltesting.MakeAdmissionCheck("budget", "other-controller").Obj(),
+			oldAc: utiltesting.MakeAdmissionCheck("budget", "budget-controller").Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(controllerNamePath, "other-controller", ""),
+			},
+		},
+		{
+			name:  "empty controller",
+			newAc: utiltesting.MakeAdmissionCheck("budget", "").Obj(),
+			oldAc: utiltesting.MakeAdmissionCheck("budget", "").Obj(),
+			wantErr: field.ErrorList{
+				field.Required(controllerNamePath, ""),
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotErr := ValidateAdmissionCheckUpdate(tc.newAc, tc.oldAc)
+			if diff := cmp.Diff(tc.wantErr, gotErr, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("ValidateAdmissionCheckUpdate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
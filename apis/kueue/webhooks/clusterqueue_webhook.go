@@ -18,7 +18,11 @@ package webhooks
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/robfig/cron/v3"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apivalidation "k8s.io/apimachinery/pkg/api/validation"
@@ -35,16 +39,22 @@ import (
 )
 
 const (
-	isNegativeErrorMsg string = `must be greater than or equal to 0`
+	isNegativeErrorMsg        string = `must be greater than or equal to 0`
+	lendingLimitErrorMsg      string = `must be less than or equal to the nominalQuota`
+	limitIsNotAllowedErrorMsg string = `must be nil when cohort is empty`
+	fairSharingDisabledMsg    string = `requires fair sharing to be enabled`
 )
 
-type ClusterQueueWebhook struct{}
+type ClusterQueueWebhook struct {
+	fairSharing bool
+}
 
-func setupWebhookForClusterQueue(mgr ctrl.Manager) error {
+func setupWebhookForClusterQueue(mgr ctrl.Manager, fairSharing bool) error {
+	wh := &ClusterQueueWebhook{fairSharing: fairSharing}
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(&kueue.ClusterQueue{}).
-		WithDefaulter(&ClusterQueueWebhook{}).
-		WithValidator(&ClusterQueueWebhook{}).
+		WithDefaulter(wh).
+		WithValidator(wh).
 		Complete()
 }
 
@@ -79,6 +89,9 @@ func (w *ClusterQueueWebhook) ValidateCreate(ctx context.Context, obj runtime.Ob
 	log := ctrl.LoggerFrom(ctx).WithName("clusterqueue-webhook")
 	log.V(5).Info("Validating create", "clusterQueue", klog.KObj(cq))
 	allErrs := ValidateClusterQueue(cq)
+	if !w.fairSharing {
+		allErrs = append(allErrs, validatePreemptionWithoutFairSharing(cq.Spec.Preemption, nil)...)
+	}
 	return allErrs.ToAggregate()
 }
 
@@ -90,6 +103,9 @@ func (w *ClusterQueueWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj
 	log := ctrl.LoggerFrom(ctx).WithName("clusterqueue-webhook")
 	log.V(5).Info("Validating update", "clusterQueue", klog.KObj(newCQ))
 	allErrs := ValidateClusterQueueUpdate(newCQ, oldCQ)
+	if !w.fairSharing {
+		allErrs = append(allErrs, validatePreemptionWithoutFairSharing(newCQ.Spec.Preemption, oldCQ.Spec.Preemption)...)
+	}
 	return allErrs.ToAggregate()
 }
 
@@ -105,13 +121,32 @@ func ValidateClusterQueue(cq *kueue.ClusterQueue) field.ErrorList {
 	if len(cq.Spec.Cohort) != 0 {
 		allErrs = append(allErrs, validateNameReference(cq.Spec.Cohort, path.Child("cohort"))...)
 	}
-	allErrs = append(allErrs, validateResourceGroups(cq.Spec.ResourceGroups, path.Child("resourceGroups"))...)
+	allErrs = append(allErrs, validateResourceGroups(cq.Spec.ResourceGroups, cq.Spec.Cohort, path.Child("resourceGroups"))...)
 	allErrs = append(allErrs,
 		validation.ValidateLabelSelector(cq.Spec.NamespaceSelector, validation.LabelSelectorValidationOptions{}, path.Child("namespaceSelector"))...)
+	if cq.Spec.FairSharing != nil && cq.Spec.FairSharing.Weight != nil {
+		allErrs = append(allErrs, validateResourceQuantity(*cq.Spec.FairSharing.Weight, path.Child("fairSharing", "weight"))...)
+	}
+	for i, ac := range cq.Spec.AdmissionChecks {
+		allErrs = append(allErrs, validateNameReference(ac, path.Child("admissionChecks").Index(i))...)
+	}
+	if cq.Spec.Preemption != nil {
+		allErrs = append(allErrs, validatePreemption(cq.Spec.Preemption, path.Child("preemption"))...)
+	}
 
 	return allErrs
 }
 
+func validatePreemption(preemption *kueue.ClusterQueuePreemption, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if preemption.BorrowWithinCohort != nil && preemption.BorrowWithinCohort.Policy != kueue.BorrowWithinCohortPolicyNever {
+		if preemption.ReclaimWithinCohort == kueue.PreemptionPolicyNever || preemption.ReclaimWithinCohort == kueue.PreemptionPolicyFairSharing {
+			allErrs = append(allErrs, field.Invalid(path, preemption, fmt.Sprintf("reclaimWithinCohort=%s and borrowWithinCohort.policy=%s cannot be used together", preemption.ReclaimWithinCohort, preemption.BorrowWithinCohort.Policy)))
+		}
+	}
+	return allErrs
+}
+
 // Since Kubernetes 1.25, we can use CEL validation rules to implement
 // a few common immutability patterns directly in the manifest for a CRD.
 // ref: https://kubernetes.io/blog/2022/09/29/enforce-immutability-using-cel/
@@ -123,7 +158,20 @@ func ValidateClusterQueueUpdate(newObj, oldObj *kueue.ClusterQueue) field.ErrorL
 	return allErrs
 }
 
-func validateResourceGroups(resourceGroups []kueue.ResourceGroup, path *field.Path) field.ErrorList {
+// validatePreemptionWithoutFairSharing rejects the FairSharing preemption
+// policy, unless it was already set, so that ClusterQueues created while fair
+// sharing was enabled can still be updated, for example to be deleted.
+func validatePreemptionWithoutFairSharing(preemption, oldPreemption *kueue.ClusterQueuePreemption) field.ErrorList {
+	if preemption == nil || preemption.ReclaimWithinCohort != kueue.PreemptionPolicyFairSharing {
+		return nil
+	}
+	if oldPreemption != nil && oldPreemption.ReclaimWithinCohort == kueue.PreemptionPolicyFairSharing {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(field.NewPath("spec", "preemption", "reclaimWithinCohort"), preemption.ReclaimWithinCohort, fairSharingDisabledMsg)}
+}
+
+func validateResourceGroups(resourceGroups []kueue.ResourceGroup, cohort string, path *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
 	seenResources := sets.New[corev1.ResourceName]()
 	seenFlavors := sets.New[kueue.ResourceFlavorReference]()
@@ -141,7 +189,7 @@ func validateResourceGroups(resourceGroups []kueue.ResourceGroup, path *field.Pa
 		}
 		for j, fqs := range rg.Flavors {
 			path := path.Child("flavors").Index(j)
-			allErrs = append(allErrs, validateFlavorQuotas(fqs, rg.CoveredResources, path)...)
+			allErrs = append(allErrs, validateFlavorQuotas(fqs, rg.CoveredResources, cohort, path)...)
 			if seenFlavors.Has(fqs.Name) {
 				allErrs = append(allErrs, field.Duplicate(path.Child("name"), fqs.Name))
 			} else {
@@ -152,7 +200,7 @@ func validateResourceGroups(resourceGroups []kueue.ResourceGroup, path *field.Pa
 	return allErrs
 }
 
-func validateFlavorQuotas(flavorQuotas kueue.FlavorQuotas, coveredResources []corev1.ResourceName, path *field.Path) field.ErrorList {
+func validateFlavorQuotas(flavorQuotas kueue.FlavorQuotas, coveredResources []corev1.ResourceName, cohort string, path *field.Path) field.ErrorList {
 	allErrs := validateNameReference(string(flavorQuotas.Name), path.Child("name"))
 	if len(flavorQuotas.Resources) != len(coveredResources) {
 		allErrs = append(allErrs, field.Invalid(path.Child("resources"), field.OmitValueType{}, "must have the same number of resources as the coveredResources"))
@@ -164,9 +212,60 @@ func validateFlavorQuotas(flavorQuotas kueue.FlavorQuotas, coveredResources []co
 			allErrs = append(allErrs, field.Invalid(path.Child("name"), rq.Name, "must match the name in coveredResources"))
 		}
 		allErrs = append(allErrs, validateResourceQuantity(rq.NominalQuota, path.Child("nominalQuota"))...)
+		if rq.NominalQuotaPercent != nil {
+			if !rq.NominalQuota.IsZero() {
+				allErrs = append(allErrs, field.Invalid(path.Child("nominalQuota"), rq.NominalQuota.String(), "must be zero when nominalQuotaPercent is set"))
+			}
+			if len(rq.Schedules) > 0 {
+				allErrs = append(allErrs, field.Forbidden(path.Child("schedules"), "must be empty when nominalQuotaPercent is set"))
+			}
+		}
 		if rq.BorrowingLimit != nil {
 			allErrs = append(allErrs, validateResourceQuantity(*rq.BorrowingLimit, path.Child("borrowingLimit"))...)
 		}
+		if rq.LendingLimit != nil {
+			lendingLimitPath := path.Child("lendingLimit")
+			allErrs = append(allErrs, validateResourceQuantity(*rq.LendingLimit, lendingLimitPath)...)
+			if len(cohort) == 0 {
+				allErrs = append(allErrs, field.Invalid(lendingLimitPath, rq.LendingLimit.String(), limitIsNotAllowedErrorMsg))
+			}
+			// With nominalQuotaPercent, the nominal quota is only known at runtime.
+			if rq.NominalQuotaPercent == nil && rq.LendingLimit.Cmp(rq.NominalQuota) > 0 {
+				allErrs = append(allErrs, field.Invalid(lendingLimitPath, rq.LendingLimit.String(), lendingLimitErrorMsg))
+			}
+		}
+		for j := range rq.Schedules {
+			allErrs = append(allErrs, validateQuotaSchedule(&rq.Schedules[j], cohort, path.Child("schedules").Index(j))...)
+		}
+	}
+	return allErrs
+}
+
+func validateQuotaSchedule(qs *kueue.QuotaSchedule, cohort string, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(qs.Name) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("name"), ""))
+	}
+	if strings.Contains(qs.Schedule, "TZ") {
+		allErrs = append(allErrs, field.Invalid(path.Child("schedule"), qs.Schedule, "cannot use TZ or CRON_TZ in schedule, use the timeZone field instead"))
+	} else if _, err := cron.ParseStandard(qs.Schedule); err != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("schedule"), qs.Schedule, err.Error()))
+	}
+	if qs.TimeZone != nil {
+		if _, err := time.LoadLocation(*qs.TimeZone); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("timeZone"), *qs.TimeZone, err.Error()))
+		}
+	}
+	if qs.Duration.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("duration"), qs.Duration.String(), "must be positive"))
+	}
+	allErrs = append(allErrs, validateResourceQuantity(qs.NominalQuota, path.Child("nominalQuota"))...)
+	if qs.BorrowingLimit != nil {
+		borrowingLimitPath := path.Child("borrowingLimit")
+		allErrs = append(allErrs, validateResourceQuantity(*qs.BorrowingLimit, borrowingLimitPath)...)
+		if len(cohort) == 0 {
+			allErrs = append(allErrs, field.Invalid(borrowingLimitPath, qs.BorrowingLimit.String(), limitIsNotAllowedErrorMsg))
+		}
 	}
 	return allErrs
 }
@@ -17,13 +17,17 @@ limitations under the License.
 package webhooks
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	testingutil "sigs.k8s.io/kueue/pkg/util/testing"
@@ -119,6 +123,144 @@ func TestValidateClusterQueue(t *testing.T) {
 				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("borrowingLimit"), "-1", ""),
 			},
 		},
+		{
+			name: "flavor quota with lendingLimit 0",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				Cohort("cohort").
+				ResourceGroup(
+					*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1", "", "0").Obj()).
+				Obj(),
+		},
+		{
+			name: "flavor quota with negative lendingLimit",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				Cohort("cohort").
+				ResourceGroup(
+					*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1", "", "-1").Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("lendingLimit"), "-1", ""),
+			},
+		},
+		{
+			name: "flavor quota with lendingLimit greater than nominalQuota",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				Cohort("cohort").
+				ResourceGroup(
+					*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1", "", "2").Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("lendingLimit"), "2", ""),
+			},
+		},
+		{
+			name: "flavor quota with lendingLimit and empty cohort",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				ResourceGroup(
+					*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1", "", "1").Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("lendingLimit"), "1", ""),
+			},
+		},
+		{
+			name: "valid quota schedule",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				Cohort("cohort").
+				ResourceGroup(
+					*testingutil.MakeFlavorQuotas("x86").
+						Resource("cpu", "1").
+						Schedule("cpu", *testingutil.MakeQuotaSchedule("business-hours", "0 9 * * 1-5", 9*time.Hour, "4").
+							TimeZone("Europe/Madrid").
+							BorrowingLimit("2").
+							Obj()).
+						Obj()).
+				Obj(),
+		},
+		{
+			name: "invalid quota schedule",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				ResourceGroup(
+					*testingutil.MakeFlavorQuotas("x86").
+						Resource("cpu", "1").
+						Schedule("cpu", *testingutil.MakeQuotaSchedule("nights", "0 20 * *", 0, "-1").
+							TimeZone("Mars/Olympus").
+							BorrowingLimit("-1").
+							Obj()).
+						Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("schedules").Index(0).Child("schedule"), "0 20 * *", ""),
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("schedules").Index(0).Child("timeZone"), "Mars/Olympus", ""),
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("schedules").Index(0).Child("duration"), "0s", ""),
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("schedules").Index(0).Child("nominalQuota"), "-1", ""),
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("schedules").Index(0).Child("borrowingLimit"), "-1", ""),
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("schedules").Index(0).Child("borrowingLimit"), "-1", ""),
+			},
+		},
+		{
+			name: "quota schedule with a time zone in the schedule",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				ResourceGroup(
+					*testingutil.MakeFlavorQuotas("x86").
+						Resource("cpu", "1").
+						Schedule("cpu", *testingutil.MakeQuotaSchedule("nights", "CRON_TZ=Europe/Madrid 0 20 * * *", time.Hour, "4").Obj()).
+						Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("schedules").Index(0).Child("schedule"), "CRON_TZ=Europe/Madrid 0 20 * * *", ""),
+			},
+		},
+		{
+			name: "valid nominal quota percent",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				Cohort("cohort").
+				ResourceGroup(
+					*testingutil.MakeFlavorQuotas("x86").
+						Resource("cpu", "0", "", "4").
+						NominalQuotaPercent("cpu", 50).
+						Obj()).
+				Obj(),
+		},
+		{
+			name: "nominal quota percent with nominal quota and schedules",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				ResourceGroup(
+					*testingutil.MakeFlavorQuotas("x86").
+						Resource("cpu", "1").
+						NominalQuotaPercent("cpu", 50).
+						Schedule("cpu", *testingutil.MakeQuotaSchedule("nights", "0 20 * * *", time.Hour, "4").Obj()).
+						Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("nominalQuota"), "1", ""),
+				field.Forbidden(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("schedules"), ""),
+			},
+		},
+		{
+			name: "zero fair sharing weight",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				FairWeight(resource.MustParse("0")).
+				Obj(),
+		},
+		{
+			name: "negative fair sharing weight",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				FairWeight(resource.MustParse("-1")).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(specPath.Child("fairSharing", "weight"), "-1", ""),
+			},
+		},
+		{
+			name: "admission checks",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				AdmissionChecks("budget", "@license").
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(specPath.Child("admissionChecks").Index(1), "@license", ""),
+			},
+		},
 		{
 			name: "empty queueing strategy is supported",
 			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
@@ -257,6 +399,46 @@ func TestValidateClusterQueue(t *testing.T) {
 				field.Duplicate(resourceGroupsPath.Index(1).Child("flavors").Index(0).Child("name"), nil),
 			},
 		},
+		{
+			name: "borrowWithinCohort with reclaimWithinCohort=LowerPriority",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				Preemption(kueue.ClusterQueuePreemption{
+					ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
+					BorrowWithinCohort: &kueue.BorrowWithinCohort{
+						Policy:               kueue.BorrowWithinCohortPolicyLowerPriority,
+						MaxPriorityThreshold: pointer.Int32(10),
+					},
+				}).
+				Obj(),
+		},
+		{
+			name: "borrowWithinCohort with reclaimWithinCohort=Never",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				Preemption(kueue.ClusterQueuePreemption{
+					ReclaimWithinCohort: kueue.PreemptionPolicyNever,
+					BorrowWithinCohort: &kueue.BorrowWithinCohort{
+						Policy: kueue.BorrowWithinCohortPolicyLowerPriority,
+					},
+				}).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(specPath.Child("preemption"), nil, ""),
+			},
+		},
+		{
+			name: "borrowWithinCohort with reclaimWithinCohort=FairSharing",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				Preemption(kueue.ClusterQueuePreemption{
+					ReclaimWithinCohort: kueue.PreemptionPolicyFairSharing,
+					BorrowWithinCohort: &kueue.BorrowWithinCohort{
+						Policy: kueue.BorrowWithinCohortPolicyLowerPriority,
+					},
+				}).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(specPath.Child("preemption"), nil, ""),
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -301,3 +483,52 @@ func TestValidateClusterQueueUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateClusterQueueWithoutFairSharing(t *testing.T) {
+	fairSharing := kueue.ClusterQueuePreemption{ReclaimWithinCohort: kueue.PreemptionPolicyFairSharing}
+	anyPolicy := kueue.ClusterQueuePreemption{ReclaimWithinCohort: kueue.PreemptionPolicyAny}
+	testcases := []struct {
+		name            string
+		newClusterQueue *kueue.ClusterQueue
+		oldClusterQueue *kueue.ClusterQueue
+		fairSharing     bool
+		wantErr         bool
+	}{
+		{
+			name:            "FairSharing policy with fair sharing enabled",
+			newClusterQueue: testingutil.MakeClusterQueue("cluster-queue").Preemption(fairSharing).Obj(),
+			fairSharing:     true,
+		},
+		{
+			name:            "FairSharing policy with fair sharing disabled",
+			newClusterQueue: testingutil.MakeClusterQueue("cluster-queue").Preemption(fairSharing).Obj(),
+			wantErr:         true,
+		},
+		{
+			name:            "update to the FairSharing policy with fair sharing disabled",
+			newClusterQueue: testingutil.MakeClusterQueue("cluster-queue").Preemption(fairSharing).Obj(),
+			oldClusterQueue: testingutil.MakeClusterQueue("cluster-queue").Preemption(anyPolicy).Obj(),
+			wantErr:         true,
+		},
+		{
+			name:            "FairSharing policy already set with fair sharing disabled",
+			newClusterQueue: testingutil.MakeClusterQueue("cluster-queue").Preemption(fairSharing).Obj(),
+			oldClusterQueue: testingutil.MakeClusterQueue("cluster-queue").Preemption(fairSharing).Obj(),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			wh := &ClusterQueueWebhook{fairSharing: tc.fairSharing}
+			var gotErr error
+			if tc.oldClusterQueue == nil {
+				gotErr = wh.ValidateCreate(context.Background(), tc.newClusterQueue)
+			} else {
+				gotErr = wh.ValidateUpdate(context.Background(), tc.oldClusterQueue, tc.newClusterQueue)
+			}
+			if (gotErr != nil) != tc.wantErr {
+				t.Errorf("Unexpected error: %v, want error: %t", gotErr, tc.wantErr)
+			}
+		})
+	}
+}
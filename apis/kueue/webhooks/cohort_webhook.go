@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+)
+
+type CohortWebhook struct{}
+
+func setupWebhookForCohort(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&kueue.Cohort{}).
+		WithValidator(&CohortWebhook{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-kueue-x-k8s-io-v1beta1-cohort,mutating=false,failurePolicy=fail,sideEffects=None,groups=kueue.x-k8s.io,resources=cohorts,verbs=create;update,versions=v1beta1,name=vcohort.kb.io,admissionReviewVersions=v1
+
+var _ webhook.CustomValidator = &CohortWebhook{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *CohortWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	cohort := obj.(*kueue.Cohort)
+	log := ctrl.LoggerFrom(ctx).WithName("cohort-webhook")
+	log.V(5).Info("Validating create", "cohort", klog.KObj(cohort))
+	return ValidateCohort(cohort).ToAggregate()
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *CohortWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	newCohort := newObj.(*kueue.Cohort)
+	log := ctrl.LoggerFrom(ctx).WithName("cohort-webhook")
+	log.V(5).Info("Validating update", "cohort", klog.KObj(newCohort))
+	return ValidateCohort(newCohort).ToAggregate()
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *CohortWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func ValidateCohort(cohort *kueue.Cohort) field.ErrorList {
+	var allErrs field.ErrorList
+
+	parentPath := field.NewPath("spec", "parent")
+	if len(cohort.Spec.Parent) > 0 {
+		allErrs = append(allErrs, validateNameReference(cohort.Spec.Parent, parentPath)...)
+		if cohort.Spec.Parent == cohort.Name {
+			allErrs = append(allErrs, field.Invalid(parentPath, cohort.Spec.Parent, "must not be the cohort itself"))
+		}
+	}
+	allErrs = append(allErrs, validateCohortResourceGroups(cohort.Spec.ResourceGroups, field.NewPath("spec", "resourceGroups"))...)
+	return allErrs
+}
+
+// validateCohortResourceGroups validates the resource groups of a cohort like
+// the ones of a ClusterQueue, with the limits and schedules forbidden, as
+// they only apply to the quota of a ClusterQueue.
+func validateCohortResourceGroups(resourceGroups []kueue.ResourceGroup, path *field.Path) field.ErrorList {
+	allErrs := validateResourceGroups(resourceGroups, "", path)
+	for i, rg := range resourceGroups {
+		for j, fqs := range rg.Flavors {
+			for k, rq := range fqs.Resources {
+				path := path.Index(i).Child("flavors").Index(j).Child("resources").Index(k)
+				if rq.BorrowingLimit != nil {
+					allErrs = append(allErrs, field.Forbidden(path.Child("borrowingLimit"), "not supported in a cohort"))
+				}
+				if rq.LendingLimit != nil {
+					allErrs = append(allErrs, field.Forbidden(path.Child("lendingLimit"), "not supported in a cohort"))
+				}
+				if len(rq.Schedules) > 0 {
+					allErrs = append(allErrs, field.Forbidden(path.Child("schedules"), "not supported in a cohort"))
+				}
+				if rq.NominalQuotaPercent != nil {
+					allErrs = append(allErrs, field.Forbidden(path.Child("nominalQuotaPercent"), "not supported in a cohort"))
+				}
+			}
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+)
+
+func TestValidateCohort(t *testing.T) {
+	parentPath := field.NewPath("spec", "parent")
+	resourceGroupsPath := field.NewPath("spec", "resourceGroups")
+	testcases := []struct {
+		name    string
+		cohort  *kueue.Cohort
+		wantErr field.ErrorList
+	}{
+		{
+			name:   "without parent",
+			cohort: utiltesting.MakeCohort("team-a").Obj(),
+		},
+		{
+			name:   "with parent",
+			cohort: utiltesting.MakeCohort("team-a").Parent("department").Obj(),
+		},
+		{
+			name:   "invalid parent name",
+			cohort: utiltesting.MakeCohort("team-a").Parent("@department").Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(parentPath, "@department", ""),
+			},
+		},
+		{
+			name: "with resource groups",
+			cohort: utiltesting.MakeCohort("team-a").
+				ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource("cpu", "10").Obj()).
+				Obj(),
+		},
+		{
+			name: "with limits in the resource groups",
+			cohort: utiltesting.MakeCohort("team-a").
+				ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource("cpu", "10", "5", "5").Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("lendingLimit"), "5", ""),
+				field.Forbidden(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("borrowingLimit"), ""),
+				field.Forbidden(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("lendingLimit"), ""),
+			},
+		},
+		{
+			name: "with nominal quota percent in the resource groups",
+			cohort: utiltesting.MakeCohort("team-a").
+				ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource("cpu").NominalQuotaPercent("cpu", 50).Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Forbidden(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("nominalQuotaPercent"), ""),
+			},
+		},
+		{
+			name:   "parent is itself",
+			cohort: utiltesting.MakeCohort("team-a").Parent("team-a").Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(parentPath, "team-a", ""),
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotErr := ValidateCohort(tc.cohort)
+			if diff := cmp.Diff(tc.wantErr, gotErr, cmpopts.IgnoreFields(field.Error{}, "Detail")); diff != "" {
+				t.Errorf("ValidateCohort() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
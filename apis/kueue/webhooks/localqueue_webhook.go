@@ -68,9 +68,30 @@ func ValidateLocalQueue(q *kueue.LocalQueue) field.ErrorList {
 	var allErrs field.ErrorList
 	clusterQueuePath := field.NewPath("spec", "clusterQueue")
 	allErrs = append(allErrs, validateNameReference(string(q.Spec.ClusterQueue), clusterQueuePath)...)
+	allErrs = append(allErrs, validateLocalQueueLimits(q.Spec.Limits, field.NewPath("spec", "limits"))...)
 	return allErrs
 }
 
 func ValidateLocalQueueUpdate(newObj, oldObj *kueue.LocalQueue) field.ErrorList {
-	return apivalidation.ValidateImmutableField(newObj.Spec.ClusterQueue, oldObj.Spec.ClusterQueue, field.NewPath("spec", "clusterQueue"))
+	allErrs := apivalidation.ValidateImmutableField(newObj.Spec.ClusterQueue, oldObj.Spec.ClusterQueue, field.NewPath("spec", "clusterQueue"))
+	allErrs = append(allErrs, validateLocalQueueLimits(newObj.Spec.Limits, field.NewPath("spec", "limits"))...)
+	return allErrs
+}
+
+func validateLocalQueueLimits(limits *kueue.LocalQueueLimits, path *field.Path) field.ErrorList {
+	if limits == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	for i, fl := range limits.Flavors {
+		flavorPath := path.Child("flavors").Index(i)
+		allErrs = append(allErrs, validateNameReference(string(fl.Name), flavorPath.Child("name"))...)
+		for j, rl := range fl.Resources {
+			allErrs = append(allErrs, validateResourceQuantity(rl.MaxUsage, flavorPath.Child("resources").Index(j).Child("maxUsage"))...)
+		}
+	}
+	if limits.MaxAdmittedWorkloads != nil && *limits.MaxAdmittedWorkloads < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxAdmittedWorkloads"), *limits.MaxAdmittedWorkloads, isNegativeErrorMsg))
+	}
+	return allErrs
 }
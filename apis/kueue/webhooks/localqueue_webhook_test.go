@@ -43,6 +43,24 @@ func TestValidateLocalQueueCreate(t *testing.T) {
 				field.Invalid(field.NewPath("spec").Child("clusterQueue"), "invalid_name", ""),
 			},
 		},
+		"should allow queue creation with limits": {
+			queue: testingutil.MakeLocalQueue(testLocalQueueName, testLocalQueueNamespace).
+				ClusterQueue("foo").
+				MaxUsage("default", "cpu", "10").
+				MaxAdmittedWorkloads(5).
+				Obj(),
+		},
+		"should reject queue creation with invalid limits": {
+			queue: testingutil.MakeLocalQueue(testLocalQueueName, testLocalQueueNamespace).
+				ClusterQueue("foo").
+				MaxUsage("default", "cpu", "-1").
+				MaxAdmittedWorkloads(-1).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "limits", "flavors").Index(0).Child("resources").Index(0).Child("maxUsage"), "-1", ""),
+				field.Invalid(field.NewPath("spec", "limits", "maxAdmittedWorkloads"), -1, ""),
+			},
+		},
 	}
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
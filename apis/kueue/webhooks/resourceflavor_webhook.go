@@ -92,6 +92,21 @@ func ValidateResourceFlavor(rf *kueue.ResourceFlavor) field.ErrorList {
 	allErrs = append(allErrs, metavalidation.ValidateLabels(rf.Spec.NodeLabels, specPath.Child("nodeLabels"))...)
 
 	allErrs = append(allErrs, validateNodeTaints(rf.Spec.NodeTaints, specPath.Child("nodeTaints"))...)
+	allErrs = append(allErrs, validateTopologyLevels(rf.Spec.TopologyLevels, specPath.Child("topologyLevels"))...)
+	return allErrs
+}
+
+func validateTopologyLevels(levels []kueue.TopologyLevel, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := sets.New[string]()
+	for i, level := range levels {
+		labelPath := fldPath.Index(i).Child("nodeLabel")
+		allErrs = append(allErrs, metavalidation.ValidateLabelName(level.NodeLabel, labelPath)...)
+		if seen.Has(level.NodeLabel) {
+			allErrs = append(allErrs, field.Duplicate(labelPath, level.NodeLabel))
+		}
+		seen.Insert(level.NodeLabel)
+	}
 	return allErrs
 }
 
@@ -73,6 +73,22 @@ func TestValidateResourceFlavor(t *testing.T) {
 				field.Invalid(field.NewPath("spec", "nodeLabels"), "@abc", ""),
 			},
 		},
+		{
+			name: "valid topology levels",
+			rf: utiltesting.MakeResourceFlavor("resource-flavor").
+				TopologyLevels("cloud.provider.com/block", "cloud.provider.com/rack").
+				Obj(),
+		},
+		{
+			name: "invalid topology levels",
+			rf: utiltesting.MakeResourceFlavor("resource-flavor").
+				TopologyLevels("cloud.provider.com/rack", "@abc", "cloud.provider.com/rack").
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "topologyLevels").Index(1).Child("nodeLabel"), "@abc", ""),
+				field.Duplicate(field.NewPath("spec", "topologyLevels").Index(2).Child("nodeLabel"), "cloud.provider.com/rack"),
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -18,9 +18,29 @@ package webhooks
 
 import ctrl "sigs.k8s.io/controller-runtime"
 
+type options struct {
+	fairSharing bool
+}
+
+// Option configures the webhooks.
+type Option func(*options)
+
+// WithFairSharing indicates if fair sharing is enabled, which is required
+// by the FairSharing preemption policy of ClusterQueues.
+func WithFairSharing(f bool) Option {
+	return func(o *options) {
+		o.fairSharing = f
+	}
+}
+
 // Setup sets up the webhooks for core controllers. It returns the name of the
 // webhook that failed to create and an error, if any.
-func Setup(mgr ctrl.Manager) (string, error) {
+func Setup(mgr ctrl.Manager, opts ...Option) (string, error) {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	if err := setupWebhookForWorkload(mgr); err != nil {
 		return "Workload", err
 	}
@@ -29,12 +49,20 @@ func Setup(mgr ctrl.Manager) (string, error) {
 		return "ResourceFlavor", err
 	}
 
-	if err := setupWebhookForClusterQueue(mgr); err != nil {
+	if err := setupWebhookForClusterQueue(mgr, options.fairSharing); err != nil {
 		return "ClusterQueue", err
 	}
 
 	if err := setupWebhookForLocalQueue(mgr); err != nil {
 		return "Queue", err
 	}
+
+	if err := setupWebhookForCohort(mgr); err != nil {
+		return "Cohort", err
+	}
+
+	if err := setupWebhookForAdmissionCheck(mgr); err != nil {
+		return "AdmissionCheck", err
+	}
 	return "", nil
 }
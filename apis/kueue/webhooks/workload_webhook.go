@@ -18,11 +18,11 @@ package webhooks
 
 import (
 	"context"
+	"fmt"
 
 	apivalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog/v2"
@@ -109,6 +109,10 @@ func ValidateWorkload(obj *kueue.Workload) field.ErrorList {
 		}
 	}
 
+	if obj.Spec.PriorityClassSource == kueue.WorkloadPriorityClassSource && len(obj.Spec.PriorityClassName) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("priorityClassName"), "priorityClassName should be set when priorityClassSource is "+kueue.WorkloadPriorityClassSource))
+	}
+
 	if len(obj.Spec.QueueName) > 0 {
 		allErrs = append(allErrs, validateNameReference(obj.Spec.QueueName, specPath.Child("queueName"))...)
 	}
@@ -119,6 +123,7 @@ func ValidateWorkload(obj *kueue.Workload) field.ErrorList {
 	}
 
 	allErrs = append(allErrs, metav1validation.ValidateConditions(obj.Status.Conditions, statusPath.Child("conditions"))...)
+	allErrs = append(allErrs, validateReclaimablePods(obj, statusPath.Child("reclaimablePods"))...)
 
 	return allErrs
 }
@@ -129,6 +134,9 @@ func validatePodSet(ps *kueue.PodSet, path *field.Path) field.ErrorList {
 	for _, msg := range validation.IsDNS1123Label(ps.Name) {
 		allErrs = append(allErrs, field.Invalid(path.Child("name"), ps.Name, msg))
 	}
+	if ps.MinCount != nil && (*ps.MinCount < 1 || *ps.MinCount > ps.Count) {
+		allErrs = append(allErrs, field.Invalid(path.Child("minCount"), *ps.MinCount, fmt.Sprintf("must be positive and not greater than count (%d)", ps.Count)))
+	}
 	return allErrs
 }
 
@@ -137,24 +145,60 @@ func validateAdmission(obj *kueue.Workload, path *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
 	allErrs = append(allErrs, validateNameReference(string(admission.ClusterQueue), path.Child("clusterQueue"))...)
 
-	names := sets.NewString()
-	for _, ps := range obj.Spec.PodSets {
-		names.Insert(ps.Name)
+	podSets := make(map[string]*kueue.PodSet, len(obj.Spec.PodSets))
+	for i := range obj.Spec.PodSets {
+		podSets[obj.Spec.PodSets[i].Name] = &obj.Spec.PodSets[i]
 	}
 	psFlavorsPath := path.Child("podSetFlavors")
-	if names.Len() != len(admission.PodSetAssignments) {
+	if len(podSets) != len(admission.PodSetAssignments) {
 		allErrs = append(allErrs, field.Invalid(psFlavorsPath, field.OmitValueType{}, "must have the same number of podSets as the spec"))
 	}
 
-	for i, ps := range admission.PodSetAssignments {
-		if !names.Has(ps.Name) {
-			allErrs = append(allErrs, field.NotFound(psFlavorsPath.Index(i).Child("name"), ps.Name))
+	for i, psa := range admission.PodSetAssignments {
+		ps, found := podSets[psa.Name]
+		if !found {
+			allErrs = append(allErrs, field.NotFound(psFlavorsPath.Index(i).Child("name"), psa.Name))
+			continue
+		}
+		if psa.Count != nil {
+			minCount := ps.Count
+			if ps.MinCount != nil {
+				minCount = *ps.MinCount
+			}
+			if *psa.Count < minCount || *psa.Count > ps.Count {
+				allErrs = append(allErrs, field.Invalid(psFlavorsPath.Index(i).Child("count"), *psa.Count, fmt.Sprintf("must be between the minCount (%d) and the count (%d) of the podSet", minCount, ps.Count)))
+			}
+		}
+		if ta := psa.TopologyAssignment; ta != nil && len(ta.Values) != len(ta.Levels) {
+			allErrs = append(allErrs, field.Invalid(psFlavorsPath.Index(i).Child("topologyAssignment", "values"), ta.Values, "must have the same number of elements as the levels"))
 		}
 	}
 
 	return allErrs
 }
 
+func validateReclaimablePods(obj *kueue.Workload, path *field.Path) field.ErrorList {
+	if len(obj.Status.ReclaimablePods) == 0 {
+		return nil
+	}
+	podSets := make(map[string]*kueue.PodSet, len(obj.Spec.PodSets))
+	for i := range obj.Spec.PodSets {
+		podSets[obj.Spec.PodSets[i].Name] = &obj.Spec.PodSets[i]
+	}
+	var allErrs field.ErrorList
+	for i, rp := range obj.Status.ReclaimablePods {
+		ps, found := podSets[rp.Name]
+		if !found {
+			allErrs = append(allErrs, field.NotFound(path.Index(i).Child("name"), rp.Name))
+			continue
+		}
+		if rp.Count < 0 || rp.Count > ps.Count {
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("count"), rp.Count, fmt.Sprintf("must be between 0 and the count (%d) of the podSet", ps.Count)))
+		}
+	}
+	return allErrs
+}
+
 func ValidateWorkloadUpdate(newObj, oldObj *kueue.Workload) field.ErrorList {
 	var allErrs field.ErrorList
 	specPath := field.NewPath("spec")
@@ -162,12 +206,34 @@ func ValidateWorkloadUpdate(newObj, oldObj *kueue.Workload) field.ErrorList {
 	allErrs = append(allErrs, apivalidation.ValidateImmutableField(newObj.Spec.PodSets, oldObj.Spec.PodSets, specPath.Child("podSets"))...)
 	if newObj.Status.Admission != nil && oldObj.Status.Admission != nil {
 		allErrs = append(allErrs, apivalidation.ValidateImmutableField(newObj.Spec.QueueName, oldObj.Spec.QueueName, specPath.Child("queueName"))...)
+		allErrs = append(allErrs, apivalidation.ValidateImmutableField(newObj.Spec.PriorityClassSource, oldObj.Spec.PriorityClassSource, specPath.Child("priorityClassSource"))...)
+		allErrs = append(allErrs, apivalidation.ValidateImmutableField(newObj.Spec.PriorityClassName, oldObj.Spec.PriorityClassName, specPath.Child("priorityClassName"))...)
 	}
 	allErrs = append(allErrs, validateAdmissionUpdate(newObj.Status.Admission, oldObj.Status.Admission, field.NewPath("status", "admission"))...)
+	if newObj.Status.Admission != nil && oldObj.Status.Admission != nil {
+		allErrs = append(allErrs, validateReclaimablePodsUpdate(newObj, oldObj, field.NewPath("status", "reclaimablePods"))...)
+	}
 
 	return allErrs
 }
 
+// validateReclaimablePodsUpdate validates that the number of reclaimable pods
+// doesn't decrease while the workload holds its quota reservation, as the
+// quota of those pods might already be used by other workloads.
+func validateReclaimablePodsUpdate(newObj, oldObj *kueue.Workload, path *field.Path) field.ErrorList {
+	newCounts := make(map[string]int32, len(newObj.Status.ReclaimablePods))
+	for _, rp := range newObj.Status.ReclaimablePods {
+		newCounts[rp.Name] = rp.Count
+	}
+	var allErrs field.ErrorList
+	for _, rp := range oldObj.Status.ReclaimablePods {
+		if newCount := newCounts[rp.Name]; newCount < rp.Count {
+			allErrs = append(allErrs, field.Invalid(path.Key(rp.Name).Child("count"), newCount, fmt.Sprintf("cannot be less than the previous value (%d) while the workload has quota reserved", rp.Count)))
+		}
+	}
+	return allErrs
+}
+
 // validateAdmissionUpdate validates that admission can be set or unset, but the
 // fields within can't change.
 func validateAdmissionUpdate(new, old *kueue.Admission, path *field.Path) field.ErrorList {
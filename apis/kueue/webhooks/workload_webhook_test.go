@@ -138,6 +138,21 @@ func TestValidateWorkload(t *testing.T) {
 				field.Invalid(specPath.Child("priority"), nil, ""),
 			},
 		},
+		"should have priorityClassName when priorityClassSource is workloadPriorityClass": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PriorityClassSource(kueue.WorkloadPriorityClassSource).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Required(specPath.Child("priorityClassName"), ""),
+			},
+		},
+		"should pass validation with a workloadPriorityClass": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PriorityClass("high").
+				PriorityClassSource(kueue.WorkloadPriorityClassSource).
+				Priority(100).
+				Obj(),
+		},
 		"should have a valid queueName": {
 			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
 				Queue("@invalid").
@@ -181,6 +196,67 @@ func TestValidateWorkload(t *testing.T) {
 				field.NotFound(statusPath.Child("admission", "podSetFlavors").Index(2).Child("name"), nil),
 			},
 		},
+		"should have minCount not greater than count": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PodSets(*testingutil.MakePodSet("main", 3).SetMinimumCount(4).Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(podSetsPath.Index(0).Child("minCount"), nil, ""),
+			},
+		},
+		"should accept an admission with fewer pods than count": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PodSets(*testingutil.MakePodSet("main", 3).SetMinimumCount(2).Obj()).
+				Admit(testingutil.MakeAdmission("cluster-queue", "main").AssignmentPodCount(2).Obj()).
+				Obj(),
+		},
+		"should have an admitted count not lower than minCount": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PodSets(*testingutil.MakePodSet("main", 3).SetMinimumCount(2).Obj()).
+				Admit(testingutil.MakeAdmission("cluster-queue", "main").AssignmentPodCount(1).Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(statusPath.Child("admission", "podSetFlavors").Index(0).Child("count"), nil, ""),
+			},
+		},
+		"should have an admitted count equal to count without minCount": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PodSets(*testingutil.MakePodSet("main", 3).Obj()).
+				Admit(testingutil.MakeAdmission("cluster-queue", "main").AssignmentPodCount(2).Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(statusPath.Child("admission", "podSetFlavors").Index(0).Child("count"), nil, ""),
+			},
+		},
+		"should have as many topology values as levels": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PodSets(*testingutil.MakePodSet("main", 3).Obj()).
+				Admit(testingutil.MakeAdmission("cluster-queue", "main").
+					AssignmentTopology([]string{"cloud.provider.com/block", "cloud.provider.com/rack"}, []string{"b1"}).
+					Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(statusPath.Child("admission", "podSetFlavors").Index(0).Child("topologyAssignment", "values"), nil, ""),
+			},
+		},
+		"should have reclaimable pods of existing podSets": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PodSets(*testingutil.MakePodSet("main", 3).Obj()).
+				ReclaimablePods(kueue.ReclaimablePod{Name: "other", Count: 1}).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.NotFound(statusPath.Child("reclaimablePods").Index(0).Child("name"), nil),
+			},
+		},
+		"should have reclaimable pods not greater than count": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PodSets(*testingutil.MakePodSet("main", 3).Obj()).
+				ReclaimablePods(kueue.ReclaimablePod{Name: "main", Count: 4}).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(statusPath.Child("reclaimablePods").Index(0).Child("count"), nil, ""),
+			},
+		},
 	}
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
@@ -254,6 +330,24 @@ func TestValidateWorkloadUpdate(t *testing.T) {
 				Admit(testingutil.MakeAdmission("cq").Obj()).Obj(),
 			after: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).Queue("q2").Obj(),
 		},
+		"priorityClassName can be updated when not admitted": {
+			before: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PriorityClass("low").PriorityClassSource(kueue.WorkloadPriorityClassSource).Priority(10).Obj(),
+			after: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PriorityClass("high").PriorityClassSource(kueue.WorkloadPriorityClassSource).Priority(100).Obj(),
+		},
+		"priorityClassName and priorityClassSource should not be updated once admitted": {
+			before: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PriorityClass("low").PriorityClassSource(kueue.WorkloadPriorityClassSource).Priority(10).
+				Admit(testingutil.MakeAdmission("cq").Obj()).Obj(),
+			after: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				PriorityClass("high").PriorityClassSource(kueue.PodPriorityClassSource).Priority(10).
+				Admit(testingutil.MakeAdmission("cq").Obj()).Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(field.NewPath("spec").Child("priorityClassSource"), nil, ""),
+				field.Invalid(field.NewPath("spec").Child("priorityClassName"), nil, ""),
+			},
+		},
 		"admission can be set": {
 			before: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).Obj(),
 			after: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).Admit(
@@ -279,6 +373,35 @@ func TestValidateWorkloadUpdate(t *testing.T) {
 				field.Invalid(field.NewPath("status", "admission"), nil, ""),
 			},
 		},
+		"reclaimable pods can increase while admitted": {
+			before: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				Admit(testingutil.MakeAdmission("cluster-queue").Obj()).
+				ReclaimablePods(kueue.ReclaimablePod{Name: "main", Count: 0}).
+				Obj(),
+			after: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				Admit(testingutil.MakeAdmission("cluster-queue").Obj()).
+				ReclaimablePods(kueue.ReclaimablePod{Name: "main", Count: 1}).
+				Obj(),
+		},
+		"reclaimable pods should not decrease while admitted": {
+			before: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				Admit(testingutil.MakeAdmission("cluster-queue").Obj()).
+				ReclaimablePods(kueue.ReclaimablePod{Name: "main", Count: 1}).
+				Obj(),
+			after: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				Admit(testingutil.MakeAdmission("cluster-queue").Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(field.NewPath("status", "reclaimablePods").Key("main").Child("count"), nil, ""),
+			},
+		},
+		"reclaimable pods can be reset when the admission is unset": {
+			before: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				Admit(testingutil.MakeAdmission("cluster-queue").Obj()).
+				ReclaimablePods(kueue.ReclaimablePod{Name: "main", Count: 1}).
+				Obj(),
+			after: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).Obj(),
+		},
 	}
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
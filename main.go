@@ -38,24 +38,29 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	jobsetapi "sigs.k8s.io/jobset/api/v1alpha1"
 
 	config "sigs.k8s.io/kueue/apis/config/v1beta1"
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	"sigs.k8s.io/kueue/apis/kueue/webhooks"
 	"sigs.k8s.io/kueue/pkg/cache"
 	"sigs.k8s.io/kueue/pkg/constants"
+	"sigs.k8s.io/kueue/pkg/controller/admissionchecks/multikueue"
 	"sigs.k8s.io/kueue/pkg/controller/core"
 	"sigs.k8s.io/kueue/pkg/controller/core/indexer"
 	"sigs.k8s.io/kueue/pkg/controller/jobframework"
 	"sigs.k8s.io/kueue/pkg/controller/jobs/job"
+	"sigs.k8s.io/kueue/pkg/controller/jobs/jobset"
 	"sigs.k8s.io/kueue/pkg/controller/jobs/mpijob"
 	"sigs.k8s.io/kueue/pkg/controller/jobs/noop"
+	"sigs.k8s.io/kueue/pkg/controller/jobs/pod"
 	"sigs.k8s.io/kueue/pkg/metrics"
 	"sigs.k8s.io/kueue/pkg/queue"
 	"sigs.k8s.io/kueue/pkg/scheduler"
 	"sigs.k8s.io/kueue/pkg/util/cert"
 	"sigs.k8s.io/kueue/pkg/util/useragent"
 	"sigs.k8s.io/kueue/pkg/version"
+	"sigs.k8s.io/kueue/pkg/visibility"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -71,6 +76,7 @@ func init() {
 	utilruntime.Must(kueue.AddToScheme(scheme))
 	utilruntime.Must(config.AddToScheme(scheme))
 	utilruntime.Must(kubeflow.AddToScheme(scheme))
+	utilruntime.Must(jobsetapi.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -118,7 +124,10 @@ func main() {
 		close(certsReady)
 	}
 
-	cCache := cache.New(mgr.GetClient(), cache.WithPodsReadyTracking(waitForPodsReady(&cfg)))
+	cCache := cache.New(mgr.GetClient(),
+		cache.WithPodsReadyTracking(waitForPodsReady(&cfg)),
+		cache.WithFairSharing(fairSharingEnabled(&cfg)),
+	)
 	queues := queue.NewManager(mgr.GetClient(), cCache)
 
 	ctx := ctrl.SetupSignalHandler()
@@ -160,6 +169,16 @@ func setupIndexes(ctx context.Context, mgr ctrl.Manager, cfg *config.Configurati
 			setupLog.Error(err, "Unable to setup mpijob indexes")
 		}
 	}
+	if isFrameworkEnabled(cfg, jobset.FrameworkName) {
+		if err := jobset.SetupIndexes(ctx, mgr.GetFieldIndexer()); err != nil {
+			setupLog.Error(err, "Unable to setup jobset indexes")
+		}
+	}
+	if isFrameworkEnabled(cfg, pod.FrameworkName) {
+		if err := pod.SetupIndexes(ctx, mgr.GetFieldIndexer()); err != nil {
+			setupLog.Error(err, "Unable to setup pod indexes")
+		}
+	}
 }
 
 func setupControllers(mgr ctrl.Manager, cCache *cache.Cache, queues *queue.Manager, certsReady chan struct{}, cfg *config.Configuration) {
@@ -175,11 +194,25 @@ func setupControllers(mgr ctrl.Manager, cCache *cache.Cache, queues *queue.Manag
 	}
 	manageJobsWithoutQueueName := cfg.ManageJobsWithoutQueueName
 
-	if failedWebhook, err := webhooks.Setup(mgr); err != nil {
+	if failedWebhook, err := webhooks.Setup(mgr, webhooks.WithFairSharing(fairSharingEnabled(cfg))); err != nil {
 		setupLog.Error(err, "Unable to create webhook", "webhook", failedWebhook)
 		os.Exit(1)
 	}
 
+	if cfg.Visibility != nil && cfg.Visibility.Enable {
+		if err := visibility.NewServer(queues).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Unable to set up the visibility server")
+			os.Exit(1)
+		}
+	}
+
+	if cfg.MultiKueue != nil && cfg.MultiKueue.Enable {
+		if err := multikueue.SetupControllers(mgr, *cfg.Namespace); err != nil {
+			setupLog.Error(err, "Unable to create controller", "controller", "MultiKueue")
+			os.Exit(1)
+		}
+	}
+
 	if isFrameworkEnabled(cfg, job.FrameworkName) {
 		if err := job.NewReconciler(mgr.GetScheme(),
 			mgr.GetClient(),
@@ -221,6 +254,48 @@ func setupControllers(mgr ctrl.Manager, cCache *cache.Cache, queues *queue.Manag
 			os.Exit(1)
 		}
 	}
+
+	if isFrameworkEnabled(cfg, jobset.FrameworkName) {
+		if err := jobset.NewReconciler(mgr.GetScheme(),
+			mgr.GetClient(),
+			mgr.GetEventRecorderFor(constants.KueueName+"-jobset-controller"),
+			jobframework.WithManageJobsWithoutQueueName(manageJobsWithoutQueueName),
+			jobframework.WithWaitForPodsReady(waitForPodsReady(cfg)),
+		).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "JobSet")
+			os.Exit(1)
+		}
+		if err := jobset.SetupJobSetWebhook(mgr, jobframework.WithManageJobsWithoutQueueName(manageJobsWithoutQueueName)); err != nil {
+			setupLog.Error(err, "Unable to create webhook", "webhook", "JobSet")
+			os.Exit(1)
+		}
+	} else {
+		if err := noop.SetupWebhook(mgr, jobset.WebhookType()); err != nil {
+			setupLog.Error(err, "Unable to create webhook", "webhook", "JobSet")
+			os.Exit(1)
+		}
+	}
+
+	if isFrameworkEnabled(cfg, pod.FrameworkName) {
+		if err := pod.NewReconciler(mgr.GetScheme(),
+			mgr.GetClient(),
+			mgr.GetEventRecorderFor(constants.KueueName+"-pod-controller"),
+			jobframework.WithManageJobsWithoutQueueName(manageJobsWithoutQueueName),
+			jobframework.WithWaitForPodsReady(waitForPodsReady(cfg)),
+		).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Pod")
+			os.Exit(1)
+		}
+		if err := pod.SetupWebhook(mgr, jobframework.WithManageJobsWithoutQueueName(manageJobsWithoutQueueName)); err != nil {
+			setupLog.Error(err, "Unable to create webhook", "webhook", "Pod")
+			os.Exit(1)
+		}
+	} else {
+		if err := noop.SetupWebhook(mgr, pod.WebhookType()); err != nil {
+			setupLog.Error(err, "Unable to create webhook", "webhook", "Pod")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 }
 
@@ -245,6 +320,7 @@ func setupScheduler(mgr ctrl.Manager, cCache *cache.Cache, queues *queue.Manager
 		mgr.GetClient(),
 		mgr.GetEventRecorderFor(constants.AdmissionName),
 		scheduler.WithWaitForPodsReady(waitForPodsReady(cfg)),
+		scheduler.WithFairSharing(cfg.FairSharing),
 	)
 	if err := mgr.Add(sched); err != nil {
 		setupLog.Error(err, "Unable to add scheduler to manager")
@@ -256,6 +332,10 @@ func waitForPodsReady(cfg *config.Configuration) bool {
 	return cfg.WaitForPodsReady != nil && cfg.WaitForPodsReady.Enable
 }
 
+func fairSharingEnabled(cfg *config.Configuration) bool {
+	return cfg.FairSharing != nil && cfg.FairSharing.Enable
+}
+
 func encodeConfig(cfg *config.Configuration) (string, error) {
 	codecs := serializer.NewCodecFactory(scheme)
 	const mediaType = runtime.ContentTypeYAML
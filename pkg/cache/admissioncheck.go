@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+)
+
+// AdmissionCheck holds the state of an AdmissionCheck that is relevant for
+// the ClusterQueues that use it.
+type AdmissionCheck struct {
+	Active     bool
+	Controller string
+}
+
+func (c *Cache) AddOrUpdateAdmissionCheck(ac *kueue.AdmissionCheck) sets.Set[string] {
+	c.Lock()
+	defer c.Unlock()
+	c.admissionChecks[ac.Name] = AdmissionCheck{
+		Active:     apimeta.IsStatusConditionTrue(ac.Status.Conditions, kueue.AdmissionCheckActive),
+		Controller: ac.Spec.ControllerName,
+	}
+	return c.updateClusterQueues()
+}
+
+func (c *Cache) DeleteAdmissionCheck(ac *kueue.AdmissionCheck) sets.Set[string] {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.admissionChecks, ac.Name)
+	return c.updateClusterQueues()
+}
+
+// ClusterQueuesUsingAdmissionCheck returns the names of the ClusterQueues
+// that reference the admission check.
+func (c *Cache) ClusterQueuesUsingAdmissionCheck(name string) []string {
+	c.RLock()
+	defer c.RUnlock()
+	var cqs []string
+	for _, cq := range c.clusterQueues {
+		for _, ac := range cq.AdmissionChecks {
+			if ac == name {
+				cqs = append(cqs, cq.Name)
+				break
+			}
+		}
+	}
+	return cqs
+}
+
+// AdmissionChecksForClusterQueue returns the admission checks of the
+// ClusterQueue, and whether the ClusterQueue exists.
+func (c *Cache) AdmissionChecksForClusterQueue(name string) ([]string, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	cq, ok := c.clusterQueues[name]
+	if !ok {
+		return nil, false
+	}
+	return cq.AdmissionChecks, true
+}
+
+// InactiveAdmissionChecks returns the admission checks of the ClusterQueue
+// that don't exist or are not active.
+func (c *Cache) InactiveAdmissionChecks(name string) []string {
+	c.RLock()
+	defer c.RUnlock()
+	cq, ok := c.clusterQueues[name]
+	if !ok {
+		return nil
+	}
+	return cq.inactiveAdmissionChecks
+}
+
+func (c *ClusterQueue) updateWithAdmissionChecks(checks map[string]AdmissionCheck) {
+	var inactive []string
+	for _, name := range c.AdmissionChecks {
+		if ac, ok := checks[name]; !ok || !ac.Active {
+			inactive = append(inactive, name)
+		}
+	}
+	c.inactiveAdmissionChecks = inactive
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+)
+
+func TestClusterQueueAdmissionChecks(t *testing.T) {
+	cache := New(utiltesting.NewFakeClient())
+	cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+	cq := utiltesting.MakeClusterQueue("cq").
+		ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "2").Obj()).
+		AdmissionChecks("check1", "check2").
+		Obj()
+	if err := cache.AddClusterQueue(context.Background(), cq); err != nil {
+		t.Fatalf("Failed adding ClusterQueue: %v", err)
+	}
+
+	check1 := utiltesting.MakeAdmissionCheck("check1", "controller").Active(metav1.ConditionTrue).Obj()
+	check2 := utiltesting.MakeAdmissionCheck("check2", "controller").Active(metav1.ConditionFalse).Obj()
+	steps := []struct {
+		name         string
+		update       func() sets.Set[string]
+		wantInactive []string
+		wantActive   bool
+		wantActiveCQ sets.Set[string]
+	}{
+		{
+			name:         "missing checks",
+			update:       func() sets.Set[string] { return nil },
+			wantInactive: []string{"check1", "check2"},
+		},
+		{
+			name:         "one check active",
+			update:       func() sets.Set[string] { return cache.AddOrUpdateAdmissionCheck(check1) },
+			wantInactive: []string{"check2"},
+		},
+		{
+			name:         "inactive check added",
+			update:       func() sets.Set[string] { return cache.AddOrUpdateAdmissionCheck(check2) },
+			wantInactive: []string{"check2"},
+		},
+		{
+			name: "all checks active",
+			update: func() sets.Set[string] {
+				check2 = utiltesting.MakeAdmissionCheck("check2", "controller").Active(metav1.ConditionTrue).Obj()
+				return cache.AddOrUpdateAdmissionCheck(check2)
+			},
+			wantActive:   true,
+			wantActiveCQ: sets.New("cq"),
+		},
+		{
+			name:         "check deleted",
+			update:       func() sets.Set[string] { return cache.DeleteAdmissionCheck(check1) },
+			wantInactive: []string{"check1"},
+		},
+	}
+	for _, step := range steps {
+		gotActiveCQ := step.update()
+		if diff := cmp.Diff(step.wantActiveCQ, gotActiveCQ, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Step %q: Unexpected ClusterQueues becoming active (-want,+got):\n%s", step.name, diff)
+		}
+		if diff := cmp.Diff(step.wantInactive, cache.InactiveAdmissionChecks("cq")); diff != "" {
+			t.Errorf("Step %q: Unexpected inactive admission checks (-want,+got):\n%s", step.name, diff)
+		}
+		if got := cache.ClusterQueueActive("cq"); got != step.wantActive {
+			t.Errorf("Step %q: ClusterQueueActive() = %t, want %t", step.name, got, step.wantActive)
+		}
+	}
+	if diff := cmp.Diff([]string{"cq"}, cache.ClusterQueuesUsingAdmissionCheck("check2")); diff != "" {
+		t.Errorf("Unexpected ClusterQueues using the check (-want,+got):\n%s", diff)
+	}
+}
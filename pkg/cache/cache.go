@@ -20,14 +20,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -42,10 +47,14 @@ var (
 	errQueueAlreadyExists  = errors.New("queue already exists")
 	errCqNotFound          = errors.New("cluster queue not found")
 	errWorkloadNotAdmitted = errors.New("workload not admitted by a ClusterQueue")
+	errCohortCycle         = errors.New("cohort would be its own ancestor")
+	errCohortNotFound      = errors.New("cohort not found")
 )
 
 type options struct {
 	podsReadyTracking bool
+	fairSharing       bool
+	clock             clock.Clock
 }
 
 // Option configures the reconciler.
@@ -60,7 +69,25 @@ func WithPodsReadyTracking(f bool) Option {
 	}
 }
 
-var defaultOptions = options{}
+// WithFairSharing indicates if fair sharing is enabled, which allows the
+// ClusterQueues to reclaim quota with the FairSharing preemption policy.
+func WithFairSharing(f bool) Option {
+	return func(o *options) {
+		o.fairSharing = f
+	}
+}
+
+// WithClock sets the clock used to evaluate the quota schedules of the
+// ClusterQueues.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+var defaultOptions = options{
+	clock: clock.RealClock{},
+}
 
 // Cache keeps track of the Workloads that got admitted through ClusterQueues.
 type Cache struct {
@@ -72,7 +99,16 @@ type Cache struct {
 	cohorts           map[string]*Cohort
 	assumedWorkloads  map[string]string
 	resourceFlavors   map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor
+	admissionChecks   map[string]AdmissionCheck
+	nodes             map[string]*nodeInfo
+	pods              map[string]*podInfo
 	podsReadyTracking bool
+	fairSharing       bool
+	clock             clock.Clock
+
+	// flavorCapacity is the capacity of the ResourceFlavors that compute it
+	// from their Nodes.
+	flavorCapacity map[kueue.ResourceFlavorReference]workload.Requests
 }
 
 func New(client client.Client, opts ...Option) *Cache {
@@ -86,7 +122,13 @@ func New(client client.Client, opts ...Option) *Cache {
 		cohorts:           make(map[string]*Cohort),
 		assumedWorkloads:  make(map[string]string),
 		resourceFlavors:   make(map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor),
+		admissionChecks:   make(map[string]AdmissionCheck),
+		nodes:             make(map[string]*nodeInfo),
+		pods:              make(map[string]*podInfo),
+		flavorCapacity:    make(map[kueue.ResourceFlavorReference]workload.Requests),
 		podsReadyTracking: options.podsReadyTracking,
+		fairSharing:       options.fairSharing,
+		clock:             options.clock,
 	}
 	c.podsReadyCond.L = &c.RWMutex
 	return c
@@ -95,26 +137,78 @@ func New(client client.Client, opts ...Option) *Cache {
 type FlavorResourceQuantities map[kueue.ResourceFlavorReference]map[corev1.ResourceName]int64
 
 // Cohort is a set of ClusterQueues that can borrow resources from each other.
+// Cohorts can form a tree: the ClusterQueues in a cohort can also borrow the
+// unused resources of the ClusterQueues in the other cohorts of the tree.
 type Cohort struct {
-	Name    string
-	Members sets.Set[*ClusterQueue]
+	Name         string
+	Members      sets.Set[*ClusterQueue]
+	Parent       *Cohort
+	ChildCohorts sets.Set[*Cohort]
 
-	// These fields are only populated for a snapshot.
+	// These fields are only populated for a snapshot. They account for the
+	// members of the cohort and of all its descendants.
 	RequestableResources FlavorResourceQuantities
 	Usage                FlavorResourceQuantities
+
+	// quota is the nominal quota declared in the resource groups of the Cohort
+	// object, available to the ClusterQueues in the cohort and its descendants.
+	quota FlavorResourceQuantities
+
+	// The following fields are not populated in a snapshot.
+
+	// parentName is the parent declared in the Cohort object, if any.
+	parentName string
+	// hasObject indicates whether a Cohort object with this name exists.
+	hasObject bool
 }
 
 func newCohort(name string, size int) *Cohort {
 	return &Cohort{
-		Name:    name,
-		Members: make(sets.Set[*ClusterQueue], size),
+		Name:         name,
+		Members:      make(sets.Set[*ClusterQueue], size),
+		ChildCohorts: sets.New[*Cohort](),
 	}
 }
 
+// Root returns the cohort at the top of the tree the cohort belongs to.
+func (c *Cohort) Root() *Cohort {
+	root := c
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	return root
+}
+
+// AllMembers returns the ClusterQueues that are members of the cohort or of
+// any of its descendants.
+func (c *Cohort) AllMembers() sets.Set[*ClusterQueue] {
+	members := sets.New[*ClusterQueue]()
+	c.collectMembers(members)
+	return members
+}
+
+func (c *Cohort) collectMembers(members sets.Set[*ClusterQueue]) {
+	members.Insert(c.Members.UnsortedList()...)
+	for child := range c.ChildCohorts {
+		child.collectMembers(members)
+	}
+}
+
+// IsAncestorOf returns whether the cohort is other or one of its ancestors.
+func (c *Cohort) IsAncestorOf(other *Cohort) bool {
+	for ; other != nil; other = other.Parent {
+		if other == c {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	pending     = metrics.CQStatusPending
 	active      = metrics.CQStatusActive
 	terminating = metrics.CQStatusTerminating
+	stopped     = metrics.CQStatusStopped
 )
 
 // ClusterQueue is the internal implementation of kueue.ClusterQueue that
@@ -129,12 +223,34 @@ type ClusterQueue struct {
 	WorkloadsNotReady sets.Set[string]
 	NamespaceSelector labels.Selector
 	Preemption        kueue.ClusterQueuePreemption
+	FlavorFungibility kueue.FlavorFungibility
+	FairWeight        resource.Quantity
+	AdmissionChecks   []string
 	Status            metrics.ClusterQueueStatus
+	// FairSharingEnabled indicates if fair sharing is enabled in the cluster.
+	FairSharingEnabled bool
+
+	// localQueueLimits holds the limits and usage of the LocalQueues that
+	// have limits, by queue key.
+	localQueueLimits map[string]*localQueueLimits
 
 	// The following fields are not populated in a snapshot.
 
 	admittedWorkloadsPerQueue map[string]int
 	podsReadyTracking         bool
+	stopPolicy                kueue.StopPolicy
+	// inactiveAdmissionChecks are the admission checks of the ClusterQueue
+	// that don't exist or are not active.
+	inactiveAdmissionChecks []string
+	// specResourceGroups are the resource groups in the ClusterQueue spec,
+	// used to re-evaluate the quota schedules.
+	specResourceGroups []kueue.ResourceGroup
+	// quotaSchedules are the parsed quota schedules of each flavor and
+	// resource that has any.
+	quotaSchedules map[kueue.ResourceFlavorReference]map[corev1.ResourceName][]quotaSchedule
+	// nextQuotaTransition is the next time in which the window of a quota
+	// schedule starts or ends. Zero if there are no quota schedules.
+	nextQuotaTransition time.Time
 }
 
 type ResourceGroup struct {
@@ -155,6 +271,19 @@ type FlavorQuotas struct {
 type ResourceQuota struct {
 	Nominal        int64
 	BorrowingLimit *int64
+	LendingLimit   *int64
+	// ActiveSchedule is the name of the quota schedule that defines Nominal
+	// and BorrowingLimit, if any.
+	ActiveSchedule string
+}
+
+// Guaranteed returns the part of the nominal quota that is not lent to the
+// cohort, reserved for the exclusive use of the ClusterQueue.
+func (q *ResourceQuota) Guaranteed() int64 {
+	if q.LendingLimit == nil || *q.LendingLimit >= q.Nominal {
+		return 0
+	}
+	return q.Nominal - *q.LendingLimit
 }
 
 func (c *Cache) newClusterQueue(cq *kueue.ClusterQueue) (*ClusterQueue, error) {
@@ -163,9 +292,11 @@ func (c *Cache) newClusterQueue(cq *kueue.ClusterQueue) (*ClusterQueue, error) {
 		Workloads:                 make(map[string]*workload.Info),
 		WorkloadsNotReady:         sets.New[string](),
 		admittedWorkloadsPerQueue: make(map[string]int),
+		localQueueLimits:          make(map[string]*localQueueLimits),
 		podsReadyTracking:         c.podsReadyTracking,
+		FairSharingEnabled:        c.fairSharing,
 	}
-	if err := cqImpl.update(cq, c.resourceFlavors); err != nil {
+	if err := cqImpl.update(cq, c.resourceFlavors, c.admissionChecks, c.flavorCapacity, c.clock.Now()); err != nil {
 		return nil, err
 	}
 
@@ -245,8 +376,31 @@ var defaultPreemption = kueue.ClusterQueuePreemption{
 	WithinClusterQueue:  kueue.PreemptionPolicyNever,
 }
 
-func (c *ClusterQueue) update(in *kueue.ClusterQueue, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor) error {
-	c.updateResourceGroups(in.Spec.ResourceGroups)
+var defaultFlavorFungibility = kueue.FlavorFungibility{
+	WhenCanBorrow:  kueue.Borrow,
+	WhenCanPreempt: kueue.TryNextFlavor,
+}
+
+func (c *ClusterQueue) update(in *kueue.ClusterQueue, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, admissionChecks map[string]AdmissionCheck, flavorCapacity map[kueue.ResourceFlavorReference]workload.Requests, now time.Time) error {
+	c.specResourceGroups = in.Spec.ResourceGroups
+	c.quotaSchedules = nil
+	for _, rg := range in.Spec.ResourceGroups {
+		for _, f := range rg.Flavors {
+			for _, r := range f.Resources {
+				if len(r.Schedules) == 0 {
+					continue
+				}
+				if c.quotaSchedules == nil {
+					c.quotaSchedules = make(map[kueue.ResourceFlavorReference]map[corev1.ResourceName][]quotaSchedule)
+				}
+				if c.quotaSchedules[f.Name] == nil {
+					c.quotaSchedules[f.Name] = make(map[corev1.ResourceName][]quotaSchedule)
+				}
+				c.quotaSchedules[f.Name][r.Name] = parseQuotaSchedules(r.Schedules)
+			}
+		}
+	}
+	c.updateResourceGroups(in.Spec.ResourceGroups, flavorCapacity, now)
 	nsSelector, err := metav1.LabelSelectorAsSelector(in.Spec.NamespaceSelector)
 	if err != nil {
 		return err
@@ -266,6 +420,13 @@ func (c *ClusterQueue) update(in *kueue.ClusterQueue, resourceFlavors map[kueue.
 		}
 	}
 	c.Usage = usedFlavorResources
+	if in.Spec.StopPolicy != nil {
+		c.stopPolicy = *in.Spec.StopPolicy
+	} else {
+		c.stopPolicy = kueue.None
+	}
+	c.AdmissionChecks = in.Spec.AdmissionChecks
+	c.updateWithAdmissionChecks(admissionChecks)
 	c.UpdateWithFlavors(resourceFlavors)
 
 	if in.Spec.Preemption != nil {
@@ -274,11 +435,28 @@ func (c *ClusterQueue) update(in *kueue.ClusterQueue, resourceFlavors map[kueue.
 		c.Preemption = defaultPreemption
 	}
 
+	c.FlavorFungibility = defaultFlavorFungibility
+	if in.Spec.FlavorFungibility != nil {
+		if in.Spec.FlavorFungibility.WhenCanBorrow != "" {
+			c.FlavorFungibility.WhenCanBorrow = in.Spec.FlavorFungibility.WhenCanBorrow
+		}
+		if in.Spec.FlavorFungibility.WhenCanPreempt != "" {
+			c.FlavorFungibility.WhenCanPreempt = in.Spec.FlavorFungibility.WhenCanPreempt
+		}
+	}
+
+	if in.Spec.FairSharing != nil && in.Spec.FairSharing.Weight != nil {
+		c.FairWeight = *in.Spec.FairSharing.Weight
+	} else {
+		c.FairWeight = defaultFairWeight
+	}
+
 	return nil
 }
 
-func (c *ClusterQueue) updateResourceGroups(in []kueue.ResourceGroup) {
+func (c *ClusterQueue) updateResourceGroups(in []kueue.ResourceGroup, flavorCapacity map[kueue.ResourceFlavorReference]workload.Requests, now time.Time) {
 	c.ResourceGroups = make([]ResourceGroup, len(in))
+	c.nextQuotaTransition = time.Time{}
 	for i, rgIn := range in {
 		rg := &c.ResourceGroups[i]
 		*rg = ResourceGroup{
@@ -292,11 +470,28 @@ func (c *ClusterQueue) updateResourceGroups(in []kueue.ResourceGroup) {
 				Resources: make(map[corev1.ResourceName]*ResourceQuota, len(fIn.Resources)),
 			}
 			for _, rIn := range fIn.Resources {
+				nominal, borrowingLimit := rIn.NominalQuota, rIn.BorrowingLimit
+				qs, next := activeQuotaSchedule(c.quotaSchedules[fIn.Name][rIn.Name], now)
+				if qs != nil {
+					nominal, borrowingLimit = qs.NominalQuota, qs.BorrowingLimit
+				}
+				if !next.IsZero() && (c.nextQuotaTransition.IsZero() || next.Before(c.nextQuotaTransition)) {
+					c.nextQuotaTransition = next
+				}
 				rQuota := ResourceQuota{
-					Nominal: workload.ResourceValue(rIn.Name, rIn.NominalQuota),
+					Nominal: workload.ResourceValue(rIn.Name, nominal),
+				}
+				if rIn.NominalQuotaPercent != nil {
+					rQuota.Nominal = flavorCapacity[fIn.Name][rIn.Name] * int64(*rIn.NominalQuotaPercent) / 100
 				}
-				if rIn.BorrowingLimit != nil {
-					rQuota.BorrowingLimit = pointer.Int64(workload.ResourceValue(rIn.Name, *rIn.BorrowingLimit))
+				if qs != nil {
+					rQuota.ActiveSchedule = qs.Name
+				}
+				if borrowingLimit != nil {
+					rQuota.BorrowingLimit = pointer.Int64(workload.ResourceValue(rIn.Name, *borrowingLimit))
+				}
+				if rIn.LendingLimit != nil {
+					rQuota.LendingLimit = pointer.Int64(workload.ResourceValue(rIn.Name, *rIn.LendingLimit))
 				}
 				fQuotas.Resources[rIn.Name] = &rQuota
 			}
@@ -306,6 +501,24 @@ func (c *ClusterQueue) updateResourceGroups(in []kueue.ResourceGroup) {
 	c.UpdateRGByResource()
 }
 
+// guaranteedQuota returns the quota of the flavor and resource that the
+// ClusterQueue doesn't lend to its cohort.
+func (c *ClusterQueue) guaranteedQuota(fName kueue.ResourceFlavorReference, rName corev1.ResourceName) int64 {
+	rg := c.RGByResource[rName]
+	if rg == nil {
+		return 0
+	}
+	for i := range rg.Flavors {
+		if rg.Flavors[i].Name == fName {
+			if rQuota := rg.Flavors[i].Resources[rName]; rQuota != nil {
+				return rQuota.Guaranteed()
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
 func (c *ClusterQueue) UpdateRGByResource() {
 	c.RGByResource = make(map[corev1.ResourceName]*ResourceGroup)
 	for i := range c.ResourceGroups {
@@ -320,9 +533,12 @@ func (c *ClusterQueue) UpdateRGByResource() {
 // Exported only for testing.
 func (c *ClusterQueue) UpdateWithFlavors(flavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor) {
 	status := active
-	if flavorNotFound := c.updateLabelKeys(flavors); flavorNotFound {
+	if flavorNotFound := c.updateLabelKeys(flavors); flavorNotFound || len(c.inactiveAdmissionChecks) > 0 {
 		status = pending
 	}
+	if c.stopPolicy == kueue.Hold || c.stopPolicy == kueue.HoldAndDrain {
+		status = stopped
+	}
 
 	if c.Status != terminating {
 		c.Status = status
@@ -394,6 +610,10 @@ func (c *ClusterQueue) updateWorkloadUsage(wi *workload.Info, m int64) {
 	if _, ok := c.admittedWorkloadsPerQueue[qKey]; ok {
 		c.admittedWorkloadsPerQueue[qKey] += int(m)
 	}
+	if l, ok := c.localQueueLimits[qKey]; ok {
+		l.admittedWorkloads += int(m)
+		updateUsage(wi, l.usage, m)
+	}
 }
 
 func updateUsage(wi *workload.Info, cqUsage FlavorResourceQuantities, m int64) {
@@ -424,12 +644,14 @@ func (c *ClusterQueue) addLocalQueue(q *kueue.LocalQueue) error {
 		}
 	}
 	c.admittedWorkloadsPerQueue[qKey] = workloads
+	c.setLocalQueueLimits(q)
 	return nil
 }
 
 func (c *ClusterQueue) deleteLocalQueue(q *kueue.LocalQueue) {
 	qKey := queueKey(q)
 	delete(c.admittedWorkloadsPerQueue, qKey)
+	delete(c.localQueueLimits, qKey)
 }
 
 func (c *ClusterQueue) flavorInUse(flavor string) bool {
@@ -451,6 +673,7 @@ func (c *Cache) updateClusterQueues() sets.Set[string] {
 		// We call update on all ClusterQueues irrespective of which CQ actually use this flavor
 		// because it is not expensive to do so, and is not worth tracking which ClusterQueues use
 		// which flavors.
+		cq.updateWithAdmissionChecks(c.admissionChecks)
 		cq.UpdateWithFlavors(c.resourceFlavors)
 		curStatus := cq.Status
 		if prevStatus == pending && curStatus == active {
@@ -464,14 +687,16 @@ func (c *Cache) AddOrUpdateResourceFlavor(rf *kueue.ResourceFlavor) sets.Set[str
 	c.Lock()
 	defer c.Unlock()
 	c.resourceFlavors[kueue.ResourceFlavorReference(rf.Name)] = rf
-	return c.updateClusterQueues()
+	cqs := c.updateClusterQueues()
+	return cqs.Union(c.refreshFlavorCapacity())
 }
 
 func (c *Cache) DeleteResourceFlavor(rf *kueue.ResourceFlavor) sets.Set[string] {
 	c.Lock()
 	defer c.Unlock()
 	delete(c.resourceFlavors, kueue.ResourceFlavorReference(rf.Name))
-	return c.updateClusterQueues()
+	cqs := c.updateClusterQueues()
+	return cqs.Union(c.refreshFlavorCapacity())
 }
 
 func (c *Cache) ClusterQueueActive(name string) bool {
@@ -482,6 +707,20 @@ func (c *Cache) ClusterQueueTerminating(name string) bool {
 	return c.clusterQueueInStatus(name, terminating)
 }
 
+func (c *Cache) ClusterQueueStopped(name string) bool {
+	return c.clusterQueueInStatus(name, stopped)
+}
+
+// ClusterQueueDraining returns whether the ClusterQueue is stopped with the
+// HoldAndDrain policy.
+func (c *Cache) ClusterQueueDraining(name string) bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	cq := c.clusterQueues[name]
+	return cq != nil && cq.stopPolicy == kueue.HoldAndDrain
+}
+
 func (c *Cache) clusterQueueInStatus(name string, status metrics.ClusterQueueStatus) bool {
 	c.RLock()
 	defer c.RUnlock()
@@ -540,6 +779,7 @@ func (c *Cache) AddClusterQueue(ctx context.Context, cq *kueue.ClusterQueue) err
 		// Checking ClusterQueue name again because the field index is not available in tests.
 		if string(q.Spec.ClusterQueue) == cq.Name {
 			cqImpl.admittedWorkloadsPerQueue[queueKey(&q)] = 0
+			cqImpl.setLocalQueueLimits(&q)
 		}
 	}
 	var workloads kueue.WorkloadList
@@ -567,7 +807,7 @@ func (c *Cache) UpdateClusterQueue(cq *kueue.ClusterQueue) error {
 	if !ok {
 		return errCqNotFound
 	}
-	if err := cqImpl.update(cq, c.resourceFlavors); err != nil {
+	if err := cqImpl.update(cq, c.resourceFlavors, c.admissionChecks, c.flavorCapacity, c.clock.Now()); err != nil {
 		return err
 	}
 
@@ -617,6 +857,14 @@ func (c *Cache) DeleteLocalQueue(q *kueue.LocalQueue) {
 
 func (c *Cache) UpdateLocalQueue(oldQ, newQ *kueue.LocalQueue) error {
 	if oldQ.Spec.ClusterQueue == newQ.Spec.ClusterQueue {
+		if equality.Semantic.DeepEqual(oldQ.Spec.Limits, newQ.Spec.Limits) {
+			return nil
+		}
+		c.Lock()
+		defer c.Unlock()
+		if cq, ok := c.clusterQueues[string(newQ.Spec.ClusterQueue)]; ok {
+			cq.setLocalQueueLimits(newQ)
+		}
 		return nil
 	}
 	c.Lock()
@@ -805,6 +1053,40 @@ func (c *Cache) Usage(cqObj *kueue.ClusterQueue) ([]kueue.FlavorUsage, int, erro
 	return usage, len(cq.Workloads), nil
 }
 
+// DominantResourceShare returns the current dominant resource share of the
+// ClusterQueue, as defined by ClusterQueue.DominantResourceShare. The share
+// is computed on a snapshot, like the scheduler does, so only the active
+// ClusterQueues of the cohort tree lend resources. An inactive ClusterQueue
+// has a share of zero.
+func (c *Cache) DominantResourceShare(cqName string) (int, error) {
+	snapshot := c.Snapshot()
+	if cq := snapshot.ClusterQueues[cqName]; cq != nil {
+		drs, _ := cq.DominantResourceShare()
+		return drs, nil
+	}
+	if !snapshot.InactiveClusterQueueSets.Has(cqName) {
+		return 0, errCqNotFound
+	}
+	return 0, nil
+}
+
+// ClusterQueuesInCohortTree returns the names of the ClusterQueues in the
+// tree that the cohort belongs to.
+func (c *Cache) ClusterQueuesInCohortTree(cohortName string) []string {
+	c.RLock()
+	defer c.RUnlock()
+
+	cohort := c.cohorts[cohortName]
+	if cohort == nil {
+		return nil
+	}
+	var names []string
+	for cq := range cohort.Root().AllMembers() {
+		names = append(names, cq.Name)
+	}
+	return names
+}
+
 func (c *Cache) cleanupAssumedState(w *kueue.Workload) {
 	k := workload.Key(w)
 	assumedCQName, assumed := c.assumedWorkloads[k]
@@ -837,11 +1119,7 @@ func (c *Cache) addClusterQueueToCohort(cq *ClusterQueue, cohortName string) {
 	if cohortName == "" {
 		return
 	}
-	cohort, ok := c.cohorts[cohortName]
-	if !ok {
-		cohort = newCohort(cohortName, 1)
-		c.cohorts[cohortName] = cohort
-	}
+	cohort := c.getOrCreateCohort(cohortName)
 	cohort.Members.Insert(cq)
 	cq.Cohort = cohort
 }
@@ -851,12 +1129,171 @@ func (c *Cache) deleteClusterQueueFromCohort(cq *ClusterQueue) {
 		return
 	}
 	cq.Cohort.Members.Delete(cq)
-	if cq.Cohort.Members.Len() == 0 {
-		delete(c.cohorts, cq.Cohort.Name)
-	}
+	c.deleteCohortIfUnused(cq.Cohort)
 	cq.Cohort = nil
 }
 
+func (c *Cache) getOrCreateCohort(name string) *Cohort {
+	cohort, ok := c.cohorts[name]
+	if !ok {
+		cohort = newCohort(name, 1)
+		c.cohorts[name] = cohort
+	}
+	return cohort
+}
+
+// deleteCohortIfUnused removes the cohort from the cache when it has no
+// members, no child cohorts and no object, and then does the same for its
+// parent.
+func (c *Cache) deleteCohortIfUnused(cohort *Cohort) {
+	if cohort.Members.Len() > 0 || cohort.ChildCohorts.Len() > 0 || cohort.hasObject {
+		return
+	}
+	delete(c.cohorts, cohort.Name)
+	c.detachCohortFromParent(cohort)
+}
+
+func (c *Cache) detachCohortFromParent(cohort *Cohort) {
+	parent := cohort.Parent
+	if parent == nil {
+		return
+	}
+	parent.ChildCohorts.Delete(cohort)
+	cohort.Parent = nil
+	c.deleteCohortIfUnused(parent)
+}
+
+// linkCohortToParent attaches the cohort to the parent declared in its
+// object. If the parent is a descendant of the cohort, the cohort is left
+// without parent and an error is returned.
+func (c *Cache) linkCohortToParent(cohort *Cohort) error {
+	if cohort.Parent != nil && cohort.Parent.Name == cohort.parentName {
+		return nil
+	}
+	c.detachCohortFromParent(cohort)
+	if cohort.parentName == "" {
+		return nil
+	}
+	parent := c.getOrCreateCohort(cohort.parentName)
+	if cohort.IsAncestorOf(parent) {
+		c.deleteCohortIfUnused(parent)
+		return fmt.Errorf("%w: %q can't have %q as parent", errCohortCycle, cohort.Name, cohort.parentName)
+	}
+	parent.ChildCohorts.Insert(cohort)
+	cohort.Parent = parent
+	return nil
+}
+
+// relinkCohorts retries to attach the cohorts that couldn't be linked to
+// their parents, as a previous change in the tree might have broken the
+// cycle that prevented it.
+func (c *Cache) relinkCohorts() {
+	for _, cohort := range c.cohorts {
+		if cohort.parentName != "" && cohort.Parent == nil {
+			_ = c.linkCohortToParent(cohort)
+		}
+	}
+}
+
+func (c *Cache) AddOrUpdateCohort(cohort *kueue.Cohort) error {
+	c.Lock()
+	defer c.Unlock()
+	cohortImpl := c.getOrCreateCohort(cohort.Name)
+	cohortImpl.hasObject = true
+	cohortImpl.parentName = cohort.Spec.Parent
+	cohortImpl.quota = cohortQuota(cohort)
+	err := c.linkCohortToParent(cohortImpl)
+	c.relinkCohorts()
+	return err
+}
+
+func (c *Cache) DeleteCohort(cohort *kueue.Cohort) {
+	c.Lock()
+	defer c.Unlock()
+	cohortImpl, ok := c.cohorts[cohort.Name]
+	if !ok {
+		return
+	}
+	cohortImpl.hasObject = false
+	cohortImpl.parentName = ""
+	cohortImpl.quota = nil
+	c.detachCohortFromParent(cohortImpl)
+	c.deleteCohortIfUnused(cohortImpl)
+	c.relinkCohorts()
+}
+
+func cohortQuota(cohort *kueue.Cohort) FlavorResourceQuantities {
+	if len(cohort.Spec.ResourceGroups) == 0 {
+		return nil
+	}
+	quota := make(FlavorResourceQuantities)
+	for _, rg := range cohort.Spec.ResourceGroups {
+		for _, fq := range rg.Flavors {
+			res := quota[fq.Name]
+			if res == nil {
+				res = make(map[corev1.ResourceName]int64, len(fq.Resources))
+				quota[fq.Name] = res
+			}
+			for _, rq := range fq.Resources {
+				res[rq.Name] += workload.ResourceValue(rq.Name, rq.NominalQuota)
+			}
+		}
+	}
+	return quota
+}
+
+// CohortResources reports the resources that can be requested in the cohort
+// and their usage, accounting for the ClusterQueues in the cohort and its
+// descendants, as well as the number of those ClusterQueues.
+func (c *Cache) CohortResources(cohortObj *kueue.Cohort) ([]kueue.CohortFlavorResources, int, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	cohort := c.cohorts[cohortObj.Name]
+	if cohort == nil {
+		return nil, 0, errCohortNotFound
+	}
+
+	aggregate := cohort.subtreeResources()
+	members := cohort.AllMembers()
+
+	flavors := make([]kueue.CohortFlavorResources, 0, len(aggregate.RequestableResources))
+	for fName, requestable := range aggregate.RequestableResources {
+		outFlv := kueue.CohortFlavorResources{
+			Name:      fName,
+			Resources: make([]kueue.CohortResourceStatus, 0, len(requestable)),
+		}
+		for rName, v := range requestable {
+			outFlv.Resources = append(outFlv.Resources, kueue.CohortResourceStatus{
+				Name:        rName,
+				Requestable: workload.ResourceQuantity(rName, v),
+				Usage:       workload.ResourceQuantity(rName, aggregate.Usage[fName][rName]),
+			})
+		}
+		sort.Slice(outFlv.Resources, func(i, j int) bool {
+			return outFlv.Resources[i].Name < outFlv.Resources[j].Name
+		})
+		flavors = append(flavors, outFlv)
+	}
+	sort.Slice(flavors, func(i, j int) bool {
+		return flavors[i].Name < flavors[j].Name
+	})
+	return flavors, members.Len(), nil
+}
+
+// CohortAndAncestors returns the name of the cohort and of all its ancestors,
+// for the cohorts known to the cache.
+func (c *Cache) CohortAndAncestors(cohortName string) []string {
+	c.RLock()
+	defer c.RUnlock()
+
+	var names []string
+	for cohort := c.cohorts[cohortName]; cohort != nil; cohort = cohort.Parent {
+		names = append(names, cohort.Name)
+	}
+	return names
+}
+
 func (c *Cache) ClusterQueuesUsingFlavor(flavor string) []string {
 	c.RLock()
 	defer c.RUnlock()
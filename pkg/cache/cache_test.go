@@ -18,6 +18,7 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -111,8 +112,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"b": {
 					Name: "b",
@@ -132,8 +135,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"c": {
 					Name:              "c",
@@ -142,6 +147,8 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
 					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"d": {
 					Name:              "d",
@@ -150,6 +157,8 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
 					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"e": {
 					Name: "e",
@@ -168,8 +177,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"nonexistent-flavor": {corev1.ResourceCPU: 0},
 					},
-					Status:     pending,
-					Preemption: defaultPreemption,
+					Status:            pending,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 			},
 			wantCohorts: map[string]sets.Set[string]{
@@ -197,6 +208,27 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 						ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
 						WithinClusterQueue:  kueue.PreemptionPolicyLowerPriority,
 					},
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
+				},
+			},
+		},
+		{
+			name: "add ClusterQueue with stop policy",
+			operation: func(cache *Cache) {
+				cq := utiltesting.MakeClusterQueue("foo").StopPolicy(kueue.Hold).Obj()
+				if err := cache.AddClusterQueue(context.Background(), cq); err != nil {
+					t.Fatalf("Failed to add ClusterQueue: %v", err)
+				}
+			},
+			wantClusterQueues: map[string]*ClusterQueue{
+				"foo": {
+					Name:              "foo",
+					NamespaceSelector: labels.Everything(),
+					Status:            stopped,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 			},
 		},
@@ -233,8 +265,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"b": {
 					Name: "b",
@@ -254,8 +288,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"c": {
 					Name:              "c",
@@ -264,6 +300,8 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
 					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"d": {
 					Name:              "d",
@@ -272,6 +310,8 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
 					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"e": {
 					Name: "e",
@@ -292,8 +332,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"nonexistent-flavor": {corev1.ResourceCPU: 0},
 					},
-					Status:     pending,
-					Preemption: defaultPreemption,
+					Status:            pending,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 			},
 			wantCohorts: map[string]sets.Set[string]{
@@ -354,8 +396,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"b": {
 					Name:              "b",
@@ -364,6 +408,8 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
 					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"c": {
 					Name:              "c",
@@ -372,6 +418,8 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
 					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"d": {
 					Name:              "d",
@@ -380,6 +428,8 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
 					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"e": {
 					Name: "e",
@@ -400,8 +450,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 			},
 			wantCohorts: map[string]sets.Set[string]{
@@ -438,8 +490,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"c": {
 					Name:              "c",
@@ -448,6 +502,8 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
 					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"e": {
 					Name: "e",
@@ -468,8 +524,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"nonexistent-flavor": {corev1.ResourceCPU: 0},
 					},
-					Status:     pending,
-					Preemption: defaultPreemption,
+					Status:            pending,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 			},
 			wantCohorts: map[string]sets.Set[string]{
@@ -505,8 +563,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"b": {
 					Name: "b",
@@ -526,8 +586,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"c": {
 					Name:              "c",
@@ -536,6 +598,8 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
 					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"d": {
 					Name:              "d",
@@ -544,6 +608,8 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
 					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 				"e": {
 					Name: "e",
@@ -562,6 +628,8 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage:             FlavorResourceQuantities{"nonexistent-flavor": {corev1.ResourceCPU: 0}},
 					Status:            active,
 					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 			},
 			wantCohorts: map[string]sets.Set[string]{
@@ -651,8 +719,10 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 							"example.com/gpu": 0,
 						},
 					},
-					Status:     pending,
-					Preemption: defaultPreemption,
+					Status:            pending,
+					Preemption:        defaultPreemption,
+					FairWeight:        defaultFairWeight,
+					FlavorFungibility: defaultFlavorFungibility,
 				},
 			},
 		},
@@ -1401,6 +1471,39 @@ func TestClusterQueueUsage(t *testing.T) {
 			},
 			wantWorkloads: 2,
 		},
+		"reclaimable pods don't use quota": {
+			workloads: []kueue.Workload{
+				*utiltesting.MakeWorkload("three", "").
+					PodSets(*utiltesting.MakePodSet("main", 4).
+						Request(corev1.ResourceCPU, "2").
+						Obj()).
+					Admit(utiltesting.MakeAdmission("foo").Assignment(corev1.ResourceCPU, "default", "8000m").Obj()).
+					ReclaimablePods(kueue.ReclaimablePod{Name: "main", Count: 3}).
+					Obj(),
+			},
+			wantUsedResources: []kueue.FlavorUsage{
+				{
+					Name: "default",
+					Resources: []kueue.ResourceUsage{{
+						Name:  corev1.ResourceCPU,
+						Total: resource.MustParse("2"),
+					}},
+				},
+				{
+					Name: "model_a",
+					Resources: []kueue.ResourceUsage{{
+						Name: "example.com/gpu",
+					}},
+				},
+				{
+					Name: "model_b",
+					Resources: []kueue.ResourceUsage{{
+						Name: "example.com/gpu",
+					}},
+				},
+			},
+			wantWorkloads: 1,
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -1429,6 +1532,117 @@ func TestClusterQueueUsage(t *testing.T) {
 	}
 }
 
+func TestCohortResources(t *testing.T) {
+	cohorts := []*kueue.Cohort{
+		utiltesting.MakeCohort("department").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "5").Obj()).
+			Obj(),
+		utiltesting.MakeCohort("team-a").Parent("department").Obj(),
+		utiltesting.MakeCohort("team-b").Parent("department").Obj(),
+	}
+	cqs := []*kueue.ClusterQueue{
+		utiltesting.MakeClusterQueue("a").
+			Cohort("team-a").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "10").Obj()).
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("gpu").Resource("example.com/gpu", "4").Obj()).
+			Obj(),
+		utiltesting.MakeClusterQueue("b").
+			Cohort("team-b").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "20", "", "15").Obj()).
+			Obj(),
+	}
+	workloads := []*kueue.Workload{
+		utiltesting.MakeWorkload("alpha", "").
+			Request(corev1.ResourceCPU, "12").
+			Admit(utiltesting.MakeAdmission("a").Assignment(corev1.ResourceCPU, "default", "12").Obj()).
+			Obj(),
+		utiltesting.MakeWorkload("beta", "").
+			Request(corev1.ResourceCPU, "8").
+			Admit(utiltesting.MakeAdmission("b").Assignment(corev1.ResourceCPU, "default", "8").Obj()).
+			Obj(),
+	}
+	cases := map[string]struct {
+		cohort            string
+		wantResources     []kueue.CohortFlavorResources
+		wantClusterQueues int
+		wantErr           error
+	}{
+		"root": {
+			cohort: "department",
+			wantResources: []kueue.CohortFlavorResources{
+				{
+					Name: "default",
+					Resources: []kueue.CohortResourceStatus{{
+						Name:        corev1.ResourceCPU,
+						Requestable: resource.MustParse("30"),
+						Usage:       resource.MustParse("15"),
+					}},
+				},
+				{
+					Name: "gpu",
+					Resources: []kueue.CohortResourceStatus{{
+						Name:        "example.com/gpu",
+						Requestable: resource.MustParse("4"),
+						Usage:       resource.MustParse("0"),
+					}},
+				},
+			},
+			wantClusterQueues: 2,
+		},
+		"leaf with lending limit": {
+			cohort: "team-b",
+			wantResources: []kueue.CohortFlavorResources{
+				{
+					Name: "default",
+					Resources: []kueue.CohortResourceStatus{{
+						Name:        corev1.ResourceCPU,
+						Requestable: resource.MustParse("15"),
+						Usage:       resource.MustParse("3"),
+					}},
+				},
+			},
+			wantClusterQueues: 1,
+		},
+		"not found": {
+			cohort:  "team-c",
+			wantErr: errCohortNotFound,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cache := New(utiltesting.NewFakeClient())
+			ctx := context.Background()
+			cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+			cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("gpu").Obj())
+			for _, cohort := range cohorts {
+				if err := cache.AddOrUpdateCohort(cohort); err != nil {
+					t.Fatalf("Adding Cohort: %v", err)
+				}
+			}
+			for _, cq := range cqs {
+				if err := cache.AddClusterQueue(ctx, cq); err != nil {
+					t.Fatalf("Adding ClusterQueue: %v", err)
+				}
+			}
+			for _, w := range workloads {
+				if added := cache.AddOrUpdateWorkload(w); !added {
+					t.Fatalf("Workload %s was not added", workload.Key(w))
+				}
+			}
+			resources, clusterQueues, err := cache.CohortResources(utiltesting.MakeCohort(tc.cohort).Obj())
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Unexpected error %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantResources, resources, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Unexpected resources (-want,+got):\n%s", diff)
+			}
+			if clusterQueues != tc.wantClusterQueues {
+				t.Errorf("Got %d ClusterQueues, want %d", clusterQueues, tc.wantClusterQueues)
+			}
+		})
+	}
+}
+
 func TestCacheQueueOperations(t *testing.T) {
 	cqs := []*kueue.ClusterQueue{
 		utiltesting.MakeClusterQueue("foo").Obj(),
@@ -1640,6 +1854,101 @@ func TestCacheQueueOperations(t *testing.T) {
 	}
 }
 
+func TestCacheCohortOperations(t *testing.T) {
+	steps := []struct {
+		name string
+		// operation applies a change to the cache and returns the error of
+		// the change, if any.
+		operation   func(*Cache) error
+		wantErr     error
+		wantParents map[string]string
+	}{
+		{
+			name: "add ClusterQueue to cohort",
+			operation: func(c *Cache) error {
+				return c.AddClusterQueue(context.Background(), utiltesting.MakeClusterQueue("a").Cohort("team-a").Obj())
+			},
+			wantParents: map[string]string{"team-a": ""},
+		},
+		{
+			name: "set parent of cohort",
+			operation: func(c *Cache) error {
+				return c.AddOrUpdateCohort(utiltesting.MakeCohort("team-a").Parent("department").Obj())
+			},
+			wantParents: map[string]string{"team-a": "department", "department": ""},
+		},
+		{
+			name: "set parent of parent cohort",
+			operation: func(c *Cache) error {
+				return c.AddOrUpdateCohort(utiltesting.MakeCohort("department").Parent("company").Obj())
+			},
+			wantParents: map[string]string{"team-a": "department", "department": "company", "company": ""},
+		},
+		{
+			name: "cycle is not linked",
+			operation: func(c *Cache) error {
+				return c.AddOrUpdateCohort(utiltesting.MakeCohort("company").Parent("team-a").Obj())
+			},
+			wantErr:     errCohortCycle,
+			wantParents: map[string]string{"team-a": "department", "department": "company", "company": ""},
+		},
+		{
+			name: "breaking the cycle links the pending parent",
+			operation: func(c *Cache) error {
+				return c.AddOrUpdateCohort(utiltesting.MakeCohort("team-a").Obj())
+			},
+			wantParents: map[string]string{"team-a": "", "department": "company", "company": "team-a"},
+		},
+		{
+			name: "delete cohort objects",
+			operation: func(c *Cache) error {
+				c.DeleteCohort(utiltesting.MakeCohort("company").Obj())
+				c.DeleteCohort(utiltesting.MakeCohort("department").Obj())
+				return nil
+			},
+			wantParents: map[string]string{"team-a": ""},
+		},
+		{
+			name: "delete ClusterQueue",
+			operation: func(c *Cache) error {
+				c.DeleteClusterQueue(utiltesting.MakeClusterQueue("a").Cohort("team-a").Obj())
+				return nil
+			},
+			wantParents: map[string]string{"team-a": ""},
+		},
+		{
+			name: "delete last cohort object",
+			operation: func(c *Cache) error {
+				c.DeleteCohort(utiltesting.MakeCohort("team-a").Obj())
+				return nil
+			},
+			wantParents: map[string]string{},
+		},
+	}
+	cache := New(utiltesting.NewFakeClient())
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			err := step.operation(cache)
+			if !errors.Is(err, step.wantErr) {
+				t.Errorf("Unexpected error: %v, want %v", err, step.wantErr)
+			}
+			gotParents := make(map[string]string, len(cache.cohorts))
+			for name, cohort := range cache.cohorts {
+				gotParents[name] = ""
+				if cohort.Parent != nil {
+					gotParents[name] = cohort.Parent.Name
+					if !cohort.Parent.ChildCohorts.Has(cohort) {
+						t.Errorf("Cohort %q is not a child of its parent %q", name, cohort.Parent.Name)
+					}
+				}
+			}
+			if diff := cmp.Diff(step.wantParents, gotParents); diff != "" {
+				t.Errorf("Unexpected cohort parents (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestClusterQueuesUsingFlavor(t *testing.T) {
 	x86Rf := utiltesting.MakeResourceFlavor("x86").Obj()
 	aarch64Rf := utiltesting.MakeResourceFlavor("aarch64").Obj()
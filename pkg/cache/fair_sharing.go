@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"math"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var defaultFairWeight = resource.MustParse("1")
+
+// DominantResourceShare returns the maximum of the ratios of usage above
+// nominal quota to the lendable resources in the cohort tree, among all the
+// resources provided by the ClusterQueue, divided by the weight of the
+// ClusterQueue. The ratios are expressed in per-mille units, so the value is
+// at most 1000 for a weight of 1, and can be higher for weights below 1.
+// If zero, it means that the usage of the ClusterQueue is below the nominal
+// quota. The function also returns the resource name that yielded this value.
+// If the ClusterQueue has a weight of zero, it returns math.MaxInt.
+func (c *ClusterQueue) DominantResourceShare() (int, corev1.ResourceName) {
+	return c.dominantResourceShare(nil, 0)
+}
+
+// DominantResourceShareWith returns the dominant resource share of the
+// ClusterQueue if the given requests were admitted.
+func (c *ClusterQueue) DominantResourceShareWith(wlReq FlavorResourceQuantities) (int, corev1.ResourceName) {
+	return c.dominantResourceShare(wlReq, 1)
+}
+
+// DominantResourceShareWithout returns the dominant resource share of the
+// ClusterQueue if the given requests were removed from its usage.
+func (c *ClusterQueue) DominantResourceShareWithout(wlReq FlavorResourceQuantities) (int, corev1.ResourceName) {
+	return c.dominantResourceShare(wlReq, -1)
+}
+
+func (c *ClusterQueue) dominantResourceShare(wlReq FlavorResourceQuantities, m int64) (int, corev1.ResourceName) {
+	if c.Cohort == nil {
+		return 0, ""
+	}
+	// MilliValue rounds up, so only a zero weight, or a negative one if the
+	// validation was bypassed, would lead to a division by zero or below.
+	weight := c.FairWeight.MilliValue()
+	if weight <= 0 {
+		return math.MaxInt, ""
+	}
+
+	borrowing := make(map[corev1.ResourceName]int64)
+	for _, rg := range c.ResourceGroups {
+		for _, flvQuotas := range rg.Flavors {
+			for rName, rQuota := range flvQuotas.Resources {
+				b := c.Usage[flvQuotas.Name][rName] + m*wlReq[flvQuotas.Name][rName] - rQuota.Nominal
+				if b > 0 {
+					borrowing[rName] += b
+				}
+			}
+		}
+	}
+	if len(borrowing) == 0 {
+		return 0, ""
+	}
+
+	lendable := c.Cohort.Root().lendableResources()
+	drs := int64(-1)
+	var dRes corev1.ResourceName
+	for rName, b := range borrowing {
+		lr := lendable[rName]
+		if lr == 0 {
+			continue
+		}
+		ratio := b * 1000 / lr
+		// Use alphabetical order to get a deterministic resource name.
+		if ratio > drs || (ratio == drs && rName < dRes) {
+			drs = ratio
+			dRes = rName
+		}
+	}
+	if drs < 0 {
+		return 0, ""
+	}
+	dws := drs * 1000 / weight
+	return int(dws), dRes
+}
+
+// lendableResources returns, for each resource, the quota that the
+// ClusterQueues in the cohort tree lend, plus the quota of the cohort, added
+// up for all the flavors. The quota of the descendant cohorts is left out, as
+// it is only available to their own subtrees.
+func (c *Cohort) lendableResources() map[corev1.ResourceName]int64 {
+	lendable := make(map[corev1.ResourceName]int64)
+	for _, resQuota := range c.quota {
+		for rName, v := range resQuota {
+			lendable[rName] += v
+		}
+	}
+	for cq := range c.AllMembers() {
+		for _, rg := range cq.ResourceGroups {
+			for _, flvQuotas := range rg.Flavors {
+				for rName, rQuota := range flvQuotas.Resources {
+					lendable[rName] += rQuota.Nominal - rQuota.Guaranteed()
+				}
+			}
+		}
+	}
+	return lendable
+}
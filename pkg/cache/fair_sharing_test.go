@@ -0,0 +1,334 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+)
+
+func TestDominantResourceShare(t *testing.T) {
+	cases := map[string]struct {
+		cq        ClusterQueue
+		lendingCQ ClusterQueue
+		wlReq     FlavorResourceQuantities
+		wantDRS   int
+		wantRes   corev1.ResourceName
+	}{
+		"no cohort": {
+			cq: ClusterQueue{
+				FairWeight: defaultFairWeight,
+				Usage: FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: 3_000},
+				},
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2_000},
+						},
+					}},
+				}},
+			},
+		},
+		"usage below nominal": {
+			cq: ClusterQueue{
+				FairWeight: defaultFairWeight,
+				Usage: FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: 1_000},
+				},
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2_000},
+						},
+					}},
+				}},
+			},
+			lendingCQ: ClusterQueue{
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 8_000},
+						},
+					}},
+				}},
+			},
+		},
+		"usage above nominal": {
+			cq: ClusterQueue{
+				FairWeight: defaultFairWeight,
+				Usage: FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: 3_000, "example.com/gpu": 4},
+				},
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2_000},
+							"example.com/gpu":  {Nominal: 2},
+						},
+					}},
+				}},
+			},
+			lendingCQ: ClusterQueue{
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 8_000},
+							"example.com/gpu":  {Nominal: 8},
+						},
+					}},
+				}},
+			},
+			// cpu: 1/10, gpu: 2/10
+			wantDRS: 200,
+			wantRes: "example.com/gpu",
+		},
+		"usage above nominal with the workload": {
+			cq: ClusterQueue{
+				FairWeight: defaultFairWeight,
+				Usage: FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: 1_000},
+				},
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2_000},
+						},
+					}},
+				}},
+			},
+			lendingCQ: ClusterQueue{
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 8_000},
+						},
+					}},
+				}},
+			},
+			wlReq: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 6_000},
+			},
+			wantDRS: 500,
+			wantRes: corev1.ResourceCPU,
+		},
+		"weighted share": {
+			cq: ClusterQueue{
+				FairWeight: resource.MustParse("2"),
+				Usage: FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: 7_000},
+				},
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2_000},
+						},
+					}},
+				}},
+			},
+			lendingCQ: ClusterQueue{
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 8_000},
+						},
+					}},
+				}},
+			},
+			wantDRS: 250,
+			wantRes: corev1.ResourceCPU,
+		},
+		"weight below one": {
+			cq: ClusterQueue{
+				FairWeight: resource.MustParse("0.25"),
+				Usage: FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: 7_000},
+				},
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2_000},
+						},
+					}},
+				}},
+			},
+			lendingCQ: ClusterQueue{
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 8_000},
+						},
+					}},
+				}},
+			},
+			wantDRS: 2_000,
+			wantRes: corev1.ResourceCPU,
+		},
+		"only lent quota is accounted": {
+			cq: ClusterQueue{
+				FairWeight: defaultFairWeight,
+				Usage: FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: 4_000},
+				},
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2_000},
+						},
+					}},
+				}},
+			},
+			lendingCQ: ClusterQueue{
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 8_000, LendingLimit: pointer.Int64(2_000)},
+						},
+					}},
+				}},
+			},
+			wantDRS: 500,
+			wantRes: corev1.ResourceCPU,
+		},
+		"zero weight": {
+			cq: ClusterQueue{
+				FairWeight: resource.MustParse("0"),
+				Usage: FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: 3_000},
+				},
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2_000},
+						},
+					}},
+				}},
+			},
+			lendingCQ: ClusterQueue{
+				ResourceGroups: []ResourceGroup{{
+					Flavors: []FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 8_000},
+						},
+					}},
+				}},
+			},
+			wantDRS: math.MaxInt,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if tc.lendingCQ.ResourceGroups != nil {
+				cohort := newCohort("cohort", 2)
+				cohort.Members = sets.New(&tc.cq, &tc.lendingCQ)
+				tc.cq.Cohort = cohort
+				tc.lendingCQ.Cohort = cohort
+			}
+			drs, dRes := tc.cq.DominantResourceShareWith(tc.wlReq)
+			if drs != tc.wantDRS {
+				t.Errorf("DominantResourceShare(_) returned value %d, want %d", drs, tc.wantDRS)
+			}
+			if dRes != tc.wantRes {
+				t.Errorf("DominantResourceShare(_) returned resource %s, want %s", dRes, tc.wantRes)
+			}
+		})
+	}
+}
+
+func TestCacheDominantResourceShare(t *testing.T) {
+	ctx := context.Background()
+	cache := New(utiltesting.NewFakeClient())
+	cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+	cqs := []*kueue.ClusterQueue{
+		utiltesting.MakeClusterQueue("a").
+			Cohort("cohort").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "2").Obj()).
+			Obj(),
+		utiltesting.MakeClusterQueue("b").
+			Cohort("cohort").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "8").Obj()).
+			Obj(),
+		utiltesting.MakeClusterQueue("inactive").
+			Cohort("cohort").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("missing").Resource(corev1.ResourceCPU, "90").Obj()).
+			Obj(),
+	}
+	for _, cq := range cqs {
+		if err := cache.AddClusterQueue(ctx, cq); err != nil {
+			t.Fatalf("Adding ClusterQueue %s: %v", cq.Name, err)
+		}
+	}
+	cache.AddOrUpdateWorkload(utiltesting.MakeWorkload("wl", "").
+		Request(corev1.ResourceCPU, "4").
+		Admit(utiltesting.MakeAdmission("a").Assignment(corev1.ResourceCPU, "default", "4").Obj()).
+		Obj())
+
+	cases := map[string]struct {
+		cq      string
+		want    int
+		wantErr error
+	}{
+		"inactive ClusterQueues don't lend": {
+			cq: "a",
+			// 2 borrowed out of the 10 lent by the active ClusterQueues.
+			want: 200,
+		},
+		"inactive ClusterQueue": {
+			cq: "inactive",
+		},
+		"missing ClusterQueue": {
+			cq:      "missing",
+			wantErr: errCqNotFound,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := cache.DominantResourceShare(tc.cq)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("DominantResourceShare(%q) returned error %v, want %v", tc.cq, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("DominantResourceShare(%q) = %d, want %d", tc.cq, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+// RefreshFlavorCapacity computes the capacity of the ResourceFlavors that
+// compute it from their Nodes and updates the nominal quota that the
+// ClusterQueues define as a share of it. It returns the ClusterQueues whose
+// quota changed.
+func (c *Cache) RefreshFlavorCapacity() sets.Set[string] {
+	c.Lock()
+	defer c.Unlock()
+	return c.refreshFlavorCapacity()
+}
+
+func (c *Cache) refreshFlavorCapacity() sets.Set[string] {
+	// The Pods that belong to admitted workloads use the quota of their
+	// ClusterQueue, instead of reducing the capacity.
+	var admittedOwners sets.Set[types.UID]
+	newCapacity := make(map[kueue.ResourceFlavorReference]workload.Requests)
+	for name, rf := range c.resourceFlavors {
+		if !rf.Spec.CapacityFromNodes {
+			continue
+		}
+		if admittedOwners == nil {
+			admittedOwners = c.admittedWorkloadOwners()
+		}
+		newCapacity[name] = c.computeFlavorCapacity(rf, admittedOwners)
+	}
+	changed := sets.New[kueue.ResourceFlavorReference]()
+	for name, capacity := range newCapacity {
+		if oldCapacity, found := c.flavorCapacity[name]; !found || !equality.Semantic.DeepEqual(oldCapacity, capacity) {
+			changed.Insert(name)
+		}
+	}
+	for name := range c.flavorCapacity {
+		if _, found := newCapacity[name]; !found {
+			changed.Insert(name)
+		}
+	}
+	c.flavorCapacity = newCapacity
+
+	cqs := sets.New[string]()
+	if changed.Len() == 0 {
+		return cqs
+	}
+	now := c.clock.Now()
+	for _, cq := range c.clusterQueues {
+		if cq.usesFlavorCapacity(changed) {
+			cq.updateResourceGroups(cq.specResourceGroups, c.flavorCapacity, now)
+			cq.UpdateWithFlavors(c.resourceFlavors)
+			cqs.Insert(cq.Name)
+		}
+	}
+	return cqs
+}
+
+// computeFlavorCapacity returns the allocatable resources of the Nodes that
+// match the nodeLabels of the ResourceFlavor, minus the requests of the Pods
+// in those Nodes that don't belong to admitted workloads.
+func (c *Cache) computeFlavorCapacity(rf *kueue.ResourceFlavor, admittedOwners sets.Set[types.UID]) workload.Requests {
+	capacity := make(workload.Requests)
+	selector := labels.SelectorFromSet(rf.Spec.NodeLabels)
+	flavorNodes := sets.New[string]()
+	for name, node := range c.nodes {
+		if !selector.Matches(labels.Set(node.labels)) {
+			continue
+		}
+		flavorNodes.Insert(name)
+		for rName, v := range node.allocatable {
+			capacity[rName] += v
+		}
+	}
+	for _, pod := range c.pods {
+		if !flavorNodes.Has(pod.nodeName) || admittedOwners.HasAny(pod.owners...) {
+			continue
+		}
+		for rName, v := range pod.requests {
+			if _, found := capacity[rName]; found {
+				capacity[rName] -= v
+			}
+		}
+	}
+	for rName, v := range capacity {
+		if v < 0 {
+			capacity[rName] = 0
+		}
+	}
+	return capacity
+}
+
+// usesFlavorCapacity returns whether the ClusterQueue defines its nominal
+// quota as a share of the capacity of any of the ResourceFlavors.
+func (c *ClusterQueue) usesFlavorCapacity(flavors sets.Set[kueue.ResourceFlavorReference]) bool {
+	for _, rg := range c.specResourceGroups {
+		for _, f := range rg.Flavors {
+			if !flavors.Has(f.Name) {
+				continue
+			}
+			for _, r := range f.Resources {
+				if r.NominalQuotaPercent != nil {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+func TestRefreshFlavorCapacity(t *testing.T) {
+	makePod := func(name, nodeName, cpu string, owner types.UID) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ns",
+				UID:       types.UID(name),
+			},
+			Spec: corev1.PodSpec{
+				NodeName: nodeName,
+				Containers: []corev1.Container{{
+					Name: "c",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse(cpu),
+						},
+					},
+				}},
+			},
+		}
+		if owner != "" {
+			pod.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion: "batch/v1",
+				Kind:       "Job",
+				Name:       string(owner),
+				UID:        owner,
+				Controller: pointer.Bool(true),
+			}}
+		}
+		return pod
+	}
+	nodes := []*corev1.Node{
+		utiltesting.MakeNode("n1").Label("pool", "on-demand").
+			Allocatable(corev1.ResourceCPU, "8").Allocatable(corev1.ResourcePods, "10").Obj(),
+		utiltesting.MakeNode("n2").Label("pool", "on-demand").
+			Allocatable(corev1.ResourceCPU, "8").Allocatable(corev1.ResourcePods, "10").Obj(),
+		utiltesting.MakeNode("cordoned").Label("pool", "on-demand").
+			Allocatable(corev1.ResourceCPU, "8").Allocatable(corev1.ResourcePods, "10").
+			Unschedulable().Obj(),
+		utiltesting.MakeNode("spot").Label("pool", "spot").
+			Allocatable(corev1.ResourceCPU, "8").Allocatable(corev1.ResourcePods, "10").Obj(),
+	}
+	admittedWorkload := utiltesting.MakeWorkload("admitted", "ns").
+		Admit(utiltesting.MakeAdmission("percent").Assignment(corev1.ResourceCPU, "on-demand", "2").Obj()).
+		Obj()
+	admittedWorkload.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "batch/v1",
+		Kind:       "Job",
+		Name:       "kueue-job",
+		UID:        "kueue-job",
+	}}
+
+	cases := map[string]struct {
+		pods []*corev1.Pod
+		// indirectControllers are the controllers up the chain of the
+		// controller of the pods, by pod name.
+		indirectControllers map[string][]types.UID
+		wantCapacity        map[kueue.ResourceFlavorReference]workload.Requests
+		// wantNominal is the nominal cpu quota of the ClusterQueue with a
+		// share of the capacity.
+		wantNominal int64
+	}{
+		"only nodes": {
+			wantCapacity: map[kueue.ResourceFlavorReference]workload.Requests{
+				"on-demand": {corev1.ResourceCPU: 16_000, corev1.ResourcePods: 20},
+			},
+			wantNominal: 8_000,
+		},
+		"pods not admitted by kueue are subtracted": {
+			pods: []*corev1.Pod{
+				makePod("system", "n1", "1", ""),
+				makePod("other-job", "n2", "3", "other-job"),
+				makePod("kueue-job", "n2", "2", "kueue-job"),
+				makePod("in-spot", "spot", "2", ""),
+				makePod("pending", "", "2", ""),
+			},
+			wantCapacity: map[kueue.ResourceFlavorReference]workload.Requests{
+				"on-demand": {corev1.ResourceCPU: 12_000, corev1.ResourcePods: 18},
+			},
+			wantNominal: 6_000,
+		},
+		"pods created through a batch job of an admitted job are not subtracted": {
+			pods: []*corev1.Pod{
+				makePod("launcher", "n1", "2", "launcher-job"),
+				makePod("other-launcher", "n2", "2", "other-launcher-job"),
+			},
+			indirectControllers: map[string][]types.UID{
+				"launcher":       {"kueue-job"},
+				"other-launcher": {"other-job"},
+			},
+			wantCapacity: map[kueue.ResourceFlavorReference]workload.Requests{
+				"on-demand": {corev1.ResourceCPU: 14_000, corev1.ResourcePods: 19},
+			},
+			wantNominal: 7_000,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cache := New(utiltesting.NewFakeClient())
+			cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("on-demand").
+				Label("pool", "on-demand").
+				CapacityFromNodes().
+				Obj())
+			cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("spot").
+				Label("pool", "spot").
+				Obj())
+			cqs := []*kueue.ClusterQueue{
+				utiltesting.MakeClusterQueue("percent").
+					ResourceGroup(
+						*utiltesting.MakeFlavorQuotas("on-demand").
+							Resource(corev1.ResourceCPU).
+							NominalQuotaPercent(corev1.ResourceCPU, 50).
+							Obj(),
+						*utiltesting.MakeFlavorQuotas("spot").
+							Resource(corev1.ResourceCPU, "4").
+							Obj()).
+					Obj(),
+				utiltesting.MakeClusterQueue("fixed").
+					ResourceGroup(*utiltesting.MakeFlavorQuotas("on-demand").
+						Resource(corev1.ResourceCPU, "4").
+						Obj()).
+					Obj(),
+			}
+			for _, cq := range cqs {
+				if err := cache.AddClusterQueue(context.Background(), cq); err != nil {
+					t.Fatalf("Failed adding ClusterQueue: %v", err)
+				}
+			}
+			cache.AddOrUpdateWorkload(admittedWorkload)
+			for _, node := range nodes {
+				cache.AddOrUpdateNode(node)
+			}
+			for _, pod := range tc.pods {
+				cache.AddOrUpdatePod(pod, tc.indirectControllers[pod.Name])
+			}
+
+			gotCQs := cache.RefreshFlavorCapacity()
+			if diff := cmp.Diff(sets.New("percent"), gotCQs); diff != "" {
+				t.Errorf("Unexpected ClusterQueues with changed quota (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantCapacity, cache.flavorCapacity); diff != "" {
+				t.Errorf("Unexpected capacity (-want,+got):\n%s", diff)
+			}
+			snapshot := cache.Snapshot()
+			percentCQ := snapshot.ClusterQueues["percent"]
+			if got := percentCQ.RGByResource[corev1.ResourceCPU].Flavors[0].Resources[corev1.ResourceCPU].Nominal; got != tc.wantNominal {
+				t.Errorf("Unexpected nominal quota from the flavor capacity, want %d, got %d", tc.wantNominal, got)
+			}
+			if got := percentCQ.RGByResource[corev1.ResourceCPU].Flavors[1].Resources[corev1.ResourceCPU].Nominal; got != 4_000 {
+				t.Errorf("Unexpected nominal quota for the flavor without capacity, want 4000, got %d", got)
+			}
+			if got := snapshot.ClusterQueues["fixed"].RGByResource[corev1.ResourceCPU].Flavors[0].Resources[corev1.ResourceCPU].Nominal; got != 4_000 {
+				t.Errorf("Unexpected nominal quota for the ClusterQueue without a share, want 4000, got %d", got)
+			}
+
+			if gotCQs := cache.RefreshFlavorCapacity(); len(gotCQs) != 0 {
+				t.Errorf("Unexpected ClusterQueues with changed quota when the capacity didn't change: %v", gotCQs)
+			}
+		})
+	}
+}
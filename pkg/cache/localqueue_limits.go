@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+// localQueueLimits holds the limits of a LocalQueue and the usage of its
+// admitted workloads.
+type localQueueLimits struct {
+	maxUsage             FlavorResourceQuantities
+	maxAdmittedWorkloads *int32
+	// usage only tracks the flavors and resources in maxUsage.
+	usage             FlavorResourceQuantities
+	admittedWorkloads int
+}
+
+func newLocalQueueLimits(limits *kueue.LocalQueueLimits) *localQueueLimits {
+	l := &localQueueLimits{
+		maxUsage:             make(FlavorResourceQuantities, len(limits.Flavors)),
+		maxAdmittedWorkloads: limits.MaxAdmittedWorkloads,
+		usage:                make(FlavorResourceQuantities, len(limits.Flavors)),
+	}
+	for _, f := range limits.Flavors {
+		maxUsage := make(map[corev1.ResourceName]int64, len(f.Resources))
+		usage := make(map[corev1.ResourceName]int64, len(f.Resources))
+		for _, r := range f.Resources {
+			maxUsage[r.Name] = workload.ResourceValue(r.Name, r.MaxUsage)
+			usage[r.Name] = 0
+		}
+		l.maxUsage[f.Name] = maxUsage
+		l.usage[f.Name] = usage
+	}
+	return l
+}
+
+func (l *localQueueLimits) clone() *localQueueLimits {
+	c := &localQueueLimits{
+		// maxUsage is immutable.
+		maxUsage:             l.maxUsage,
+		maxAdmittedWorkloads: l.maxAdmittedWorkloads,
+		usage:                make(FlavorResourceQuantities, len(l.usage)),
+		admittedWorkloads:    l.admittedWorkloads,
+	}
+	for fName, rUsage := range l.usage {
+		rUsageCopy := make(map[corev1.ResourceName]int64, len(rUsage))
+		for k, v := range rUsage {
+			rUsageCopy[k] = v
+		}
+		c.usage[fName] = rUsageCopy
+	}
+	return c
+}
+
+// setLocalQueueLimits starts tracking the usage of the LocalQueue, if it has
+// limits.
+func (c *ClusterQueue) setLocalQueueLimits(q *kueue.LocalQueue) {
+	qKey := queueKey(q)
+	if q.Spec.Limits == nil {
+		delete(c.localQueueLimits, qKey)
+		return
+	}
+	l := newLocalQueueLimits(q.Spec.Limits)
+	// Workloads could have been added before receiving the queue event.
+	for _, wl := range c.Workloads {
+		if workloadBelongsToLocalQueue(wl.Obj, q) {
+			l.admittedWorkloads++
+			updateUsage(wl, l.usage, 1)
+		}
+	}
+	c.localQueueLimits[qKey] = l
+}
+
+// CheckLocalQueueLimits returns an error if admitting a workload from the
+// LocalQueue with the given key, with the given usage, would exceed the
+// limits of the LocalQueue.
+func (c *ClusterQueue) CheckLocalQueueLimits(qKey string, usage FlavorResourceQuantities) error {
+	l, ok := c.localQueueLimits[qKey]
+	if !ok {
+		return nil
+	}
+	if l.maxAdmittedWorkloads != nil && l.admittedWorkloads >= int(*l.maxAdmittedWorkloads) {
+		return fmt.Errorf("LocalQueue %s reached its limit of %d admitted workloads", qKey, *l.maxAdmittedWorkloads)
+	}
+	flavors := make([]string, 0, len(usage))
+	for fName := range usage {
+		flavors = append(flavors, string(fName))
+	}
+	sort.Strings(flavors)
+	for _, fName := range flavors {
+		fMaxUsage, ok := l.maxUsage[kueue.ResourceFlavorReference(fName)]
+		if !ok {
+			continue
+		}
+		fUsage := usage[kueue.ResourceFlavorReference(fName)]
+		resources := make([]string, 0, len(fUsage))
+		for rName := range fUsage {
+			resources = append(resources, string(rName))
+		}
+		sort.Strings(resources)
+		for _, r := range resources {
+			rName := corev1.ResourceName(r)
+			maxUsage, ok := fMaxUsage[rName]
+			if !ok {
+				continue
+			}
+			used := l.usage[kueue.ResourceFlavorReference(fName)][rName]
+			if used+fUsage[rName] > maxUsage {
+				limit := workload.ResourceQuantity(rName, maxUsage)
+				return fmt.Errorf("LocalQueue %s exceeds its limit of %s for resource %s in flavor %s", qKey, limit.String(), rName, fName)
+			}
+		}
+	}
+	return nil
+}
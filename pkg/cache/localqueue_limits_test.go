@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+)
+
+func TestCheckLocalQueueLimits(t *testing.T) {
+	cq := utiltesting.MakeClusterQueue("cq").
+		ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+			Resource(corev1.ResourceCPU, "10").
+			Resource(corev1.ResourceMemory, "10Gi").
+			Obj()).
+		Obj()
+	admitted := utiltesting.MakeWorkload("admitted", "ns").
+		Queue("lq").
+		Request(corev1.ResourceCPU, "2").
+		Admit(utiltesting.MakeAdmission("cq").Assignment(corev1.ResourceCPU, "default", "2").Obj()).
+		Obj()
+	cases := map[string]struct {
+		queue   *kueue.LocalQueue
+		usage   FlavorResourceQuantities
+		wantErr string
+	}{
+		"no limits": {
+			queue: utiltesting.MakeLocalQueue("lq", "ns").ClusterQueue("cq").Obj(),
+			usage: FlavorResourceQuantities{"default": {corev1.ResourceCPU: 8_000}},
+		},
+		"fits the limit": {
+			queue: utiltesting.MakeLocalQueue("lq", "ns").ClusterQueue("cq").
+				MaxUsage("default", corev1.ResourceCPU, "4").
+				Obj(),
+			usage: FlavorResourceQuantities{"default": {corev1.ResourceCPU: 2_000}},
+		},
+		"exceeds the limit": {
+			queue: utiltesting.MakeLocalQueue("lq", "ns").ClusterQueue("cq").
+				MaxUsage("default", corev1.ResourceCPU, "4").
+				Obj(),
+			usage:   FlavorResourceQuantities{"default": {corev1.ResourceCPU: 3_000}},
+			wantErr: "LocalQueue ns/lq exceeds its limit of 4 for resource cpu in flavor default",
+		},
+		"resource without limit": {
+			queue: utiltesting.MakeLocalQueue("lq", "ns").ClusterQueue("cq").
+				MaxUsage("default", corev1.ResourceCPU, "4").
+				Obj(),
+			usage: FlavorResourceQuantities{"default": {corev1.ResourceMemory: 8 * utiltesting.Gi}},
+		},
+		"reached max admitted workloads": {
+			queue: utiltesting.MakeLocalQueue("lq", "ns").ClusterQueue("cq").
+				MaxAdmittedWorkloads(1).
+				Obj(),
+			usage:   FlavorResourceQuantities{"default": {corev1.ResourceCPU: 1_000}},
+			wantErr: "LocalQueue ns/lq reached its limit of 1 admitted workloads",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cache := New(utiltesting.NewFakeClient())
+			cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+			if err := cache.AddClusterQueue(context.Background(), cq); err != nil {
+				t.Fatalf("Failed adding ClusterQueue: %v", err)
+			}
+			if !cache.AddOrUpdateWorkload(admitted) {
+				t.Fatalf("Failed adding workload")
+			}
+			if err := cache.AddLocalQueue(tc.queue); err != nil {
+				t.Fatalf("Failed adding LocalQueue: %v", err)
+			}
+			snapshot := cache.Snapshot()
+			var gotErr string
+			if err := snapshot.ClusterQueues["cq"].CheckLocalQueueLimits("ns/lq", tc.usage); err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("Got error %q, want %q", gotErr, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+)
+
+// QuotaScheduleStatus describes the quota schedules of a ClusterQueue at a
+// point in time.
+type QuotaScheduleStatus struct {
+	// Active lists the schedules whose window is active, in the form
+	// flavor/resource=schedule.
+	Active []string
+	// NextTransition is the next time in which the window of a schedule
+	// starts or ends. Zero if no schedule is going to start again.
+	NextTransition time.Time
+}
+
+// quotaSchedule is a quota schedule with its cron expression and time zone
+// parsed, so that they are only parsed when the ClusterQueue is added or
+// updated. A schedule that can't be parsed is never active.
+type quotaSchedule struct {
+	spec     *kueue.QuotaSchedule
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// parseQuotaSchedules parses the cron expressions and time zones of the
+// quota schedules.
+func parseQuotaSchedules(schedules []kueue.QuotaSchedule) []quotaSchedule {
+	if len(schedules) == 0 {
+		return nil
+	}
+	parsed := make([]quotaSchedule, len(schedules))
+	for i := range schedules {
+		qs := &parsed[i]
+		qs.spec = &schedules[i]
+		qs.location = time.UTC
+		if qs.spec.TimeZone != nil {
+			loc, err := time.LoadLocation(*qs.spec.TimeZone)
+			if err != nil {
+				continue
+			}
+			qs.location = loc
+		}
+		if sched, err := cron.ParseStandard(qs.spec.Schedule); err == nil {
+			qs.schedule = sched
+		}
+	}
+	return parsed
+}
+
+// activeQuotaSchedule returns the first schedule whose time window contains
+// now, if any, and the next time in which the window of any of the schedules
+// starts or ends.
+func activeQuotaSchedule(schedules []quotaSchedule, now time.Time) (*kueue.QuotaSchedule, time.Time) {
+	var active *kueue.QuotaSchedule
+	var next time.Time
+	for i := range schedules {
+		isActive, transition := schedules[i].evaluate(now)
+		if isActive && active == nil {
+			active = schedules[i].spec
+		}
+		if !transition.IsZero() && (next.IsZero() || transition.Before(next)) {
+			next = transition
+		}
+	}
+	return active, next
+}
+
+// evaluate returns whether the time window of the schedule contains now, and
+// the time in which the window ends or the next one starts.
+func (qs *quotaSchedule) evaluate(now time.Time) (bool, time.Time) {
+	duration := qs.spec.Duration.Duration
+	if qs.schedule == nil || duration <= 0 {
+		return false, time.Time{}
+	}
+	// The earliest window that could contain now is the first one starting
+	// after now-duration.
+	start := qs.schedule.Next(now.In(qs.location).Add(-duration))
+	if start.IsZero() {
+		return false, time.Time{}
+	}
+	if start.After(now) {
+		return false, start
+	}
+	// A window starting before this one ends extends it; the schedule is
+	// simply evaluated again when this one ends.
+	return true, start.Add(duration)
+}
+
+// RefreshQuotaSchedules evaluates the quota schedules of the ClusterQueue at
+// the current time. It returns whether the active schedules changed.
+func (c *Cache) RefreshQuotaSchedules(name string) bool {
+	c.Lock()
+	defer c.Unlock()
+	cq, ok := c.clusterQueues[name]
+	if !ok || cq.nextQuotaTransition.IsZero() {
+		return false
+	}
+	oldActive := cq.activeQuotaSchedules()
+	cq.updateResourceGroups(cq.specResourceGroups, c.flavorCapacity, c.clock.Now())
+	cq.UpdateWithFlavors(c.resourceFlavors)
+	newActive := cq.activeQuotaSchedules()
+	if len(oldActive) != len(newActive) {
+		return true
+	}
+	for i := range oldActive {
+		if oldActive[i] != newActive[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// QuotaSchedules returns the status of the quota schedules of the
+// ClusterQueue, or nil if it doesn't have any.
+func (c *Cache) QuotaSchedules(name string) *QuotaScheduleStatus {
+	c.RLock()
+	defer c.RUnlock()
+	cq, ok := c.clusterQueues[name]
+	if !ok || !cq.hasQuotaSchedules() {
+		return nil
+	}
+	return &QuotaScheduleStatus{
+		Active:         cq.activeQuotaSchedules(),
+		NextTransition: cq.nextQuotaTransition,
+	}
+}
+
+func (c *ClusterQueue) hasQuotaSchedules() bool {
+	return len(c.quotaSchedules) > 0
+}
+
+func (c *ClusterQueue) activeQuotaSchedules() []string {
+	var active []string
+	for _, rg := range c.ResourceGroups {
+		for _, f := range rg.Flavors {
+			for rName, rQuota := range f.Resources {
+				if rQuota.ActiveSchedule != "" {
+					active = append(active, fmt.Sprintf("%s/%s=%s", f.Name, rName, rQuota.ActiveSchedule))
+				}
+			}
+		}
+	}
+	sort.Strings(active)
+	return active
+}
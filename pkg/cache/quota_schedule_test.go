@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	testingclock "k8s.io/utils/clock/testing"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+)
+
+func TestActiveQuotaSchedule(t *testing.T) {
+	// 2023-06-14 is a Wednesday.
+	now := time.Date(2023, time.June, 14, 10, 0, 0, 0, time.UTC)
+	businessHours := utiltesting.MakeQuotaSchedule("business-hours", "0 9 * * 1-5", 9*time.Hour, "4").Obj()
+	nights := utiltesting.MakeQuotaSchedule("nights", "0 18 * * *", 15*time.Hour, "8").Obj()
+	madrid := utiltesting.MakeQuotaSchedule("madrid", "0 13 * * *", time.Hour, "8").TimeZone("Europe/Madrid").Obj()
+	cases := map[string]struct {
+		schedules  []kueue.QuotaSchedule
+		now        time.Time
+		wantActive string
+		wantNext   time.Time
+	}{
+		"no schedules": {
+			now: now,
+		},
+		"inside the window": {
+			schedules:  []kueue.QuotaSchedule{*businessHours, *nights},
+			now:        now,
+			wantActive: "business-hours",
+			wantNext:   time.Date(2023, time.June, 14, 18, 0, 0, 0, time.UTC),
+		},
+		"window started the day before": {
+			schedules:  []kueue.QuotaSchedule{*businessHours, *nights},
+			now:        time.Date(2023, time.June, 14, 2, 0, 0, 0, time.UTC),
+			wantActive: "nights",
+			wantNext:   time.Date(2023, time.June, 14, 9, 0, 0, 0, time.UTC),
+		},
+		"outside the windows": {
+			schedules: []kueue.QuotaSchedule{*businessHours},
+			now:       time.Date(2023, time.June, 17, 10, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2023, time.June, 19, 9, 0, 0, 0, time.UTC),
+		},
+		"first schedule takes precedence": {
+			schedules:  []kueue.QuotaSchedule{*nights, *businessHours},
+			now:        time.Date(2023, time.June, 14, 18, 0, 0, 0, time.UTC),
+			wantActive: "nights",
+			wantNext:   time.Date(2023, time.June, 15, 9, 0, 0, 0, time.UTC),
+		},
+		"time zone": {
+			schedules:  []kueue.QuotaSchedule{*madrid},
+			now:        time.Date(2023, time.June, 14, 11, 30, 0, 0, time.UTC),
+			wantActive: "madrid",
+			wantNext:   time.Date(2023, time.June, 14, 12, 0, 0, 0, time.UTC),
+		},
+		"invalid schedule": {
+			schedules: []kueue.QuotaSchedule{*utiltesting.MakeQuotaSchedule("invalid", "0 9 * *", time.Hour, "4").Obj()},
+			now:       now,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			active, next := activeQuotaSchedule(parseQuotaSchedules(tc.schedules), tc.now)
+			gotActive := ""
+			if active != nil {
+				gotActive = active.Name
+			}
+			if gotActive != tc.wantActive {
+				t.Errorf("Got active schedule %q, want %q", gotActive, tc.wantActive)
+			}
+			if !next.Equal(tc.wantNext) {
+				t.Errorf("Got next transition %v, want %v", next, tc.wantNext)
+			}
+		})
+	}
+}
+
+func TestRefreshQuotaSchedules(t *testing.T) {
+	fakeClock := testingclock.NewFakeClock(time.Date(2023, time.June, 14, 8, 0, 0, 0, time.UTC))
+	cache := New(utiltesting.NewFakeClient(), WithClock(fakeClock))
+	cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+	cq := utiltesting.MakeClusterQueue("cq").
+		Cohort("cohort").
+		ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+			Resource(corev1.ResourceCPU, "2", "1").
+			Schedule(corev1.ResourceCPU, *utiltesting.MakeQuotaSchedule("business-hours", "0 9 * * 1-5", 9*time.Hour, "6").Obj()).
+			Obj()).
+		Obj()
+	if err := cache.AddClusterQueue(context.Background(), cq); err != nil {
+		t.Fatalf("Failed adding ClusterQueue: %v", err)
+	}
+
+	steps := []struct {
+		now                time.Time
+		wantChanged        bool
+		wantQuota          ResourceQuota
+		wantScheduleStatus QuotaScheduleStatus
+	}{
+		{
+			now:       time.Date(2023, time.June, 14, 8, 30, 0, 0, time.UTC),
+			wantQuota: ResourceQuota{Nominal: 2_000, BorrowingLimit: pointer.Int64(1_000)},
+			wantScheduleStatus: QuotaScheduleStatus{
+				NextTransition: time.Date(2023, time.June, 14, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			now:         time.Date(2023, time.June, 14, 9, 0, 0, 0, time.UTC),
+			wantChanged: true,
+			wantQuota:   ResourceQuota{Nominal: 6_000, ActiveSchedule: "business-hours"},
+			wantScheduleStatus: QuotaScheduleStatus{
+				Active:         []string{"default/cpu=business-hours"},
+				NextTransition: time.Date(2023, time.June, 14, 18, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			now:         time.Date(2023, time.June, 14, 18, 0, 0, 0, time.UTC),
+			wantChanged: true,
+			wantQuota:   ResourceQuota{Nominal: 2_000, BorrowingLimit: pointer.Int64(1_000)},
+			wantScheduleStatus: QuotaScheduleStatus{
+				NextTransition: time.Date(2023, time.June, 15, 9, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for i, step := range steps {
+		fakeClock.SetTime(step.now)
+		if changed := cache.RefreshQuotaSchedules("cq"); changed != step.wantChanged {
+			t.Errorf("Step %d: RefreshQuotaSchedules returned %t, want %t", i, changed, step.wantChanged)
+		}
+		gotQuota := cache.clusterQueues["cq"].ResourceGroups[0].Flavors[0].Resources[corev1.ResourceCPU]
+		if diff := cmp.Diff(step.wantQuota, *gotQuota); diff != "" {
+			t.Errorf("Step %d: Unexpected quota (-want,+got):\n%s", i, diff)
+		}
+		if diff := cmp.Diff(&step.wantScheduleStatus, cache.QuotaSchedules("cq")); diff != "" {
+			t.Errorf("Step %d: Unexpected schedule status (-want,+got):\n%s", i, diff)
+		}
+	}
+}
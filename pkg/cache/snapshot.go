@@ -28,6 +28,9 @@ type Snapshot struct {
 	ClusterQueues            map[string]*ClusterQueue
 	ResourceFlavors          map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor
 	InactiveClusterQueueSets sets.Set[string]
+	// Topologies holds the unused capacity of the topology domains of the
+	// ResourceFlavors with topology levels.
+	Topologies map[kueue.ResourceFlavorReference]*FlavorTopology
 }
 
 // RemoveWorkload removes a workload from its corresponding ClusterQueue and
@@ -35,10 +38,7 @@ type Snapshot struct {
 func (s *Snapshot) RemoveWorkload(wl *workload.Info) {
 	cq := s.ClusterQueues[wl.ClusterQueue]
 	delete(cq.Workloads, workload.Key(wl.Obj))
-	updateUsage(wl, cq.Usage, -1)
-	if cq.Cohort != nil {
-		updateUsage(wl, cq.Cohort.Usage, -1)
-	}
+	cq.updateUsageInTree(wl, -1)
 }
 
 // AddWorkload removes a workload from its corresponding ClusterQueue and
@@ -46,10 +46,160 @@ func (s *Snapshot) RemoveWorkload(wl *workload.Info) {
 func (s *Snapshot) AddWorkload(wl *workload.Info) {
 	cq := s.ClusterQueues[wl.ClusterQueue]
 	cq.Workloads[workload.Key(wl.Obj)] = wl
-	updateUsage(wl, cq.Usage, 1)
-	if cq.Cohort != nil {
-		updateUsage(wl, cq.Cohort.Usage, 1)
+	cq.updateUsageInTree(wl, 1)
+}
+
+// AddUsage adds the quantities to the usage of the ClusterQueue and of the
+// cohorts in its tree, for a workload that was admitted after the snapshot
+// was taken.
+func (s *Snapshot) AddUsage(cqName string, usage FlavorResourceQuantities) {
+	cq := s.ClusterQueues[cqName]
+	for fName, resUsage := range usage {
+		for rName, v := range resUsage {
+			cq.addUsageInTree(fName, rName, v)
+		}
+	}
+}
+
+// updateUsageInTree updates the usage of the ClusterQueue and of all the
+// cohorts in its tree.
+func (c *ClusterQueue) updateUsageInTree(wi *workload.Info, m int64) {
+	for _, ps := range wi.TotalRequests {
+		for rName, fName := range ps.Flavors {
+			if v, wlResExist := ps.Requests[rName]; wlResExist {
+				c.addUsageInTree(fName, rName, v*m)
+			}
+		}
+	}
+}
+
+// addUsageInTree adds val to the usage of the resource in the flavor by the
+// ClusterQueue. The cohorts only account for the usage above the quota that
+// the ClusterQueue doesn't lend, and above the quota of the cohorts below
+// them.
+func (c *ClusterQueue) addUsageInTree(fName kueue.ResourceFlavorReference, rName corev1.ResourceName, val int64) {
+	cqFlv, cqFlvExist := c.Usage[fName]
+	if !cqFlvExist {
+		return
+	}
+	if _, exists := cqFlv[rName]; !exists {
+		return
+	}
+	guaranteed := c.guaranteedQuota(fName, rName)
+	before := usageAboveGuaranteed(cqFlv[rName], guaranteed)
+	cqFlv[rName] += val
+	delta := usageAboveGuaranteed(cqFlv[rName], guaranteed) - before
+	for cohort := c.Cohort; cohort != nil && delta != 0; cohort = cohort.Parent {
+		cohortFlv := cohort.Usage[fName]
+		if cohortFlv == nil {
+			continue
+		}
+		// The parent only accounts for the usage above the quota of the
+		// cohort, which is only available to its subtree.
+		retained := cohort.quota[fName][rName]
+		before := usageAboveGuaranteed(cohortFlv[rName], retained)
+		cohortFlv[rName] += delta
+		delta = usageAboveGuaranteed(cohortFlv[rName], retained) - before
+	}
+}
+
+// cohortUsageDelta returns how much the usage accounted in the root of the
+// cohort tree grows when the ClusterQueue uses val more of the resource in
+// the flavor. The quota that the ClusterQueue doesn't lend is used first,
+// then the quota of each cohort, from the nearest one up to the root.
+func (c *ClusterQueue) cohortUsageDelta(fName kueue.ResourceFlavorReference, rName corev1.ResourceName, val int64) int64 {
+	used := c.Usage[fName][rName]
+	guaranteed := c.guaranteedQuota(fName, rName)
+	delta := usageAboveGuaranteed(used+val, guaranteed) - usageAboveGuaranteed(used, guaranteed)
+	for cohort := c.Cohort; cohort.Parent != nil && delta > 0; cohort = cohort.Parent {
+		cohortUsed := cohort.Usage[fName][rName]
+		retained := cohort.quota[fName][rName]
+		delta = usageAboveGuaranteed(cohortUsed+delta, retained) - usageAboveGuaranteed(cohortUsed, retained)
+	}
+	return delta
+}
+
+// CohortLack returns how much of val, the quantity of the resource in the
+// flavor that the ClusterQueue would use on top of its current usage,
+// doesn't fit in the unused quota of its cohort tree. A value lower or
+// equal to zero means that it fits.
+func (c *ClusterQueue) CohortLack(fName kueue.ResourceFlavorReference, rName corev1.ResourceName, val int64) int64 {
+	// The request is served by the nearest cohort first, and the part that
+	// it can't serve by its parent. As the unused quota of a cohort can also
+	// be lent to the rest of the tree, the top of the tree, which accounts
+	// for the usage of all the cohorts, has to be able to serve the request.
+	cohort := c.Cohort
+	for cohort.Parent != nil {
+		cohort = cohort.Parent
+	}
+	return cohort.Usage[fName][rName] + c.cohortUsageDelta(fName, rName, val) - cohort.RequestableResources[fName][rName]
+}
+
+// BorrowingDepth returns how far up the cohort tree the ClusterQueue has to
+// go to borrow the quantities, on top of its current usage: 0 if the unused
+// quota of its own cohort is enough, 1 if the parent cohort is needed, and
+// so on. Unused quota flows up to the nearest ancestor first, so the
+// workloads that borrow from a nearer cohort are admitted first.
+func (c *ClusterQueue) BorrowingDepth(q FlavorResourceQuantities) int {
+	depth := 0
+	for fName, resources := range q {
+		for rName, val := range resources {
+			if d := c.borrowingDepth(fName, rName, val); d > depth {
+				depth = d
+			}
+		}
+	}
+	return depth
+}
+
+func (c *ClusterQueue) borrowingDepth(fName kueue.ResourceFlavorReference, rName corev1.ResourceName, val int64) int {
+	used := c.Usage[fName][rName]
+	guaranteed := c.guaranteedQuota(fName, rName)
+	delta := usageAboveGuaranteed(used+val, guaranteed) - usageAboveGuaranteed(used, guaranteed)
+	depth := 0
+	for cohort := c.Cohort; cohort.Parent != nil && delta > 0; cohort = cohort.Parent {
+		cohortUsed := cohort.Usage[fName][rName]
+		if cohortUsed+delta <= cohort.RequestableResources[fName][rName] {
+			break
+		}
+		// The parent only serves the part above the quota of the cohort.
+		retained := cohort.quota[fName][rName]
+		delta = usageAboveGuaranteed(cohortUsed+delta, retained) - usageAboveGuaranteed(cohortUsed, retained)
+		depth++
+	}
+	return depth
+}
+
+// FitsInCohort returns whether the quantities, used by the ClusterQueue on
+// top of its current usage, fit in the unused quota of its cohort tree.
+func (c *ClusterQueue) FitsInCohort(q FlavorResourceQuantities) bool {
+	for fName, resources := range q {
+		for rName, val := range resources {
+			if c.CohortLack(fName, rName, val) > 0 {
+				return false
+			}
+		}
 	}
+	return true
+}
+
+// CohortCapacity returns the quantity of the resource in the flavor that
+// the ClusterQueue could use if no other ClusterQueue in its cohort tree
+// used any quota.
+func (c *ClusterQueue) CohortCapacity(fName kueue.ResourceFlavorReference, rName corev1.ResourceName) int64 {
+	capacity := c.guaranteedQuota(fName, rName)
+	cohort := c.Cohort
+	for ; cohort.Parent != nil; cohort = cohort.Parent {
+		capacity += cohort.quota[fName][rName]
+	}
+	return capacity + cohort.RequestableResources[fName][rName]
+}
+
+func usageAboveGuaranteed(used, guaranteed int64) int64 {
+	if used <= guaranteed {
+		return 0
+	}
+	return used - guaranteed
 }
 
 func (c *Cache) Snapshot() Snapshot {
@@ -60,6 +210,7 @@ func (c *Cache) Snapshot() Snapshot {
 		ClusterQueues:            make(map[string]*ClusterQueue, len(c.clusterQueues)),
 		ResourceFlavors:          make(map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, len(c.resourceFlavors)),
 		InactiveClusterQueueSets: sets.New[string](),
+		Topologies:               c.snapshotTopologies(),
 	}
 	for _, cq := range c.clusterQueues {
 		if !cq.Active() {
@@ -72,17 +223,35 @@ func (c *Cache) Snapshot() Snapshot {
 		// Shallow copy is enough
 		snap.ResourceFlavors[name] = rf
 	}
-	for _, cohort := range c.cohorts {
-		cohortCopy := newCohort(cohort.Name, cohort.Members.Len())
+	cohortCopies := make(map[string]*Cohort, len(c.cohorts))
+	for name, cohort := range c.cohorts {
+		cohortCopy := newCohort(name, cohort.Members.Len())
+		cohortCopy.quota = cohort.quota // Shallow copy is enough.
+		cohortCopies[name] = cohortCopy
+	}
+	for name, cohort := range c.cohorts {
+		if cohort.Parent != nil {
+			cohortCopy := cohortCopies[name]
+			parentCopy := cohortCopies[cohort.Parent.Name]
+			cohortCopy.Parent = parentCopy
+			parentCopy.ChildCohorts.Insert(cohortCopy)
+		}
+	}
+	for name, cohort := range c.cohorts {
+		cohortCopy := cohortCopies[name]
 		for cq := range cohort.Members {
 			if cq.Active() {
 				cqCopy := snap.ClusterQueues[cq.Name]
-				cqCopy.accumulateResources(cohortCopy)
 				cqCopy.Cohort = cohortCopy
 				cohortCopy.Members.Insert(cqCopy)
 			}
 		}
 	}
+	for _, cohortCopy := range cohortCopies {
+		subtree := cohortCopy.subtreeResources()
+		cohortCopy.RequestableResources = subtree.RequestableResources
+		cohortCopy.Usage = subtree.Usage
+	}
 	return snap
 }
 
@@ -90,14 +259,22 @@ func (c *Cache) Snapshot() Snapshot {
 // objects and deep copies of changing ones. A reference to the cohort is not included.
 func (c *ClusterQueue) snapshot() *ClusterQueue {
 	cc := &ClusterQueue{
-		Name:              c.Name,
-		ResourceGroups:    c.ResourceGroups, // Shallow copy is enough.
-		RGByResource:      c.RGByResource,   // Shallow copy is enough.
-		Usage:             make(FlavorResourceQuantities, len(c.Usage)),
-		Workloads:         make(map[string]*workload.Info, len(c.Workloads)),
-		Preemption:        c.Preemption,
-		NamespaceSelector: c.NamespaceSelector,
-		Status:            c.Status,
+		Name:               c.Name,
+		ResourceGroups:     c.ResourceGroups, // Shallow copy is enough.
+		RGByResource:       c.RGByResource,   // Shallow copy is enough.
+		Usage:              make(FlavorResourceQuantities, len(c.Usage)),
+		Workloads:          make(map[string]*workload.Info, len(c.Workloads)),
+		Preemption:         c.Preemption,
+		FlavorFungibility:  c.FlavorFungibility,
+		AdmissionChecks:    c.AdmissionChecks,
+		FairWeight:         c.FairWeight,
+		NamespaceSelector:  c.NamespaceSelector,
+		Status:             c.Status,
+		FairSharingEnabled: c.FairSharingEnabled,
+		localQueueLimits:   make(map[string]*localQueueLimits, len(c.localQueueLimits)),
+	}
+	for qKey, l := range c.localQueueLimits {
+		cc.localQueueLimits[qKey] = l.clone()
 	}
 	for fName, rUsage := range c.Usage {
 		rUsageCopy := make(map[corev1.ResourceName]int64, len(rUsage))
@@ -125,7 +302,8 @@ func (c *ClusterQueue) accumulateResources(cohort *Cohort) {
 				cohort.RequestableResources[flvQuotas.Name] = res
 			}
 			for rName, rQuota := range flvQuotas.Resources {
-				res[rName] += rQuota.Nominal
+				// Only the quota that the ClusterQueue lends is available to the cohort.
+				res[rName] += rQuota.Nominal - rQuota.Guaranteed()
 			}
 		}
 	}
@@ -139,7 +317,85 @@ func (c *ClusterQueue) accumulateResources(cohort *Cohort) {
 			cohort.Usage[fName] = used
 		}
 		for res, val := range resUsages {
-			used[res] += val
+			used[res] += usageAboveGuaranteed(val, c.guaranteedQuota(fName, res))
+		}
+	}
+}
+
+// accumulateQuota adds the quota declared by a Cohort object to the
+// resources that can be requested in the cohort.
+func (c *Cohort) accumulateQuota(quota FlavorResourceQuantities) {
+	if len(quota) == 0 {
+		return
+	}
+	if c.RequestableResources == nil {
+		c.RequestableResources = make(FlavorResourceQuantities, len(quota))
+	}
+	if c.Usage == nil {
+		c.Usage = make(FlavorResourceQuantities, len(quota))
+	}
+	for fName, resQuota := range quota {
+		res := c.RequestableResources[fName]
+		if res == nil {
+			res = make(map[corev1.ResourceName]int64, len(resQuota))
+			c.RequestableResources[fName] = res
+		}
+		if c.Usage[fName] == nil {
+			c.Usage[fName] = make(map[corev1.ResourceName]int64, len(resQuota))
+		}
+		for rName, v := range resQuota {
+			res[rName] += v
+		}
+	}
+}
+
+// subtreeResources returns a cohort that aggregates the resources that can
+// be requested in the cohort and their usage. The unused quota of the active
+// ClusterQueues in the subtree is available to the cohort, while the quota of
+// a descendant cohort is only available to that descendant's subtree. Hence,
+// a child cohort only accounts for the usage above its own quota.
+func (c *Cohort) subtreeResources() *Cohort {
+	aggregate := newCohort(c.Name, 0)
+	aggregate.accumulateQuota(c.quota)
+	for cq := range c.Members {
+		if cq.Active() {
+			cq.accumulateResources(aggregate)
+		}
+	}
+	for child := range c.ChildCohorts {
+		aggregate.accumulateChild(child.quota, child.subtreeResources())
+	}
+	return aggregate
+}
+
+// accumulateChild adds the resources of the subtree of a child cohort, except
+// for the quota of the child, to the resources that can be requested in the
+// cohort.
+func (c *Cohort) accumulateChild(childQuota FlavorResourceQuantities, child *Cohort) {
+	if c.RequestableResources == nil {
+		c.RequestableResources = make(FlavorResourceQuantities, len(child.RequestableResources))
+	}
+	if c.Usage == nil {
+		c.Usage = make(FlavorResourceQuantities, len(child.Usage))
+	}
+	for fName, resRequestable := range child.RequestableResources {
+		res := c.RequestableResources[fName]
+		if res == nil {
+			res = make(map[corev1.ResourceName]int64, len(resRequestable))
+			c.RequestableResources[fName] = res
+		}
+		for rName, v := range resRequestable {
+			res[rName] += v - childQuota[fName][rName]
+		}
+	}
+	for fName, resUsage := range child.Usage {
+		used := c.Usage[fName]
+		if used == nil {
+			used = make(map[corev1.ResourceName]int64, len(resUsage))
+			c.Usage[fName] = used
+		}
+		for rName, v := range resUsage {
+			used[rName] += usageAboveGuaranteed(v, childQuota[fName][rName])
 		}
 	}
 }
@@ -25,6 +25,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
@@ -33,13 +34,14 @@ import (
 
 var snapCmpOpts = []cmp.Option{
 	cmpopts.EquateEmpty(),
-	cmpopts.IgnoreUnexported(ClusterQueue{}),
+	cmpopts.IgnoreUnexported(ClusterQueue{}, Cohort{}),
 	cmpopts.IgnoreFields(ClusterQueue{}, "RGByResource"),
-	cmpopts.IgnoreFields(Cohort{}, "Members"), // avoid recursion.
+	cmpopts.IgnoreFields(Cohort{}, "Members", "ChildCohorts"), // avoid recursion.
 }
 
 func TestSnapshot(t *testing.T) {
 	testCases := map[string]struct {
+		cohorts      []*kueue.Cohort
 		cqs          []*kueue.ClusterQueue
 		rfs          []*kueue.ResourceFlavor
 		wls          []*kueue.Workload
@@ -68,7 +70,9 @@ func TestSnapshot(t *testing.T) {
 								utiltesting.MakeWorkload("alpha", "").
 									Admit(&kueue.Admission{ClusterQueue: "a"}).Obj()),
 						},
-						Preemption: defaultPreemption,
+						Preemption:        defaultPreemption,
+						FairWeight:        defaultFairWeight,
+						FlavorFungibility: defaultFlavorFungibility,
 					},
 					"b": {
 						Name:              "b",
@@ -79,7 +83,9 @@ func TestSnapshot(t *testing.T) {
 								utiltesting.MakeWorkload("beta", "").
 									Admit(&kueue.Admission{ClusterQueue: "b"}).Obj()),
 						},
-						Preemption: defaultPreemption,
+						Preemption:        defaultPreemption,
+						FairWeight:        defaultFairWeight,
+						FlavorFungibility: defaultFlavorFungibility,
 					},
 				},
 			},
@@ -243,6 +249,8 @@ func TestSnapshot(t *testing.T) {
 									Obj()),
 							},
 							Preemption:        defaultPreemption,
+							FairWeight:        defaultFairWeight,
+							FlavorFungibility: defaultFlavorFungibility,
 							NamespaceSelector: labels.Everything(),
 							Status:            active,
 						},
@@ -296,6 +304,8 @@ func TestSnapshot(t *testing.T) {
 									Obj()),
 							},
 							Preemption:        defaultPreemption,
+							FairWeight:        defaultFairWeight,
+							FlavorFungibility: defaultFlavorFungibility,
 							NamespaceSelector: labels.Everything(),
 							Status:            active,
 						},
@@ -318,6 +328,8 @@ func TestSnapshot(t *testing.T) {
 								},
 							},
 							Preemption:        defaultPreemption,
+							FairWeight:        defaultFairWeight,
+							FlavorFungibility: defaultFlavorFungibility,
 							NamespaceSelector: labels.Everything(),
 							Status:            active,
 						},
@@ -330,6 +342,291 @@ func TestSnapshot(t *testing.T) {
 				}
 			}(),
 		},
+		"cohort tree": {
+			cohorts: []*kueue.Cohort{
+				utiltesting.MakeCohort("team-a").Parent("department").Obj(),
+				utiltesting.MakeCohort("team-b").Parent("department").Obj(),
+			},
+			cqs: []*kueue.ClusterQueue{
+				utiltesting.MakeClusterQueue("a").
+					Cohort("team-a").
+					ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "10").Obj()).
+					Obj(),
+				utiltesting.MakeClusterQueue("b").
+					Cohort("team-b").
+					ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "20").Obj()).
+					Obj(),
+			},
+			rfs: []*kueue.ResourceFlavor{
+				utiltesting.MakeResourceFlavor("default").Obj(),
+			},
+			wls: []*kueue.Workload{
+				utiltesting.MakeWorkload("alpha", "").
+					Request(corev1.ResourceCPU, "5").
+					Admit(utiltesting.MakeAdmission("a").Assignment(corev1.ResourceCPU, "default", "5000m").Obj()).
+					Obj(),
+			},
+			wantSnapshot: func() Snapshot {
+				department := &Cohort{
+					Name: "department",
+					RequestableResources: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 30_000},
+					},
+					Usage: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 5_000},
+					},
+				}
+				teamA := &Cohort{
+					Name:   "team-a",
+					Parent: department,
+					RequestableResources: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 10_000},
+					},
+					Usage: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 5_000},
+					},
+				}
+				teamB := &Cohort{
+					Name:   "team-b",
+					Parent: department,
+					RequestableResources: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 20_000},
+					},
+					Usage: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 0},
+					},
+				}
+				return Snapshot{
+					ClusterQueues: map[string]*ClusterQueue{
+						"a": {
+							Name:   "a",
+							Cohort: teamA,
+							ResourceGroups: []ResourceGroup{{
+								CoveredResources: sets.New(corev1.ResourceCPU),
+								Flavors: []FlavorQuotas{{
+									Name: "default",
+									Resources: map[corev1.ResourceName]*ResourceQuota{
+										corev1.ResourceCPU: {Nominal: 10_000},
+									},
+								}},
+								LabelKeys: sets.New[string](),
+							}},
+							Usage: FlavorResourceQuantities{
+								"default": {corev1.ResourceCPU: 5_000},
+							},
+							Workloads: map[string]*workload.Info{
+								"/alpha": workload.NewInfo(utiltesting.MakeWorkload("alpha", "").
+									Request(corev1.ResourceCPU, "5").
+									Admit(utiltesting.MakeAdmission("a").Assignment(corev1.ResourceCPU, "default", "5000m").Obj()).
+									Obj()),
+							},
+							Preemption:        defaultPreemption,
+							FairWeight:        defaultFairWeight,
+							FlavorFungibility: defaultFlavorFungibility,
+							NamespaceSelector: labels.Everything(),
+							Status:            active,
+						},
+						"b": {
+							Name:   "b",
+							Cohort: teamB,
+							ResourceGroups: []ResourceGroup{{
+								CoveredResources: sets.New(corev1.ResourceCPU),
+								Flavors: []FlavorQuotas{{
+									Name: "default",
+									Resources: map[corev1.ResourceName]*ResourceQuota{
+										corev1.ResourceCPU: {Nominal: 20_000},
+									},
+								}},
+								LabelKeys: sets.New[string](),
+							}},
+							Usage: FlavorResourceQuantities{
+								"default": {corev1.ResourceCPU: 0},
+							},
+							Preemption:        defaultPreemption,
+							FairWeight:        defaultFairWeight,
+							FlavorFungibility: defaultFlavorFungibility,
+							NamespaceSelector: labels.Everything(),
+							Status:            active,
+						},
+					},
+					ResourceFlavors: map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+						"default": utiltesting.MakeResourceFlavor("default").Obj(),
+					},
+				}
+			}(),
+		},
+		"cohort tree with quota": {
+			cohorts: []*kueue.Cohort{
+				utiltesting.MakeCohort("department").
+					ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "5").Obj()).
+					Obj(),
+				utiltesting.MakeCohort("team-a").
+					Parent("department").
+					ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "2").Obj()).
+					Obj(),
+			},
+			cqs: []*kueue.ClusterQueue{
+				utiltesting.MakeClusterQueue("a").
+					Cohort("team-a").
+					ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "10").Obj()).
+					Obj(),
+			},
+			rfs: []*kueue.ResourceFlavor{
+				utiltesting.MakeResourceFlavor("default").Obj(),
+			},
+			wantSnapshot: func() Snapshot {
+				department := &Cohort{
+					Name: "department",
+					RequestableResources: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 15_000},
+					},
+					Usage: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 0},
+					},
+				}
+				teamA := &Cohort{
+					Name:   "team-a",
+					Parent: department,
+					RequestableResources: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 12_000},
+					},
+					Usage: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 0},
+					},
+				}
+				return Snapshot{
+					ClusterQueues: map[string]*ClusterQueue{
+						"a": {
+							Name:   "a",
+							Cohort: teamA,
+							ResourceGroups: []ResourceGroup{{
+								CoveredResources: sets.New(corev1.ResourceCPU),
+								Flavors: []FlavorQuotas{{
+									Name: "default",
+									Resources: map[corev1.ResourceName]*ResourceQuota{
+										corev1.ResourceCPU: {Nominal: 10_000},
+									},
+								}},
+								LabelKeys: sets.New[string](),
+							}},
+							Usage: FlavorResourceQuantities{
+								"default": {corev1.ResourceCPU: 0},
+							},
+							Workloads:         map[string]*workload.Info{},
+							Preemption:        defaultPreemption,
+							FairWeight:        defaultFairWeight,
+							FlavorFungibility: defaultFlavorFungibility,
+							NamespaceSelector: labels.Everything(),
+							Status:            active,
+						},
+					},
+					ResourceFlavors: map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+						"default": utiltesting.MakeResourceFlavor("default").Obj(),
+					},
+				}
+			}(),
+		},
+		"lending limit": {
+			cqs: []*kueue.ClusterQueue{
+				utiltesting.MakeClusterQueue("a").
+					Cohort("cohort").
+					ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "10", "", "4").Obj()).
+					Obj(),
+				utiltesting.MakeClusterQueue("b").
+					Cohort("cohort").
+					ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "10").Obj()).
+					Obj(),
+			},
+			rfs: []*kueue.ResourceFlavor{
+				utiltesting.MakeResourceFlavor("default").Obj(),
+			},
+			wls: []*kueue.Workload{
+				utiltesting.MakeWorkload("alpha", "").
+					Request(corev1.ResourceCPU, "8").
+					Admit(utiltesting.MakeAdmission("a").Assignment(corev1.ResourceCPU, "default", "8000m").Obj()).
+					Obj(),
+				utiltesting.MakeWorkload("beta", "").
+					Request(corev1.ResourceCPU, "3").
+					Admit(utiltesting.MakeAdmission("b").Assignment(corev1.ResourceCPU, "default", "3000m").Obj()).
+					Obj(),
+			},
+			wantSnapshot: func() Snapshot {
+				cohort := &Cohort{
+					Name: "cohort",
+					// Only 4 CPUs of ClusterQueue "a" are lent to the cohort.
+					RequestableResources: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 14_000},
+					},
+					// Only 2 CPUs used by ClusterQueue "a" are above its guaranteed quota.
+					Usage: FlavorResourceQuantities{
+						"default": {corev1.ResourceCPU: 5_000},
+					},
+				}
+				return Snapshot{
+					ClusterQueues: map[string]*ClusterQueue{
+						"a": {
+							Name:   "a",
+							Cohort: cohort,
+							ResourceGroups: []ResourceGroup{{
+								CoveredResources: sets.New(corev1.ResourceCPU),
+								Flavors: []FlavorQuotas{{
+									Name: "default",
+									Resources: map[corev1.ResourceName]*ResourceQuota{
+										corev1.ResourceCPU: {Nominal: 10_000, LendingLimit: pointer.Int64(4_000)},
+									},
+								}},
+								LabelKeys: sets.New[string](),
+							}},
+							Usage: FlavorResourceQuantities{
+								"default": {corev1.ResourceCPU: 8_000},
+							},
+							Workloads: map[string]*workload.Info{
+								"/alpha": workload.NewInfo(utiltesting.MakeWorkload("alpha", "").
+									Request(corev1.ResourceCPU, "8").
+									Admit(utiltesting.MakeAdmission("a").Assignment(corev1.ResourceCPU, "default", "8000m").Obj()).
+									Obj()),
+							},
+							Preemption:        defaultPreemption,
+							FairWeight:        defaultFairWeight,
+							FlavorFungibility: defaultFlavorFungibility,
+							NamespaceSelector: labels.Everything(),
+							Status:            active,
+						},
+						"b": {
+							Name:   "b",
+							Cohort: cohort,
+							ResourceGroups: []ResourceGroup{{
+								CoveredResources: sets.New(corev1.ResourceCPU),
+								Flavors: []FlavorQuotas{{
+									Name: "default",
+									Resources: map[corev1.ResourceName]*ResourceQuota{
+										corev1.ResourceCPU: {Nominal: 10_000},
+									},
+								}},
+								LabelKeys: sets.New[string](),
+							}},
+							Usage: FlavorResourceQuantities{
+								"default": {corev1.ResourceCPU: 3_000},
+							},
+							Workloads: map[string]*workload.Info{
+								"/beta": workload.NewInfo(utiltesting.MakeWorkload("beta", "").
+									Request(corev1.ResourceCPU, "3").
+									Admit(utiltesting.MakeAdmission("b").Assignment(corev1.ResourceCPU, "default", "3000m").Obj()).
+									Obj()),
+							},
+							Preemption:        defaultPreemption,
+							FairWeight:        defaultFairWeight,
+							FlavorFungibility: defaultFlavorFungibility,
+							NamespaceSelector: labels.Everything(),
+							Status:            active,
+						},
+					},
+					ResourceFlavors: map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+						"default": utiltesting.MakeResourceFlavor("default").Obj(),
+					},
+				}
+			}(),
+		},
 		"clusterQueues with preemption": {
 			cqs: []*kueue.ClusterQueue{
 				utiltesting.MakeClusterQueue("with-preemption").
@@ -349,6 +646,8 @@ func TestSnapshot(t *testing.T) {
 							ReclaimWithinCohort: kueue.PreemptionPolicyAny,
 							WithinClusterQueue:  kueue.PreemptionPolicyLowerPriority,
 						},
+						FairWeight:        defaultFairWeight,
+						FlavorFungibility: defaultFlavorFungibility,
 					},
 				},
 			},
@@ -358,6 +657,11 @@ func TestSnapshot(t *testing.T) {
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
 			cache := New(utiltesting.NewFakeClient())
+			for _, cohort := range tc.cohorts {
+				if err := cache.AddOrUpdateCohort(cohort); err != nil {
+					t.Fatalf("Failed adding Cohort: %v", err)
+				}
+			}
 			for _, cq := range tc.cqs {
 				// Purposely do not make a copy of clusterQueues. Clones of necessary fields are
 				// done in AddClusterQueue.
@@ -594,7 +898,7 @@ func TestSnapshotAddRemoveWorkload(t *testing.T) {
 		},
 	}
 	cmpOpts := append(snapCmpOpts,
-		cmpopts.IgnoreFields(ClusterQueue{}, "NamespaceSelector", "Preemption", "Status"),
+		cmpopts.IgnoreFields(ClusterQueue{}, "NamespaceSelector", "Preemption", "FlavorFungibility", "FairWeight", "Status"),
 		cmpopts.IgnoreFields(Snapshot{}, "ResourceFlavors"),
 		cmpopts.IgnoreTypes(&workload.Info{}))
 	for name, tc := range cases {
@@ -612,3 +916,239 @@ func TestSnapshotAddRemoveWorkload(t *testing.T) {
 		})
 	}
 }
+
+func TestSnapshotAddRemoveWorkloadWithLendingLimit(t *testing.T) {
+	flavors := []*kueue.ResourceFlavor{
+		utiltesting.MakeResourceFlavor("default").Obj(),
+	}
+	clusterQueues := []*kueue.ClusterQueue{
+		utiltesting.MakeClusterQueue("lend-a").
+			Cohort("lend").
+			ResourceGroup(
+				*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "10", "", "4").Obj(),
+			).
+			Obj(),
+		utiltesting.MakeClusterQueue("lend-b").
+			Cohort("lend").
+			ResourceGroup(
+				*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "10", "", "6").Obj(),
+			).
+			Obj(),
+	}
+	workloads := []kueue.Workload{
+		*utiltesting.MakeWorkload("lend-a-1", "").
+			Request(corev1.ResourceCPU, "1").
+			Admit(utiltesting.MakeAdmission("lend-a").Assignment(corev1.ResourceCPU, "default", "1").Obj()).
+			Obj(),
+		*utiltesting.MakeWorkload("lend-a-2", "").
+			Request(corev1.ResourceCPU, "9").
+			Admit(utiltesting.MakeAdmission("lend-a").Assignment(corev1.ResourceCPU, "default", "9").Obj()).
+			Obj(),
+		*utiltesting.MakeWorkload("lend-b-1", "").
+			Request(corev1.ResourceCPU, "4").
+			Admit(utiltesting.MakeAdmission("lend-b").Assignment(corev1.ResourceCPU, "default", "4").Obj()).
+			Obj(),
+	}
+
+	ctx := context.Background()
+	cl := utiltesting.NewClientBuilder().WithLists(&kueue.WorkloadList{Items: workloads}).Build()
+
+	cqCache := New(cl)
+	for _, flv := range flavors {
+		cqCache.AddOrUpdateResourceFlavor(flv)
+	}
+	for _, cq := range clusterQueues {
+		if err := cqCache.AddClusterQueue(ctx, cq); err != nil {
+			t.Fatalf("Couldn't add ClusterQueue to cache: %v", err)
+		}
+	}
+	wlInfos := make(map[string]*workload.Info, len(workloads))
+	for _, cq := range cqCache.clusterQueues {
+		for _, wl := range cq.Workloads {
+			wlInfos[workload.Key(wl.Obj)] = wl
+		}
+	}
+	cases := map[string]struct {
+		remove          []string
+		add             []string
+		wantCohortUsage FlavorResourceQuantities
+	}{
+		"no-op": {
+			// lend-a uses 4 above its guaranteed quota, lend-b uses none.
+			wantCohortUsage: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 4_000},
+			},
+		},
+		"remove usage above the guaranteed quota": {
+			remove: []string{"/lend-a-2"},
+			wantCohortUsage: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 0},
+			},
+		},
+		"remove usage within the guaranteed quota": {
+			remove: []string{"/lend-a-1"},
+			wantCohortUsage: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 3_000},
+			},
+		},
+		"remove and add all": {
+			remove: []string{"/lend-a-1", "/lend-a-2", "/lend-b-1"},
+			add:    []string{"/lend-b-1", "/lend-a-2", "/lend-a-1"},
+			wantCohortUsage: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 4_000},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			snap := cqCache.Snapshot()
+			for _, name := range tc.remove {
+				snap.RemoveWorkload(wlInfos[name])
+			}
+			for _, name := range tc.add {
+				snap.AddWorkload(wlInfos[name])
+			}
+			if diff := cmp.Diff(tc.wantCohortUsage, snap.ClusterQueues["lend-a"].Cohort.Usage); diff != "" {
+				t.Errorf("Unexpected cohort usage (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSnapshotCohortQuotaInSubtrees(t *testing.T) {
+	cohorts := []*kueue.Cohort{
+		utiltesting.MakeCohort("department").Obj(),
+		utiltesting.MakeCohort("team-a").
+			Parent("department").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "5").Obj()).
+			Obj(),
+		utiltesting.MakeCohort("team-b").
+			Parent("department").
+			Obj(),
+	}
+	clusterQueues := []*kueue.ClusterQueue{
+		utiltesting.MakeClusterQueue("a").
+			Cohort("team-a").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "0").Obj()).
+			Obj(),
+		utiltesting.MakeClusterQueue("b").
+			Cohort("team-b").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "0").Obj()).
+			Obj(),
+		utiltesting.MakeClusterQueue("c").
+			Cohort("team-b").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "4").Obj()).
+			Obj(),
+	}
+	type request struct {
+		cq  string
+		val int64
+	}
+	cases := map[string]struct {
+		workloads    []*kueue.Workload
+		wantCapacity map[string]int64
+		wantLack     map[request]int64
+		wantUsage    map[string]int64
+	}{
+		"no usage": {
+			wantCapacity: map[string]int64{"a": 9_000, "b": 4_000, "c": 4_000},
+			wantLack: map[request]int64{
+				{cq: "a", val: 9_000}:  0,
+				{cq: "a", val: 10_000}: 1_000,
+				// The quota of team-a isn't available to team-b.
+				{cq: "b", val: 5_000}: 1_000,
+			},
+			wantUsage: map[string]int64{"department": 0, "team-a": 0, "team-b": 0},
+		},
+		"usage within the quota of team-a": {
+			workloads: []*kueue.Workload{
+				utiltesting.MakeWorkload("wl-a", "").
+					Request(corev1.ResourceCPU, "3").
+					Admit(utiltesting.MakeAdmission("a").Assignment(corev1.ResourceCPU, "default", "3").Obj()).
+					Obj(),
+			},
+			wantCapacity: map[string]int64{"a": 9_000, "b": 4_000, "c": 4_000},
+			wantLack: map[request]int64{
+				{cq: "a", val: 6_000}: 0,
+				{cq: "a", val: 7_000}: 1_000,
+				{cq: "b", val: 4_000}: 0,
+				{cq: "b", val: 5_000}: 1_000,
+			},
+			wantUsage: map[string]int64{"department": 0, "team-a": 3_000, "team-b": 0},
+		},
+		"team-a borrowing from team-b": {
+			workloads: []*kueue.Workload{
+				utiltesting.MakeWorkload("wl-a", "").
+					Request(corev1.ResourceCPU, "7").
+					Admit(utiltesting.MakeAdmission("a").Assignment(corev1.ResourceCPU, "default", "7").Obj()).
+					Obj(),
+			},
+			wantCapacity: map[string]int64{"a": 9_000, "b": 4_000, "c": 4_000},
+			wantLack: map[request]int64{
+				{cq: "a", val: 2_000}: 0,
+				{cq: "a", val: 3_000}: 1_000,
+				{cq: "b", val: 2_000}: 0,
+				{cq: "b", val: 3_000}: 1_000,
+				{cq: "c", val: 3_000}: 1_000,
+			},
+			wantUsage: map[string]int64{"department": 2_000, "team-a": 7_000, "team-b": 0},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cache := New(utiltesting.NewFakeClient())
+			for _, cohort := range cohorts {
+				if err := cache.AddOrUpdateCohort(cohort); err != nil {
+					t.Fatalf("Failed adding Cohort: %v", err)
+				}
+			}
+			for _, cq := range clusterQueues {
+				if err := cache.AddClusterQueue(context.Background(), cq); err != nil {
+					t.Fatalf("Failed adding ClusterQueue: %v", err)
+				}
+			}
+			cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+			for _, wl := range tc.workloads {
+				cache.AddOrUpdateWorkload(wl)
+			}
+			snapshot := cache.Snapshot()
+			gotCapacity := make(map[string]int64)
+			for name, cq := range snapshot.ClusterQueues {
+				gotCapacity[name] = cq.CohortCapacity("default", corev1.ResourceCPU)
+			}
+			if diff := cmp.Diff(tc.wantCapacity, gotCapacity); diff != "" {
+				t.Errorf("Unexpected capacity (-want,+got):\n%s", diff)
+			}
+			gotLack := make(map[request]int64)
+			for req := range tc.wantLack {
+				lack := snapshot.ClusterQueues[req.cq].CohortLack("default", corev1.ResourceCPU, req.val)
+				if lack < 0 {
+					lack = 0
+				}
+				gotLack[req] = lack
+			}
+			if diff := cmp.Diff(tc.wantLack, gotLack, cmp.AllowUnexported(request{})); diff != "" {
+				t.Errorf("Unexpected lack (-want,+got):\n%s", diff)
+			}
+			gotUsage := make(map[string]int64)
+			for cohort := snapshot.ClusterQueues["a"].Cohort; cohort != nil; cohort = cohort.Parent {
+				gotUsage[cohort.Name] = cohort.Usage["default"][corev1.ResourceCPU]
+			}
+			gotUsage["team-b"] = snapshot.ClusterQueues["b"].Cohort.Usage["default"][corev1.ResourceCPU]
+			if diff := cmp.Diff(tc.wantUsage, gotUsage); diff != "" {
+				t.Errorf("Unexpected cohort usage (-want,+got):\n%s", diff)
+			}
+
+			// Removing and adding back the workloads leaves the same usage.
+			for _, cq := range snapshot.ClusterQueues {
+				for _, wl := range cq.Workloads {
+					snapshot.RemoveWorkload(wl)
+					snapshot.AddWorkload(wl)
+				}
+			}
+			if got := snapshot.ClusterQueues["a"].Cohort.Root().Usage["default"][corev1.ResourceCPU]; got != tc.wantUsage["department"] {
+				t.Errorf("Unexpected usage in the root after re-adding the workloads, want %d, got %d", tc.wantUsage["department"], got)
+			}
+		})
+	}
+}
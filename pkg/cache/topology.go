@@ -0,0 +1,498 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/util/limitrange"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+// nodeInfo holds the information of a Node that is needed to compute the
+// capacity of the topology domains.
+type nodeInfo struct {
+	labels      map[string]string
+	allocatable workload.Requests
+}
+
+// newNodeInfo returns the information of the Node, or nil if pods can't be
+// scheduled in the Node.
+func newNodeInfo(node *corev1.Node) *nodeInfo {
+	if node.Spec.Unschedulable {
+		return nil
+	}
+	ready := false
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			ready = cond.Status == corev1.ConditionTrue
+			break
+		}
+	}
+	if !ready {
+		return nil
+	}
+	return &nodeInfo{
+		labels:      node.Labels,
+		allocatable: workload.NewRequests(node.Status.Allocatable),
+	}
+}
+
+// AddOrUpdateNode records the capacity of the Node for topology-aware
+// admission. It returns whether the capacity of any topology domain might
+// have changed.
+func (c *Cache) AddOrUpdateNode(node *corev1.Node) bool {
+	c.Lock()
+	defer c.Unlock()
+	info := newNodeInfo(node)
+	oldInfo, found := c.nodes[node.Name]
+	if info == nil {
+		delete(c.nodes, node.Name)
+		return found
+	}
+	c.nodes[node.Name] = info
+	return !found || !equality.Semantic.DeepEqual(oldInfo.labels, info.labels) ||
+		!equality.Semantic.DeepEqual(oldInfo.allocatable, info.allocatable)
+}
+
+// DeleteNode removes the capacity of the Node from the topology domains.
+func (c *Cache) DeleteNode(node *corev1.Node) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.nodes, node.Name)
+}
+
+// podInfo holds the information of a Pod bound to a Node that is needed to
+// compute the unused capacity of the topology domains and the capacity of the
+// ResourceFlavors.
+type podInfo struct {
+	nodeName string
+	// owners are the UIDs of the Pod and its chain of controllers, used to
+	// find out whether the Pod belongs to an admitted workload.
+	owners   []types.UID
+	requests workload.Requests
+}
+
+// newPodInfo returns the information of the Pod, or nil if the Pod doesn't
+// take resources from a Node. indirectControllers are the UIDs of the
+// controllers up the chain of the Pod's controller.
+func newPodInfo(pod *corev1.Pod, indirectControllers []types.UID) *podInfo {
+	if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return nil
+	}
+	info := &podInfo{
+		nodeName: pod.Spec.NodeName,
+		owners:   []types.UID{pod.UID},
+		requests: workload.NewRequests(limitrange.TotalRequests(&pod.Spec)),
+	}
+	info.requests[corev1.ResourcePods] = 1
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			info.owners = append(info.owners, ref.UID)
+		}
+	}
+	info.owners = append(info.owners, indirectControllers...)
+	return info
+}
+
+// AddOrUpdatePod records the resources that the Pod takes from its Node.
+// indirectControllers are the UIDs of the controllers up the chain of the
+// Pod's controller, so that the Pods that a job creates through other objects
+// are matched to its workload. It returns whether the capacity of the
+// topology domains or the ResourceFlavors might have changed.
+func (c *Cache) AddOrUpdatePod(pod *corev1.Pod, indirectControllers []types.UID) bool {
+	c.Lock()
+	defer c.Unlock()
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}.String()
+	info := newPodInfo(pod, indirectControllers)
+	oldInfo, found := c.pods[key]
+	if info == nil {
+		delete(c.pods, key)
+		return found
+	}
+	c.pods[key] = info
+	return !found || oldInfo.nodeName != info.nodeName || !equality.Semantic.DeepEqual(oldInfo.owners, info.owners) ||
+		!equality.Semantic.DeepEqual(oldInfo.requests, info.requests)
+}
+
+// DeletePod releases the resources that the Pod takes from its Node. It
+// returns whether the capacity of the topology domains or the ResourceFlavors
+// might have changed.
+func (c *Cache) DeletePod(pod *corev1.Pod) bool {
+	c.Lock()
+	defer c.Unlock()
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}.String()
+	_, found := c.pods[key]
+	delete(c.pods, key)
+	return found
+}
+
+// admittedWorkloadOwners returns the UIDs of the owners of the admitted
+// workloads.
+func (c *Cache) admittedWorkloadOwners() sets.Set[types.UID] {
+	owners := sets.New[types.UID]()
+	for _, cq := range c.clusterQueues {
+		for _, wl := range cq.Workloads {
+			for _, ref := range wl.Obj.OwnerReferences {
+				owners.Insert(ref.UID)
+			}
+		}
+	}
+	return owners
+}
+
+// ClusterQueuesUsingTopology returns the ClusterQueues that use at least one
+// ResourceFlavor with topology levels.
+func (c *Cache) ClusterQueuesUsingTopology() sets.Set[string] {
+	c.RLock()
+	defer c.RUnlock()
+	cqs := sets.New[string]()
+	for name, rf := range c.resourceFlavors {
+		if len(rf.Spec.TopologyLevels) == 0 {
+			continue
+		}
+		for _, cq := range c.clusterQueues {
+			if cq.flavorInUse(string(name)) {
+				cqs.Insert(cq.Name)
+			}
+		}
+	}
+	return cqs
+}
+
+// TopologyRequests returns the resources that the pods of the pod set take
+// from the Nodes of a topology domain.
+func TopologyRequests(psr *workload.PodSetResources) workload.Requests {
+	requests := make(workload.Requests, len(psr.Requests))
+	for name, v := range psr.Requests {
+		if name != kueue.ResourceWorkloads {
+			requests[name] = v
+		}
+	}
+	requests[corev1.ResourcePods] = int64(psr.Count)
+	return requests
+}
+
+// TopologyDomainUsage is the usage of topology domains, keyed by the values
+// of the levels down to the level of the domain.
+type TopologyDomainUsage map[string]workload.Requests
+
+// Add adds the requests to the usage of the domain and its ancestors.
+func (u TopologyDomainUsage) Add(values []string, requests workload.Requests) {
+	for i := range values {
+		key := domainKey(values[:i+1])
+		used := u[key]
+		if used == nil {
+			used = make(workload.Requests, len(requests))
+			u[key] = used
+		}
+		for name, v := range requests {
+			used[name] += v
+		}
+	}
+}
+
+// FlavorTopology holds the capacity of the topology domains of a
+// ResourceFlavor that is not used by admitted workloads, computed from the
+// Nodes that match the nodeLabels of the ResourceFlavor.
+type FlavorTopology struct {
+	Levels []string
+
+	// free is the unused capacity of each domain, keyed by the values of the
+	// levels down to the level of the domain.
+	free map[string]workload.Requests
+	// nodeFree is the capacity of each Node that is not used by the Pods
+	// bound to it, keyed by the values of all the levels of its domain.
+	nodeFree map[string][]workload.Requests
+	// domains has, for each level, the values that identify its domains,
+	// sorted by key.
+	domains [][][]string
+}
+
+func domainKey(values []string) string {
+	// Label values can't contain commas.
+	return strings.Join(values, ",")
+}
+
+// newFlavorTopology returns the topology of the ResourceFlavor. The capacity
+// of the domains has the requests of the Pods that don't belong to admitted
+// workloads subtracted, as the usage of the admitted workloads is accounted
+// for by their topology assignment. The capacity of each Node has the
+// requests of all its Pods subtracted.
+func newFlavorTopology(rf *kueue.ResourceFlavor, nodes map[string]*nodeInfo, usage *nodesUsage) *FlavorTopology {
+	t := &FlavorTopology{
+		Levels:   make([]string, len(rf.Spec.TopologyLevels)),
+		free:     make(map[string]workload.Requests),
+		nodeFree: make(map[string][]workload.Requests),
+		domains:  make([][][]string, len(rf.Spec.TopologyLevels)),
+	}
+	for i, level := range rf.Spec.TopologyLevels {
+		t.Levels[i] = level.NodeLabel
+	}
+	selector := labels.SelectorFromSet(rf.Spec.NodeLabels)
+	for nodeName, node := range nodes {
+		if !selector.Matches(labels.Set(node.labels)) {
+			continue
+		}
+		values := make([]string, 0, len(t.Levels))
+		for _, level := range t.Levels {
+			v, found := node.labels[level]
+			if !found {
+				break
+			}
+			values = append(values, v)
+		}
+		if len(values) < len(t.Levels) {
+			// The Node is not part of any domain of the lowest level.
+			continue
+		}
+		for i := range values {
+			key := domainKey(values[:i+1])
+			free := t.free[key]
+			if free == nil {
+				free = make(workload.Requests, len(node.allocatable))
+				t.free[key] = free
+				t.domains[i] = append(t.domains[i], values[:i+1])
+			}
+			for name, v := range node.allocatable {
+				free[name] += v - usage.nonAdmitted[nodeName][name]
+			}
+		}
+		nodeFree := make(workload.Requests, len(node.allocatable))
+		for name, v := range node.allocatable {
+			nodeFree[name] = v - usage.all[nodeName][name]
+		}
+		key := domainKey(values)
+		t.nodeFree[key] = append(t.nodeFree[key], nodeFree)
+	}
+	for _, domains := range t.domains {
+		sort.Slice(domains, func(i, j int) bool {
+			return domainKey(domains[i]) < domainKey(domains[j])
+		})
+	}
+	return t
+}
+
+// LevelIndex returns the index of the level with the node label, or -1 if
+// the topology doesn't have such a level.
+func (t *FlavorTopology) LevelIndex(nodeLabel string) int {
+	for i, l := range t.Levels {
+		if l == nodeLabel {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindDomain returns the values that identify the first domain, in
+// alphabetical order, of the level with the node label that has enough
+// unused capacity for the requests, in addition to the given usage.
+func (t *FlavorTopology) FindDomain(nodeLabel string, requests workload.Requests, usage TopologyDomainUsage) ([]string, bool) {
+	idx := t.LevelIndex(nodeLabel)
+	if idx < 0 {
+		return nil, false
+	}
+	for _, values := range t.domains[idx] {
+		if t.Fits(values, requests, usage) {
+			return values, true
+		}
+	}
+	return nil, false
+}
+
+// Fits returns whether the domain and its ancestors have enough unused
+// capacity for the requests, in addition to the given usage, and whether the
+// Nodes of the domain can host all the pods. The requests are the total
+// requests of the pods, as returned by TopologyRequests.
+func (t *FlavorTopology) Fits(values []string, requests workload.Requests, usage TopologyDomainUsage) bool {
+	for i := range values {
+		key := domainKey(values[:i+1])
+		free, found := t.free[key]
+		if !found {
+			return false
+		}
+		for name, v := range requests {
+			if v > free[name]-usage[key][name] {
+				return false
+			}
+		}
+	}
+	return t.podsFit(values, requests)
+}
+
+// podsFit returns whether the Nodes of the domain have room for all the pods
+// of the requests, when each pod has to fit in a single Node. Each Node hosts
+// as many pods as fit in its unused capacity.
+// The usage of the domains assigned in the same scheduling cycle, and of the
+// admitted workloads whose Pods aren't bound yet, is only accounted for in
+// the capacity of the domains.
+func (t *FlavorTopology) podsFit(values []string, requests workload.Requests) bool {
+	count := requests[corev1.ResourcePods]
+	if count <= 0 {
+		return true
+	}
+	perPod := make(workload.Requests, len(requests))
+	for name, v := range requests {
+		if v > 0 {
+			perPod[name] = v / count
+		}
+	}
+	prefix := domainKey(values)
+	var capacity int64
+	for key, nodes := range t.nodeFree {
+		if key != prefix && !strings.HasPrefix(key, prefix+",") {
+			continue
+		}
+		for _, free := range nodes {
+			capacity += podCapacity(free, perPod)
+			if capacity >= count {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// podCapacity returns how many pods with the requests fit in the unused
+// capacity of a Node.
+func podCapacity(free, perPod workload.Requests) int64 {
+	capacity := int64(-1)
+	for name, v := range perPod {
+		if v <= 0 {
+			continue
+		}
+		n := free[name] / v
+		if n <= 0 {
+			return 0
+		}
+		if capacity < 0 || n < capacity {
+			capacity = n
+		}
+	}
+	return capacity
+}
+
+// AddUsage reduces the unused capacity of the domain and its ancestors by
+// the requests.
+func (t *FlavorTopology) AddUsage(values []string, requests workload.Requests) {
+	for i := range values {
+		free := t.free[domainKey(values[:i+1])]
+		if free == nil {
+			continue
+		}
+		for name, v := range requests {
+			free[name] -= v
+		}
+	}
+}
+
+// hasLevels returns whether the levels are the highest levels of the
+// topology.
+func (t *FlavorTopology) hasLevels(levels []string) bool {
+	if len(levels) == 0 || len(levels) > len(t.Levels) {
+		return false
+	}
+	for i := range levels {
+		if levels[i] != t.Levels[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// snapshotTopologies returns the topologies of the ResourceFlavors with
+// topology levels, with the capacity used by the admitted workloads
+// subtracted.
+func (c *Cache) snapshotTopologies() map[kueue.ResourceFlavorReference]*FlavorTopology {
+	topologies := make(map[kueue.ResourceFlavorReference]*FlavorTopology)
+	var usage *nodesUsage
+	for name, rf := range c.resourceFlavors {
+		if len(rf.Spec.TopologyLevels) > 0 {
+			if usage == nil {
+				usage = c.nodesUsage()
+			}
+			topologies[name] = newFlavorTopology(rf, c.nodes, usage)
+		}
+	}
+	if len(topologies) == 0 {
+		return nil
+	}
+	for _, cq := range c.clusterQueues {
+		for _, wl := range cq.Workloads {
+			admission := wl.Obj.Status.Admission
+			if admission == nil {
+				continue
+			}
+			for i := range wl.TotalRequests {
+				psr := &wl.TotalRequests[i]
+				if i >= len(admission.PodSetAssignments) || admission.PodSetAssignments[i].TopologyAssignment == nil {
+					continue
+				}
+				ta := admission.PodSetAssignments[i].TopologyAssignment
+				for _, fName := range psr.Flavors {
+					if t := topologies[fName]; t != nil && t.hasLevels(ta.Levels) {
+						t.AddUsage(ta.Values, TopologyRequests(psr))
+						break
+					}
+				}
+			}
+		}
+	}
+	return topologies
+}
+
+// nodesUsage holds the requests of the Pods bound to each Node.
+type nodesUsage struct {
+	// all are the requests of all the Pods.
+	all map[string]workload.Requests
+	// nonAdmitted are the requests of the Pods that don't belong to admitted
+	// workloads.
+	nonAdmitted map[string]workload.Requests
+}
+
+func (c *Cache) nodesUsage() *nodesUsage {
+	usage := &nodesUsage{
+		all:         make(map[string]workload.Requests),
+		nonAdmitted: make(map[string]workload.Requests),
+	}
+	admittedOwners := c.admittedWorkloadOwners()
+	add := func(perNode map[string]workload.Requests, pod *podInfo) {
+		used := perNode[pod.nodeName]
+		if used == nil {
+			used = make(workload.Requests, len(pod.requests))
+			perNode[pod.nodeName] = used
+		}
+		for name, v := range pod.requests {
+			used[name] += v
+		}
+	}
+	for _, pod := range c.pods {
+		add(usage.all, pod)
+		if !admittedOwners.HasAny(pod.owners...) {
+			add(usage.nonAdmitted, pod)
+		}
+	}
+	return usage
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+	testingpod "sigs.k8s.io/kueue/pkg/util/testingjobs/pod"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+func TestSnapshotTopologies(t *testing.T) {
+	rack := func(block, rack string, cpu string) *utiltesting.NodeWrapper {
+		return utiltesting.MakeNode(block+"-"+rack).
+			Label("type", "tas").
+			Label("block", block).
+			Label("rack", rack).
+			Allocatable(corev1.ResourceCPU, cpu).
+			Allocatable(corev1.ResourcePods, "10")
+	}
+	cases := map[string]struct {
+		nodes   []*corev1.Node
+		pods    []*corev1.Pod
+		wls     []*kueue.Workload
+		wantNil bool
+		// wantFree is the unused capacity of each domain of the "tas" flavor.
+		wantFree map[string]workload.Requests
+	}{
+		"no nodes": {
+			wantFree: map[string]workload.Requests{},
+		},
+		"capacity of ready and schedulable nodes": {
+			nodes: []*corev1.Node{
+				rack("b1", "r1", "2").Obj(),
+				rack("b1", "r2", "4").Obj(),
+				rack("b2", "r1", "8").Unschedulable().Obj(),
+				utiltesting.MakeNode("other-flavor").
+					Label("block", "b1").Label("rack", "r1").
+					Allocatable(corev1.ResourceCPU, "8").Obj(),
+				utiltesting.MakeNode("without-rack").
+					Label("type", "tas").Label("block", "b1").
+					Allocatable(corev1.ResourceCPU, "8").Obj(),
+			},
+			wantFree: map[string]workload.Requests{
+				"b1":    {corev1.ResourceCPU: 6_000, corev1.ResourcePods: 20},
+				"b1,r1": {corev1.ResourceCPU: 2_000, corev1.ResourcePods: 10},
+				"b1,r2": {corev1.ResourceCPU: 4_000, corev1.ResourcePods: 10},
+			},
+		},
+		"usage of admitted workloads is subtracted": {
+			nodes: []*corev1.Node{
+				rack("b1", "r1", "2").Obj(),
+				rack("b1", "r2", "4").Obj(),
+			},
+			wls: []*kueue.Workload{
+				utiltesting.MakeWorkload("in-rack", "").
+					PodSets(*utiltesting.MakePodSet("main", 3).
+						Request(corev1.ResourceCPU, "1").
+						Obj()).
+					Admit(utiltesting.MakeAdmission("cq").
+						Assignment(corev1.ResourceCPU, "tas", "3").
+						AssignmentPodCount(3).
+						AssignmentTopology([]string{"block", "rack"}, []string{"b1", "r2"}).
+						Obj()).
+					Obj(),
+				utiltesting.MakeWorkload("without-topology", "").
+					PodSets(*utiltesting.MakePodSet("main", 1).
+						Request(corev1.ResourceCPU, "1").
+						Obj()).
+					Admit(utiltesting.MakeAdmission("cq").
+						Assignment(corev1.ResourceCPU, "tas", "1").
+						AssignmentPodCount(1).
+						Obj()).
+					Obj(),
+			},
+			wantFree: map[string]workload.Requests{
+				"b1":    {corev1.ResourceCPU: 3_000, corev1.ResourcePods: 17},
+				"b1,r1": {corev1.ResourceCPU: 2_000, corev1.ResourcePods: 10},
+				"b1,r2": {corev1.ResourceCPU: 1_000, corev1.ResourcePods: 7},
+			},
+		},
+		"usage of pods not admitted by kueue is subtracted": {
+			nodes: []*corev1.Node{
+				rack("b1", "r1", "2").Obj(),
+				rack("b1", "r2", "4").Obj(),
+			},
+			pods: []*corev1.Pod{
+				testingpod.MakePod("system", "kube-system").
+					NodeName("b1-r1").
+					Request(corev1.ResourceCPU, "500m").
+					Obj(),
+				testingpod.MakePod("finished", "ns").
+					NodeName("b1-r2").
+					Request(corev1.ResourceCPU, "1").
+					StatusPhase(corev1.PodSucceeded).
+					Obj(),
+				testingpod.MakePod("in-rack", "ns").
+					NodeName("b1-r2").
+					Request(corev1.ResourceCPU, "1").
+					OwnerReference("in-rack-job", batchv1.SchemeGroupVersion.WithKind("Job"), true).
+					Obj(),
+			},
+			wls: []*kueue.Workload{
+				utiltesting.MakeWorkload("in-rack", "").
+					ControllerReference(batchv1.SchemeGroupVersion.WithKind("Job"), "in-rack-job", "in-rack-job").
+					PodSets(*utiltesting.MakePodSet("main", 1).
+						Request(corev1.ResourceCPU, "1").
+						Obj()).
+					Admit(utiltesting.MakeAdmission("cq").
+						Assignment(corev1.ResourceCPU, "tas", "1").
+						AssignmentPodCount(1).
+						AssignmentTopology([]string{"block", "rack"}, []string{"b1", "r2"}).
+						Obj()).
+					Obj(),
+			},
+			wantFree: map[string]workload.Requests{
+				"b1":    {corev1.ResourceCPU: 4_500, corev1.ResourcePods: 18},
+				"b1,r1": {corev1.ResourceCPU: 1_500, corev1.ResourcePods: 9},
+				"b1,r2": {corev1.ResourceCPU: 3_000, corev1.ResourcePods: 9},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cache := New(utiltesting.NewFakeClient())
+			cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("tas").
+				Label("type", "tas").
+				TopologyLevels("block", "rack").
+				Obj())
+			cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+			cq := utiltesting.MakeClusterQueue("cq").
+				ResourceGroup(*utiltesting.MakeFlavorQuotas("tas").Resource(corev1.ResourceCPU, "10").Obj()).
+				Obj()
+			if err := cache.AddClusterQueue(context.Background(), cq); err != nil {
+				t.Fatalf("Failed adding ClusterQueue: %v", err)
+			}
+			for _, node := range tc.nodes {
+				cache.AddOrUpdateNode(node)
+			}
+			for _, pod := range tc.pods {
+				cache.AddOrUpdatePod(pod, nil)
+			}
+			for _, wl := range tc.wls {
+				cache.AddOrUpdateWorkload(wl)
+			}
+			snapshot := cache.Snapshot()
+			if _, found := snapshot.Topologies["default"]; found {
+				t.Errorf("Unexpected topology for a flavor without topology levels")
+			}
+			topology := snapshot.Topologies["tas"]
+			if topology == nil {
+				t.Fatalf("Missing topology for flavor tas")
+			}
+			if diff := cmp.Diff([]string{"block", "rack"}, topology.Levels); diff != "" {
+				t.Errorf("Unexpected levels (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantFree, topology.free); diff != "" {
+				t.Errorf("Unexpected free capacity (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTopologyFits(t *testing.T) {
+	node := func(name, block, rack, cpu string) *corev1.Node {
+		return utiltesting.MakeNode(name).
+			Label("type", "tas").
+			Label("block", block).
+			Label("rack", rack).
+			Allocatable(corev1.ResourceCPU, cpu).
+			Allocatable(corev1.ResourcePods, "10").
+			Obj()
+	}
+	cache := New(utiltesting.NewFakeClient())
+	cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("tas").
+		Label("type", "tas").
+		TopologyLevels("block", "rack").
+		Obj())
+	cache.AddOrUpdateNode(node("n1", "b1", "r1", "3"))
+	cache.AddOrUpdateNode(node("n2", "b1", "r1", "3"))
+	cache.AddOrUpdateNode(node("n3", "b1", "r2", "8"))
+	cache.AddOrUpdatePod(testingpod.MakePod("system", "kube-system").
+		NodeName("n3").
+		Request(corev1.ResourceCPU, "1").
+		Obj(), nil)
+	topology := cache.Snapshot().Topologies["tas"]
+
+	cases := map[string]struct {
+		values []string
+		count  int32
+		// milliCPU is the cpu request of each pod.
+		milliCPU int64
+		want     bool
+	}{
+		"pods fit in the nodes of the rack": {
+			values:   []string{"b1", "r1"},
+			count:    2,
+			milliCPU: 1_500,
+			want:     true,
+		},
+		"pods don't fit in a single node, despite the capacity of the rack": {
+			values:   []string{"b1", "r1"},
+			count:    3,
+			milliCPU: 2_000,
+		},
+		"usage of pods not admitted by kueue is subtracted": {
+			values:   []string{"b1", "r2"},
+			count:    4,
+			milliCPU: 2_000,
+		},
+		"pods fit in the nodes of the block": {
+			values:   []string{"b1"},
+			count:    5,
+			milliCPU: 2_000,
+			want:     true,
+		},
+		"pods don't fit in the nodes of the block": {
+			values:   []string{"b1"},
+			count:    6,
+			milliCPU: 2_000,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			psr := workload.PodSetResources{
+				Count:    tc.count,
+				Requests: workload.Requests{corev1.ResourceCPU: tc.milliCPU * int64(tc.count)},
+			}
+			if got := topology.Fits(tc.values, TopologyRequests(&psr), nil); got != tc.want {
+				t.Errorf("Fits() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddOrUpdateNode(t *testing.T) {
+	cache := New(utiltesting.NewFakeClient())
+	node := utiltesting.MakeNode("n1").Label("rack", "r1").Allocatable(corev1.ResourceCPU, "2")
+	if !cache.AddOrUpdateNode(node.Obj()) {
+		t.Errorf("Adding a new node should report a change")
+	}
+	if cache.AddOrUpdateNode(node.Obj()) {
+		t.Errorf("Updating a node without changes should not report a change")
+	}
+	if !cache.AddOrUpdateNode(node.Allocatable(corev1.ResourceCPU, "4").Obj()) {
+		t.Errorf("Updating the allocatable of a node should report a change")
+	}
+	if !cache.AddOrUpdateNode(node.Unschedulable().Obj()) {
+		t.Errorf("Cordoning a node should report a change")
+	}
+	if cache.AddOrUpdateNode(node.Obj()) {
+		t.Errorf("Updating a cordoned node should not report a change")
+	}
+}
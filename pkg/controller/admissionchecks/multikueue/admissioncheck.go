@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multikueue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+)
+
+// inactiveRetryDelay is the time after which an inactive AdmissionCheck is
+// reconciled again, to retry connecting to its worker clusters.
+const inactiveRetryDelay = time.Minute
+
+// ACReconciler maintains the Active condition of the MultiKueue
+// AdmissionChecks. A check is active when all the worker clusters of its
+// MultiKueueConfig are reachable.
+type ACReconciler struct {
+	client  client.Client
+	clients *clientsManager
+}
+
+func newACReconciler(c client.Client, clients *clientsManager) *ACReconciler {
+	return &ACReconciler{
+		client:  c,
+		clients: clients,
+	}
+}
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=admissionchecks,verbs=get;list;watch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=admissionchecks/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=multikueueconfigs,verbs=get;list;watch
+
+func (a *ACReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var ac kueue.AdmissionCheck
+	if err := a.client.Get(ctx, req.NamespacedName, &ac); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if ac.Spec.ControllerName != kueue.MultiKueueControllerName {
+		return ctrl.Result{}, nil
+	}
+	log := ctrl.LoggerFrom(ctx).WithValues("admissionCheck", klog.KObj(&ac))
+	ctx = ctrl.LoggerInto(ctx, log)
+	log.V(2).Info("Reconciling AdmissionCheck")
+
+	newCondition := metav1.Condition{
+		Type:    kueue.AdmissionCheckActive,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Active",
+		Message: "Connected to the worker clusters",
+	}
+	var result ctrl.Result
+	clients, err := a.clients.clientsForAdmissionCheck(ctx, &ac)
+	if err != nil {
+		result.RequeueAfter = inactiveRetryDelay
+		log.V(2).Info("Some worker clusters are not reachable", "error", err.Error())
+		newCondition.Status = metav1.ConditionFalse
+		newCondition.Reason = "Inactive"
+		newCondition.Message = fmt.Sprintf("Connected to %d worker clusters: %v", len(clients), err)
+	}
+	oldCondition := apimeta.FindStatusCondition(ac.Status.Conditions, kueue.AdmissionCheckActive)
+	if oldCondition != nil && oldCondition.Status == newCondition.Status && oldCondition.Reason == newCondition.Reason && oldCondition.Message == newCondition.Message {
+		return result, nil
+	}
+	apimeta.SetStatusCondition(&ac.Status.Conditions, newCondition)
+	return result, client.IgnoreNotFound(a.client.Status().Update(ctx, &ac))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (a *ACReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("multikueue-admissioncheck").
+		For(&kueue.AdmissionCheck{}).
+		Watches(&source.Kind{Type: &kueue.MultiKueueConfig{}}, &acConfigHandler{client: a.client}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, &acSecretHandler{client: a.client, namespace: a.clients.namespace}).
+		Complete(a)
+}
+
+// acConfigHandler queues the reconciliation of the AdmissionChecks that
+// reference a MultiKueueConfig when it changes.
+type acConfigHandler struct {
+	client client.Client
+}
+
+func (h *acConfigHandler) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.queue(e.Object, q)
+}
+
+func (h *acConfigHandler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	h.queue(e.ObjectNew, q)
+}
+
+func (h *acConfigHandler) Delete(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.queue(e.Object, q)
+}
+
+func (h *acConfigHandler) Generic(event.GenericEvent, workqueue.RateLimitingInterface) {
+}
+
+func (h *acConfigHandler) queue(obj client.Object, q workqueue.RateLimitingInterface) {
+	queueMultiKueueAdmissionChecks(h.client, q, func(ac *kueue.AdmissionCheck) bool {
+		return ac.Spec.Parameters != nil && ac.Spec.Parameters.Kind == "MultiKueueConfig" && ac.Spec.Parameters.Name == obj.GetName()
+	})
+}
+
+// acSecretHandler queues the reconciliation of the MultiKueue
+// AdmissionChecks when a Secret in the namespace of Kueue changes, as it
+// could hold the kubeconfig of a worker cluster.
+type acSecretHandler struct {
+	client    client.Client
+	namespace string
+}
+
+func (h *acSecretHandler) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.queue(e.Object, q)
+}
+
+func (h *acSecretHandler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	h.queue(e.ObjectNew, q)
+}
+
+func (h *acSecretHandler) Delete(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.queue(e.Object, q)
+}
+
+func (h *acSecretHandler) Generic(event.GenericEvent, workqueue.RateLimitingInterface) {
+}
+
+func (h *acSecretHandler) queue(obj client.Object, q workqueue.RateLimitingInterface) {
+	if obj.GetNamespace() != h.namespace {
+		return
+	}
+	queueMultiKueueAdmissionChecks(h.client, q, func(*kueue.AdmissionCheck) bool { return true })
+}
+
+func queueMultiKueueAdmissionChecks(c client.Client, q workqueue.RateLimitingInterface, filter func(*kueue.AdmissionCheck) bool) {
+	//TODO: the eventHandler should get a context soon, and this could be dropped
+	// https://github.com/kubernetes-sigs/controller-runtime/blob/master/pkg/handler/eventhandler.go
+	ctx := context.TODO()
+	var lst kueue.AdmissionCheckList
+	if err := c.List(ctx, &lst); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Could not list AdmissionChecks")
+		return
+	}
+	for i := range lst.Items {
+		ac := &lst.Items[i]
+		if ac.Spec.ControllerName == kueue.MultiKueueControllerName && filter(ac) {
+			q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Name: ac.Name}})
+		}
+	}
+}
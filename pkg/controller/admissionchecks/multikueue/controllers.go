@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package multikueue implements the controller of the AdmissionChecks with
+// the kueue.x-k8s.io/multikueue controllerName. The Workloads that reserve
+// quota in a ClusterQueue with such a check are copied, together with their
+// jobs, to the worker clusters listed in the MultiKueueConfig referenced by
+// the check. The jobs run in the first worker cluster that reserves quota
+// for them, while the original jobs are kept suspended and get their status
+// from the remote ones.
+package multikueue
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// OriginLabel is the label set on the objects created in the worker
+	// clusters.
+	OriginLabel = "kueue.x-k8s.io/multikueue-origin"
+
+	// originValue is the value of OriginLabel.
+	originValue = "multikueue"
+
+	// kubeconfigKey is the key of the kubeconfig in the Secrets referenced
+	// by the MultiKueueConfigs.
+	kubeconfigKey = "kubeconfig"
+
+	updateChBuffer = 100
+)
+
+// SetupControllers sets up the MultiKueue controllers. The kubeconfigs of
+// the worker clusters are read from Secrets in the given namespace.
+func SetupControllers(mgr ctrl.Manager, namespace string) error {
+	wlUpdateCh := make(chan event.GenericEvent, updateChBuffer)
+	clients := newClientsManager(mgr.GetClient(), mgr.GetScheme(), namespace, wlUpdateCh)
+
+	acRec := newACReconciler(mgr.GetClient(), clients)
+	if err := acRec.SetupWithManager(mgr); err != nil {
+		return err
+	}
+
+	wlRec := newWlReconciler(mgr.GetClient(), clients, wlUpdateCh)
+	return wlRec.SetupWithManager(mgr)
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multikueue
+
+import (
+	"context"
+
+	kubeflow "github.com/kubeflow/mpi-operator/pkg/apis/kubeflow/v2beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+)
+
+// jobAdapter copies a kind of job to the worker clusters, and its status
+// back to the local job.
+type jobAdapter interface {
+	// createRemoteObject creates a copy of the local job in the worker
+	// cluster, which uses the prebuilt Workload with the given name.
+	createRemoteObject(ctx context.Context, localClient, remoteClient client.Client, key types.NamespacedName, workloadName string) error
+	// copyStatusRemoteObject copies the status of the job in the worker
+	// cluster to the local job, if it differs.
+	copyStatusRemoteObject(ctx context.Context, localClient, remoteClient client.Client, key types.NamespacedName) error
+	// newEmptyObject returns an empty object of the kind of job, used to
+	// delete the copies in the worker clusters.
+	newEmptyObject() client.Object
+}
+
+var adapters = map[schema.GroupKind]jobAdapter{
+	batchv1.SchemeGroupVersion.WithKind("Job").GroupKind(): &batchJobAdapter{},
+	kubeflow.SchemeGroupVersionKind.GroupKind():            &mpiJobAdapter{},
+}
+
+// adapterFor returns the adapter of the kind of the owner, or nil if the
+// kind is not supported.
+func adapterFor(owner *metav1.OwnerReference) jobAdapter {
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return nil
+	}
+	return adapters[gv.WithKind(owner.Kind).GroupKind()]
+}
+
+// remoteObjectMeta returns the metadata of the copy of a local object in a
+// worker cluster.
+func remoteObjectMeta(local *metav1.ObjectMeta, workloadName string) metav1.ObjectMeta {
+	labels := make(map[string]string, len(local.Labels)+2)
+	for k, v := range local.Labels {
+		labels[k] = v
+	}
+	labels[OriginLabel] = originValue
+	if workloadName != "" {
+		labels[jobframework.PrebuiltWorkloadLabel] = workloadName
+	}
+	annotations := make(map[string]string, len(local.Annotations))
+	for k, v := range local.Annotations {
+		if k != jobframework.OriginalNodeSelectorsAnnotation {
+			annotations[k] = v
+		}
+	}
+	return metav1.ObjectMeta{
+		Name:        local.Name,
+		Namespace:   local.Namespace,
+		Labels:      labels,
+		Annotations: annotations,
+	}
+}
+
+// batchJobAdapter copies batch Jobs. The local Job is kept suspended, so the
+// Job controller of the manager cluster keeps syncing its status and might
+// overwrite parts of the copied status, such as the number of active pods,
+// until the next copy. Once the copied status has a Complete or Failed
+// condition, the Job is finished and the Job controller leaves it alone.
+type batchJobAdapter struct{}
+
+var _ jobAdapter = (*batchJobAdapter)(nil)
+
+func (a *batchJobAdapter) createRemoteObject(ctx context.Context, localClient, remoteClient client.Client, key types.NamespacedName, workloadName string) error {
+	localJob := batchv1.Job{}
+	if err := localClient.Get(ctx, key, &localJob); err != nil {
+		return err
+	}
+	remoteJob := batchv1.Job{
+		ObjectMeta: remoteObjectMeta(&localJob.ObjectMeta, workloadName),
+		Spec:       *localJob.Spec.DeepCopy(),
+	}
+	// The selector and the labels that match it are generated by the API
+	// server of the worker cluster.
+	if remoteJob.Spec.ManualSelector == nil || !*remoteJob.Spec.ManualSelector {
+		remoteJob.Spec.Selector = nil
+		for _, label := range []string{"controller-uid", "job-name", "batch.kubernetes.io/controller-uid", "batch.kubernetes.io/job-name"} {
+			delete(remoteJob.Spec.Template.Labels, label)
+		}
+	}
+	return client.IgnoreAlreadyExists(remoteClient.Create(ctx, &remoteJob))
+}
+
+func (a *batchJobAdapter) copyStatusRemoteObject(ctx context.Context, localClient, remoteClient client.Client, key types.NamespacedName) error {
+	localJob := batchv1.Job{}
+	if err := localClient.Get(ctx, key, &localJob); err != nil {
+		return err
+	}
+	remoteJob := batchv1.Job{}
+	if err := remoteClient.Get(ctx, key, &remoteJob); err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(localJob.Status, remoteJob.Status) {
+		return nil
+	}
+	localJob.Status = remoteJob.Status
+	return localClient.Status().Update(ctx, &localJob)
+}
+
+func (a *batchJobAdapter) newEmptyObject() client.Object {
+	return &batchv1.Job{}
+}
+
+// mpiJobAdapter copies MPIJobs. Like for batch Jobs, the MPIJob controller
+// of the manager cluster might overwrite parts of the copied status of the
+// suspended local MPIJob until the next copy.
+type mpiJobAdapter struct{}
+
+var _ jobAdapter = (*mpiJobAdapter)(nil)
+
+func (a *mpiJobAdapter) createRemoteObject(ctx context.Context, localClient, remoteClient client.Client, key types.NamespacedName, workloadName string) error {
+	localJob := kubeflow.MPIJob{}
+	if err := localClient.Get(ctx, key, &localJob); err != nil {
+		return err
+	}
+	remoteJob := kubeflow.MPIJob{
+		ObjectMeta: remoteObjectMeta(&localJob.ObjectMeta, workloadName),
+		Spec:       *localJob.Spec.DeepCopy(),
+	}
+	return client.IgnoreAlreadyExists(remoteClient.Create(ctx, &remoteJob))
+}
+
+func (a *mpiJobAdapter) copyStatusRemoteObject(ctx context.Context, localClient, remoteClient client.Client, key types.NamespacedName) error {
+	localJob := kubeflow.MPIJob{}
+	if err := localClient.Get(ctx, key, &localJob); err != nil {
+		return err
+	}
+	remoteJob := kubeflow.MPIJob{}
+	if err := remoteClient.Get(ctx, key, &remoteJob); err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(localJob.Status, remoteJob.Status) {
+		return nil
+	}
+	localJob.Status = remoteJob.Status
+	return localClient.Status().Update(ctx, &localJob)
+}
+
+func (a *mpiJobAdapter) newEmptyObject() client.Object {
+	return &kubeflow.MPIJob{}
+}
+
+// deleteRemoteObjects deletes the copies of a job and its Workload from a
+// worker cluster. If the adapter is nil, the jobs of all the supported kinds
+// that use the Workload are deleted.
+func deleteRemoteObjects(ctx context.Context, remoteClient client.Client, adapter jobAdapter, namespace, workloadName string) error {
+	jobAdapters := []jobAdapter{adapter}
+	if adapter == nil {
+		jobAdapters = make([]jobAdapter, 0, len(adapters))
+		for _, a := range adapters {
+			jobAdapters = append(jobAdapters, a)
+		}
+	}
+	for _, a := range jobAdapters {
+		err := remoteClient.DeleteAllOf(ctx, a.newEmptyObject(),
+			client.InNamespace(namespace),
+			client.MatchingLabels{OriginLabel: originValue, jobframework.PrebuiltWorkloadLabel: workloadName},
+			client.PropagationPolicy(metav1.DeletePropagationBackground))
+		// The worker cluster might not have the CRD of the kind of job.
+		if err != nil && !apierrors.IsNotFound(err) && !apimeta.IsNoMatchError(err) {
+			return err
+		}
+	}
+	wl := &kueue.Workload{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: workloadName}}
+	return client.IgnoreNotFound(remoteClient.Delete(ctx, wl))
+}
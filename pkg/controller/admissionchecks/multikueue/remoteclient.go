@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multikueue
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+)
+
+// watchRetryDelay is the time to wait before starting a new watch of the
+// Workloads of a worker cluster after the previous one was closed.
+const watchRetryDelay = 5 * time.Second
+
+var errInvalidConfig = errors.New("invalid MultiKueue configuration")
+
+// remoteClient is the client of a worker cluster. It watches the Workloads
+// created by MultiKueue in the worker cluster and queues the reconciliation
+// of the local Workloads with the same name.
+type remoteClient struct {
+	client      client.WithWatch
+	kubeconfig  []byte
+	watchCancel context.CancelFunc
+}
+
+// clientsManager keeps the clients of the worker clusters, indexed by the
+// name of the Secret that holds their kubeconfig.
+type clientsManager struct {
+	sync.Mutex
+	localClient client.Client
+	scheme      *runtime.Scheme
+	namespace   string
+	wlUpdateCh  chan<- event.GenericEvent
+	clients     map[string]*remoteClient
+}
+
+func newClientsManager(localClient client.Client, scheme *runtime.Scheme, namespace string, wlUpdateCh chan<- event.GenericEvent) *clientsManager {
+	return &clientsManager{
+		localClient: localClient,
+		scheme:      scheme,
+		namespace:   namespace,
+		wlUpdateCh:  wlUpdateCh,
+		clients:     make(map[string]*remoteClient),
+	}
+}
+
+// configForAdmissionCheck returns the MultiKueueConfig referenced by the
+// parameters of the admission check.
+func (m *clientsManager) configForAdmissionCheck(ctx context.Context, ac *kueue.AdmissionCheck) (*kueue.MultiKueueConfig, error) {
+	params := ac.Spec.Parameters
+	if params == nil || params.APIGroup != kueue.GroupVersion.Group || params.Kind != "MultiKueueConfig" {
+		return nil, fmt.Errorf("%w: the parameters of the AdmissionCheck %s don't reference a MultiKueueConfig", errInvalidConfig, ac.Name)
+	}
+	cfg := &kueue.MultiKueueConfig{}
+	if err := m.localClient.Get(ctx, types.NamespacedName{Name: params.Name}, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// clientsForAdmissionCheck returns the clients of the worker clusters of the
+// admission check, indexed by cluster name.
+func (m *clientsManager) clientsForAdmissionCheck(ctx context.Context, ac *kueue.AdmissionCheck) (map[string]client.Client, error) {
+	cfg, err := m.configForAdmissionCheck(ctx, ac)
+	if err != nil {
+		return nil, err
+	}
+	clients := make(map[string]client.Client, len(cfg.Spec.Clusters))
+	var errs []error
+	for _, cluster := range cfg.Spec.Clusters {
+		c, err := m.clientFor(ctx, cluster.KubeconfigRef)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cluster %s: %w", cluster.Name, err))
+			continue
+		}
+		clients[cluster.Name] = c
+	}
+	return clients, errors.Join(errs...)
+}
+
+// clientFor returns the client for the kubeconfig, creating it, or updating
+// it if the kubeconfig changed.
+func (m *clientsManager) clientFor(ctx context.Context, ref kueue.KubeconfigRef) (client.Client, error) {
+	if ref.LocationType != kueue.SecretLocationType {
+		return nil, fmt.Errorf("%w: unsupported kubeconfig location type %q", errInvalidConfig, ref.LocationType)
+	}
+	secret := &corev1.Secret{}
+	if err := m.localClient.Get(ctx, types.NamespacedName{Namespace: m.namespace, Name: ref.Location}, secret); err != nil {
+		return nil, err
+	}
+	kubeconfig, found := secret.Data[kubeconfigKey]
+	if !found {
+		return nil, fmt.Errorf("%w: key %q not found in Secret %s", errInvalidConfig, kubeconfigKey, ref.Location)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	rc, found := m.clients[ref.Location]
+	if found && bytes.Equal(rc.kubeconfig, kubeconfig) {
+		return rc.client, nil
+	}
+	if found {
+		rc.watchCancel()
+		delete(m.clients, ref.Location)
+	}
+	rc, err := m.newRemoteClient(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	m.clients[ref.Location] = rc
+	return rc.client, nil
+}
+
+// newRemoteClient creates a client from the kubeconfig and starts watching
+// the Workloads created by MultiKueue in the worker cluster. The watch lasts
+// until ctx is done or the client is replaced.
+func (m *clientsManager) newRemoteClient(ctx context.Context, kubeconfig []byte) (*remoteClient, error) {
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.NewWithWatch(restConfig, client.Options{Scheme: m.scheme})
+	if err != nil {
+		return nil, err
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	w, err := c.Watch(watchCtx, &kueue.WorkloadList{}, client.MatchingLabels{OriginLabel: originValue})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go m.queueWorkloadEvents(watchCtx, c, w)
+	return &remoteClient{
+		client:      c,
+		kubeconfig:  kubeconfig,
+		watchCancel: cancel,
+	}, nil
+}
+
+// queueWorkloadEvents queues the reconciliation of the local Workloads when
+// their copies in a worker cluster change. Watches closed by the server are
+// started again.
+func (m *clientsManager) queueWorkloadEvents(ctx context.Context, c client.WithWatch, w watch.Interface) {
+	log := ctrl.LoggerFrom(ctx)
+	for {
+		for ev := range w.ResultChan() {
+			if wl, ok := ev.Object.(*kueue.Workload); ok {
+				m.wlUpdateCh <- event.GenericEvent{Object: wl}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetryDelay):
+		}
+		newWatch, err := c.Watch(ctx, &kueue.WorkloadList{}, client.MatchingLabels{OriginLabel: originValue})
+		if err != nil {
+			log.Error(err, "Watching the Workloads of a worker cluster")
+			w = watch.NewEmptyWatch()
+			continue
+		}
+		w = newWatch
+	}
+}
+
+// allClients returns the clients of all the known worker clusters.
+func (m *clientsManager) allClients() []client.Client {
+	m.Lock()
+	defer m.Unlock()
+	clients := make([]client.Client, 0, len(m.clients))
+	for _, rc := range m.clients {
+		clients = append(clients, rc.client)
+	}
+	return clients
+}
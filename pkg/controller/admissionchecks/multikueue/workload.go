@@ -0,0 +1,227 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multikueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+// wlReconciler dispatches the Workloads that reserved quota in a
+// ClusterQueue with a MultiKueue AdmissionCheck to the worker clusters.
+type wlReconciler struct {
+	client     client.Client
+	clients    *clientsManager
+	wlUpdateCh <-chan event.GenericEvent
+}
+
+func newWlReconciler(c client.Client, clients *clientsManager, wlUpdateCh <-chan event.GenericEvent) *wlReconciler {
+	return &wlReconciler{
+		client:     c,
+		clients:    clients,
+		wlUpdateCh: wlUpdateCh,
+	}
+}
+
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloads,verbs=get;list;watch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloads/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=batch,resources=jobs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeflow.org,resources=mpijobs/status,verbs=get;update;patch
+
+func (w *wlReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx).WithValues("workload", req.NamespacedName)
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	wl := &kueue.Workload{}
+	if err := w.client.Get(ctx, req.NamespacedName, wl); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		// The workload was deleted, remove its copies from the worker clusters.
+		return ctrl.Result{}, deleteFromClusters(ctx, w.clients.allClients(), nil, req.Namespace, req.Name)
+	}
+
+	ac, check, err := w.multiKueueAdmissionCheck(ctx, wl)
+	if err != nil || ac == nil {
+		return ctrl.Result{}, err
+	}
+	clients, err := w.clients.clientsForAdmissionCheck(ctx, ac)
+	if err != nil {
+		if len(clients) == 0 {
+			return ctrl.Result{}, err
+		}
+		log.V(2).Info("Some worker clusters are not reachable", "error", err.Error())
+	}
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	owner := metav1.GetControllerOf(wl)
+	var adapter jobAdapter
+	if owner != nil {
+		adapter = adapterFor(owner)
+	}
+
+	if !workload.HasQuotaReservation(wl) || apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadFinished) {
+		log.V(3).Info("Removing the copies of the workload from the worker clusters")
+		return ctrl.Result{}, deleteFromClusters(ctx, clientsOf(clients, names), adapter, wl.Namespace, wl.Name)
+	}
+	if adapter == nil {
+		log.V(2).Info("The owner of the workload is not supported by MultiKueue, not dispatching it")
+		return ctrl.Result{}, nil
+	}
+
+	remoteWls := make(map[string]*kueue.Workload, len(clients))
+	reserving := ""
+	for _, name := range names {
+		remoteWl := &kueue.Workload{}
+		if err := clients[name].Get(ctx, req.NamespacedName, remoteWl); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return ctrl.Result{}, err
+		}
+		remoteWls[name] = remoteWl
+		if reserving == "" && workload.HasQuotaReservation(remoteWl) {
+			reserving = name
+		}
+	}
+
+	if reserving == "" {
+		// Copy the workload to all the worker clusters; it runs in the first
+		// one that reserves quota for it.
+		for _, name := range names {
+			if remoteWls[name] != nil {
+				continue
+			}
+			log.V(3).Info("Copying the workload to a worker cluster", "cluster", name)
+			if err := clients[name].Create(ctx, remoteWorkload(wl)); client.IgnoreAlreadyExists(err) != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Keep the workload only in the worker cluster that reserved quota.
+	var others []client.Client
+	for _, name := range names {
+		if name != reserving && remoteWls[name] != nil {
+			others = append(others, clients[name])
+		}
+	}
+	if err := deleteFromClusters(ctx, others, adapter, wl.Namespace, wl.Name); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	remoteClient := clients[reserving]
+	jobKey := types.NamespacedName{Namespace: wl.Namespace, Name: owner.Name}
+	if err := adapter.createRemoteObject(ctx, w.client, remoteClient, jobKey, wl.Name); err != nil {
+		return ctrl.Result{}, err
+	}
+	// The job reconciler marks the local workload as finished once the status
+	// copied from the remote job shows it finished.
+	if err := adapter.copyStatusRemoteObject(ctx, w.client, remoteClient, jobKey); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if check.State != kueue.CheckStateReady {
+		log.V(2).Info("The workload reserved quota in a worker cluster", "cluster", reserving)
+		workload.SetAdmissionCheckState(&wl.Status.AdmissionChecks, kueue.AdmissionCheckState{
+			Name:    ac.Name,
+			State:   kueue.CheckStateReady,
+			Message: fmt.Sprintf("The workload got reservation on %q", reserving),
+		})
+		return ctrl.Result{}, client.IgnoreNotFound(workload.ApplyAdmissionStatus(ctx, w.client, wl))
+	}
+	return ctrl.Result{}, nil
+}
+
+// multiKueueAdmissionCheck returns the MultiKueue admission check of the
+// workload and its state, if any.
+func (w *wlReconciler) multiKueueAdmissionCheck(ctx context.Context, wl *kueue.Workload) (*kueue.AdmissionCheck, *kueue.AdmissionCheckState, error) {
+	for i := range wl.Status.AdmissionChecks {
+		check := &wl.Status.AdmissionChecks[i]
+		ac := &kueue.AdmissionCheck{}
+		if err := w.client.Get(ctx, types.NamespacedName{Name: check.Name}, ac); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, nil, err
+		}
+		if ac.Spec.ControllerName == kueue.MultiKueueControllerName {
+			return ac, check, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// remoteWorkload returns the copy of the workload to create in a worker
+// cluster.
+func remoteWorkload(wl *kueue.Workload) *kueue.Workload {
+	return &kueue.Workload{
+		ObjectMeta: remoteObjectMeta(&wl.ObjectMeta, ""),
+		Spec:       *wl.Spec.DeepCopy(),
+	}
+}
+
+func clientsOf(clients map[string]client.Client, names []string) []client.Client {
+	list := make([]client.Client, len(names))
+	for i, name := range names {
+		list[i] = clients[name]
+	}
+	return list
+}
+
+// deleteFromClusters deletes the copies of the workload and its job from
+// the worker clusters.
+func deleteFromClusters(ctx context.Context, clients []client.Client, adapter jobAdapter, namespace, name string) error {
+	var errs []error
+	for _, c := range clients {
+		if err := deleteRemoteObjects(ctx, c, adapter, namespace, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (w *wlReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("multikueue-workload").
+		For(&kueue.Workload{}).
+		// The copies in the worker clusters have the same namespace and
+		// name as the local workloads.
+		Watches(&source.Channel{Source: w.wlUpdateCh}, &handler.EnqueueRequestForObject{}).
+		Complete(w)
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multikueue
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	kubeflow "github.com/kubeflow/mpi-operator/pkg/apis/kubeflow/v2beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+	testingjob "sigs.k8s.io/kueue/pkg/util/testingjobs/job"
+)
+
+const (
+	testNamespace  = "ns"
+	kueueNamespace = "kueue-system"
+)
+
+func TestWlReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{corev1.AddToScheme, batchv1.AddToScheme, kubeflow.AddToScheme, kueue.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatalf("Failed adding to the scheme: %v", err)
+		}
+	}
+
+	quotaReserved := metav1.Condition{
+		Type:   kueue.WorkloadQuotaReserved,
+		Status: metav1.ConditionTrue,
+		Reason: "QuotaReserved",
+	}
+	finished := metav1.Condition{
+		Type:   kueue.WorkloadFinished,
+		Status: metav1.ConditionTrue,
+		Reason: "JobFinished",
+	}
+	baseJob := func() *batchv1.Job {
+		return testingjob.MakeJob("job", testNamespace).Queue("queue").Obj()
+	}
+	baseWl := func() *utiltesting.WorkloadWrapper {
+		return utiltesting.MakeWorkload("wl", testNamespace).
+			ControllerReference(batchv1.SchemeGroupVersion.WithKind("Job"), "job", "uid")
+	}
+	remoteWl := func(conditions ...metav1.Condition) *kueue.Workload {
+		wl := utiltesting.MakeWorkload("wl", testNamespace)
+		for _, c := range conditions {
+			wl.Condition(c)
+		}
+		obj := wl.Obj()
+		obj.Labels = map[string]string{OriginLabel: originValue}
+		return obj
+	}
+	remoteJob := func() *batchv1.Job {
+		job := baseJob()
+		job.Labels = map[string]string{
+			OriginLabel:                        originValue,
+			jobframework.PrebuiltWorkloadLabel: "wl",
+		}
+		return job
+	}
+
+	cases := map[string]struct {
+		workload       *kueue.Workload
+		worker1Objects []client.Object
+		worker2Objects []client.Object
+
+		wantWorker1Workloads []string
+		wantWorker1Jobs      []string
+		wantWorker2Workloads []string
+		wantChecks           []kueue.AdmissionCheckState
+	}{
+		"the workload is copied to all the worker clusters": {
+			workload: baseWl().
+				Condition(quotaReserved).
+				AdmissionCheck("ac", kueue.CheckStatePending).
+				Obj(),
+			wantWorker1Workloads: []string{"wl"},
+			wantWorker2Workloads: []string{"wl"},
+			wantChecks: []kueue.AdmissionCheckState{
+				{Name: "ac", State: kueue.CheckStatePending},
+			},
+		},
+		"the workload is only kept in the worker cluster that reserved quota": {
+			workload: baseWl().
+				Condition(quotaReserved).
+				AdmissionCheck("ac", kueue.CheckStatePending).
+				Obj(),
+			worker1Objects:       []client.Object{remoteWl(quotaReserved)},
+			worker2Objects:       []client.Object{remoteWl()},
+			wantWorker1Workloads: []string{"wl"},
+			wantWorker1Jobs:      []string{"job"},
+			wantChecks: []kueue.AdmissionCheckState{
+				{Name: "ac", State: kueue.CheckStateReady, Message: `The workload got reservation on "worker1"`},
+			},
+		},
+		"the workload is removed from the worker clusters when it finishes": {
+			workload: baseWl().
+				Condition(quotaReserved).
+				Condition(finished).
+				AdmissionCheck("ac", kueue.CheckStateReady).
+				Obj(),
+			worker1Objects: []client.Object{remoteWl(quotaReserved), remoteJob()},
+			wantChecks: []kueue.AdmissionCheckState{
+				{Name: "ac", State: kueue.CheckStateReady},
+			},
+		},
+		"the workload is removed from the worker clusters when it's deleted": {
+			worker1Objects: []client.Object{remoteWl(quotaReserved), remoteJob()},
+			worker2Objects: []client.Object{remoteWl()},
+		},
+		"the workload is not dispatched without quota reservation": {
+			workload: baseWl().
+				AdmissionCheck("ac", kueue.CheckStatePending).
+				Obj(),
+			wantChecks: []kueue.AdmissionCheckState{
+				{Name: "ac", State: kueue.CheckStatePending},
+			},
+		},
+		"the workload is not dispatched without a MultiKueue check": {
+			workload: baseWl().
+				Condition(quotaReserved).
+				AdmissionCheck("other", kueue.CheckStatePending).
+				Obj(),
+			wantChecks: []kueue.AdmissionCheckState{
+				{Name: "other", State: kueue.CheckStatePending},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := ctrl.LoggerInto(context.Background(), testr.New(t))
+			localObjects := []client.Object{
+				baseJob(),
+				utiltesting.MakeAdmissionCheck("ac", kueue.MultiKueueControllerName).
+					Parameters(kueue.GroupVersion.Group, "MultiKueueConfig", "config").
+					Obj(),
+				utiltesting.MakeAdmissionCheck("other", "other-controller").Obj(),
+				utiltesting.MakeMultiKueueConfig("config").
+					Cluster("worker1", "worker1-secret").
+					Cluster("worker2", "worker2-secret").
+					Obj(),
+				kubeconfigSecret("worker1-secret"),
+				kubeconfigSecret("worker2-secret"),
+			}
+			if tc.workload != nil {
+				localObjects = append(localObjects, tc.workload)
+			}
+			localClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(localObjects...).Build()
+			worker1 := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tc.worker1Objects...).Build()
+			worker2 := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tc.worker2Objects...).Build()
+
+			clients := newClientsManager(localClient, scheme, kueueNamespace, nil)
+			clients.clients["worker1-secret"] = &remoteClient{client: worker1, kubeconfig: []byte("worker1-secret"), watchCancel: func() {}}
+			clients.clients["worker2-secret"] = &remoteClient{client: worker2, kubeconfig: []byte("worker2-secret"), watchCancel: func() {}}
+
+			reconciler := newWlReconciler(localClient, clients, nil)
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: "wl"}}
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				t.Fatalf("Reconcile returned error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.wantWorker1Workloads, workloadNames(ctx, t, worker1), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Unexpected workloads in worker1 (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantWorker1Jobs, jobNames(ctx, t, worker1), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Unexpected jobs in worker1 (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantWorker2Workloads, workloadNames(ctx, t, worker2), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Unexpected workloads in worker2 (-want,+got):\n%s", diff)
+			}
+			if tc.workload == nil {
+				return
+			}
+			var wl kueue.Workload
+			if err := localClient.Get(ctx, req.NamespacedName, &wl); err != nil {
+				t.Fatalf("Failed getting the local workload: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantChecks, wl.Status.AdmissionChecks, cmpopts.IgnoreFields(kueue.AdmissionCheckState{}, "LastTransitionTime")); diff != "" {
+				t.Errorf("Unexpected admission checks (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRemoteObjectMeta(t *testing.T) {
+	local := testingjob.MakeJob("job", testNamespace).
+		Queue("queue").
+		OriginalNodeSelectorsAnnotation(`[{"name":"main"}]`).
+		Obj()
+	local.UID = "uid"
+	local.ResourceVersion = "1"
+
+	got := remoteObjectMeta(&local.ObjectMeta, "wl")
+	want := metav1.ObjectMeta{
+		Name:      "job",
+		Namespace: testNamespace,
+		Labels: map[string]string{
+			jobframework.QueueLabel:            "queue",
+			OriginLabel:                        originValue,
+			jobframework.PrebuiltWorkloadLabel: "wl",
+		},
+		Annotations: map[string]string{},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected metadata (-want,+got):\n%s", diff)
+	}
+}
+
+func TestCopyStatusRemoteObject(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := batchv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed adding to the scheme: %v", err)
+	}
+	completed := batchv1.JobStatus{
+		Succeeded: 1,
+		Conditions: []batchv1.JobCondition{{
+			Type:   batchv1.JobComplete,
+			Status: corev1.ConditionTrue,
+		}},
+	}
+	cases := map[string]struct {
+		localStatus  batchv1.JobStatus
+		remoteStatus batchv1.JobStatus
+		wantUpdated  bool
+	}{
+		"the status differs": {
+			remoteStatus: completed,
+			wantUpdated:  true,
+		},
+		"the status is already copied": {
+			localStatus:  completed,
+			remoteStatus: completed,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			localJob := testingjob.MakeJob("job", testNamespace).Obj()
+			localJob.Status = tc.localStatus
+			remoteJob := testingjob.MakeJob("job", testNamespace).Obj()
+			remoteJob.Status = tc.remoteStatus
+			localClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(localJob).Build()
+			remoteClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(remoteJob).Build()
+			key := client.ObjectKeyFromObject(localJob)
+			var before batchv1.Job
+			if err := localClient.Get(ctx, key, &before); err != nil {
+				t.Fatalf("Failed getting the local job: %v", err)
+			}
+
+			if err := (&batchJobAdapter{}).copyStatusRemoteObject(ctx, localClient, remoteClient, key); err != nil {
+				t.Fatalf("Failed copying the status: %v", err)
+			}
+
+			var after batchv1.Job
+			if err := localClient.Get(ctx, key, &after); err != nil {
+				t.Fatalf("Failed getting the local job: %v", err)
+			}
+			if diff := cmp.Diff(tc.remoteStatus, after.Status); diff != "" {
+				t.Errorf("Unexpected status of the local job (-want,+got):\n%s", diff)
+			}
+			if updated := after.ResourceVersion != before.ResourceVersion; updated != tc.wantUpdated {
+				t.Errorf("Unexpected update of the local job, want %t, got %t", tc.wantUpdated, updated)
+			}
+		})
+	}
+}
+
+func kubeconfigSecret(name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: kueueNamespace, Name: name},
+		Data:       map[string][]byte{kubeconfigKey: []byte(name)},
+	}
+}
+
+func workloadNames(ctx context.Context, t *testing.T, c client.Client) []string {
+	t.Helper()
+	var list kueue.WorkloadList
+	if err := c.List(ctx, &list); err != nil {
+		t.Fatalf("Failed listing workloads: %v", err)
+	}
+	names := make([]string, len(list.Items))
+	for i := range list.Items {
+		names[i] = list.Items[i].Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func jobNames(ctx context.Context, t *testing.T, c client.Client) []string {
+	t.Helper()
+	var list batchv1.JobList
+	if err := c.List(ctx, &list); err != nil {
+		t.Fatalf("Failed listing jobs: %v", err)
+	}
+	names := make([]string, len(list.Items))
+	for i := range list.Items {
+		names[i] = list.Items[i].Name
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/cache"
+	"sigs.k8s.io/kueue/pkg/queue"
+)
+
+type AdmissionCheckUpdateWatcher interface {
+	NotifyAdmissionCheckUpdate(*kueue.AdmissionCheck)
+}
+
+// AdmissionCheckReconciler reconciles an AdmissionCheck object
+type AdmissionCheckReconciler struct {
+	log      logr.Logger
+	qManager *queue.Manager
+	cache    *cache.Cache
+	client   client.Client
+	watchers []AdmissionCheckUpdateWatcher
+}
+
+func NewAdmissionCheckReconciler(client client.Client, qMgr *queue.Manager, cache *cache.Cache) *AdmissionCheckReconciler {
+	return &AdmissionCheckReconciler{
+		log:      ctrl.Log.WithName("admissioncheck-reconciler"),
+		qManager: qMgr,
+		cache:    cache,
+		client:   client,
+	}
+}
+
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=admissionchecks,verbs=get;list;watch
+
+func (r *AdmissionCheckReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var ac kueue.AdmissionCheck
+	if err := r.client.Get(ctx, req.NamespacedName, &ac); err != nil {
+		// we'll ignore not-found errors, since there is nothing to do.
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	log := ctrl.LoggerFrom(ctx).WithValues("admissionCheck", klog.KObj(&ac))
+	log.V(2).Info("Reconciling AdmissionCheck")
+	return ctrl.Result{}, nil
+}
+
+func (r *AdmissionCheckReconciler) AddUpdateWatcher(watchers ...AdmissionCheckUpdateWatcher) {
+	r.watchers = watchers
+}
+
+func (r *AdmissionCheckReconciler) notifyWatchers(ac *kueue.AdmissionCheck) {
+	for _, w := range r.watchers {
+		w.NotifyAdmissionCheckUpdate(ac)
+	}
+}
+
+func (r *AdmissionCheckReconciler) Create(e event.CreateEvent) bool {
+	ac, match := e.Object.(*kueue.AdmissionCheck)
+	if !match {
+		return false
+	}
+	defer r.notifyWatchers(ac)
+	r.log.V(2).Info("AdmissionCheck create event", "admissionCheck", klog.KObj(ac))
+	r.addOrUpdate(ac)
+	return true
+}
+
+func (r *AdmissionCheckReconciler) Update(e event.UpdateEvent) bool {
+	ac, match := e.ObjectNew.(*kueue.AdmissionCheck)
+	if !match {
+		return false
+	}
+	defer r.notifyWatchers(ac)
+	r.log.V(2).Info("AdmissionCheck update event", "admissionCheck", klog.KObj(ac))
+	r.addOrUpdate(ac)
+	return true
+}
+
+func (r *AdmissionCheckReconciler) Delete(e event.DeleteEvent) bool {
+	ac, match := e.Object.(*kueue.AdmissionCheck)
+	if !match {
+		return false
+	}
+	defer r.notifyWatchers(ac)
+	r.log.V(2).Info("AdmissionCheck delete event", "admissionCheck", klog.KObj(ac))
+	r.cache.DeleteAdmissionCheck(ac)
+	return false
+}
+
+func (r *AdmissionCheckReconciler) Generic(e event.GenericEvent) bool {
+	r.log.V(3).Info("Ignore generic event", "obj", klog.KObj(e.Object), "kind", e.Object.GetObjectKind().GroupVersionKind())
+	return false
+}
+
+func (r *AdmissionCheckReconciler) addOrUpdate(ac *kueue.AdmissionCheck) {
+	if cqNames := r.cache.AddOrUpdateAdmissionCheck(ac); len(cqNames) > 0 {
+		r.qManager.QueueInadmissibleWorkloads(context.Background(), cqNames)
+		// The ClusterQueues that became active should get evaluated by the
+		// scheduler, even if their workloads were not inadmissible.
+		r.qManager.Broadcast()
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AdmissionCheckReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&kueue.AdmissionCheck{}).
+		WithEventFilter(r).
+		Complete(r)
+}
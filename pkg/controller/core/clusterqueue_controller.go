@@ -18,6 +18,9 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -47,29 +50,64 @@ type ClusterQueueUpdateWatcher interface {
 
 // ClusterQueueReconciler reconciles a ClusterQueue object
 type ClusterQueueReconciler struct {
-	client     client.Client
-	log        logr.Logger
-	qManager   *queue.Manager
-	cache      *cache.Cache
-	wlUpdateCh chan event.GenericEvent
-	rfUpdateCh chan event.GenericEvent
-	watchers   []ClusterQueueUpdateWatcher
+	client             client.Client
+	log                logr.Logger
+	qManager           *queue.Manager
+	cache              *cache.Cache
+	wlUpdateCh         chan event.GenericEvent
+	rfUpdateCh         chan event.GenericEvent
+	cohortUpdateCh     chan event.GenericEvent
+	acUpdateCh         chan event.GenericEvent
+	watchers           []ClusterQueueUpdateWatcher
+	fairSharingEnabled bool
 }
 
+type clusterQueueReconcilerOptions struct {
+	watchers           []ClusterQueueUpdateWatcher
+	fairSharingEnabled bool
+}
+
+// ClusterQueueReconcilerOption configures the reconciler.
+type ClusterQueueReconcilerOption func(*clusterQueueReconcilerOptions)
+
+// WithWatchers sets the watchers notified of the ClusterQueue updates.
+func WithWatchers(watchers ...ClusterQueueUpdateWatcher) ClusterQueueReconcilerOption {
+	return func(o *clusterQueueReconcilerOptions) {
+		o.watchers = watchers
+	}
+}
+
+// WithFairSharing indicates if the reconciler should report the fair sharing
+// status of the ClusterQueues.
+func WithFairSharing(enabled bool) ClusterQueueReconcilerOption {
+	return func(o *clusterQueueReconcilerOptions) {
+		o.fairSharingEnabled = enabled
+	}
+}
+
+var defaultCQOptions = clusterQueueReconcilerOptions{}
+
 func NewClusterQueueReconciler(
 	client client.Client,
 	qMgr *queue.Manager,
 	cache *cache.Cache,
-	watchers ...ClusterQueueUpdateWatcher,
+	opts ...ClusterQueueReconcilerOption,
 ) *ClusterQueueReconciler {
+	options := defaultCQOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
 	return &ClusterQueueReconciler{
-		client:     client,
-		log:        ctrl.Log.WithName("cluster-queue-reconciler"),
-		qManager:   qMgr,
-		cache:      cache,
-		wlUpdateCh: make(chan event.GenericEvent, updateChBuffer),
-		rfUpdateCh: make(chan event.GenericEvent, updateChBuffer),
-		watchers:   watchers,
+		client:             client,
+		log:                ctrl.Log.WithName("cluster-queue-reconciler"),
+		qManager:           qMgr,
+		cache:              cache,
+		wlUpdateCh:         make(chan event.GenericEvent, updateChBuffer),
+		rfUpdateCh:         make(chan event.GenericEvent, updateChBuffer),
+		cohortUpdateCh:     make(chan event.GenericEvent, updateChBuffer),
+		acUpdateCh:         make(chan event.GenericEvent, updateChBuffer),
+		watchers:           options.watchers,
+		fairSharingEnabled: options.fairSharingEnabled,
 	}
 }
 
@@ -116,6 +154,11 @@ func (r *ClusterQueueReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
+	if r.cache.RefreshQuotaSchedules(cqObj.Name) {
+		log.V(2).Info("Quota schedules changed")
+		r.qManager.QueueInadmissibleWorkloads(ctx, sets.New(cqObj.Name))
+	}
+
 	newCQObj := cqObj.DeepCopy()
 	if r.cache.ClusterQueueActive(newCQObj.Name) {
 		msg := "Can admit new workloads"
@@ -127,6 +170,16 @@ func (r *ClusterQueueReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		if err := r.updateCqStatusIfChanged(ctx, newCQObj, metav1.ConditionFalse, "Terminating", msg); err != nil {
 			return ctrl.Result{}, client.IgnoreNotFound(err)
 		}
+	} else if r.cache.ClusterQueueStopped(newCQObj.Name) {
+		msg := "Can't admit new workloads; clusterQueue is stopped"
+		if err := r.updateCqStatusIfChanged(ctx, newCQObj, metav1.ConditionFalse, "Stopped", msg); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+	} else if inactive := r.cache.InactiveAdmissionChecks(newCQObj.Name); len(inactive) > 0 {
+		msg := fmt.Sprintf("Can't admit new workloads; some admission checks are not found or inactive: %s", strings.Join(inactive, ", "))
+		if err := r.updateCqStatusIfChanged(ctx, newCQObj, metav1.ConditionFalse, "CheckNotFoundOrInactive", msg); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
 	} else {
 		msg := "Can't admit new workloads; some flavors are not found"
 		if err := r.updateCqStatusIfChanged(ctx, newCQObj, metav1.ConditionFalse, "FlavorNotFound", msg); err != nil {
@@ -134,6 +187,10 @@ func (r *ClusterQueueReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
+	if qs := r.cache.QuotaSchedules(newCQObj.Name); qs != nil && !qs.NextTransition.IsZero() {
+		// Reconcile again when the window of a quota schedule starts or ends.
+		return ctrl.Result{RequeueAfter: time.Until(qs.NextTransition)}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -154,12 +211,26 @@ func (r *ClusterQueueReconciler) notifyWatchers(oldCQ, newCQ *kueue.ClusterQueue
 	for _, w := range r.watchers {
 		w.NotifyClusterQueueUpdate(oldCQ, newCQ)
 	}
+	if r.fairSharingEnabled {
+		// The quota that a ClusterQueue lends changes the share of the other
+		// ClusterQueues in its cohort tree.
+		if oldCQ != nil && oldCQ.Spec.Cohort != "" {
+			r.cohortUpdateCh <- event.GenericEvent{Object: oldCQ}
+		}
+		if newCQ != nil && newCQ.Spec.Cohort != "" && (oldCQ == nil || oldCQ.Spec.Cohort != newCQ.Spec.Cohort) {
+			r.cohortUpdateCh <- event.GenericEvent{Object: newCQ}
+		}
+	}
 }
 
 func (r *ClusterQueueReconciler) NotifyResourceFlavorUpdate(rf *kueue.ResourceFlavor) {
 	r.rfUpdateCh <- event.GenericEvent{Object: rf}
 }
 
+func (r *ClusterQueueReconciler) NotifyAdmissionCheckUpdate(ac *kueue.AdmissionCheck) {
+	r.acUpdateCh <- event.GenericEvent{Object: ac}
+}
+
 // Event handlers return true to signal the controller to reconcile the
 // ClusterQueue associated with the event.
 
@@ -224,6 +295,10 @@ func (r *ClusterQueueReconciler) Update(e event.UpdateEvent) bool {
 	if err := r.qManager.UpdateClusterQueue(context.Background(), newCq); err != nil {
 		log.Error(err, "Failed to update clusterQueue in queue manager")
 	}
+	if stopPolicy(oldCq) != kueue.None && stopPolicy(newCq) == kueue.None {
+		// The workloads of the ClusterQueue can be popped again.
+		r.qManager.Broadcast()
+	}
 	return true
 }
 
@@ -334,6 +409,61 @@ func (h *cqResourceFlavorHandler) Generic(e event.GenericEvent, q workqueue.Rate
 	}
 }
 
+// cqCohortHandler signals the controller to reconcile the ClusterQueues in
+// the cohort tree of the ClusterQueue in the event.
+// Since the events come from a channel Source, only the Generic handler will
+// receive events.
+type cqCohortHandler struct {
+	cache *cache.Cache
+}
+
+func (h *cqCohortHandler) Create(event.CreateEvent, workqueue.RateLimitingInterface) {
+}
+
+func (h *cqCohortHandler) Update(event.UpdateEvent, workqueue.RateLimitingInterface) {
+}
+
+func (h *cqCohortHandler) Delete(event.DeleteEvent, workqueue.RateLimitingInterface) {
+}
+
+func (h *cqCohortHandler) Generic(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+	cq, ok := e.Object.(*kueue.ClusterQueue)
+	if !ok {
+		return
+	}
+	for _, name := range h.cache.ClusterQueuesInCohortTree(cq.Spec.Cohort) {
+		if name != cq.Name {
+			q.AddAfter(reconcile.Request{NamespacedName: types.NamespacedName{Name: name}}, constants.UpdatesBatchPeriod)
+		}
+	}
+}
+
+type cqAdmissionCheckHandler struct {
+	cache *cache.Cache
+}
+
+func (h *cqAdmissionCheckHandler) Create(event.CreateEvent, workqueue.RateLimitingInterface) {
+}
+
+func (h *cqAdmissionCheckHandler) Update(event.UpdateEvent, workqueue.RateLimitingInterface) {
+}
+
+func (h *cqAdmissionCheckHandler) Delete(event.DeleteEvent, workqueue.RateLimitingInterface) {
+}
+
+func (h *cqAdmissionCheckHandler) Generic(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+	ac, ok := e.Object.(*kueue.AdmissionCheck)
+	if !ok {
+		return
+	}
+	for _, cq := range h.cache.ClusterQueuesUsingAdmissionCheck(ac.Name) {
+		q.Add(reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name: cq,
+			}})
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterQueueReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	wHandler := cqWorkloadHandler{
@@ -346,11 +476,19 @@ func (r *ClusterQueueReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	rfHandler := cqResourceFlavorHandler{
 		cache: r.cache,
 	}
+	cohortHandler := cqCohortHandler{
+		cache: r.cache,
+	}
+	acHandler := cqAdmissionCheckHandler{
+		cache: r.cache,
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&kueue.ClusterQueue{}).
 		Watches(&source.Kind{Type: &corev1.Namespace{}}, &nsHandler).
 		Watches(&source.Channel{Source: r.wlUpdateCh}, &wHandler).
 		Watches(&source.Channel{Source: r.rfUpdateCh}, &rfHandler).
+		Watches(&source.Channel{Source: r.cohortUpdateCh}, &cohortHandler).
+		Watches(&source.Channel{Source: r.acUpdateCh}, &acHandler).
 		WithEventFilter(r).
 		Complete(r)
 }
@@ -373,14 +511,53 @@ func (r *ClusterQueueReconciler) updateCqStatusIfChanged(
 	cq.Status.FlavorsUsage = usage
 	cq.Status.AdmittedWorkloads = int32(workloads)
 	cq.Status.PendingWorkloads = int32(pendingWorkloads)
+	if r.fairSharingEnabled {
+		drs, err := r.cache.DominantResourceShare(cq.Name)
+		if err != nil {
+			r.log.Error(err, "Failed getting the dominant resource share from cache")
+			return err
+		}
+		cq.Status.FairSharing = &kueue.FairSharingStatus{WeightedShare: int64(drs)}
+	} else {
+		cq.Status.FairSharing = nil
+	}
 	meta.SetStatusCondition(&cq.Status.Conditions, metav1.Condition{
 		Type:    kueue.ClusterQueueActive,
 		Status:  conditionStatus,
 		Reason:  reason,
 		Message: msg,
 	})
+	if qs := r.cache.QuotaSchedules(cq.Name); qs != nil {
+		meta.SetStatusCondition(&cq.Status.Conditions, quotaScheduleCondition(qs))
+	} else {
+		meta.RemoveStatusCondition(&cq.Status.Conditions, kueue.ClusterQueueQuotaScheduleActive)
+	}
 	if !equality.Semantic.DeepEqual(cq.Status, oldStatus) {
 		return r.client.Status().Update(ctx, cq)
 	}
 	return nil
 }
+
+func quotaScheduleCondition(qs *cache.QuotaScheduleStatus) metav1.Condition {
+	if len(qs.Active) == 0 {
+		return metav1.Condition{
+			Type:    kueue.ClusterQueueQuotaScheduleActive,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NoActiveSchedule",
+			Message: "The nominal quotas and borrowing limits of the resources apply",
+		}
+	}
+	return metav1.Condition{
+		Type:    kueue.ClusterQueueQuotaScheduleActive,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ScheduleActive",
+		Message: fmt.Sprintf("Active quota schedules: %s", strings.Join(qs.Active, ", ")),
+	}
+}
+
+func stopPolicy(cq *kueue.ClusterQueue) kueue.StopPolicy {
+	if cq.Spec.StopPolicy == nil {
+		return kueue.None
+	}
+	return *cq.Spec.StopPolicy
+}
@@ -19,10 +19,12 @@ package core
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr/testr"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -48,6 +50,8 @@ func TestUpdateCqStatusIfChanged(t *testing.T) {
 		newReason          string
 		newMessage         string
 		newWl              *kueue.Workload
+		fairSharingEnabled bool
+		resourceGroup      *kueue.FlavorQuotas
 		wantCqStatus       kueue.ClusterQueueStatus
 	}{
 		"empty ClusterQueueStatus": {
@@ -158,12 +162,95 @@ func TestUpdateCqStatusIfChanged(t *testing.T) {
 				}},
 			},
 		},
+		"fair sharing enabled": {
+			cqStatus:           kueue.ClusterQueueStatus{},
+			newConditionStatus: metav1.ConditionTrue,
+			newReason:          "Ready",
+			newMessage:         "Can admit new workloads",
+			fairSharingEnabled: true,
+			wantCqStatus: kueue.ClusterQueueStatus{
+				PendingWorkloads: int32(len(defaultWls.Items)),
+				Conditions: []metav1.Condition{{
+					Type:    kueue.ClusterQueueActive,
+					Status:  metav1.ConditionTrue,
+					Reason:  "Ready",
+					Message: "Can admit new workloads",
+				}},
+				FairSharing: &kueue.FairSharingStatus{WeightedShare: 0},
+			},
+		},
+		"active quota schedule": {
+			cqStatus:           kueue.ClusterQueueStatus{},
+			newConditionStatus: metav1.ConditionTrue,
+			newReason:          "Ready",
+			newMessage:         "Can admit new workloads",
+			resourceGroup: utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "2").
+				Schedule(corev1.ResourceCPU, *utiltesting.MakeQuotaSchedule("always", "* * * * *", time.Hour, "4").Obj()).
+				Obj(),
+			wantCqStatus: kueue.ClusterQueueStatus{
+				PendingWorkloads: int32(len(defaultWls.Items)),
+				FlavorsUsage: []kueue.FlavorUsage{{
+					Name:      "default",
+					Resources: []kueue.ResourceUsage{{Name: corev1.ResourceCPU}},
+				}},
+				Conditions: []metav1.Condition{
+					{
+						Type:    kueue.ClusterQueueActive,
+						Status:  metav1.ConditionTrue,
+						Reason:  "Ready",
+						Message: "Can admit new workloads",
+					},
+					{
+						Type:    kueue.ClusterQueueQuotaScheduleActive,
+						Status:  metav1.ConditionTrue,
+						Reason:  "ScheduleActive",
+						Message: "Active quota schedules: default/cpu=always",
+					},
+				},
+			},
+		},
+		"inactive quota schedule": {
+			cqStatus:           kueue.ClusterQueueStatus{},
+			newConditionStatus: metav1.ConditionTrue,
+			newReason:          "Ready",
+			newMessage:         "Can admit new workloads",
+			resourceGroup: utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "2").
+				Schedule(corev1.ResourceCPU, *utiltesting.MakeQuotaSchedule("never", "0 0 30 2 *", time.Hour, "4").Obj()).
+				Obj(),
+			wantCqStatus: kueue.ClusterQueueStatus{
+				PendingWorkloads: int32(len(defaultWls.Items)),
+				FlavorsUsage: []kueue.FlavorUsage{{
+					Name:      "default",
+					Resources: []kueue.ResourceUsage{{Name: corev1.ResourceCPU}},
+				}},
+				Conditions: []metav1.Condition{
+					{
+						Type:    kueue.ClusterQueueActive,
+						Status:  metav1.ConditionTrue,
+						Reason:  "Ready",
+						Message: "Can admit new workloads",
+					},
+					{
+						Type:    kueue.ClusterQueueQuotaScheduleActive,
+						Status:  metav1.ConditionFalse,
+						Reason:  "NoActiveSchedule",
+						Message: "The nominal quotas and borrowing limits of the resources apply",
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			cq := utiltesting.MakeClusterQueue(cqName).
-				QueueingStrategy(kueue.StrictFIFO).Obj()
+			cqWrapper := utiltesting.MakeClusterQueue(cqName).
+				QueueingStrategy(kueue.StrictFIFO)
+			if tc.resourceGroup != nil {
+				cqWrapper.ResourceGroup(*tc.resourceGroup)
+			}
+			cq := cqWrapper.Obj()
 			cq.Status = tc.cqStatus
 			lq := utiltesting.MakeLocalQueue(lqName, "").
 				ClusterQueue(cqName).Obj()
@@ -189,10 +276,11 @@ func TestUpdateCqStatusIfChanged(t *testing.T) {
 				cqCache.AddOrUpdateWorkload(&wl)
 			}
 			r := &ClusterQueueReconciler{
-				client:   cl,
-				log:      log,
-				cache:    cqCache,
-				qManager: qManager,
+				client:             cl,
+				log:                log,
+				cache:              cqCache,
+				qManager:           qManager,
+				fairSharingEnabled: tc.fairSharingEnabled,
 			}
 			if tc.newWl != nil {
 				r.qManager.AddOrUpdateWorkload(tc.newWl)
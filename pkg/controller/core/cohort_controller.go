@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/cache"
+	"sigs.k8s.io/kueue/pkg/constants"
+	"sigs.k8s.io/kueue/pkg/queue"
+)
+
+// CohortReconciler reconciles a Cohort object
+type CohortReconciler struct {
+	log      logr.Logger
+	qManager *queue.Manager
+	cache    *cache.Cache
+	client   client.Client
+}
+
+func NewCohortReconciler(client client.Client, qMgr *queue.Manager, cache *cache.Cache) *CohortReconciler {
+	return &CohortReconciler{
+		log:      ctrl.Log.WithName("cohort-reconciler"),
+		qManager: qMgr,
+		cache:    cache,
+		client:   client,
+	}
+}
+
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=cohorts,verbs=get;list;watch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=cohorts/status,verbs=get;update;patch
+
+func (r *CohortReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var cohort kueue.Cohort
+	if err := r.client.Get(ctx, req.NamespacedName, &cohort); err != nil {
+		// we'll ignore not-found errors, since there is nothing to do.
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	log := ctrl.LoggerFrom(ctx).WithValues("cohort", klog.KObj(&cohort))
+	ctx = ctrl.LoggerInto(ctx, log)
+	log.V(2).Info("Reconciling Cohort")
+	return ctrl.Result{}, r.updateStatusIfChanged(ctx, &cohort)
+}
+
+func (r *CohortReconciler) updateStatusIfChanged(ctx context.Context, cohort *kueue.Cohort) error {
+	oldStatus := cohort.Status.DeepCopy()
+	flavorsResources, clusterQueues, err := r.cache.CohortResources(cohort)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed getting resources from cache")
+		return err
+	}
+	cohort.Status.ClusterQueues = int32(clusterQueues)
+	cohort.Status.FlavorsResources = flavorsResources
+	if !equality.Semantic.DeepEqual(cohort.Status, oldStatus) {
+		return client.IgnoreNotFound(r.client.Status().Update(ctx, cohort))
+	}
+	return nil
+}
+
+func (r *CohortReconciler) Create(e event.CreateEvent) bool {
+	cohort, match := e.Object.(*kueue.Cohort)
+	if !match {
+		// No need to interact with the cache for other objects.
+		return true
+	}
+	log := r.log.WithValues("cohort", klog.KObj(cohort))
+	log.V(2).Info("Cohort create event")
+	r.addOrUpdate(logr.NewContext(context.Background(), log), cohort)
+	return true
+}
+
+func (r *CohortReconciler) Update(e event.UpdateEvent) bool {
+	cohort, match := e.ObjectNew.(*kueue.Cohort)
+	if !match {
+		// No need to interact with the cache for other objects.
+		return true
+	}
+	log := r.log.WithValues("cohort", klog.KObj(cohort))
+	log.V(2).Info("Cohort update event")
+	r.addOrUpdate(logr.NewContext(context.Background(), log), cohort)
+	return true
+}
+
+func (r *CohortReconciler) Delete(e event.DeleteEvent) bool {
+	cohort, match := e.Object.(*kueue.Cohort)
+	if !match {
+		// No need to interact with the cache for other objects.
+		return true
+	}
+	r.log.V(2).Info("Cohort delete event", "cohort", klog.KObj(cohort))
+	r.cache.DeleteCohort(cohort)
+	r.qManager.DeleteCohort(cohort)
+	// The ancestors of the cohort need to update their status.
+	return true
+}
+
+func (r *CohortReconciler) Generic(e event.GenericEvent) bool {
+	r.log.V(3).Info("Ignore generic event", "obj", klog.KObj(e.Object), "kind", e.Object.GetObjectKind().GroupVersionKind())
+	return false
+}
+
+func (r *CohortReconciler) addOrUpdate(ctx context.Context, cohort *kueue.Cohort) {
+	if err := r.cache.AddOrUpdateCohort(cohort); err != nil {
+		logr.FromContextOrDiscard(ctx).Error(err, "Failed to link cohort to its parent in the cache")
+	}
+	r.qManager.AddOrUpdateCohort(ctx, cohort)
+}
+
+// cohortTreeHandler signals the controller to reconcile the cohort that a
+// ClusterQueue or a Cohort in the event belongs to, and its ancestors, so
+// that their status reflects the quota and usage of their subtree.
+type cohortTreeHandler struct {
+	cache *cache.Cache
+}
+
+func (h *cohortTreeHandler) Create(e event.CreateEvent, wq workqueue.RateLimitingInterface) {
+	h.addCohortsToWorkQueue(parentCohort(e.Object), wq)
+}
+
+func (h *cohortTreeHandler) Update(e event.UpdateEvent, wq workqueue.RateLimitingInterface) {
+	oldParent := parentCohort(e.ObjectOld)
+	newParent := parentCohort(e.ObjectNew)
+	if oldParent != newParent {
+		h.addCohortsToWorkQueue(oldParent, wq)
+	}
+	h.addCohortsToWorkQueue(newParent, wq)
+}
+
+func (h *cohortTreeHandler) Delete(e event.DeleteEvent, wq workqueue.RateLimitingInterface) {
+	h.addCohortsToWorkQueue(parentCohort(e.Object), wq)
+}
+
+func (h *cohortTreeHandler) Generic(event.GenericEvent, workqueue.RateLimitingInterface) {
+}
+
+func (h *cohortTreeHandler) addCohortsToWorkQueue(cohort string, wq workqueue.RateLimitingInterface) {
+	if cohort == "" {
+		return
+	}
+	// The object might not be in the cache anymore, but the cohort it
+	// belonged to still needs to be updated.
+	names := sets.New(cohort)
+	names.Insert(h.cache.CohortAndAncestors(cohort)...)
+	for name := range names {
+		// Batch the updates, as the usage of the ClusterQueues changes often.
+		wq.AddAfter(reconcile.Request{NamespacedName: types.NamespacedName{Name: name}}, constants.UpdatesBatchPeriod)
+	}
+}
+
+// parentCohort returns the cohort of a ClusterQueue or the parent of a
+// Cohort.
+func parentCohort(obj client.Object) string {
+	switch o := obj.(type) {
+	case *kueue.ClusterQueue:
+		return o.Spec.Cohort
+	case *kueue.Cohort:
+		return o.Spec.Parent
+	}
+	return ""
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CohortReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	treeHandler := cohortTreeHandler{
+		cache: r.cache,
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&kueue.Cohort{}).
+		Watches(&source.Kind{Type: &kueue.ClusterQueue{}}, &treeHandler).
+		Watches(&source.Kind{Type: &kueue.Cohort{}}, &treeHandler).
+		WithEventFilter(r).
+		Complete(r)
+}
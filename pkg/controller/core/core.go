@@ -35,16 +35,34 @@ func SetupControllers(mgr ctrl.Manager, qManager *queue.Manager, cc *cache.Cache
 	if err := rfRec.SetupWithManager(mgr); err != nil {
 		return "ResourceFlavor", err
 	}
+	nodeRec := NewNodeReconciler(mgr.GetClient(), qManager, cc)
+	if err := nodeRec.SetupWithManager(mgr); err != nil {
+		return "Node", err
+	}
 	qRec := NewLocalQueueReconciler(mgr.GetClient(), qManager, cc)
 	if err := qRec.SetupWithManager(mgr); err != nil {
 		return "LocalQueue", err
 	}
-	cqRec := NewClusterQueueReconciler(mgr.GetClient(), qManager, cc, rfRec)
-	rfRec.AddUpdateWatcher(cqRec)
+	cohortRec := NewCohortReconciler(mgr.GetClient(), qManager, cc)
+	if err := cohortRec.SetupWithManager(mgr); err != nil {
+		return "Cohort", err
+	}
+	acRec := NewAdmissionCheckReconciler(mgr.GetClient(), qManager, cc)
+	if err := acRec.SetupWithManager(mgr); err != nil {
+		return "AdmissionCheck", err
+	}
+	cqRec := NewClusterQueueReconciler(mgr.GetClient(), qManager, cc,
+		WithWatchers(rfRec),
+		WithFairSharing(fairSharingEnabled(cfg)))
+	rfRec.AddUpdateWatcher(cqRec, nodeRec)
+	acRec.AddUpdateWatcher(cqRec)
 	if err := cqRec.SetupWithManager(mgr); err != nil {
 		return "ClusterQueue", err
 	}
-	if err := NewWorkloadReconciler(mgr.GetClient(), qManager, cc, WithWorkloadUpdateWatchers(qRec, cqRec), WithPodsReadyTimeout(podsReadyTimeout(cfg))).SetupWithManager(mgr); err != nil {
+	if err := NewWorkloadReconciler(mgr.GetClient(), qManager, cc,
+		WithWorkloadUpdateWatchers(qRec, cqRec),
+		WithPodsReadyTimeout(podsReadyTimeout(cfg)),
+		WithRequeuingBackoff(requeuingBackoff(cfg))).SetupWithManager(mgr); err != nil {
 		return "Workload", err
 	}
 	return "", nil
@@ -56,3 +74,22 @@ func podsReadyTimeout(cfg *config.Configuration) *time.Duration {
 	}
 	return nil
 }
+
+func requeuingBackoff(cfg *config.Configuration) (time.Duration, time.Duration, *int32) {
+	if cfg.WaitForPodsReady == nil || cfg.WaitForPodsReady.RequeuingStrategy == nil {
+		return 0, 0, nil
+	}
+	strategy := cfg.WaitForPodsReady.RequeuingStrategy
+	var base, max time.Duration
+	if strategy.BackoffBaseSeconds != nil {
+		base = time.Duration(*strategy.BackoffBaseSeconds) * time.Second
+	}
+	if strategy.BackoffMaxSeconds != nil {
+		max = time.Duration(*strategy.BackoffMaxSeconds) * time.Second
+	}
+	return base, max, strategy.BackoffLimitCount
+}
+
+func fairSharingEnabled(cfg *config.Configuration) bool {
+	return cfg.FairSharing != nil && cfg.FairSharing.Enable
+}
@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -152,6 +153,10 @@ func (r *LocalQueueReconciler) Update(e event.UpdateEvent) bool {
 	if err := r.cache.UpdateLocalQueue(oldQ, q); err != nil {
 		log.Error(err, "Failed to update localQueue in the cache")
 	}
+	if !equality.Semantic.DeepEqual(oldQ.Spec.Limits, q.Spec.Limits) {
+		// Workloads that didn't fit in the old limits might fit now.
+		r.queues.QueueInadmissibleWorkloads(context.Background(), sets.New(string(q.Spec.ClusterQueue)))
+	}
 	return true
 }
 
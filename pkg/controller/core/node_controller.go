@@ -0,0 +1,234 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/cache"
+	"sigs.k8s.io/kueue/pkg/constants"
+	"sigs.k8s.io/kueue/pkg/queue"
+)
+
+// NodeReconciler keeps the capacity of the Nodes, and the resources that the
+// Pods take from them, in the cache. They are used for topology-aware
+// admission and to compute the capacity of the ResourceFlavors.
+type NodeReconciler struct {
+	log      logr.Logger
+	client   client.Client
+	qManager *queue.Manager
+	cache    *cache.Cache
+
+	// watchPods starts watching the Pods. The Pods are only watched once a
+	// ResourceFlavor needs them, see NotifyResourceFlavorUpdate.
+	watchPods    func() error
+	podsMu       sync.Mutex
+	watchingPods bool
+}
+
+var _ ResourceFlavorUpdateWatcher = (*NodeReconciler)(nil)
+
+func NewNodeReconciler(client client.Client, qMgr *queue.Manager, cache *cache.Cache) *NodeReconciler {
+	return &NodeReconciler{
+		log:      ctrl.Log.WithName("node-reconciler"),
+		client:   client,
+		qManager: qMgr,
+		cache:    cache,
+	}
+}
+
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch
+
+// Reconcile computes the capacity of the ResourceFlavors from the Nodes and
+// requeues the workloads of the ClusterQueues whose quota changed. All the
+// events are reconciled together, see flavorCapacityHandler.
+func (r *NodeReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if cqNames := r.cache.RefreshFlavorCapacity(); len(cqNames) > 0 {
+		log.V(2).Info("Nominal quota changed with the capacity of the flavors", "clusterQueues", cqNames)
+		r.qManager.QueueInadmissibleWorkloads(ctx, cqNames)
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *NodeReconciler) Create(e event.CreateEvent) bool {
+	switch obj := e.Object.(type) {
+	case *corev1.Node:
+		r.log.V(5).Info("Node create event", "node", klog.KObj(obj))
+		return r.addOrUpdateNode(obj)
+	case *corev1.Pod:
+		return r.cache.AddOrUpdatePod(obj, r.indirectControllers(obj))
+	}
+	return false
+}
+
+func (r *NodeReconciler) Delete(e event.DeleteEvent) bool {
+	switch obj := e.Object.(type) {
+	case *corev1.Node:
+		r.log.V(5).Info("Node delete event", "node", klog.KObj(obj))
+		r.cache.DeleteNode(obj)
+		return true
+	case *corev1.Pod:
+		if !r.cache.DeletePod(obj) {
+			return false
+		}
+		r.queueTopologyClusterQueues()
+		return true
+	}
+	return false
+}
+
+func (r *NodeReconciler) Update(e event.UpdateEvent) bool {
+	switch obj := e.ObjectNew.(type) {
+	case *corev1.Node:
+		r.log.V(5).Info("Node update event", "node", klog.KObj(obj))
+		return r.addOrUpdateNode(obj)
+	case *corev1.Pod:
+		if !r.cache.AddOrUpdatePod(obj, r.indirectControllers(obj)) {
+			return false
+		}
+		if obj.Status.Phase == corev1.PodSucceeded || obj.Status.Phase == corev1.PodFailed {
+			r.queueTopologyClusterQueues()
+		}
+		return true
+	}
+	return false
+}
+
+func (r *NodeReconciler) Generic(event.GenericEvent) bool {
+	return false
+}
+
+// addOrUpdateNode records the Node in the cache. If the capacity of the
+// topology domains might have grown, the workloads that didn't fit are
+// requeued. It returns whether the capacity of the Node changed.
+func (r *NodeReconciler) addOrUpdateNode(node *corev1.Node) bool {
+	if !r.cache.AddOrUpdateNode(node) {
+		return false
+	}
+	r.queueTopologyClusterQueues()
+	return true
+}
+
+// queueTopologyClusterQueues requeues the workloads that didn't fit in the
+// topology domains, as the capacity of the domains might have grown.
+func (r *NodeReconciler) queueTopologyClusterQueues() {
+	if cqNames := r.cache.ClusterQueuesUsingTopology(); len(cqNames) > 0 {
+		r.qManager.QueueInadmissibleWorkloads(context.Background(), cqNames)
+	}
+}
+
+// indirectControllers returns the UIDs of the controllers up the chain of
+// the Pod's controller. Some jobs create their Pods through a batch Job, like
+// the launcher of an MPIJob, while their workload is owned by the top-level
+// job.
+func (r *NodeReconciler) indirectControllers(pod *corev1.Pod) []types.UID {
+	var uids []types.UID
+	seen := sets.New(pod.UID)
+	owner := metav1.GetControllerOf(pod)
+	for owner != nil && owner.Kind == "Job" && owner.APIVersion == batchv1.SchemeGroupVersion.String() && !seen.Has(owner.UID) {
+		seen.Insert(owner.UID)
+		var job batchv1.Job
+		if err := r.client.Get(context.Background(), types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name}, &job); err != nil || job.UID != owner.UID {
+			break
+		}
+		if owner = metav1.GetControllerOf(&job); owner != nil {
+			uids = append(uids, owner.UID)
+		}
+	}
+	return uids
+}
+
+// NotifyResourceFlavorUpdate starts watching the Pods the first time that a
+// ResourceFlavor computes its capacity from the Nodes or has topology levels,
+// as only those need the requests of the Pods. Otherwise, the Pods of the
+// whole cluster would be cached for nothing.
+func (r *NodeReconciler) NotifyResourceFlavorUpdate(rf *kueue.ResourceFlavor) {
+	if !rf.Spec.CapacityFromNodes && len(rf.Spec.TopologyLevels) == 0 {
+		return
+	}
+	r.podsMu.Lock()
+	defer r.podsMu.Unlock()
+	if r.watchingPods || r.watchPods == nil {
+		return
+	}
+	if err := r.watchPods(); err != nil {
+		r.log.Error(err, "Failed to watch the Pods")
+		return
+	}
+	r.log.V(2).Info("Started watching the Pods", "resourceFlavor", klog.KObj(rf))
+	r.watchingPods = true
+}
+
+// flavorCapacityHandler enqueues a single request for all the Node and Pod
+// events, after a batch period, so that the capacity of the ResourceFlavors
+// is computed once for a burst of events.
+type flavorCapacityHandler struct{}
+
+var flavorCapacityRequest = reconcile.Request{}
+
+func (h *flavorCapacityHandler) Create(_ event.CreateEvent, q workqueue.RateLimitingInterface) {
+	q.AddAfter(flavorCapacityRequest, constants.UpdatesBatchPeriod)
+}
+
+func (h *flavorCapacityHandler) Update(_ event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	q.AddAfter(flavorCapacityRequest, constants.UpdatesBatchPeriod)
+}
+
+func (h *flavorCapacityHandler) Delete(_ event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	q.AddAfter(flavorCapacityRequest, constants.UpdatesBatchPeriod)
+}
+
+func (h *flavorCapacityHandler) Generic(event.GenericEvent, workqueue.RateLimitingInterface) {
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	handler := flavorCapacityHandler{}
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		Named("node").
+		Watches(&source.Kind{Type: &corev1.Node{}}, &handler).
+		WithEventFilter(r).
+		Build(r)
+	if err != nil {
+		return err
+	}
+	r.podsMu.Lock()
+	defer r.podsMu.Unlock()
+	r.watchPods = func() error {
+		return c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler, r)
+	}
+	return nil
+}
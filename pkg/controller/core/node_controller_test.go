@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/kueue/pkg/cache"
+	"sigs.k8s.io/kueue/pkg/queue"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+	testingjob "sigs.k8s.io/kueue/pkg/util/testingjobs/job"
+	testingpod "sigs.k8s.io/kueue/pkg/util/testingjobs/pod"
+)
+
+func TestIndirectControllers(t *testing.T) {
+	mpiJobGVK := schema.GroupVersionKind{Group: "kubeflow.org", Version: "v2beta1", Kind: "MPIJob"}
+	jobGVK := batchv1.SchemeGroupVersion.WithKind("Job")
+	launcherJob := testingjob.MakeJob("launcher", "ns").UID("launcher").Obj()
+	launcherJob.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: mpiJobGVK.GroupVersion().String(),
+		Kind:       mpiJobGVK.Kind,
+		Name:       "mpijob",
+		UID:        "mpijob",
+		Controller: pointer.Bool(true),
+	}}
+	cl := utiltesting.NewClientBuilder(batchv1.AddToScheme).WithObjects(launcherJob).Build()
+	cqCache := cache.New(cl)
+	r := NewNodeReconciler(cl, queue.NewManager(cl, cqCache), cqCache)
+
+	cases := map[string]struct {
+		pod  *corev1.Pod
+		want []types.UID
+	}{
+		"pod without controller": {
+			pod: testingpod.MakePod("pod", "ns").Obj(),
+		},
+		"pod controlled by a job": {
+			pod: testingpod.MakePod("pod", "ns").OwnerReference("mpijob", mpiJobGVK, true).Obj(),
+		},
+		"pod controlled by a batch job of another job": {
+			pod:  testingpod.MakePod("pod", "ns").OwnerReference("launcher", jobGVK, true).Obj(),
+			want: []types.UID{"mpijob"},
+		},
+		"pod controlled by a missing batch job": {
+			pod: testingpod.MakePod("pod", "ns").OwnerReference("missing", jobGVK, true).Obj(),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, r.indirectControllers(tc.pod)); diff != "" {
+				t.Errorf("Unexpected indirect controllers (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNotifyResourceFlavorUpdateWatchesPods(t *testing.T) {
+	cl := utiltesting.NewFakeClient()
+	cqCache := cache.New(cl)
+	r := NewNodeReconciler(cl, queue.NewManager(cl, cqCache), cqCache)
+	watches := 0
+	r.watchPods = func() error {
+		watches++
+		return nil
+	}
+
+	r.NotifyResourceFlavorUpdate(utiltesting.MakeResourceFlavor("default").Obj())
+	if watches != 0 {
+		t.Errorf("Pods watched for a flavor that doesn't need them")
+	}
+	r.NotifyResourceFlavorUpdate(utiltesting.MakeResourceFlavor("on-demand").CapacityFromNodes().Obj())
+	r.NotifyResourceFlavorUpdate(utiltesting.MakeResourceFlavor("tas").TopologyLevels("rack").Obj())
+	if watches != 1 {
+		t.Errorf("Pods watched %d times, want 1", watches)
+	}
+}
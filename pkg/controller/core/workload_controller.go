@@ -33,22 +33,26 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	"sigs.k8s.io/kueue/pkg/cache"
+	"sigs.k8s.io/kueue/pkg/constants"
 	"sigs.k8s.io/kueue/pkg/controller/core/indexer"
 	"sigs.k8s.io/kueue/pkg/queue"
 	"sigs.k8s.io/kueue/pkg/util/limitrange"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
 	"sigs.k8s.io/kueue/pkg/util/resource"
 	"sigs.k8s.io/kueue/pkg/workload"
 )
 
 const (
 	// statuses for logging purposes
-	pending  = "pending"
-	admitted = "admitted"
-	finished = "finished"
+	pending       = "pending"
+	quotaReserved = "quotaReserved"
+	admitted      = "admitted"
+	finished      = "finished"
 )
 
 var (
@@ -56,8 +60,11 @@ var (
 )
 
 type options struct {
-	watchers         []WorkloadUpdateWatcher
-	podsReadyTimeout *time.Duration
+	watchers                   []WorkloadUpdateWatcher
+	podsReadyTimeout           *time.Duration
+	requeuingBackoffBase       time.Duration
+	requeuingBackoffMax        time.Duration
+	requeuingBackoffLimitCount *int32
 }
 
 // Option configures the reconciler.
@@ -71,6 +78,18 @@ func WithPodsReadyTimeout(value *time.Duration) Option {
 	}
 }
 
+// WithRequeuingBackoff configures the exponential backoff applied before
+// requeuing a workload evicted by the PodsReady timeout, and the number of
+// times it can be requeued before it's deactivated. A zero base disables the
+// backoff and a nil limitCount allows to requeue the workload indefinitely.
+func WithRequeuingBackoff(base, max time.Duration, limitCount *int32) Option {
+	return func(o *options) {
+		o.requeuingBackoffBase = base
+		o.requeuingBackoffMax = max
+		o.requeuingBackoffLimitCount = limitCount
+	}
+}
+
 // WithWorkloadUpdateWatchers allows to specify the workload update watchers
 func WithWorkloadUpdateWatchers(value ...WorkloadUpdateWatcher) Option {
 	return func(o *options) {
@@ -92,6 +111,10 @@ type WorkloadReconciler struct {
 	client           client.Client
 	watchers         []WorkloadUpdateWatcher
 	podsReadyTimeout *time.Duration
+
+	requeuingBackoffBase       time.Duration
+	requeuingBackoffMax        time.Duration
+	requeuingBackoffLimitCount *int32
 }
 
 func NewWorkloadReconciler(client client.Client, queues *queue.Manager, cache *cache.Cache, opts ...Option) *WorkloadReconciler {
@@ -107,6 +130,10 @@ func NewWorkloadReconciler(client client.Client, queues *queue.Manager, cache *c
 		cache:            cache,
 		watchers:         options.watchers,
 		podsReadyTimeout: options.podsReadyTimeout,
+
+		requeuingBackoffBase:       options.requeuingBackoffBase,
+		requeuingBackoffMax:        options.requeuingBackoffMax,
+		requeuingBackoffLimitCount: options.requeuingBackoffLimitCount,
 	}
 }
 
@@ -130,7 +157,22 @@ func (r *WorkloadReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	if apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadFinished) {
 		return ctrl.Result{}, nil
 	}
-	if apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadAdmitted) {
+	if !workload.IsActive(&wl) {
+		return ctrl.Result{}, r.reconcileInactive(ctx, &wl)
+	}
+	if apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadDeactivated) {
+		return ctrl.Result{}, r.reconcileReactivated(ctx, &wl)
+	}
+	if workload.HasQuotaReservation(&wl) {
+		if wl.Status.Admission != nil && r.cache.ClusterQueueDraining(string(wl.Status.Admission.ClusterQueue)) {
+			log.V(2).Info("Cancelling admission of the workload because its ClusterQueue is stopped")
+			err := workload.Evict(ctx, r.client, &wl,
+				kueue.WorkloadEvictedByClusterQueueStopped, fmt.Sprintf("The ClusterQueue %s is stopped", wl.Status.Admission.ClusterQueue))
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		if !workload.IsAdmitted(&wl) {
+			return ctrl.Result{}, r.reconcileAdmissionChecks(ctx, &wl)
+		}
 		return r.reconcileNotReadyTimeout(ctx, req, &wl)
 	}
 
@@ -155,9 +197,101 @@ func (r *WorkloadReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			"Inadmissible", fmt.Sprintf("ClusterQueue %s is inactive", cqName))
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+
+	if wl.Status.RequeueState != nil && wl.Status.RequeueState.RequeueAt != nil {
+		if remaining := workload.RemainingRequeueBackoff(&wl, realClock.Now()); remaining > 0 {
+			log.V(3).Info("Workload is waiting for its requeuing backoff to expire", "requeueAt", wl.Status.RequeueState.RequeueAt)
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+		if r.queues.QueueWorkloadAfterBackoff(&wl) {
+			log.V(2).Info("Workload requeued after its requeuing backoff expired")
+		}
+	}
 	return ctrl.Result{}, nil
 }
 
+// reconcileInactive evicts the workload that has .spec.active set to false,
+// if it holds a quota reservation, and sets its Deactivated condition.
+func (r *WorkloadReconciler) reconcileInactive(ctx context.Context, wl *kueue.Workload) error {
+	log := ctrl.LoggerFrom(ctx)
+	if workload.HasQuotaReservation(wl) {
+		log.V(2).Info("Cancelling admission of the workload because it's deactivated")
+		err := workload.Evict(ctx, r.client, wl,
+			kueue.WorkloadEvictedByDeactivation, "The workload is deactivated")
+		return client.IgnoreNotFound(err)
+	}
+	if apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadDeactivated) {
+		return nil
+	}
+	log.V(2).Info("Workload deactivated")
+	err := workload.UpdateStatus(ctx, r.client, wl, kueue.WorkloadDeactivated, metav1.ConditionTrue,
+		kueue.WorkloadInactive, "The workload has .spec.active set to false", constants.AdmissionName)
+	return client.IgnoreNotFound(err)
+}
+
+// reconcileReactivated resets the requeue state of the workload that had
+// .spec.active set back to true, so that it gets the whole requeuing backoff
+// limit again, and sets its Deactivated condition to False.
+func (r *WorkloadReconciler) reconcileReactivated(ctx context.Context, wl *kueue.Workload) error {
+	log := ctrl.LoggerFrom(ctx)
+	log.V(2).Info("Workload reactivated")
+	if wl.Status.RequeueState != nil {
+		patch := client.MergeFrom(wl.DeepCopy())
+		wl.Status.RequeueState = nil
+		if err := r.client.Status().Patch(ctx, wl, patch); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+	}
+	err := workload.UpdateStatus(ctx, r.client, wl, kueue.WorkloadDeactivated, metav1.ConditionFalse,
+		kueue.WorkloadReactivated, "The workload has .spec.active set to true", constants.AdmissionName)
+	return client.IgnoreNotFound(err)
+}
+
+// reconcileAdmissionChecks syncs the admission checks of a workload that
+// reserved quota with the ones of its ClusterQueue. The quota reservation is
+// released if any check is in the Retry state, the workload is deactivated if
+// any check is in the Rejected state, and the workload is admitted once all of
+// them are Ready.
+func (r *WorkloadReconciler) reconcileAdmissionChecks(ctx context.Context, wl *kueue.Workload) error {
+	log := ctrl.LoggerFrom(ctx)
+	if wl.Status.Admission == nil {
+		return nil
+	}
+	checks, found := r.cache.AdmissionChecksForClusterQueue(string(wl.Status.Admission.ClusterQueue))
+	if !found {
+		return nil
+	}
+	changed := workload.SyncAdmissionChecks(wl, checks, metav1.Now())
+	if check := workload.FirstCheckInState(wl, kueue.CheckStateRejected); check != nil {
+		// Releasing the quota reservation would queue the workload again, only
+		// to be rejected once more, so the workload is deactivated instead.
+		log.V(2).Info("Deactivating the workload because an admission check rejected it", "admissionCheck", check.Name)
+		err := workload.Deactivate(ctx, r.client, wl, kueue.WorkloadAdmissionCheckRejected,
+			fmt.Sprintf("The admission check %s rejected the workload: %s", check.Name, check.Message))
+		return client.IgnoreNotFound(err)
+	}
+	if check := workload.FirstCheckInState(wl, kueue.CheckStateRetry); check != nil {
+		log.V(2).Info("Releasing the quota reservation of the workload because of an admission check", "admissionCheck", check.Name, "state", check.State)
+		err := workload.UnsetAdmissionWithCondition(ctx, r.client, wl,
+			"AdmissionCheck", fmt.Sprintf("The admission check %s is in state %s: %s", check.Name, check.State, check.Message))
+		return client.IgnoreNotFound(err)
+	}
+	if workload.HasAllChecksReady(wl) {
+		changed = true
+		apimeta.SetStatusCondition(&wl.Status.Conditions, metav1.Condition{
+			Type:    kueue.WorkloadAdmitted,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Admitted",
+			Message: "The workload is admitted",
+		})
+		log.V(2).Info("All the admission checks of the workload are ready")
+	}
+	if !changed {
+		return nil
+	}
+	return client.IgnoreNotFound(workload.ApplyAdmissionStatus(ctx, r.client, wl))
+}
+
 func (r *WorkloadReconciler) reconcileNotReadyTimeout(ctx context.Context, req ctrl.Request, wl *kueue.Workload) (ctrl.Result, error) {
 	countingTowardsTimeout, recheckAfter := r.admittedNotReadyWorkload(wl, realClock)
 	if !countingTowardsTimeout {
@@ -166,14 +300,45 @@ func (r *WorkloadReconciler) reconcileNotReadyTimeout(ctx context.Context, req c
 	if recheckAfter > 0 {
 		klog.V(4).InfoS("Workload not yet ready and did not exceed its timeout", "workload", req.NamespacedName.String(), "recheckAfter", recheckAfter)
 		return ctrl.Result{RequeueAfter: recheckAfter}, nil
+	} else if r.requeuingBackoffLimitCount != nil && workload.RequeueCount(wl) >= *r.requeuingBackoffLimitCount {
+		klog.V(2).InfoS("Deactivating the workload due to exceeding the PodsReady timeout more times than the requeuing limit", "workload", req.NamespacedName.String(), "requeueCount", workload.RequeueCount(wl))
+		err := workload.Deactivate(ctx, r.client, wl, kueue.WorkloadRequeuingLimitExceeded,
+			fmt.Sprintf("The workload exceeded the PodsReady timeout after being requeued %d times", workload.RequeueCount(wl)))
+		return ctrl.Result{}, client.IgnoreNotFound(err)
 	} else {
 		klog.V(2).InfoS("Cancelling admission of the workload due to exceeding the PodsReady timeout", "workload", req.NamespacedName.String())
-		err := workload.UnsetAdmissionWithCondition(ctx, r.client, wl,
-			"Evicted", fmt.Sprintf("Exceeded the PodsReady timeout %s", req.NamespacedName.String()))
+		patch := workload.EvictionPatch(wl, kueue.WorkloadEvictedByPodsReadyTimeout, fmt.Sprintf("Exceeded the PodsReady timeout %s", req.NamespacedName.String()))
+		// Use resourceVersion to avoid overriding admissions by the scheduler that
+		// happen in a different routine.
+		patch.ResourceVersion = wl.ResourceVersion
+		patch.Status.RequeueState = r.nextRequeueState(wl, realClock.Now())
+		err := workload.ApplyUnsetAdmission(ctx, r.client, patch)
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 }
 
+// nextRequeueState returns the requeue state of the workload after being
+// evicted once more. The workload is eligible to be requeued after an
+// exponential backoff of base*2^(n-1), capped at max, where n is the number of
+// times it was requeued, including this one. A zero max disables the
+// exponential growth.
+func (r *WorkloadReconciler) nextRequeueState(wl *kueue.Workload, now time.Time) *kueue.RequeueState {
+	count := workload.RequeueCount(wl) + 1
+	state := &kueue.RequeueState{Count: pointer.Int32(count)}
+	if r.requeuingBackoffBase <= 0 {
+		return state
+	}
+	backoff := r.requeuingBackoffBase
+	for i := int32(1); i < count && backoff < r.requeuingBackoffMax; i++ {
+		backoff *= 2
+	}
+	if r.requeuingBackoffMax > 0 && backoff > r.requeuingBackoffMax {
+		backoff = r.requeuingBackoffMax
+	}
+	state.RequeueAt = &metav1.Time{Time: now.Add(backoff)}
+	return state
+}
+
 func (r *WorkloadReconciler) Create(e event.CreateEvent) bool {
 	wl, isWorkload := e.Object.(*kueue.Workload)
 	if !isWorkload {
@@ -193,6 +358,10 @@ func (r *WorkloadReconciler) Create(e event.CreateEvent) bool {
 	r.adjustResources(log, wlCopy)
 
 	if wl.Status.Admission == nil {
+		if !workload.IsActive(wl) {
+			log.V(2).Info("Workload is deactivated; not queued")
+			return true
+		}
 		if !r.queues.AddOrUpdateWorkload(wlCopy) {
 			log.V(2).Info("Queue for workload didn't exist; ignored for now")
 		}
@@ -288,22 +457,25 @@ func (r *WorkloadReconciler) Update(e event.UpdateEvent) bool {
 			// Delete the workload from cache while holding the queues lock
 			// to guarantee that requeueued workloads are taken into account before
 			// the next scheduling cycle.
-			if err := r.cache.DeleteWorkload(oldWl); err != nil && prevStatus == admitted {
+			if err := r.cache.DeleteWorkload(oldWl); err != nil && (prevStatus == quotaReserved || prevStatus == admitted) {
 				log.Error(err, "Failed to delete workload from cache")
 			}
 		})
 
+	case prevStatus == pending && status == pending && !workload.IsActive(wl):
+		r.queues.DeleteWorkload(oldWl)
+
 	case prevStatus == pending && status == pending:
 		if !r.queues.UpdateWorkload(oldWl, wlCopy) {
 			log.V(2).Info("Queue for updated workload didn't exist; ignoring for now")
 		}
 
-	case prevStatus == pending && status == admitted:
+	case prevStatus == pending && (status == quotaReserved || status == admitted):
 		r.queues.DeleteWorkload(oldWl)
 		if !r.cache.AddOrUpdateWorkload(wlCopy) {
 			log.V(2).Info("ClusterQueue for workload didn't exist; ignored for now")
 		}
-	case prevStatus == admitted && status == pending:
+	case (prevStatus == quotaReserved || prevStatus == admitted) && status == pending:
 		// trigger the move of associated inadmissibleWorkloads, if there are any.
 		r.queues.QueueAssociatedInadmissibleWorkloadsAfter(ctx, wl, func() {
 			// Delete the workload from cache while holding the queues lock
@@ -313,15 +485,26 @@ func (r *WorkloadReconciler) Update(e event.UpdateEvent) bool {
 				log.Error(err, "Failed to delete workload from cache")
 			}
 		})
-		if !r.queues.AddOrUpdateWorkload(wlCopy) {
+		if !workload.IsActive(wl) {
+			log.V(2).Info("Workload is deactivated; not queued")
+		} else if !r.queues.AddOrUpdateWorkload(wlCopy) {
 			log.V(2).Info("Queue for workload didn't exist; ignored for now")
 		}
 
 	default:
 		// Workload update in the cache is handled here; however, some fields are immutable
 		// and are not supposed to actually change anything.
-		if err := r.cache.UpdateWorkload(oldWl, wlCopy); err != nil {
-			log.Error(err, "Updating workload in cache")
+		updateCache := func() {
+			if err := r.cache.UpdateWorkload(oldWl, wlCopy); err != nil {
+				log.Error(err, "Updating workload in cache")
+			}
+		}
+		if workload.HasQuotaReservation(wl) && !workload.ReclaimablePodsAreEqual(oldWl.Status.ReclaimablePods, wl.Status.ReclaimablePods) {
+			// The quota of the reclaimable pods is released, which might make
+			// room for the inadmissible workloads in the cohort.
+			r.queues.QueueAssociatedInadmissibleWorkloadsAfter(ctx, wl, updateCache)
+		} else {
+			updateCache()
 		}
 	}
 
@@ -348,6 +531,7 @@ func (r *WorkloadReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&kueue.Workload{}).
 		Watches(&source.Kind{Type: &corev1.LimitRange{}}, ruh).
 		Watches(&source.Kind{Type: &nodev1.RuntimeClass{}}, ruh).
+		Watches(&source.Kind{Type: &kueue.ClusterQueue{}}, &workloadCqHandler{client: r.client}).
 		WithEventFilter(r).
 		Complete(r)
 }
@@ -391,9 +575,12 @@ func workloadStatus(w *kueue.Workload) string {
 	if apimeta.IsStatusConditionTrue(w.Status.Conditions, kueue.WorkloadFinished) {
 		return finished
 	}
-	if apimeta.IsStatusConditionTrue(w.Status.Conditions, kueue.WorkloadAdmitted) {
+	if workload.IsAdmitted(w) {
 		return admitted
 	}
+	if workload.HasQuotaReservation(w) {
+		return quotaReserved
+	}
 	return pending
 }
 
@@ -523,7 +710,7 @@ func (h *resourceUpdatesHandler) handle(ctx context.Context, obj client.Object,
 func (h *resourceUpdatesHandler) queueReconcileForPending(ctx context.Context, q workqueue.RateLimitingInterface, opts ...client.ListOption) {
 	log := ctrl.LoggerFrom(ctx)
 	lst := kueue.WorkloadList{}
-	opts = append(opts, client.MatchingFields{indexer.WorkloadAdmittedKey: string(metav1.ConditionFalse)})
+	opts = append(opts, client.MatchingFields{indexer.WorkloadQuotaReservedKey: string(metav1.ConditionFalse)})
 	err := h.r.client.List(ctx, &lst, opts...)
 	if err != nil {
 		log.Error(err, "Could not list pending workloads")
@@ -539,3 +726,45 @@ func (h *resourceUpdatesHandler) queueReconcileForPending(ctx context.Context, q
 		}
 	}
 }
+
+// workloadCqHandler signals the controller to reconcile the workloads admitted
+// by a ClusterQueue when it starts draining.
+type workloadCqHandler struct {
+	client client.Client
+}
+
+func (h *workloadCqHandler) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+	if cq, ok := e.Object.(*kueue.ClusterQueue); ok && stopPolicy(cq) == kueue.HoldAndDrain {
+		h.queueReconcileForAdmitted(cq, q)
+	}
+}
+
+func (h *workloadCqHandler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	oldCq, oldOk := e.ObjectOld.(*kueue.ClusterQueue)
+	newCq, newOk := e.ObjectNew.(*kueue.ClusterQueue)
+	if oldOk && newOk && stopPolicy(oldCq) != kueue.HoldAndDrain && stopPolicy(newCq) == kueue.HoldAndDrain {
+		h.queueReconcileForAdmitted(newCq, q)
+	}
+}
+
+func (h *workloadCqHandler) Delete(event.DeleteEvent, workqueue.RateLimitingInterface) {
+}
+
+func (h *workloadCqHandler) Generic(event.GenericEvent, workqueue.RateLimitingInterface) {
+}
+
+func (h *workloadCqHandler) queueReconcileForAdmitted(cq *kueue.ClusterQueue, q workqueue.RateLimitingInterface) {
+	//TODO: the eventHandler should get a context soon, and this could be dropped
+	// https://github.com/kubernetes-sigs/controller-runtime/blob/master/pkg/handler/eventhandler.go
+	ctx := context.TODO()
+	log := ctrl.LoggerFrom(ctx).WithValues("clusterQueue", klog.KObj(cq))
+	var lst kueue.WorkloadList
+	if err := h.client.List(ctx, &lst, client.MatchingFields{indexer.WorkloadClusterQueueKey: cq.Name}); err != nil {
+		log.Error(err, "Could not list admitted workloads")
+		return
+	}
+	log.V(4).Info("Queueing reconcile for the workloads of a draining ClusterQueue", "count", len(lst.Items))
+	for i := range lst.Items {
+		q.Add(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&lst.Items[i])})
+	}
+}
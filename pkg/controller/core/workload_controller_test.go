@@ -17,14 +17,25 @@ limitations under the License.
 package core
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	testingclock "k8s.io/utils/clock/testing"
 	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/cache"
+	"sigs.k8s.io/kueue/pkg/queue"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
 )
 
 func TestAdmittedNotReadyWorkload(t *testing.T) {
@@ -191,3 +202,319 @@ func TestAdmittedNotReadyWorkload(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcile(t *testing.T) {
+	admittedWorkload := func() *utiltesting.WorkloadWrapper {
+		return utiltesting.MakeWorkload("wl", "ns").
+			Queue("queue").
+			Request(corev1.ResourceCPU, "1").
+			Admit(utiltesting.MakeAdmission("cq").Assignment(corev1.ResourceCPU, "default", "1").Obj()).
+			Condition(metav1.Condition{
+				Type:   kueue.WorkloadAdmitted,
+				Status: metav1.ConditionTrue,
+				Reason: "Admitted",
+			})
+	}
+	testCases := map[string]struct {
+		clusterQueue *kueue.ClusterQueue
+		workload     *kueue.Workload
+		// wantConditions are the conditions that the workload has after the
+		// reconciliation, ignoring their transition time.
+		wantConditions []metav1.Condition
+		// wantNoConditions are the condition types that the workload
+		// doesn't have after the reconciliation.
+		wantNoConditions []string
+		wantRequeueState *kueue.RequeueState
+	}{
+		"admitted workload in a ClusterQueue with HoldAndDrain is evicted": {
+			clusterQueue: utiltesting.MakeClusterQueue("cq").StopPolicy(kueue.HoldAndDrain).Obj(),
+			workload:     admittedWorkload().Obj(),
+			wantConditions: []metav1.Condition{{
+				Type:    kueue.WorkloadAdmitted,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Evicted",
+				Message: "The ClusterQueue cq is stopped",
+			}},
+		},
+		"admitted workload in a ClusterQueue with Hold keeps running": {
+			clusterQueue: utiltesting.MakeClusterQueue("cq").StopPolicy(kueue.Hold).Obj(),
+			workload:     admittedWorkload().Obj(),
+			wantConditions: []metav1.Condition{{
+				Type:   kueue.WorkloadAdmitted,
+				Status: metav1.ConditionTrue,
+				Reason: "Admitted",
+			}},
+		},
+		"pending workload in a ClusterQueue with Hold is inadmissible": {
+			clusterQueue: utiltesting.MakeClusterQueue("cq").StopPolicy(kueue.Hold).Obj(),
+			workload:     utiltesting.MakeWorkload("wl", "ns").Queue("queue").Request(corev1.ResourceCPU, "1").Obj(),
+			wantConditions: []metav1.Condition{{
+				Type:    kueue.WorkloadAdmitted,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Inadmissible",
+				Message: "ClusterQueue cq is inactive",
+			}},
+		},
+		"pending workload in a ClusterQueue without stopPolicy waits for admission": {
+			clusterQueue:     utiltesting.MakeClusterQueue("cq").StopPolicy(kueue.None).Obj(),
+			workload:         utiltesting.MakeWorkload("wl", "ns").Queue("queue").Request(corev1.ResourceCPU, "1").Obj(),
+			wantNoConditions: []string{kueue.WorkloadAdmitted},
+		},
+		"admitted inactive workload is evicted": {
+			clusterQueue: utiltesting.MakeClusterQueue("cq").Obj(),
+			workload:     admittedWorkload().Active(false).Obj(),
+			wantConditions: []metav1.Condition{{
+				Type:    kueue.WorkloadEvicted,
+				Status:  metav1.ConditionTrue,
+				Reason:  kueue.WorkloadEvictedByDeactivation,
+				Message: "The workload is deactivated",
+			}},
+		},
+		"pending inactive workload is deactivated": {
+			clusterQueue: utiltesting.MakeClusterQueue("cq").Obj(),
+			workload:     utiltesting.MakeWorkload("wl", "ns").Queue("queue").Active(false).Obj(),
+			wantConditions: []metav1.Condition{{
+				Type:    kueue.WorkloadDeactivated,
+				Status:  metav1.ConditionTrue,
+				Reason:  kueue.WorkloadInactive,
+				Message: "The workload has .spec.active set to false",
+			}},
+			wantNoConditions: []string{kueue.WorkloadEvicted},
+		},
+		"reactivated workload resets its requeue state": {
+			clusterQueue: utiltesting.MakeClusterQueue("cq").Obj(),
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				Queue("queue").
+				Active(true).
+				RequeueState(3, time.Now().Add(time.Hour)).
+				Condition(metav1.Condition{
+					Type:   kueue.WorkloadDeactivated,
+					Status: metav1.ConditionTrue,
+					Reason: kueue.WorkloadRequeuingLimitExceeded,
+				}).
+				Obj(),
+			wantConditions: []metav1.Condition{{
+				Type:    kueue.WorkloadDeactivated,
+				Status:  metav1.ConditionFalse,
+				Reason:  kueue.WorkloadReactivated,
+				Message: "The workload has .spec.active set to true",
+			}},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			reconciler, cl, _ := newTestReconciler(ctx, t, tc.clusterQueue, tc.workload)
+
+			key := client.ObjectKeyFromObject(tc.workload)
+			if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+			var gotWorkload kueue.Workload
+			if err := cl.Get(ctx, key, &gotWorkload); err != nil {
+				t.Fatalf("Getting the workload: %v", err)
+			}
+			for _, want := range tc.wantConditions {
+				got := apimeta.FindStatusCondition(gotWorkload.Status.Conditions, want.Type)
+				if diff := cmp.Diff(&want, got, cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")); diff != "" {
+					t.Errorf("Unexpected %s condition (-want,+got):\n%s", want.Type, diff)
+				}
+			}
+			for _, condType := range tc.wantNoConditions {
+				if cond := apimeta.FindStatusCondition(gotWorkload.Status.Conditions, condType); cond != nil {
+					t.Errorf("Unexpected %s condition: %v", condType, cond)
+				}
+			}
+			if diff := cmp.Diff(tc.wantRequeueState, gotWorkload.Status.RequeueState); diff != "" {
+				t.Errorf("Unexpected requeue state (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNextRequeueState(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	testCases := map[string]struct {
+		requeueState *kueue.RequeueState
+		backoffBase  time.Duration
+		backoffMax   time.Duration
+		want         *kueue.RequeueState
+	}{
+		"first eviction": {
+			backoffBase: time.Minute,
+			backoffMax:  time.Hour,
+			want: &kueue.RequeueState{
+				Count:     pointer.Int32(1),
+				RequeueAt: &metav1.Time{Time: now.Add(time.Minute)},
+			},
+		},
+		"third eviction": {
+			requeueState: &kueue.RequeueState{
+				Count:     pointer.Int32(2),
+				RequeueAt: &metav1.Time{Time: now.Add(-time.Hour)},
+			},
+			backoffBase: time.Minute,
+			backoffMax:  time.Hour,
+			want: &kueue.RequeueState{
+				Count:     pointer.Int32(3),
+				RequeueAt: &metav1.Time{Time: now.Add(4 * time.Minute)},
+			},
+		},
+		"backoff capped at the max": {
+			requeueState: &kueue.RequeueState{
+				Count: pointer.Int32(10),
+			},
+			backoffBase: time.Minute,
+			backoffMax:  time.Hour,
+			want: &kueue.RequeueState{
+				Count:     pointer.Int32(11),
+				RequeueAt: &metav1.Time{Time: now.Add(time.Hour)},
+			},
+		},
+		"backoff disabled": {
+			requeueState: &kueue.RequeueState{
+				Count: pointer.Int32(1),
+			},
+			want: &kueue.RequeueState{
+				Count: pointer.Int32(2),
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			wRec := WorkloadReconciler{
+				requeuingBackoffBase: tc.backoffBase,
+				requeuingBackoffMax:  tc.backoffMax,
+			}
+			wl := &kueue.Workload{
+				Status: kueue.WorkloadStatus{
+					RequeueState: tc.requeueState,
+				},
+			}
+			got := wRec.nextRequeueState(wl, now)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unexpected requeue state (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestReconcileRejectedAdmissionCheck tests that a workload with a Rejected
+// admission check is deactivated and evicted, and that it's not queued again
+// once its quota reservation is released.
+func TestReconcileRejectedAdmissionCheck(t *testing.T) {
+	ctx := context.Background()
+	cq := utiltesting.MakeClusterQueue("cq").AdmissionChecks("check").Obj()
+	wl := utiltesting.MakeWorkload("wl", "ns").
+		Queue("queue").
+		Request(corev1.ResourceCPU, "1").
+		Admit(utiltesting.MakeAdmission("cq").Assignment(corev1.ResourceCPU, "default", "1").Obj()).
+		Condition(metav1.Condition{
+			Type:               kueue.WorkloadQuotaReserved,
+			Status:             metav1.ConditionTrue,
+			Reason:             "QuotaReserved",
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Minute)),
+		}).
+		AdmissionCheck("check", kueue.CheckStateRejected).
+		Obj()
+	wl.Status.AdmissionChecks[0].LastTransitionTime = metav1.Now()
+	reconciler, cl, qManager := newTestReconciler(ctx, t, cq, wl)
+	key := client.ObjectKeyFromObject(wl)
+	ignoreTime := cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")
+
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	var gotWorkload kueue.Workload
+	if err := cl.Get(ctx, key, &gotWorkload); err != nil {
+		t.Fatalf("Getting the workload: %v", err)
+	}
+	if gotWorkload.Spec.Active == nil || *gotWorkload.Spec.Active {
+		t.Errorf("Workload with a rejected admission check has .spec.active=%v, want false", gotWorkload.Spec.Active)
+	}
+	wantDeactivated := &metav1.Condition{
+		Type:    kueue.WorkloadDeactivated,
+		Status:  metav1.ConditionTrue,
+		Reason:  kueue.WorkloadAdmissionCheckRejected,
+		Message: "The admission check check rejected the workload: ",
+	}
+	if diff := cmp.Diff(wantDeactivated, apimeta.FindStatusCondition(gotWorkload.Status.Conditions, kueue.WorkloadDeactivated), ignoreTime); diff != "" {
+		t.Errorf("Unexpected Deactivated condition (-want,+got):\n%s", diff)
+	}
+
+	// The fake client replaces the conditions on apply, instead of merging
+	// them by type.
+	gotWorkload.Status.Conditions = append(gotWorkload.Status.Conditions, wl.Status.Conditions...)
+	if err := cl.Status().Update(ctx, &gotWorkload); err != nil {
+		t.Fatalf("Restoring the conditions of the workload: %v", err)
+	}
+
+	// The inactive workload is evicted.
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if err := cl.Get(ctx, key, &gotWorkload); err != nil {
+		t.Fatalf("Getting the workload: %v", err)
+	}
+	wantEvicted := &metav1.Condition{
+		Type:    kueue.WorkloadEvicted,
+		Status:  metav1.ConditionTrue,
+		Reason:  kueue.WorkloadEvictedByDeactivation,
+		Message: "The workload is deactivated",
+	}
+	if diff := cmp.Diff(wantEvicted, apimeta.FindStatusCondition(gotWorkload.Status.Conditions, kueue.WorkloadEvicted), ignoreTime); diff != "" {
+		t.Errorf("Unexpected Evicted condition (-want,+got):\n%s", diff)
+	}
+
+	// The workload is not queued again once its quota reservation is released.
+	released := gotWorkload.DeepCopy()
+	released.Status.Admission = nil
+	apimeta.SetStatusCondition(&released.Status.Conditions, metav1.Condition{
+		Type:   kueue.WorkloadQuotaReserved,
+		Status: metav1.ConditionFalse,
+		Reason: "Pending",
+	})
+	reconciler.Update(event.UpdateEvent{ObjectOld: &gotWorkload, ObjectNew: released})
+	if dump := qManager.Dump(); dump != nil {
+		t.Errorf("Workload with a rejected admission check was queued: %v", dump)
+	}
+	if dump := qManager.DumpInadmissible(); dump != nil {
+		t.Errorf("Workload with a rejected admission check was queued as inadmissible: %v", dump)
+	}
+}
+
+// newTestReconciler returns a WorkloadReconciler with a fake client that has
+// the ClusterQueue, the LocalQueue "queue" in the namespace "ns" pointing to
+// it, and the given workloads.
+func newTestReconciler(ctx context.Context, t *testing.T, cq *kueue.ClusterQueue, workloads ...*kueue.Workload) (*WorkloadReconciler, client.Client, *queue.Manager) {
+	t.Helper()
+	localQueue := utiltesting.MakeLocalQueue("queue", "ns").ClusterQueue(cq.Name).Obj()
+	builder := utiltesting.NewClientBuilder().
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}},
+			cq,
+			localQueue,
+		)
+	for _, wl := range workloads {
+		builder = builder.WithObjects(wl)
+	}
+	cl := builder.Build()
+	cqCache := cache.New(cl)
+	qManager := queue.NewManager(cl, cqCache)
+	cqCache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+	for _, check := range cq.Spec.AdmissionChecks {
+		cqCache.AddOrUpdateAdmissionCheck(utiltesting.MakeAdmissionCheck(check, "controller").Active(metav1.ConditionTrue).Obj())
+	}
+	if err := cqCache.AddClusterQueue(ctx, cq); err != nil {
+		t.Fatalf("Inserting ClusterQueue in the cache: %v", err)
+	}
+	if err := qManager.AddClusterQueue(ctx, cq); err != nil {
+		t.Fatalf("Inserting ClusterQueue in the queue manager: %v", err)
+	}
+	if err := qManager.AddLocalQueue(ctx, localQueue); err != nil {
+		t.Fatalf("Inserting LocalQueue in the queue manager: %v", err)
+	}
+	return NewWorkloadReconciler(cl, qManager, cqCache), cl, qManager
+}
@@ -34,8 +34,20 @@ const (
 	ParentWorkloadAnnotation = "kueue.x-k8s.io/parent-workload"
 
 	// OriginalNodeSelectorsAnnotation is the annotation in which the original
-	// node selectors are recorded upon a workload admission. This information
-	// will be used to restore them when the job is suspended.
-	// The content is a json marshaled slice of selectors.
+	// node selectors and pod counts are recorded upon a workload admission.
+	// This information will be used to restore them when the job is suspended.
+	// The content is a json marshaled slice of PodSetInfo.
 	OriginalNodeSelectorsAnnotation = "kueue.x-k8s.io/original-node-selectors"
+
+	// PrebuiltWorkloadLabel is the label used to indicate that the Workload of
+	// a job was created beforehand. The value is the name of the Workload, in
+	// the same namespace. Kueue doesn't create a Workload for the job; it
+	// waits for the prebuilt one and makes the job its owner.
+	PrebuiltWorkloadLabel = "kueue.x-k8s.io/prebuilt-workload-name"
+
+	// WorkloadPriorityClassLabel is the label key in the job that holds the
+	// name of the WorkloadPriorityClass. When set, the priority of the
+	// workload is taken from the WorkloadPriorityClass instead of the
+	// PriorityClass of the job's pods.
+	WorkloadPriorityClassLabel = "kueue.x-k8s.io/priority-class"
 )
@@ -14,6 +14,8 @@ limitations under the License.
 package jobframework
 
 import (
+	"context"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -31,10 +33,10 @@ type GenericJob interface {
 	// ResetStatus will reset the job status to the original state.
 	// If true, status is modified, if not, status is as it was.
 	ResetStatus() bool
-	// RunWithNodeAffinity will inject the node affinity extracting from workload to job and unsuspend the job.
-	RunWithNodeAffinity(nodeSelectors []PodSetNodeSelector)
-	// RestoreNodeAffinity will restore the original node affinity of job.
-	RestoreNodeAffinity(nodeSelectors []PodSetNodeSelector)
+	// RunWithPodSetsInfo will inject the node affinity extracting from workload to job and unsuspend the job.
+	RunWithPodSetsInfo(nodeSelectors []PodSetInfo)
+	// RestorePodSetsInfo will restore the original node affinity of job.
+	RestorePodSetsInfo(nodeSelectors []PodSetInfo)
 	// Finished means whether the job is completed/failed or not,
 	// condition represents the workload finished condition.
 	Finished() (condition metav1.Condition, finished bool)
@@ -52,10 +54,39 @@ type GenericJob interface {
 	GetGVK() schema.GroupVersionKind
 }
 
+// JobWithReclaimablePods is an optional interface for jobs that can report
+// the pods that won't run again, so that their quota can be released before
+// the job finishes.
+type JobWithReclaimablePods interface {
+	// ReclaimablePods returns, per pod set, the number of pods whose quota
+	// is no longer needed.
+	ReclaimablePods() []kueue.ReclaimablePod
+}
+
+// JobWithCustomStop is an optional interface for jobs that can't be stopped
+// by suspending them and restoring their scheduling directives, such as
+// plain pods.
+type JobWithCustomStop interface {
+	// Stop stops the job, and returns whether it was stopped by this call.
+	Stop(ctx context.Context, c client.Client) (bool, error)
+}
+
 func ParentWorkloadName(job GenericJob) string {
 	return job.Object().GetAnnotations()[ParentWorkloadAnnotation]
 }
 
+// PrebuiltWorkloadName returns the name of the Workload created beforehand
+// for the job, if any.
+func PrebuiltWorkloadName(job GenericJob) string {
+	return job.Object().GetLabels()[PrebuiltWorkloadLabel]
+}
+
+// WorkloadPriorityClassName returns the name of the WorkloadPriorityClass
+// referenced by the job, if any.
+func WorkloadPriorityClassName(job GenericJob) string {
+	return job.Object().GetLabels()[WorkloadPriorityClassLabel]
+}
+
 func QueueName(job GenericJob) string {
 	if queueLabel := job.Object().GetLabels()[QueueLabel]; queueLabel != "" {
 		return queueLabel
@@ -23,9 +23,13 @@ import (
 )
 
 func KnownWorkloadOwner(owner *metav1.OwnerReference) bool {
-	return IsMPIJob(owner)
+	return IsMPIJob(owner) || IsJobSet(owner)
 }
 
 func IsMPIJob(owner *metav1.OwnerReference) bool {
 	return owner.Kind == "MPIJob" && strings.HasPrefix(owner.APIVersion, "kubeflow.org/v2")
 }
+
+func IsJobSet(owner *metav1.OwnerReference) bool {
+	return owner.Kind == "JobSet" && strings.HasPrefix(owner.APIVersion, "jobset.x-k8s.io/")
+}
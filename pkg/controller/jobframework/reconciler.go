@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -124,6 +125,21 @@ func (r *JobReconciler) ReconcileGenericJob(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
+	// 1.1 update the reclaimable pods, to release the quota of the pods
+	// that won't run again.
+	if wl != nil {
+		if jobRecl, implements := job.(JobWithReclaimablePods); implements {
+			if rp := jobRecl.ReclaimablePods(); !workload.ReclaimablePodsAreEqual(rp, wl.Status.ReclaimablePods) {
+				log.V(3).Info("Updating the reclaimable pods", "reclaimablePods", rp)
+				if err := workload.UpdateReclaimablePods(ctx, r.client, wl, rp); err != nil {
+					log.Error(err, "Updating reclaimable pods")
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{}, nil
+			}
+		}
+	}
+
 	// 2. handle job is finished.
 	if condition, finished := job.Finished(); finished {
 		if wl == nil || apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadFinished) {
@@ -141,6 +157,10 @@ func (r *JobReconciler) ReconcileGenericJob(ctx context.Context, req ctrl.Reques
 		if !isStandaloneJob {
 			return ctrl.Result{}, nil
 		}
+		if name := PrebuiltWorkloadName(job); name != "" {
+			log.V(3).Info("Waiting for the prebuilt workload", "workload", klog.KRef(object.GetNamespace(), name))
+			return ctrl.Result{}, nil
+		}
 		err := r.handleJobWithNoWorkload(ctx, job, object)
 		if err != nil {
 			log.Error(err, "Handling job with no workload")
@@ -168,10 +188,18 @@ func (r *JobReconciler) ReconcileGenericJob(ctx context.Context, req ctrl.Reques
 
 	// 5. handle job is suspended.
 	if job.IsSuspended() {
-		// start the job if the workload has been admitted, and the job is still suspended
-		if wl.Status.Admission != nil {
+		// start the job if the workload has been admitted and it's active, and the job is still suspended
+		if workload.IsAdmitted(wl) && workload.IsActive(wl) {
+			dispatched, err := r.dispatchedToWorkerCluster(ctx, wl)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if dispatched {
+				log.V(3).Info("Job is running in a worker cluster, keeping it suspended")
+				return ctrl.Result{}, nil
+			}
 			log.V(2).Info("Job admitted, unsuspending")
-			err := r.startJob(ctx, job, object, wl)
+			err = r.startJob(ctx, job, object, wl)
 			if err != nil {
 				log.Error(err, "Unsuspending job")
 			}
@@ -189,12 +217,31 @@ func (r *JobReconciler) ReconcileGenericJob(ctx context.Context, req ctrl.Reques
 			}
 			return ctrl.Result{}, err
 		}
-		log.V(3).Info("Job is suspended and workload not yet admitted by a clusterQueue, nothing to do")
+		log.V(3).Info("Job is suspended and workload not yet admitted by a clusterQueue or waiting for admission checks, nothing to do")
 		return ctrl.Result{}, nil
 	}
 
 	// 6. handle job is unsuspended.
-	if wl.Status.Admission == nil {
+	if !workload.IsActive(wl) {
+		// the job must be suspended if the workload was deactivated, even
+		// before the workload is evicted.
+		log.V(2).Info("The workload is deactivated, suspending the job")
+		err := r.stopJob(ctx, job, object, wl, "The workload is deactivated")
+		if err != nil {
+			log.Error(err, "Suspending job with deactivated workload")
+		}
+		return ctrl.Result{}, err
+	}
+	if evictedCond := apimeta.FindStatusCondition(wl.Status.Conditions, kueue.WorkloadEvicted); evictedCond != nil && evictedCond.Status == metav1.ConditionTrue {
+		// the job must be suspended if the workload was evicted.
+		log.V(2).Info("The workload is evicted, suspending the job", "reason", evictedCond.Reason)
+		err := r.stopJob(ctx, job, object, wl, fmt.Sprintf("Evicted (%s): %s", evictedCond.Reason, evictedCond.Message))
+		if err != nil {
+			log.Error(err, "Suspending job with evicted workload")
+		}
+		return ctrl.Result{}, err
+	}
+	if !workload.IsAdmitted(wl) {
 		// the job must be suspended if the workload is not yet admitted.
 		log.V(2).Info("Running job is not admitted by a cluster queue, suspending")
 		err := r.stopJob(ctx, job, object, wl, "Not admitted by cluster queue")
@@ -215,6 +262,10 @@ func (r *JobReconciler) ReconcileGenericJob(ctx context.Context, req ctrl.Reques
 func (r *JobReconciler) ensureOneWorkload(ctx context.Context, job GenericJob, object client.Object) (*kueue.Workload, error) {
 	log := ctrl.LoggerFrom(ctx)
 
+	if name := PrebuiltWorkloadName(job); name != "" {
+		return r.ensurePrebuiltWorkload(ctx, job, object, name)
+	}
+
 	// Find a matching workload first if there is one.
 	var toDelete []*kueue.Workload
 	var match *kueue.Workload
@@ -292,6 +343,79 @@ func (r *JobReconciler) ensureOneWorkload(ctx context.Context, job GenericJob, o
 	return match, nil
 }
 
+// ensurePrebuiltWorkload returns the prebuilt workload of the job, setting
+// the job as its controller if it doesn't have one yet. The returned workload
+// is nil if it doesn't exist yet, if it's controlled by another object or if
+// it doesn't match the job. In the latter case, the workload is marked as
+// finished, as it can't be recreated for the job. A running job without a
+// matching workload is stopped.
+func (r *JobReconciler) ensurePrebuiltWorkload(ctx context.Context, job GenericJob, object client.Object, name string) (*kueue.Workload, error) {
+	log := ctrl.LoggerFrom(ctx)
+	wl, err := r.prebuiltWorkload(ctx, job, object, name)
+	if err != nil || wl != nil {
+		return wl, err
+	}
+	if !job.IsSuspended() {
+		log.V(2).Info("job with no matching prebuilt workload, suspending")
+		if err := r.stopJob(ctx, job, object, nil, "No matching Workload"); err != nil {
+			log.Error(err, "stopping job")
+		}
+	}
+	return nil, nil
+}
+
+func (r *JobReconciler) prebuiltWorkload(ctx context.Context, job GenericJob, object client.Object, name string) (*kueue.Workload, error) {
+	log := ctrl.LoggerFrom(ctx)
+	wl := &kueue.Workload{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: name, Namespace: object.GetNamespace()}, wl); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	if owner := metav1.GetControllerOf(wl); owner == nil {
+		if err := ctrl.SetControllerReference(object, wl, r.scheme); err != nil {
+			return nil, err
+		}
+		if err := r.client.Update(ctx, wl); err != nil {
+			return nil, err
+		}
+	} else if !metav1.IsControlledBy(wl, object) {
+		log.V(2).Info("The prebuilt workload is controlled by another object", "workload", klog.KObj(wl), "controller", owner.Name)
+		return nil, nil
+	}
+	if !job.EquivalentToWorkload(*wl) {
+		if !apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadFinished) {
+			log.V(2).Info("The prebuilt workload doesn't match the job, finishing it", "workload", klog.KObj(wl))
+			err := workload.UpdateStatus(ctx, r.client, wl, kueue.WorkloadFinished, metav1.ConditionTrue,
+				"OutOfSync", "The prebuilt workload doesn't match its job", constants.JobControllerName)
+			if err != nil {
+				return nil, err
+			}
+			r.record.Eventf(object, corev1.EventTypeNormal, "FinishedWorkload",
+				"Finished not matching prebuilt Workload: %v", workload.Key(wl))
+		}
+		return nil, nil
+	}
+	return wl, nil
+}
+
+// dispatchedToWorkerCluster returns whether the workload has a MultiKueue
+// admission check. Such workloads run in a worker cluster and their jobs
+// are kept suspended in this cluster.
+func (r *JobReconciler) dispatchedToWorkerCluster(ctx context.Context, wl *kueue.Workload) (bool, error) {
+	for i := range wl.Status.AdmissionChecks {
+		var ac kueue.AdmissionCheck
+		if err := r.client.Get(ctx, types.NamespacedName{Name: wl.Status.AdmissionChecks[i].Name}, &ac); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if ac.Spec.ControllerName == kueue.MultiKueueControllerName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // equivalentToWorkload checks if the job corresponds to the workload
 func (r *JobReconciler) equivalentToWorkload(job GenericJob, object client.Object, wl *kueue.Workload) bool {
 	owner := metav1.GetControllerOf(wl)
@@ -303,19 +427,20 @@ func (r *JobReconciler) equivalentToWorkload(job GenericJob, object client.Objec
 	return job.EquivalentToWorkload(*wl)
 }
 
-// startJob will unsuspend the job, and also inject the node affinity.
+// startJob will unsuspend the job, and also inject the node affinity and
+// the admitted pod counts.
 func (r *JobReconciler) startJob(ctx context.Context, job GenericJob, object client.Object, wl *kueue.Workload) error {
-	//get the original selectors and store them in the job object
-	originalSelectors := r.getNodeSelectorsFromPodSets(wl)
-	if err := setNodeSelectorsInAnnotation(object, originalSelectors); err != nil {
-		return fmt.Errorf("startJob, record original node selectors: %w", err)
+	//get the original pod sets info and store it in the job object
+	originalInfo := r.getPodSetsInfoFromPodSets(wl)
+	if err := setPodSetsInfoInAnnotation(object, originalInfo); err != nil {
+		return fmt.Errorf("startJob, record original pod sets info: %w", err)
 	}
 
-	nodeSelectors, err := r.getNodeSelectorsFromAdmission(ctx, wl)
+	info, err := GetPodSetsInfoFromAdmission(ctx, r.client, wl)
 	if err != nil {
 		return err
 	}
-	job.RunWithNodeAffinity(nodeSelectors)
+	job.RunWithPodSetsInfo(info)
 
 	if err := r.client.Update(ctx, object); err != nil {
 		return err
@@ -330,6 +455,17 @@ func (r *JobReconciler) startJob(ctx context.Context, job GenericJob, object cli
 // stopJob will suspend the job, and also restore node affinity, reset job status if needed.
 func (r *JobReconciler) stopJob(ctx context.Context, job GenericJob, object client.Object, wl *kueue.Workload, eventMsg string) error {
 	log := ctrl.LoggerFrom(ctx)
+	if jws, implements := job.(JobWithCustomStop); implements {
+		stopped, err := jws.Stop(ctx, r.client)
+		if err != nil {
+			return err
+		}
+		if stopped {
+			r.record.Eventf(object, corev1.EventTypeNormal, "Stopped", eventMsg)
+		}
+		return nil
+	}
+
 	// Suspend the job at first then we're able to update the scheduling directives.
 	job.Suspend()
 
@@ -345,12 +481,12 @@ func (r *JobReconciler) stopJob(ctx context.Context, job GenericJob, object clie
 		}
 	}
 
-	log.V(3).Info("restore node selectors from annotation")
-	selectors, err := getNodeSelectorsFromObjectAnnotation(object)
+	log.V(3).Info("restore pod sets info from annotation")
+	info, err := getPodSetsInfoFromObjectAnnotation(object)
 	if err != nil {
-		log.V(3).Error(err, "Unable to get original node selectors")
+		log.V(3).Error(err, "Unable to get original pod sets info")
 	} else {
-		job.RestoreNodeAffinity(selectors)
+		job.RestorePodSetsInfo(info)
 		return r.client.Update(ctx, object)
 	}
 
@@ -370,13 +506,13 @@ func (r *JobReconciler) constructWorkload(ctx context.Context, job GenericJob, o
 		},
 	}
 
-	priorityClassName, p, err := utilpriority.GetPriorityFromPriorityClass(
-		ctx, r.client, job.PriorityClass())
+	priorityClassName, source, p, err := ExtractPriority(ctx, r.client, job)
 	if err != nil {
 		return nil, err
 	}
 
 	wl.Spec.PriorityClassName = priorityClassName
+	wl.Spec.PriorityClassSource = source
 	wl.Spec.Priority = &p
 
 	if err := ctrl.SetControllerReference(object, wl, r.scheme); err != nil {
@@ -385,24 +521,52 @@ func (r *JobReconciler) constructWorkload(ctx context.Context, job GenericJob, o
 	return wl, nil
 }
 
-type PodSetNodeSelector struct {
+// ExtractPriority returns the priority class name, its source and the
+// priority value for the workload of the job. The WorkloadPriorityClass
+// referenced in the job's label takes precedence over the PriorityClass of
+// the job's pods, so that the workload priority can be set independently
+// of the pod priority used by kube-scheduler.
+func ExtractPriority(ctx context.Context, c client.Client, job GenericJob) (string, string, int32, error) {
+	if wpc := WorkloadPriorityClassName(job); len(wpc) > 0 {
+		name, p, err := utilpriority.GetPriorityFromWorkloadPriorityClass(ctx, c, wpc)
+		if err != nil {
+			return "", "", 0, err
+		}
+		return name, kueue.WorkloadPriorityClassSource, p, nil
+	}
+	name, p, err := utilpriority.GetPriorityFromPriorityClass(ctx, c, job.PriorityClass())
+	if err != nil {
+		return "", "", 0, err
+	}
+	if len(name) == 0 {
+		return "", "", p, nil
+	}
+	return name, kueue.PodPriorityClassSource, p, nil
+}
+
+// PodSetInfo holds the scheduling directives of a pod set that Kueue injects
+// into the job when it's admitted, and restores when it's stopped.
+type PodSetInfo struct {
 	Name         string            `json:"name"`
 	NodeSelector map[string]string `json:"nodeSelector"`
+	Count        int32             `json:"count"`
 }
 
-// getNodeSelectorsFromAdmission will extract node selectors from admitted workloads.
-func (r *JobReconciler) getNodeSelectorsFromAdmission(ctx context.Context, w *kueue.Workload) ([]PodSetNodeSelector, error) {
+// GetPodSetsInfoFromAdmission will extract node selectors and pod counts
+// from admitted workloads.
+func GetPodSetsInfoFromAdmission(ctx context.Context, c client.Client, w *kueue.Workload) ([]PodSetInfo, error) {
 	if len(w.Status.Admission.PodSetAssignments) == 0 {
 		return nil, nil
 	}
 
-	nodeSelectors := make([]PodSetNodeSelector, len(w.Status.Admission.PodSetAssignments))
+	nodeSelectors := make([]PodSetInfo, len(w.Status.Admission.PodSetAssignments))
 
 	for i, podSetFlavor := range w.Status.Admission.PodSetAssignments {
 		processedFlvs := sets.NewString()
-		nodeSelector := PodSetNodeSelector{
+		nodeSelector := PodSetInfo{
 			Name:         podSetFlavor.Name,
 			NodeSelector: make(map[string]string),
+			Count:        workload.AdmittedCount(w, &podSetFlavor),
 		}
 		for _, flvRef := range podSetFlavor.Flavors {
 			flvName := string(flvRef)
@@ -411,7 +575,7 @@ func (r *JobReconciler) getNodeSelectorsFromAdmission(ctx context.Context, w *ku
 			}
 			// Lookup the ResourceFlavors to fetch the node affinity labels to apply on the job.
 			flv := kueue.ResourceFlavor{}
-			if err := r.client.Get(ctx, types.NamespacedName{Name: string(flvName)}, &flv); err != nil {
+			if err := c.Get(ctx, types.NamespacedName{Name: string(flvName)}, &flv); err != nil {
 				return nil, err
 			}
 			for k, v := range flv.Spec.NodeLabels {
@@ -419,24 +583,32 @@ func (r *JobReconciler) getNodeSelectorsFromAdmission(ctx context.Context, w *ku
 			}
 			processedFlvs.Insert(flvName)
 		}
+		// Restrict the pods to the topology domain chosen at admission.
+		if ta := podSetFlavor.TopologyAssignment; ta != nil {
+			for j := range ta.Levels {
+				nodeSelector.NodeSelector[ta.Levels[j]] = ta.Values[j]
+			}
+		}
 
 		nodeSelectors[i] = nodeSelector
 	}
 	return nodeSelectors, nil
 }
 
-// getNodeSelectorsFromPodSets will extract node selectors from a workload's podSets.
-func (r *JobReconciler) getNodeSelectorsFromPodSets(w *kueue.Workload) []PodSetNodeSelector {
+// getPodSetsInfoFromPodSets will extract node selectors and pod counts from a
+// workload's podSets.
+func (r *JobReconciler) getPodSetsInfoFromPodSets(w *kueue.Workload) []PodSetInfo {
 	podSets := w.Spec.PodSets
 	if len(podSets) == 0 {
 		return nil
 	}
-	ret := make([]PodSetNodeSelector, len(podSets))
+	ret := make([]PodSetInfo, len(podSets))
 	for psi := range podSets {
 		ps := &podSets[psi]
-		ret[psi] = PodSetNodeSelector{
+		ret[psi] = PodSetInfo{
 			Name:         ps.Name,
 			NodeSelector: cloneNodeSelector(ps.Template.Spec.NodeSelector),
+			Count:        ps.Count,
 		}
 	}
 	return ret
@@ -492,24 +664,24 @@ func cloneNodeSelector(src map[string]string) map[string]string {
 	return ret
 }
 
-// getNodeSelectorsFromObjectAnnotation tries to retrieve a node selectors slice from the
+// getPodSetsInfoFromObjectAnnotation tries to retrieve a pod sets info slice from the
 // object's annotations fails if it's not found or is unable to unmarshal
-func getNodeSelectorsFromObjectAnnotation(obj client.Object) ([]PodSetNodeSelector, error) {
+func getPodSetsInfoFromObjectAnnotation(obj client.Object) ([]PodSetInfo, error) {
 	str, found := obj.GetAnnotations()[OriginalNodeSelectorsAnnotation]
 	if !found {
 		return nil, errNodeSelectorsNotFound
 	}
 	// unmarshal
-	ret := []PodSetNodeSelector{}
+	ret := []PodSetInfo{}
 	if err := json.Unmarshal([]byte(str), &ret); err != nil {
 		return nil, err
 	}
 	return ret, nil
 }
 
-// setNodeSelectorsInAnnotation - sets an annotation containing the provided node selectors into
+// setPodSetsInfoInAnnotation - sets an annotation containing the provided pod sets info into
 // a job object, even if very unlikely it could return an error related to json.marshaling
-func setNodeSelectorsInAnnotation(obj client.Object, nodeSelectors []PodSetNodeSelector) error {
+func setPodSetsInfoInAnnotation(obj client.Object, nodeSelectors []PodSetInfo) error {
 	nodeSelectorsBytes, err := json.Marshal(nodeSelectors)
 	if err != nil {
 		return err
@@ -23,10 +23,11 @@ import (
 )
 
 var (
-	annotationsPath       = field.NewPath("metadata", "annotations")
-	labelsPath            = field.NewPath("metadata", "labels")
-	parentWorkloadKeyPath = annotationsPath.Key(ParentWorkloadAnnotation)
-	queueNameLabelPath    = labelsPath.Key(QueueLabel)
+	annotationsPath               = field.NewPath("metadata", "annotations")
+	labelsPath                    = field.NewPath("metadata", "labels")
+	parentWorkloadKeyPath         = annotationsPath.Key(ParentWorkloadAnnotation)
+	queueNameLabelPath            = labelsPath.Key(QueueLabel)
+	workloadPriorityClassNamePath = labelsPath.Key(WorkloadPriorityClassLabel)
 
 	originalNodeSelectorsWorkloadKeyPath = annotationsPath.Key(OriginalNodeSelectorsAnnotation)
 )
@@ -38,6 +39,10 @@ func ValidateCreateForQueueName(job GenericJob) field.ErrorList {
 	return allErrs
 }
 
+func ValidateCreateForWorkloadPriorityClassName(job GenericJob) field.ErrorList {
+	return validateLabelAsCRDName(job, WorkloadPriorityClassLabel)
+}
+
 func ValidateAnnotationAsCRDName(job GenericJob, crdNameAnnotation string) field.ErrorList {
 	var allErrs field.ErrorList
 	if value, exists := job.Object().GetAnnotations()[crdNameAnnotation]; exists {
@@ -66,6 +71,15 @@ func ValidateUpdateForQueueName(oldJob, newJob GenericJob) field.ErrorList {
 	return allErrs
 }
 
+func ValidateUpdateForWorkloadPriorityClassName(oldJob, newJob GenericJob) field.ErrorList {
+	var allErrs field.ErrorList
+	if errList := apivalidation.ValidateImmutableField(WorkloadPriorityClassName(newJob),
+		WorkloadPriorityClassName(oldJob), workloadPriorityClassNamePath); len(errList) > 0 {
+		allErrs = append(allErrs, field.Forbidden(workloadPriorityClassNamePath, "this label is immutable"))
+	}
+	return allErrs
+}
+
 func ValidateUpdateForParentWorkload(oldJob, newJob GenericJob) field.ErrorList {
 	var allErrs field.ErrorList
 	if errList := apivalidation.ValidateImmutableField(ParentWorkloadName(newJob),
@@ -83,7 +97,7 @@ func ValidateUpdateForOriginalNodeSelectors(oldJob, newJob GenericJob) field.Err
 			allErrs = append(allErrs, field.Forbidden(originalNodeSelectorsWorkloadKeyPath, "this annotation is immutable while the job is not changing its suspended state"))
 		}
 	} else if av, found := newJob.Object().GetAnnotations()[OriginalNodeSelectorsAnnotation]; found {
-		out := []PodSetNodeSelector{}
+		out := []PodSetInfo{}
 		if err := json.Unmarshal([]byte(av), &out); err != nil {
 			allErrs = append(allErrs, field.Invalid(originalNodeSelectorsWorkloadKeyPath, av, err.Error()))
 		}
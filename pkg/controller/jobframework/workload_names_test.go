@@ -46,6 +46,10 @@ func TestGetWorkloadNameForOwner(t *testing.T) {
 			owner:            &metav1.OwnerReference{Kind: "MPIJob", APIVersion: "kubeflow.org/v2", Name: "myjob"},
 			wantWorkloadName: "mpijob-myjob-98672",
 		},
+		"simple JobSet name": {
+			owner:            &metav1.OwnerReference{Kind: "JobSet", APIVersion: "jobset.x-k8s.io/v1alpha1", Name: "myjobset"},
+			wantWorkloadName: "jobset-myjobset-faf9b",
+		},
 		"invalid APIVersion": {
 			owner:   &metav1.OwnerReference{Kind: "Job", APIVersion: "batch/v1/beta1", Name: "myjob"},
 			wantErr: errors.New("unexpected GroupVersion string: batch/v1/beta1"),
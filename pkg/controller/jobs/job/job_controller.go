@@ -18,6 +18,7 @@ package job
 
 import (
 	"context"
+	"strconv"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -40,6 +41,14 @@ import (
 	"sigs.k8s.io/kueue/pkg/controller/jobframework"
 )
 
+const (
+	// JobMinParallelismAnnotation is the annotation that enables the partial
+	// admission of a Job. Its value is the minimum parallelism with which the
+	// Job can be admitted if there is not enough quota for its full
+	// parallelism.
+	JobMinParallelismAnnotation = "kueue.x-k8s.io/job-min-parallelism"
+)
+
 var (
 	parentWorkloadKey = ".metadata.parentWorkload"
 	gvk               = batchv1.SchemeGroupVersion.WithKind("Job")
@@ -148,16 +157,41 @@ func (j *Job) PodSets() []kueue.PodSet {
 		{
 			Template: *j.Spec.Template.DeepCopy(),
 			Count:    j.podsCount(),
+			MinCount: j.podSetMinCount(),
 		},
 	}
 }
 
-func (j *Job) RunWithNodeAffinity(nodeSelectors []jobframework.PodSetNodeSelector) {
+var _ jobframework.JobWithReclaimablePods = (*Job)(nil)
+
+// ReclaimablePods returns the number of pods that won't be created again
+// because the remaining completions are fewer than the pods count.
+func (j *Job) ReclaimablePods() []kueue.ReclaimablePod {
+	podsCount := j.podsCount()
+	if podsCount <= 1 || j.Status.Succeeded == 0 {
+		return nil
+	}
+
+	remaining := pointer.Int32Deref(j.Spec.Completions, podsCount) - j.Status.Succeeded
+	if remaining >= podsCount {
+		return nil
+	}
+	return []kueue.ReclaimablePod{{
+		Name:  kueue.DefaultPodSetName,
+		Count: podsCount - remaining,
+	}}
+}
+
+func (j *Job) RunWithPodSetsInfo(nodeSelectors []jobframework.PodSetInfo) {
 	j.Spec.Suspend = pointer.Bool(false)
 	if len(nodeSelectors) == 0 {
 		return
 	}
 
+	if j.minPodsCount() != nil {
+		j.Spec.Parallelism = pointer.Int32(nodeSelectors[0].Count)
+	}
+
 	if j.Spec.Template.Spec.NodeSelector == nil {
 		j.Spec.Template.Spec.NodeSelector = nodeSelectors[0].NodeSelector
 	} else {
@@ -167,8 +201,16 @@ func (j *Job) RunWithNodeAffinity(nodeSelectors []jobframework.PodSetNodeSelecto
 	}
 }
 
-func (j *Job) RestoreNodeAffinity(nodeSelectors []jobframework.PodSetNodeSelector) {
-	if len(nodeSelectors) == 0 || equality.Semantic.DeepEqual(j.Spec.Template.Spec.NodeSelector, nodeSelectors[0].NodeSelector) {
+func (j *Job) RestorePodSetsInfo(nodeSelectors []jobframework.PodSetInfo) {
+	if len(nodeSelectors) == 0 {
+		return
+	}
+
+	if j.minPodsCount() != nil && nodeSelectors[0].Count > 0 {
+		j.Spec.Parallelism = pointer.Int32(nodeSelectors[0].Count)
+	}
+
+	if equality.Semantic.DeepEqual(j.Spec.Template.Spec.NodeSelector, nodeSelectors[0].NodeSelector) {
 		return
 	}
 
@@ -209,7 +251,21 @@ func (j *Job) EquivalentToWorkload(wl kueue.Workload) bool {
 		return false
 	}
 
-	if *j.Spec.Parallelism != wl.Spec.PodSets[0].Count {
+	ps := &wl.Spec.PodSets[0]
+	wantParallelism := ps.Count
+	// A partially admitted job runs with the admitted count as parallelism.
+	if !j.IsSuspended() && wl.Status.Admission != nil && len(wl.Status.Admission.PodSetAssignments) == 1 {
+		if count := wl.Status.Admission.PodSetAssignments[0].Count; count != nil {
+			wantParallelism = *count
+		}
+	}
+	if *j.Spec.Parallelism != wantParallelism {
+		return false
+	}
+
+	// The parallelism of a running job might be the admitted count, so the
+	// minimum count can only be compared while the job is suspended.
+	if j.IsSuspended() && !equality.Semantic.DeepEqual(j.podSetMinCount(), ps.MinCount) {
 		return false
 	}
 
@@ -241,6 +297,30 @@ func (j *Job) podsCount() int32 {
 	return podsCount
 }
 
+// minPodsCount returns the minimum parallelism of the job, or nil if it
+// can't be partially admitted.
+func (j *Job) minPodsCount() *int32 {
+	strVal, found := j.GetAnnotations()[JobMinParallelismAnnotation]
+	if !found {
+		return nil
+	}
+	minParallelism, err := strconv.ParseInt(strVal, 10, 32)
+	if err != nil || minParallelism <= 0 {
+		// Invalid values are rejected by the webhook.
+		return nil
+	}
+	return pointer.Int32(int32(minParallelism))
+}
+
+// podSetMinCount returns the minimum count of the pod set of the job, only
+// if it's lower than its count.
+func (j *Job) podSetMinCount() *int32 {
+	if minCount := j.minPodsCount(); minCount != nil && *minCount < j.podsCount() {
+		return minCount
+	}
+	return nil
+}
+
 // SetupWithManager sets up the controller with the Manager. It indexes workloads
 // based on the owning jobs.
 func (r *JobReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -266,6 +346,7 @@ func SetupIndexes(ctx context.Context, indexer client.FieldIndexer) error {
 }
 
 //+kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=list;get;watch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloadpriorityclasses,verbs=list;get;watch
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;watch;update
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=batch,resources=jobs/status,verbs=get
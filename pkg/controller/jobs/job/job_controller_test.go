@@ -17,11 +17,23 @@ limitations under the License.
 package job
 
 import (
+	"context"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
 	"sigs.k8s.io/kueue/pkg/util/pointer"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+	testingjob "sigs.k8s.io/kueue/pkg/util/testingjobs/job"
 )
 
 func TestPodsReady(t *testing.T) {
@@ -148,3 +160,254 @@ func TestPodsReady(t *testing.T) {
 		})
 	}
 }
+
+func TestPartialAdmission(t *testing.T) {
+	testcases := map[string]struct {
+		job             *batchv1.Job
+		info            []jobframework.PodSetInfo
+		wantMinCount    *int32
+		wantParallelism int32
+	}{
+		"without min-parallelism, the parallelism is kept": {
+			job:             testingjob.MakeJob("job", "ns").Parallelism(4).Obj(),
+			info:            []jobframework.PodSetInfo{{Name: kueue.DefaultPodSetName, Count: 2}},
+			wantParallelism: 4,
+		},
+		"with min-parallelism, the admitted count is used as parallelism": {
+			job: testingjob.MakeJob("job", "ns").
+				Parallelism(4).
+				SetAnnotation(JobMinParallelismAnnotation, "2").
+				Obj(),
+			info:            []jobframework.PodSetInfo{{Name: kueue.DefaultPodSetName, Count: 3}},
+			wantMinCount:    pointer.Int32(2),
+			wantParallelism: 3,
+		},
+		"min-parallelism equal to the parallelism": {
+			job: testingjob.MakeJob("job", "ns").
+				Parallelism(4).
+				SetAnnotation(JobMinParallelismAnnotation, "4").
+				Obj(),
+			info:            []jobframework.PodSetInfo{{Name: kueue.DefaultPodSetName, Count: 4}},
+			wantParallelism: 4,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			job := &Job{*tc.job}
+			podSets := job.PodSets()
+			if diff := cmp.Diff(tc.wantMinCount, podSets[0].MinCount); diff != "" {
+				t.Errorf("Unexpected minCount (-want,+got):\n%s", diff)
+			}
+			originalParallelism := *job.Spec.Parallelism
+
+			job.RunWithPodSetsInfo(tc.info)
+			if got := *job.Spec.Parallelism; got != tc.wantParallelism {
+				t.Errorf("Got parallelism %d after starting, want %d", got, tc.wantParallelism)
+			}
+
+			job.Suspend()
+			job.RestorePodSetsInfo([]jobframework.PodSetInfo{{Name: kueue.DefaultPodSetName, Count: podSets[0].Count}})
+			if got := *job.Spec.Parallelism; got != originalParallelism {
+				t.Errorf("Got parallelism %d after stopping, want %d", got, originalParallelism)
+			}
+		})
+	}
+}
+
+func TestReclaimablePods(t *testing.T) {
+	testcases := map[string]struct {
+		job  *batchv1.Job
+		want []kueue.ReclaimablePod
+	}{
+		"no succeeded pods": {
+			job: testingjob.MakeJob("job", "ns").Parallelism(4).Completions(10).Obj(),
+		},
+		"remaining completions not lower than the parallelism": {
+			job: testingjob.MakeJob("job", "ns").Parallelism(4).Completions(10).Succeeded(6).Obj(),
+		},
+		"remaining completions lower than the parallelism": {
+			job: testingjob.MakeJob("job", "ns").Parallelism(4).Completions(10).Succeeded(8).Obj(),
+			want: []kueue.ReclaimablePod{
+				{Name: kueue.DefaultPodSetName, Count: 2},
+			},
+		},
+		"completions lower than the parallelism": {
+			job: testingjob.MakeJob("job", "ns").Parallelism(5).Completions(3).Succeeded(2).Obj(),
+			want: []kueue.ReclaimablePod{
+				{Name: kueue.DefaultPodSetName, Count: 2},
+			},
+		},
+		"without completions": {
+			job: testingjob.MakeJob("job", "ns").Parallelism(4).Succeeded(1).Obj(),
+			want: []kueue.ReclaimablePod{
+				{Name: kueue.DefaultPodSetName, Count: 1},
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			got := (&Job{*tc.job}).ReclaimablePods()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unexpected reclaimable pods (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcilePrebuiltWorkload(t *testing.T) {
+	baseJob := func() *testingjob.JobWrapper {
+		return testingjob.MakeJob("job", "ns").
+			UID("job-uid").
+			Queue("queue").
+			Label(jobframework.PrebuiltWorkloadLabel, "wl").
+			Request(corev1.ResourceCPU, "1")
+	}
+	baseWorkload := func(job *batchv1.Job) *utiltesting.WorkloadWrapper {
+		wl := utiltesting.MakeWorkload("wl", "ns").Queue("queue")
+		wl.Spec.PodSets = (&Job{*job}).PodSets()
+		return wl
+	}
+	testcases := map[string]struct {
+		job              *batchv1.Job
+		workload         *kueue.Workload
+		wantController   string
+		wantFinished     bool
+		wantJobSuspended bool
+	}{
+		"the job becomes the controller of the prebuilt workload": {
+			job:              baseJob().Obj(),
+			workload:         baseWorkload(baseJob().Obj()).Obj(),
+			wantController:   "job",
+			wantJobSuspended: true,
+		},
+		"prebuilt workload controlled by another job": {
+			job: baseJob().Suspend(false).Obj(),
+			workload: baseWorkload(baseJob().Obj()).
+				ControllerReference(batchv1.SchemeGroupVersion.WithKind("Job"), "other", "other-uid").
+				Obj(),
+			wantController:   "other",
+			wantJobSuspended: true,
+		},
+		"prebuilt workload controlled by a job with the same name": {
+			job: baseJob().Suspend(false).Obj(),
+			workload: baseWorkload(baseJob().Obj()).
+				ControllerReference(batchv1.SchemeGroupVersion.WithKind("Job"), "job", "old-job-uid").
+				Obj(),
+			wantController:   "job",
+			wantJobSuspended: true,
+		},
+		"prebuilt workload that doesn't match the job": {
+			job:              baseJob().Suspend(false).Obj(),
+			workload:         baseWorkload(baseJob().Parallelism(2).Obj()).Obj(),
+			wantController:   "job",
+			wantFinished:     true,
+			wantJobSuspended: true,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cl := utiltesting.NewClientBuilder(batchv1.AddToScheme).WithObjects(tc.job, tc.workload).Build()
+			r := NewReconciler(cl.Scheme(), cl, record.NewFakeRecorder(10))
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(tc.job)}); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			var gotWorkload kueue.Workload
+			if err := cl.Get(ctx, client.ObjectKeyFromObject(tc.workload), &gotWorkload); err != nil {
+				t.Fatalf("Getting the workload: %v", err)
+			}
+			var gotController string
+			if owner := metav1.GetControllerOf(&gotWorkload); owner != nil {
+				gotController = owner.Name
+			}
+			if gotController != tc.wantController {
+				t.Errorf("Unexpected controller of the workload, want %q, got %q", tc.wantController, gotController)
+			}
+			if got := apimeta.IsStatusConditionTrue(gotWorkload.Status.Conditions, kueue.WorkloadFinished); got != tc.wantFinished {
+				t.Errorf("Unexpected finished condition, want %t, got %t", tc.wantFinished, got)
+			}
+
+			var gotJob batchv1.Job
+			if err := cl.Get(ctx, client.ObjectKeyFromObject(tc.job), &gotJob); err != nil {
+				t.Fatalf("Getting the job: %v", err)
+			}
+			if got := gotJob.Spec.Suspend != nil && *gotJob.Spec.Suspend; got != tc.wantJobSuspended {
+				t.Errorf("Unexpected suspend of the job, want %t, got %t", tc.wantJobSuspended, got)
+			}
+		})
+	}
+}
+
+func TestReconcileAdmittedWorkload(t *testing.T) {
+	baseJob := func() *testingjob.JobWrapper {
+		return testingjob.MakeJob("job", "ns").
+			UID("job-uid").
+			Queue("queue").
+			Request(corev1.ResourceCPU, "1").
+			Suspend(false)
+	}
+	admittedWorkload := func() *utiltesting.WorkloadWrapper {
+		wl := utiltesting.MakeWorkload("wl", "ns").
+			Queue("queue").
+			ControllerReference(batchv1.SchemeGroupVersion.WithKind("Job"), "job", "job-uid").
+			Admit(utiltesting.MakeAdmission("cq").Assignment(corev1.ResourceCPU, "default", "1").Obj()).
+			Condition(metav1.Condition{
+				Type:   kueue.WorkloadQuotaReserved,
+				Status: metav1.ConditionTrue,
+				Reason: "QuotaReserved",
+			}).
+			Condition(metav1.Condition{
+				Type:   kueue.WorkloadAdmitted,
+				Status: metav1.ConditionTrue,
+				Reason: "Admitted",
+			})
+		wl.Spec.PodSets = (&Job{*baseJob().Obj()}).PodSets()
+		return wl
+	}
+	testcases := map[string]struct {
+		workload         *kueue.Workload
+		wantJobSuspended bool
+	}{
+		"the job keeps running with an active workload": {
+			workload: admittedWorkload().Obj(),
+		},
+		"the job is suspended when its workload is deactivated": {
+			workload:         admittedWorkload().Active(false).Obj(),
+			wantJobSuspended: true,
+		},
+		"the job is suspended when its workload is evicted": {
+			workload: admittedWorkload().
+				Condition(metav1.Condition{
+					Type:   kueue.WorkloadEvicted,
+					Status: metav1.ConditionTrue,
+					Reason: kueue.WorkloadEvictedByPreemption,
+				}).
+				Obj(),
+			wantJobSuspended: true,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			job := baseJob().Obj()
+			builder := utiltesting.NewClientBuilder(batchv1.AddToScheme)
+			if err := SetupIndexes(ctx, utiltesting.AsIndexer(builder)); err != nil {
+				t.Fatalf("Setting up the indexes: %v", err)
+			}
+			cl := builder.WithObjects(job, tc.workload).Build()
+			r := NewReconciler(cl.Scheme(), cl, record.NewFakeRecorder(10))
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(job)}); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			var gotJob batchv1.Job
+			if err := cl.Get(ctx, client.ObjectKeyFromObject(job), &gotJob); err != nil {
+				t.Fatalf("Getting the job: %v", err)
+			}
+			if got := pointer.BoolDeref(gotJob.Spec.Suspend, false); got != tc.wantJobSuspended {
+				t.Errorf("Unexpected suspend of the job, want %t, got %t", tc.wantJobSuspended, got)
+			}
+		})
+	}
+}
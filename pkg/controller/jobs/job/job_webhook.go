@@ -18,12 +18,15 @@ package job
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 
 	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
@@ -91,13 +94,36 @@ func (w *JobWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) err
 	return validateCreate(&Job{*job}).ToAggregate()
 }
 
+var minParallelismAnnotationPath = field.NewPath("metadata", "annotations").Key(JobMinParallelismAnnotation)
+
 func validateCreate(job jobframework.GenericJob) field.ErrorList {
 	var allErrs field.ErrorList
 	allErrs = append(allErrs, jobframework.ValidateAnnotationAsCRDName(job, jobframework.ParentWorkloadAnnotation)...)
 	allErrs = append(allErrs, jobframework.ValidateCreateForQueueName(job)...)
+	allErrs = append(allErrs, jobframework.ValidateCreateForWorkloadPriorityClassName(job)...)
+	allErrs = append(allErrs, validateMinParallelism(job)...)
 	return allErrs
 }
 
+func validateMinParallelism(job jobframework.GenericJob) field.ErrorList {
+	strVal, found := job.Object().GetAnnotations()[JobMinParallelismAnnotation]
+	if !found {
+		return nil
+	}
+	minParallelism, err := strconv.ParseInt(strVal, 10, 32)
+	if err != nil {
+		return field.ErrorList{field.Invalid(minParallelismAnnotationPath, strVal, err.Error())}
+	}
+	if minParallelism <= 0 {
+		return field.ErrorList{field.Invalid(minParallelismAnnotationPath, strVal, "should be greater than 0")}
+	}
+	batchJob := job.Object().(*batchv1.Job)
+	if parallelism := pointer.Int32Deref(batchJob.Spec.Parallelism, 1); int32(minParallelism) > parallelism {
+		return field.ErrorList{field.Invalid(minParallelismAnnotationPath, strVal, fmt.Sprintf("should not be greater than the parallelism (%d)", parallelism))}
+	}
+	return nil
+}
+
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
 func (w *JobWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
 	oldJob := oldObj.(*batchv1.Job)
@@ -112,6 +138,7 @@ func validateUpdate(oldJob, newJob jobframework.GenericJob) field.ErrorList {
 	allErrs = append(allErrs, jobframework.ValidateUpdateForParentWorkload(oldJob, newJob)...)
 	allErrs = append(allErrs, jobframework.ValidateUpdateForOriginalNodeSelectors(oldJob, newJob)...)
 	allErrs = append(allErrs, jobframework.ValidateUpdateForQueueName(oldJob, newJob)...)
+	allErrs = append(allErrs, jobframework.ValidateUpdateForWorkloadPriorityClassName(oldJob, newJob)...)
 	return allErrs
 }
 
@@ -33,11 +33,12 @@ const (
 )
 
 var (
-	annotationsPath          = field.NewPath("metadata", "annotations")
-	labelsPath               = field.NewPath("metadata", "labels")
-	parentWorkloadKeyPath    = annotationsPath.Key(jobframework.ParentWorkloadAnnotation)
-	queueNameLabelPath       = labelsPath.Key(jobframework.QueueLabel)
-	queueNameAnnotationsPath = annotationsPath.Key(jobframework.QueueAnnotation)
+	annotationsPath               = field.NewPath("metadata", "annotations")
+	labelsPath                    = field.NewPath("metadata", "labels")
+	parentWorkloadKeyPath         = annotationsPath.Key(jobframework.ParentWorkloadAnnotation)
+	queueNameLabelPath            = labelsPath.Key(jobframework.QueueLabel)
+	queueNameAnnotationsPath      = annotationsPath.Key(jobframework.QueueAnnotation)
+	workloadPriorityClassNamePath = labelsPath.Key(jobframework.WorkloadPriorityClassLabel)
 
 	originalNodeSelectorsKeyPath = annotationsPath.Key(jobframework.OriginalNodeSelectorsAnnotation)
 )
@@ -73,6 +74,31 @@ func TestValidateCreate(t *testing.T) {
 			job:     testingutil.MakeJob("job", "default").QueueNameAnnotation("queue name").Obj(),
 			wantErr: field.ErrorList{field.Invalid(queueNameAnnotationsPath, "queue name", invalidRFC1123Message)},
 		},
+		{
+			name:    "invalid workload priority class label",
+			job:     testingutil.MakeJob("job", "default").WorkloadPriorityClass("high priority").Obj(),
+			wantErr: field.ErrorList{field.Invalid(workloadPriorityClassNamePath, "high priority", invalidRFC1123Message)},
+		},
+		{
+			name:    "valid min-parallelism annotation",
+			job:     testingutil.MakeJob("job", "default").Parallelism(4).SetAnnotation(JobMinParallelismAnnotation, "2").Obj(),
+			wantErr: nil,
+		},
+		{
+			name:    "non-numeric min-parallelism annotation",
+			job:     testingutil.MakeJob("job", "default").Parallelism(4).SetAnnotation(JobMinParallelismAnnotation, "two").Obj(),
+			wantErr: field.ErrorList{field.Invalid(minParallelismAnnotationPath, "two", `strconv.ParseInt: parsing "two": invalid syntax`)},
+		},
+		{
+			name:    "zero min-parallelism annotation",
+			job:     testingutil.MakeJob("job", "default").Parallelism(4).SetAnnotation(JobMinParallelismAnnotation, "0").Obj(),
+			wantErr: field.ErrorList{field.Invalid(minParallelismAnnotationPath, "0", "should be greater than 0")},
+		},
+		{
+			name:    "min-parallelism annotation greater than the parallelism",
+			job:     testingutil.MakeJob("job", "default").Parallelism(4).SetAnnotation(JobMinParallelismAnnotation, "5").Obj(),
+			wantErr: field.ErrorList{field.Invalid(minParallelismAnnotationPath, "5", "should not be greater than the parallelism (4)")},
+		},
 		{
 			name: "invalid queue-name and parent-workload annotation",
 			job:  testingutil.MakeJob("job", "default").Queue("queue name").ParentWorkload("parent workload name").Obj(),
@@ -169,6 +195,12 @@ func TestValidateUpdate(t *testing.T) {
 				field.Forbidden(parentWorkloadKeyPath, "this annotation is immutable"),
 			},
 		},
+		{
+			name:    "update the workload priority class label",
+			oldJob:  testingutil.MakeJob("job", "default").WorkloadPriorityClass("low").Obj(),
+			newJob:  testingutil.MakeJob("job", "default").WorkloadPriorityClass("high").Obj(),
+			wantErr: field.ErrorList{field.Forbidden(workloadPriorityClassNamePath, "this label is immutable")},
+		},
 		{
 			name:    "original node selectors can be set while unsuspending",
 			oldJob:  testingutil.MakeJob("job", "default").Suspend(true).Obj(),
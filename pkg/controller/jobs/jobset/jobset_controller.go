@@ -0,0 +1,229 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobset
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	jobsetapi "sigs.k8s.io/jobset/api/v1alpha1"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+)
+
+var (
+	gvk = jobsetapi.GroupVersion.WithKind("JobSet")
+
+	FrameworkName = "jobset.x-k8s.io/jobset"
+)
+
+// JobSetReconciler reconciles a JobSet object
+type JobSetReconciler jobframework.JobReconciler
+
+func NewReconciler(
+	scheme *runtime.Scheme,
+	client client.Client,
+	record record.EventRecorder,
+	opts ...jobframework.Option) *JobSetReconciler {
+	return (*JobSetReconciler)(jobframework.NewReconciler(scheme,
+		client,
+		record,
+		opts...,
+	))
+}
+
+type JobSet jobsetapi.JobSet
+
+func (j *JobSet) Object() client.Object {
+	return (*jobsetapi.JobSet)(j)
+}
+
+func (j *JobSet) IsSuspended() bool {
+	return pointer.BoolDeref(j.Spec.Suspend, false)
+}
+
+// IsActive always returns false, the JobSet status doesn't report the active
+// pods. Once the JobSet is suspended, its child Jobs are suspended by the
+// JobSet controller, that deletes their pods.
+func (j *JobSet) IsActive() bool {
+	return false
+}
+
+func (j *JobSet) Suspend() {
+	j.Spec.Suspend = pointer.Bool(true)
+}
+
+func (j *JobSet) ResetStatus() bool {
+	return false
+}
+
+func (j *JobSet) GetGVK() schema.GroupVersionKind {
+	return gvk
+}
+
+func (j *JobSet) PodSets() []kueue.PodSet {
+	podSets := make([]kueue.PodSet, len(j.Spec.ReplicatedJobs))
+	for index, replicatedJob := range j.Spec.ReplicatedJobs {
+		podSets[index] = kueue.PodSet{
+			Name:     replicatedJob.Name,
+			Template: *replicatedJob.Template.Spec.Template.DeepCopy(),
+			Count:    podsCount(&replicatedJob),
+		}
+	}
+	return podSets
+}
+
+func (j *JobSet) RunWithPodSetsInfo(nodeSelectors []jobframework.PodSetInfo) {
+	j.Spec.Suspend = pointer.Bool(false)
+	// The node selectors are provided in the same order as the replicated jobs.
+	for index := range nodeSelectors {
+		if index >= len(j.Spec.ReplicatedJobs) {
+			break
+		}
+		templateSpec := &j.Spec.ReplicatedJobs[index].Template.Spec.Template.Spec
+		if len(nodeSelectors[index].NodeSelector) != 0 {
+			if templateSpec.NodeSelector == nil {
+				templateSpec.NodeSelector = make(map[string]string, len(nodeSelectors[index].NodeSelector))
+			}
+			for k, v := range nodeSelectors[index].NodeSelector {
+				templateSpec.NodeSelector[k] = v
+			}
+		}
+	}
+}
+
+func (j *JobSet) RestorePodSetsInfo(nodeSelectors []jobframework.PodSetInfo) {
+	for index, nodeSelector := range nodeSelectors {
+		if index >= len(j.Spec.ReplicatedJobs) {
+			break
+		}
+		templateSpec := &j.Spec.ReplicatedJobs[index].Template.Spec.Template.Spec
+		if !equality.Semantic.DeepEqual(templateSpec.NodeSelector, nodeSelector.NodeSelector) {
+			templateSpec.NodeSelector = map[string]string{}
+			for k, v := range nodeSelector.NodeSelector {
+				templateSpec.NodeSelector[k] = v
+			}
+		}
+	}
+}
+
+func (j *JobSet) Finished() (metav1.Condition, bool) {
+	if c := apimeta.FindStatusCondition(j.Status.Conditions, string(jobsetapi.JobSetFailed)); c != nil && c.Status == metav1.ConditionTrue {
+		return metav1.Condition{
+			Type:    kueue.WorkloadFinished,
+			Status:  metav1.ConditionTrue,
+			Reason:  "JobFinished",
+			Message: c.Message,
+		}, true
+	}
+	if c := apimeta.FindStatusCondition(j.Status.Conditions, string(jobsetapi.JobSetCompleted)); c != nil && c.Status == metav1.ConditionTrue {
+		return metav1.Condition{
+			Type:    kueue.WorkloadFinished,
+			Status:  metav1.ConditionTrue,
+			Reason:  "JobFinished",
+			Message: c.Message,
+		}, true
+	}
+	return metav1.Condition{}, false
+}
+
+func (j *JobSet) EquivalentToWorkload(wl kueue.Workload) bool {
+	if len(wl.Spec.PodSets) != len(j.Spec.ReplicatedJobs) {
+		return false
+	}
+	for index := range j.Spec.ReplicatedJobs {
+		replicatedJob := &j.Spec.ReplicatedJobs[index]
+		podSet := &wl.Spec.PodSets[index]
+		if replicatedJob.Name != podSet.Name || podsCount(replicatedJob) != podSet.Count {
+			return false
+		}
+		// nodeSelector may change, hence we are not checking for
+		// equality of the whole pod template spec.
+		podSpec := &replicatedJob.Template.Spec.Template.Spec
+		if !equality.Semantic.DeepEqual(podSpec.InitContainers, podSet.Template.Spec.InitContainers) {
+			return false
+		}
+		if !equality.Semantic.DeepEqual(podSpec.Containers, podSet.Template.Spec.Containers) {
+			return false
+		}
+	}
+	return true
+}
+
+// PriorityClass returns the first priorityClassName set in the pod templates
+// of the replicated jobs, in the order they are listed.
+func (j *JobSet) PriorityClass() string {
+	for _, replicatedJob := range j.Spec.ReplicatedJobs {
+		if len(replicatedJob.Template.Spec.Template.Spec.PriorityClassName) != 0 {
+			return replicatedJob.Template.Spec.Template.Spec.PriorityClassName
+		}
+	}
+	return ""
+}
+
+// PodsReady returns true once the JobSet completed, the JobSet status doesn't
+// report the ready pods of its child Jobs.
+func (j *JobSet) PodsReady() bool {
+	return apimeta.IsStatusConditionTrue(j.Status.Conditions, string(jobsetapi.JobSetCompleted))
+}
+
+// SetupWithManager sets up the controller with the Manager. It indexes workloads
+// based on the owning jobs.
+func (r *JobSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jobsetapi.JobSet{}).
+		Owns(&kueue.Workload{}).
+		Complete(r)
+}
+
+func SetupIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	return jobframework.SetupWorkloadOwnerIndex(ctx, indexer, gvk)
+}
+
+//+kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=list;get;watch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloadpriorityclasses,verbs=list;get;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;watch;update
+//+kubebuilder:rbac:groups=jobset.x-k8s.io,resources=jobsets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=jobset.x-k8s.io,resources=jobsets/status,verbs=get
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloads,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloads/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloads/finalizers,verbs=update
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=resourceflavors,verbs=get;list;watch
+
+func (r *JobSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	fjr := (*jobframework.JobReconciler)(r)
+	return fjr.ReconcileGenericJob(ctx, req, &JobSet{})
+}
+
+// podsCount returns the number of pods of a replicated job: each of its
+// replicas is a Job running parallelism pods.
+func podsCount(replicatedJob *jobsetapi.ReplicatedJob) int32 {
+	return int32(replicatedJob.Replicas) * pointer.Int32Deref(replicatedJob.Template.Spec.Parallelism, 1)
+}
+
+func GetWorkloadNameForJobSet(jobSetName string) string {
+	return jobframework.GetWorkloadNameForOwnerWithGVK(jobSetName, gvk)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobset
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	jobsetapi "sigs.k8s.io/jobset/api/v1alpha1"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+	testingutil "sigs.k8s.io/kueue/pkg/util/testingjobs/jobset"
+)
+
+func TestPodSets(t *testing.T) {
+	jobSet := testingutil.MakeJobSet("jobset", "default").
+		ReplicatedJobs(
+			testingutil.ReplicatedJobRequirements{Name: "leader", Replicas: 1, Parallelism: 1},
+			testingutil.ReplicatedJobRequirements{Name: "workers", Replicas: 3, Parallelism: 4},
+		).
+		Request("workers", corev1.ResourceCPU, "1").
+		Obj()
+
+	got := (*JobSet)(jobSet).PodSets()
+	want := []kueue.PodSet{
+		{
+			Name:     "leader",
+			Template: *jobSet.Spec.ReplicatedJobs[0].Template.Spec.Template.DeepCopy(),
+			Count:    1,
+		},
+		{
+			Name:     "workers",
+			Template: *jobSet.Spec.ReplicatedJobs[1].Template.Spec.Template.DeepCopy(),
+			Count:    12,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected podSets (-want,+got):\n%s", diff)
+	}
+	if !(*JobSet)(jobSet).EquivalentToWorkload(kueue.Workload{Spec: kueue.WorkloadSpec{PodSets: want}}) {
+		t.Errorf("The JobSet isn't equivalent to a workload with its podSets")
+	}
+}
+
+func TestRunWithPodSetsInfo(t *testing.T) {
+	testcases := map[string]struct {
+		jobSet            *jobsetapi.JobSet
+		podSetsInfo       []jobframework.PodSetInfo
+		wantNodeSelectors []map[string]string
+	}{
+		"node selectors are injected in each replicated job": {
+			jobSet: testingutil.MakeJobSet("jobset", "default").
+				ReplicatedJobs(
+					testingutil.ReplicatedJobRequirements{Name: "leader", Replicas: 1, Parallelism: 1},
+					testingutil.ReplicatedJobRequirements{Name: "workers", Replicas: 2, Parallelism: 2},
+				).
+				Obj(),
+			podSetsInfo: []jobframework.PodSetInfo{
+				{Name: "leader", NodeSelector: map[string]string{"instance": "on-demand"}},
+				{Name: "workers", NodeSelector: map[string]string{"instance": "spot"}},
+			},
+			wantNodeSelectors: []map[string]string{
+				{"instance": "on-demand"},
+				{"instance": "spot"},
+			},
+		},
+		"node selectors are merged with the existing ones": {
+			jobSet: func() *jobsetapi.JobSet {
+				js := testingutil.MakeJobSet("jobset", "default").
+					ReplicatedJobs(testingutil.ReplicatedJobRequirements{Name: "workers", Replicas: 1, Parallelism: 1}).
+					Obj()
+				js.Spec.ReplicatedJobs[0].Template.Spec.Template.Spec.NodeSelector = map[string]string{"zone": "a"}
+				return js
+			}(),
+			podSetsInfo: []jobframework.PodSetInfo{
+				{Name: "workers", NodeSelector: map[string]string{"instance": "spot"}},
+			},
+			wantNodeSelectors: []map[string]string{
+				{"zone": "a", "instance": "spot"},
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			jobSet := (*JobSet)(tc.jobSet)
+			original := jobSet.PodSets()
+			jobSet.RunWithPodSetsInfo(tc.podSetsInfo)
+			if jobSet.IsSuspended() {
+				t.Errorf("The JobSet is still suspended")
+			}
+			var gotNodeSelectors []map[string]string
+			for _, replicatedJob := range jobSet.Spec.ReplicatedJobs {
+				gotNodeSelectors = append(gotNodeSelectors, replicatedJob.Template.Spec.Template.Spec.NodeSelector)
+			}
+			if diff := cmp.Diff(tc.wantNodeSelectors, gotNodeSelectors); diff != "" {
+				t.Errorf("Unexpected node selectors (-want,+got):\n%s", diff)
+			}
+
+			restoreInfo := make([]jobframework.PodSetInfo, len(original))
+			for i := range original {
+				restoreInfo[i] = jobframework.PodSetInfo{Name: original[i].Name, NodeSelector: original[i].Template.Spec.NodeSelector}
+			}
+			jobSet.RestorePodSetsInfo(restoreInfo)
+			if diff := cmp.Diff(original, jobSet.PodSets(), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Unexpected podSets after restoring (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFinished(t *testing.T) {
+	testcases := map[string]struct {
+		conditions   []metav1.Condition
+		wantFinished bool
+		wantMessage  string
+	}{
+		"running": {
+			conditions: []metav1.Condition{
+				{Type: string(jobsetapi.JobSetSuspended), Status: metav1.ConditionFalse},
+			},
+		},
+		"completed": {
+			conditions: []metav1.Condition{
+				{Type: string(jobsetapi.JobSetCompleted), Status: metav1.ConditionTrue, Message: "jobset completed successfully"},
+			},
+			wantFinished: true,
+			wantMessage:  "jobset completed successfully",
+		},
+		"failed": {
+			conditions: []metav1.Condition{
+				{Type: string(jobsetapi.JobSetFailed), Status: metav1.ConditionTrue, Message: "jobset failed"},
+			},
+			wantFinished: true,
+			wantMessage:  "jobset failed",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			jobSet := (*JobSet)(testingutil.MakeJobSet("jobset", "default").Obj())
+			jobSet.Status.Conditions = tc.conditions
+			condition, finished := jobSet.Finished()
+			if finished != tc.wantFinished {
+				t.Errorf("Finished() = %v, want %v", finished, tc.wantFinished)
+			}
+			if finished && (condition.Type != kueue.WorkloadFinished || condition.Message != tc.wantMessage) {
+				t.Errorf("Unexpected finished condition: %+v", condition)
+			}
+		})
+	}
+}
+
+func TestPriorityClass(t *testing.T) {
+	jobSet := testingutil.MakeJobSet("jobset", "default").
+		ReplicatedJobs(
+			testingutil.ReplicatedJobRequirements{Name: "leader", Replicas: 1, Parallelism: 1},
+			testingutil.ReplicatedJobRequirements{Name: "workers", Replicas: 1, Parallelism: 1},
+		).
+		Obj()
+	if got := (*JobSet)(jobSet).PriorityClass(); got != "" {
+		t.Errorf("PriorityClass() = %q, want empty", got)
+	}
+	jobSet.Spec.ReplicatedJobs[1].Template.Spec.Template.Spec.PriorityClassName = "workers-priority"
+	if got := (*JobSet)(jobSet).PriorityClass(); got != "workers-priority" {
+		t.Errorf("PriorityClass() = %q, want %q", got, "workers-priority")
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	jobsetapi "sigs.k8s.io/jobset/api/v1alpha1"
+
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+	testingutil "sigs.k8s.io/kueue/pkg/util/testingjobs/jobset"
+)
+
+var (
+	originalNodeSelectorsKeyPath = field.NewPath("metadata", "annotations").Key(jobframework.OriginalNodeSelectorsAnnotation)
+)
+
+func TestDefault(t *testing.T) {
+	testcases := map[string]struct {
+		jobSet                     *jobsetapi.JobSet
+		manageJobsWithoutQueueName bool
+		want                       *jobsetapi.JobSet
+	}{
+		"unmanaged jobset without queue name": {
+			jobSet: testingutil.MakeJobSet("jobset", "default").Suspend(false).Obj(),
+			want:   testingutil.MakeJobSet("jobset", "default").Suspend(false).Obj(),
+		},
+		"jobset with queue name is suspended": {
+			jobSet: testingutil.MakeJobSet("jobset", "default").Queue("queue").Suspend(false).Obj(),
+			want:   testingutil.MakeJobSet("jobset", "default").Queue("queue").Suspend(true).Obj(),
+		},
+		"jobset without queue name is suspended when managing jobs without queue name": {
+			jobSet:                     testingutil.MakeJobSet("jobset", "default").Suspend(false).Obj(),
+			manageJobsWithoutQueueName: true,
+			want:                       testingutil.MakeJobSet("jobset", "default").Suspend(true).Obj(),
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			wh := &JobSetWebhook{manageJobsWithoutQueueName: tc.manageJobsWithoutQueueName}
+			if err := wh.Default(context.Background(), tc.jobSet); err != nil {
+				t.Fatalf("Default() failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, tc.jobSet); diff != "" {
+				t.Errorf("Default() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	validPodSelectors := `
+[
+  {
+    "name": "podSetName",
+    "nodeSelector": {
+      "l1": "v1"
+    }
+  }
+]
+`
+	testcases := map[string]struct {
+		oldJob  *jobsetapi.JobSet
+		newJob  *jobsetapi.JobSet
+		wantErr error
+	}{
+		"original node selectors can be set while unsuspending": {
+			oldJob:  testingutil.MakeJobSet("jobset", "default").Suspend(true).Obj(),
+			newJob:  testingutil.MakeJobSet("jobset", "default").Suspend(false).OriginalNodeSelectorsAnnotation(validPodSelectors).Obj(),
+			wantErr: nil,
+		},
+		"original node selectors can be set while suspending": {
+			oldJob:  testingutil.MakeJobSet("jobset", "default").Suspend(false).Obj(),
+			newJob:  testingutil.MakeJobSet("jobset", "default").Suspend(true).OriginalNodeSelectorsAnnotation(validPodSelectors).Obj(),
+			wantErr: nil,
+		},
+		"immutable original node selectors while not suspended": {
+			oldJob: testingutil.MakeJobSet("jobset", "default").Suspend(false).OriginalNodeSelectorsAnnotation(validPodSelectors).Obj(),
+			newJob: testingutil.MakeJobSet("jobset", "default").Suspend(false).OriginalNodeSelectorsAnnotation("").Obj(),
+			wantErr: field.ErrorList{
+				field.Forbidden(originalNodeSelectorsKeyPath, "this annotation is immutable while the job is not changing its suspended state"),
+			}.ToAggregate(),
+		},
+		"immutable original node selectors while suspended": {
+			oldJob: testingutil.MakeJobSet("jobset", "default").Suspend(true).OriginalNodeSelectorsAnnotation(validPodSelectors).Obj(),
+			newJob: testingutil.MakeJobSet("jobset", "default").Suspend(true).OriginalNodeSelectorsAnnotation("").Obj(),
+			wantErr: field.ErrorList{
+				field.Forbidden(originalNodeSelectorsKeyPath, "this annotation is immutable while the job is not changing its suspended state"),
+			}.ToAggregate(),
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			wh := &JobSetWebhook{}
+			result := wh.ValidateUpdate(context.Background(), tc.oldJob, tc.newJob)
+
+			if diff := cmp.Diff(tc.wantErr, result); diff != "" {
+				t.Errorf("ValidateUpdate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -80,7 +80,9 @@ func (w *MPIJobWebhook) ValidateCreate(ctx context.Context, obj runtime.Object)
 }
 
 func validateCreate(job jobframework.GenericJob) field.ErrorList {
-	return jobframework.ValidateAnnotationAsCRDName(job, jobframework.QueueAnnotation)
+	allErrs := jobframework.ValidateAnnotationAsCRDName(job, jobframework.QueueAnnotation)
+	allErrs = append(allErrs, jobframework.ValidateCreateForWorkloadPriorityClassName(job)...)
+	return allErrs
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
@@ -93,6 +95,7 @@ func (w *MPIJobWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runti
 	log.Info("Validating update", "job", klog.KObj(newJob))
 	allErrs := jobframework.ValidateUpdateForQueueName(oldGenJob, newGenJob)
 	allErrs = append(allErrs, jobframework.ValidateUpdateForOriginalNodeSelectors(oldGenJob, newGenJob)...)
+	allErrs = append(allErrs, jobframework.ValidateUpdateForWorkloadPriorityClassName(oldGenJob, newGenJob)...)
 	return allErrs.ToAggregate()
 }
 
@@ -0,0 +1,333 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+)
+
+const (
+	// SchedulingGateName is the scheduling gate that keeps the pods managed
+	// by Kueue from being scheduled until their workload is admitted.
+	SchedulingGateName = "kueue.x-k8s.io/admission"
+
+	// ManagedLabelKey is the label that the webhook adds to the pods that
+	// are managed by Kueue.
+	ManagedLabelKey   = "kueue.x-k8s.io/managed"
+	ManagedLabelValue = "true"
+
+	// GroupNameLabel is the label that groups several pods in a single
+	// workload.
+	GroupNameLabel = "kueue.x-k8s.io/pod-group-name"
+	// GroupTotalCountAnnotation is the annotation with the number of pods
+	// in the group. The workload of the group is created once all the
+	// pods exist.
+	GroupTotalCountAnnotation = "kueue.x-k8s.io/pod-group-total-count"
+
+	// PodFinalizer is the finalizer that the webhook adds to the pods of a
+	// group, so that the terminated and deleted pods are still accounted
+	// for until the workload of the group finishes.
+	PodFinalizer = ManagedLabelKey
+)
+
+var (
+	gvk = corev1.SchemeGroupVersion.WithKind("Pod")
+
+	FrameworkName = "pod"
+)
+
+// Reconciler reconciles a Pod object
+type Reconciler struct {
+	*jobframework.JobReconciler
+	client           client.Client
+	scheme           *runtime.Scheme
+	record           record.EventRecorder
+	waitForPodsReady bool
+}
+
+func NewReconciler(
+	scheme *runtime.Scheme,
+	client client.Client,
+	record record.EventRecorder,
+	opts ...jobframework.Option) *Reconciler {
+	options := jobframework.DefaultOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Reconciler{
+		JobReconciler:    jobframework.NewReconciler(scheme, client, record, opts...),
+		client:           client,
+		scheme:           scheme,
+		record:           record,
+		waitForPodsReady: options.WaitForPodsReady,
+	}
+}
+
+type Pod struct {
+	corev1.Pod
+}
+
+var _ jobframework.GenericJob = (*Pod)(nil)
+var _ jobframework.JobWithCustomStop = (*Pod)(nil)
+
+func (p *Pod) Object() client.Object {
+	return &p.Pod
+}
+
+// IsSuspended returns whether the pod still has the Kueue scheduling gate.
+func (p *Pod) IsSuspended() bool {
+	return hasSchedulingGate(&p.Pod)
+}
+
+func (p *Pod) IsActive() bool {
+	return p.Status.Phase == corev1.PodRunning
+}
+
+// Suspend is a no-op, as a scheduling gate can't be added back to a pod.
+// Pods are stopped by deleting them, see Stop.
+func (p *Pod) Suspend() {
+}
+
+func (p *Pod) ResetStatus() bool {
+	return false
+}
+
+func (p *Pod) GetGVK() schema.GroupVersionKind {
+	return gvk
+}
+
+func (p *Pod) PodSets() []kueue.PodSet {
+	return []kueue.PodSet{
+		{
+			Name:     kueue.DefaultPodSetName,
+			Count:    1,
+			Template: podTemplate(&p.Pod),
+		},
+	}
+}
+
+// RunWithPodSetsInfo removes the scheduling gate and injects the node
+// selector of the admitted flavors.
+func (p *Pod) RunWithPodSetsInfo(nodeSelectors []jobframework.PodSetInfo) {
+	ungatePod(&p.Pod)
+	if len(nodeSelectors) == 0 {
+		return
+	}
+	injectNodeSelector(&p.Pod, nodeSelectors[0].NodeSelector)
+}
+
+// RestorePodSetsInfo is a no-op, as the node selector of a pod can't
+// change once the pod is ungated.
+func (p *Pod) RestorePodSetsInfo(nodeSelectors []jobframework.PodSetInfo) {
+}
+
+func (p *Pod) Finished() (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:    kueue.WorkloadFinished,
+		Status:  metav1.ConditionTrue,
+		Reason:  "PodFinished",
+		Message: "Pod finished successfully",
+	}
+	if p.Status.Phase == corev1.PodFailed {
+		condition.Message = "Pod failed"
+	}
+	return condition, isPodTerminated(&p.Pod)
+}
+
+func (p *Pod) EquivalentToWorkload(wl kueue.Workload) bool {
+	if len(wl.Spec.PodSets) != 1 || wl.Spec.PodSets[0].Count != 1 {
+		return false
+	}
+	// nodeSelector may change, hence we are not checking for
+	// equality of the whole pod spec.
+	ps := &wl.Spec.PodSets[0]
+	if !equality.Semantic.DeepEqual(p.Spec.InitContainers, ps.Template.Spec.InitContainers) {
+		return false
+	}
+	return equality.Semantic.DeepEqual(p.Spec.Containers, ps.Template.Spec.Containers)
+}
+
+func (p *Pod) PriorityClass() string {
+	return p.Spec.PriorityClassName
+}
+
+func (p *Pod) PodsReady() bool {
+	return isPodReady(&p.Pod)
+}
+
+// Stop deletes the pod if it was already ungated. A gated pod doesn't take
+// any resources, so it's left as is.
+func (p *Pod) Stop(ctx context.Context, c client.Client) (bool, error) {
+	if p.IsSuspended() || isPodTerminated(&p.Pod) || p.DeletionTimestamp != nil {
+		return false, nil
+	}
+	if err := c.Delete(ctx, &p.Pod); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return true, nil
+}
+
+func hasSchedulingGate(pod *corev1.Pod) bool {
+	for _, g := range pod.Spec.SchedulingGates {
+		if g.Name == SchedulingGateName {
+			return true
+		}
+	}
+	return false
+}
+
+func gatePod(pod *corev1.Pod) {
+	if !hasSchedulingGate(pod) {
+		pod.Spec.SchedulingGates = append(pod.Spec.SchedulingGates, corev1.PodSchedulingGate{Name: SchedulingGateName})
+	}
+}
+
+func ungatePod(pod *corev1.Pod) {
+	pod.Spec.SchedulingGates = withoutSchedulingGate(pod.Spec.SchedulingGates)
+}
+
+func withoutSchedulingGate(gates []corev1.PodSchedulingGate) []corev1.PodSchedulingGate {
+	for i, g := range gates {
+		if g.Name == SchedulingGateName {
+			if len(gates) == 1 {
+				return nil
+			}
+			return append(gates[:i:i], gates[i+1:]...)
+		}
+	}
+	return gates
+}
+
+func injectNodeSelector(pod *corev1.Pod, nodeSelector map[string]string) {
+	if len(nodeSelector) == 0 {
+		return
+	}
+	if pod.Spec.NodeSelector == nil {
+		pod.Spec.NodeSelector = make(map[string]string, len(nodeSelector))
+	}
+	for k, v := range nodeSelector {
+		pod.Spec.NodeSelector[k] = v
+	}
+}
+
+func isPodTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded {
+		return true
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podTemplate returns the template of the pod set for the pod, without the
+// Kueue scheduling gate.
+func podTemplate(pod *corev1.Pod) corev1.PodTemplateSpec {
+	meta := pod.ObjectMeta.DeepCopy()
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      meta.Labels,
+			Annotations: meta.Annotations,
+		},
+		Spec: *pod.Spec.DeepCopy(),
+	}
+	template.Spec.SchedulingGates = withoutSchedulingGate(template.Spec.SchedulingGates)
+	template.Spec.NodeName = ""
+	return template
+}
+
+func isManagedPod(obj client.Object) bool {
+	return obj.GetLabels()[ManagedLabelKey] == ManagedLabelValue
+}
+
+// SetupWithManager sets up the controller with the Manager. Only the pods
+// that the webhook labeled as managed are reconciled.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Pod{}, builder.WithPredicates(predicate.NewPredicateFuncs(isManagedPod))).
+		Watches(&source.Kind{Type: &kueue.Workload{}}, handler.EnqueueRequestsFromMapFunc(podsForWorkload)).
+		Complete(r)
+}
+
+// podsForWorkload maps a workload to the pods that own it, either as its
+// controller or, for pod groups, as one of its owners.
+func podsForWorkload(obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Kind != gvk.Kind || ref.APIVersion != gvk.GroupVersion().String() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: ref.Name, Namespace: obj.GetNamespace()},
+		})
+	}
+	return requests
+}
+
+func SetupIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	return jobframework.SetupWorkloadOwnerIndex(ctx, indexer, gvk)
+}
+
+//+kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=list;get;watch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloadpriorityclasses,verbs=list;get;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;watch;update
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=pods/status,verbs=get
+//+kubebuilder:rbac:groups="",resources=pods/finalizers,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloads,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloads/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=workloads/finalizers,verbs=update
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=resourceflavors,verbs=get;list;watch
+
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	pod := &corev1.Pod{}
+	if err := r.client.Get(ctx, req.NamespacedName, pod); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if groupName := pod.Labels[GroupNameLabel]; groupName != "" {
+		return r.reconcileGroup(ctx, pod, groupName)
+	}
+	return r.ReconcileGenericJob(ctx, req, &Pod{})
+}
+
+func GetWorkloadNameForPod(podName string) string {
+	return jobframework.GetWorkloadNameForOwnerWithGVK(podName, gvk)
+}
@@ -0,0 +1,358 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+	testingpod "sigs.k8s.io/kueue/pkg/util/testingjobs/pod"
+)
+
+func TestRunWithPodSetsInfo(t *testing.T) {
+	pod := &Pod{Pod: *testingpod.MakePod("pod", "ns").
+		Gate("other-gate").
+		Gate(SchedulingGateName).
+		NodeSelector("zone", "a").
+		Obj()}
+	if !pod.IsSuspended() {
+		t.Fatalf("Pod with the scheduling gate should be suspended")
+	}
+	pod.RunWithPodSetsInfo([]jobframework.PodSetInfo{{
+		Name:         kueue.DefaultPodSetName,
+		NodeSelector: map[string]string{"pool": "spot"},
+		Count:        1,
+	}})
+	if pod.IsSuspended() {
+		t.Errorf("Pod should not be suspended after running it")
+	}
+	if diff := cmp.Diff([]corev1.PodSchedulingGate{{Name: "other-gate"}}, pod.Spec.SchedulingGates); diff != "" {
+		t.Errorf("Unexpected scheduling gates (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"zone": "a", "pool": "spot"}, pod.Spec.NodeSelector); diff != "" {
+		t.Errorf("Unexpected node selector (-want,+got):\n%s", diff)
+	}
+}
+
+func TestPodSets(t *testing.T) {
+	pod := &Pod{Pod: *testingpod.MakePod("pod", "ns").
+		Queue("queue").
+		Gate(SchedulingGateName).
+		Request(corev1.ResourceCPU, "1").
+		Obj()}
+	podSets := pod.PodSets()
+	if len(podSets) != 1 || podSets[0].Name != kueue.DefaultPodSetName || podSets[0].Count != 1 {
+		t.Fatalf("Unexpected pod sets: %v", podSets)
+	}
+	if len(podSets[0].Template.Spec.SchedulingGates) != 0 {
+		t.Errorf("Unexpected scheduling gates in the pod set template: %v", podSets[0].Template.Spec.SchedulingGates)
+	}
+	if !hasSchedulingGate(&pod.Pod) {
+		t.Errorf("Building the pod sets should not ungate the pod")
+	}
+	if !pod.EquivalentToWorkload(*utiltesting.MakeWorkload("wl", "ns").PodSets(podSets...).Obj()) {
+		t.Errorf("Pod should be equivalent to its workload")
+	}
+}
+
+func TestFinished(t *testing.T) {
+	cases := map[string]struct {
+		phase        corev1.PodPhase
+		wantFinished bool
+		wantMessage  string
+	}{
+		"running": {
+			phase: corev1.PodRunning,
+		},
+		"succeeded": {
+			phase:        corev1.PodSucceeded,
+			wantFinished: true,
+			wantMessage:  "Pod finished successfully",
+		},
+		"failed": {
+			phase:        corev1.PodFailed,
+			wantFinished: true,
+			wantMessage:  "Pod failed",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := &Pod{Pod: *testingpod.MakePod("pod", "ns").StatusPhase(tc.phase).Obj()}
+			condition, finished := pod.Finished()
+			if finished != tc.wantFinished {
+				t.Errorf("Unexpected finished, want %t, got %t", tc.wantFinished, finished)
+			}
+			if finished && condition.Message != tc.wantMessage {
+				t.Errorf("Unexpected message, want %q, got %q", tc.wantMessage, condition.Message)
+			}
+		})
+	}
+}
+
+func TestReconcileGroup(t *testing.T) {
+	groupPod := func(name string) *testingpod.PodWrapper {
+		return testingpod.MakePod(name, "ns").
+			Queue("queue").
+			Label(ManagedLabelKey, ManagedLabelValue).
+			Label(GroupNameLabel, "group").
+			Annotation(GroupTotalCountAnnotation, "3").
+			Finalizer(PodFinalizer).
+			Request(corev1.ResourceCPU, "1")
+	}
+	// released returns the pod without the finalizer, as left after the
+	// workload finishes.
+	released := func(p *testingpod.PodWrapper) *corev1.Pod {
+		pod := p.Obj()
+		pod.Finalizers = nil
+		return pod
+	}
+	driver := groupPod("driver").Request(corev1.ResourceCPU, "2").Gate(SchedulingGateName)
+	worker1 := groupPod("worker1").Gate(SchedulingGateName)
+	worker2 := groupPod("worker2").Gate(SchedulingGateName)
+	driverRole := roleHash(driver.Obj())
+	workerRole := roleHash(worker1.Obj())
+	if driverRole == workerRole {
+		t.Fatalf("Pods with different requests should have different roles")
+	}
+
+	wlName := GetWorkloadNameForPodGroup("group")
+	baseWorkload := func() *utiltesting.WorkloadWrapper {
+		wl := utiltesting.MakeWorkload(wlName, "ns")
+		wl.Labels = map[string]string{GroupNameLabel: "group"}
+		for _, p := range []*corev1.Pod{driver.Obj(), worker1.Obj(), worker2.Obj()} {
+			wl.OwnerReferences = append(wl.OwnerReferences, metav1.OwnerReference{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Name:       p.Name,
+				UID:        p.UID,
+			})
+		}
+		return wl
+	}
+	admission := utiltesting.MakeAdmission("cq", driverRole, workerRole).Obj()
+	admission.PodSetAssignments[0].Flavors[corev1.ResourceCPU] = "on-demand"
+	admission.PodSetAssignments[1].Flavors[corev1.ResourceCPU] = "spot"
+	admittedCondition := metav1.Condition{
+		Type:   kueue.WorkloadAdmitted,
+		Status: metav1.ConditionTrue,
+		Reason: "Admitted",
+	}
+
+	cases := map[string]struct {
+		pods     []*corev1.Pod
+		workload *kueue.Workload
+
+		wantWorkload bool
+		wantPodSets  []kueue.PodSet
+		// wantPods are the pods left after the reconciliation, by name.
+		wantPods            map[string]*corev1.Pod
+		wantFinished        bool
+		wantFinishedMessage string
+	}{
+		"waits for all the pods of the group": {
+			pods: []*corev1.Pod{driver.Obj(), worker1.Obj()},
+			wantPods: map[string]*corev1.Pod{
+				"driver":  driver.Obj(),
+				"worker1": worker1.Obj(),
+			},
+		},
+		"creates the workload with a pod set for each role": {
+			pods:         []*corev1.Pod{driver.Obj(), worker1.Obj(), worker2.Obj()},
+			wantWorkload: true,
+			wantPodSets: func() []kueue.PodSet {
+				podSets := []kueue.PodSet{
+					{Name: driverRole, Count: 1, Template: podTemplate(driver.Obj())},
+					{Name: workerRole, Count: 2, Template: podTemplate(worker1.Obj())},
+				}
+				if workerRole < driverRole {
+					podSets[0], podSets[1] = podSets[1], podSets[0]
+				}
+				return podSets
+			}(),
+			wantPods: map[string]*corev1.Pod{
+				"driver":  driver.Obj(),
+				"worker1": worker1.Obj(),
+				"worker2": worker2.Obj(),
+			},
+		},
+		"ungates the pods of the admitted workload": {
+			pods: []*corev1.Pod{driver.Obj(), worker1.Obj(), worker2.Obj()},
+			workload: baseWorkload().
+				Admit(admission).
+				Condition(admittedCondition).
+				Obj(),
+			wantWorkload: true,
+			wantPods: map[string]*corev1.Pod{
+				"driver":  groupPod("driver").Request(corev1.ResourceCPU, "2").NodeSelector("pool", "on-demand").Obj(),
+				"worker1": groupPod("worker1").NodeSelector("pool", "spot").Obj(),
+				"worker2": groupPod("worker2").NodeSelector("pool", "spot").Obj(),
+			},
+		},
+		"releases the pods deleted before the workload exists": {
+			pods: []*corev1.Pod{driver.Obj(), worker1.Clone().Delete().Obj(), worker2.Obj()},
+			wantPods: map[string]*corev1.Pod{
+				"driver":  driver.Obj(),
+				"worker2": worker2.Obj(),
+			},
+		},
+		"keeps the gated pods of the evicted workload": {
+			pods: []*corev1.Pod{driver.Obj(), worker1.Obj(), worker2.Obj()},
+			workload: baseWorkload().
+				Admit(admission).
+				Condition(metav1.Condition{
+					Type:   kueue.WorkloadEvicted,
+					Status: metav1.ConditionTrue,
+					Reason: kueue.WorkloadEvictedByPreemption,
+				}).
+				Obj(),
+			wantWorkload: true,
+			wantPods: map[string]*corev1.Pod{
+				"driver":  driver.Obj(),
+				"worker1": worker1.Obj(),
+				"worker2": worker2.Obj(),
+			},
+		},
+		"deletes the pods and finishes the evicted workload of a partially ungated group": {
+			pods: []*corev1.Pod{
+				groupPod("driver").Request(corev1.ResourceCPU, "2").StatusPhase(corev1.PodRunning).Obj(),
+				groupPod("worker1").StatusPhase(corev1.PodSucceeded).Obj(),
+				worker2.Obj(),
+			},
+			workload: baseWorkload().
+				Admit(admission).
+				Condition(metav1.Condition{
+					Type:   kueue.WorkloadEvicted,
+					Status: metav1.ConditionTrue,
+					Reason: kueue.WorkloadEvictedByPreemption,
+				}).
+				Obj(),
+			wantWorkload: true,
+			wantPods: map[string]*corev1.Pod{
+				"worker1": released(groupPod("worker1").StatusPhase(corev1.PodSucceeded)),
+			},
+			wantFinished:        true,
+			wantFinishedMessage: "The pods of the group were stopped: Evicted (Preempted): ",
+		},
+		"finishes the workload when all the pods terminated": {
+			pods: []*corev1.Pod{
+				groupPod("driver").Request(corev1.ResourceCPU, "2").StatusPhase(corev1.PodSucceeded).Obj(),
+				groupPod("worker1").StatusPhase(corev1.PodSucceeded).Obj(),
+				groupPod("worker2").StatusPhase(corev1.PodFailed).Obj(),
+			},
+			workload: baseWorkload().
+				Admit(admission).
+				Condition(admittedCondition).
+				Obj(),
+			wantWorkload: true,
+			wantPods: map[string]*corev1.Pod{
+				"driver":  released(groupPod("driver").Request(corev1.ResourceCPU, "2").StatusPhase(corev1.PodSucceeded)),
+				"worker1": released(groupPod("worker1").StatusPhase(corev1.PodSucceeded)),
+				"worker2": released(groupPod("worker2").StatusPhase(corev1.PodFailed)),
+			},
+			wantFinished:        true,
+			wantFinishedMessage: "1 pods failed",
+		},
+		"finishes the workload when the running pods were deleted": {
+			pods: []*corev1.Pod{
+				groupPod("driver").Request(corev1.ResourceCPU, "2").StatusPhase(corev1.PodRunning).Delete().Obj(),
+				groupPod("worker1").StatusPhase(corev1.PodSucceeded).Obj(),
+				groupPod("worker2").StatusPhase(corev1.PodSucceeded).Obj(),
+			},
+			workload: baseWorkload().
+				Admit(admission).
+				Condition(admittedCondition).
+				Obj(),
+			wantWorkload: true,
+			wantPods: map[string]*corev1.Pod{
+				"worker1": released(groupPod("worker1").StatusPhase(corev1.PodSucceeded)),
+				"worker2": released(groupPod("worker2").StatusPhase(corev1.PodSucceeded)),
+			},
+			wantFinished:        true,
+			wantFinishedMessage: "1 pods failed",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			objs := []client.Object{
+				utiltesting.MakeResourceFlavor("on-demand").Label("pool", "on-demand").Obj(),
+				utiltesting.MakeResourceFlavor("spot").Label("pool", "spot").Obj(),
+			}
+			for _, p := range tc.pods {
+				objs = append(objs, p.DeepCopy())
+			}
+			if tc.workload != nil {
+				objs = append(objs, tc.workload.DeepCopy())
+			}
+			cl := utiltesting.NewClientBuilder().WithObjects(objs...).Build()
+			r := NewReconciler(cl.Scheme(), cl, record.NewFakeRecorder(10))
+			ctx := context.Background()
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: tc.pods[0].Name, Namespace: "ns"}}
+			if _, err := r.Reconcile(ctx, req); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			var wl kueue.Workload
+			err := cl.Get(ctx, types.NamespacedName{Name: wlName, Namespace: "ns"}, &wl)
+			if gotWorkload := err == nil; gotWorkload != tc.wantWorkload {
+				t.Fatalf("Unexpected workload existence, want %t, got %t (err: %v)", tc.wantWorkload, gotWorkload, err)
+			}
+			if tc.wantWorkload {
+				if tc.wantPodSets != nil {
+					if diff := cmp.Diff(tc.wantPodSets, wl.Spec.PodSets, cmpopts.EquateEmpty()); diff != "" {
+						t.Errorf("Unexpected pod sets (-want,+got):\n%s", diff)
+					}
+					if len(wl.OwnerReferences) != len(tc.pods) {
+						t.Errorf("Unexpected owners of the workload, want %d, got %d", len(tc.pods), len(wl.OwnerReferences))
+					}
+				}
+				if got := apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadFinished); got != tc.wantFinished {
+					t.Errorf("Unexpected finished condition, want %t, got %t", tc.wantFinished, got)
+				}
+				if cond := apimeta.FindStatusCondition(wl.Status.Conditions, kueue.WorkloadFinished); tc.wantFinished && cond.Message != tc.wantFinishedMessage {
+					t.Errorf("Unexpected finished message, want %q, got %q", tc.wantFinishedMessage, cond.Message)
+				}
+			}
+
+			var pods corev1.PodList
+			if err := cl.List(ctx, &pods, client.InNamespace("ns")); err != nil {
+				t.Fatalf("Listing pods: %v", err)
+			}
+			gotPods := make(map[string]*corev1.Pod, len(pods.Items))
+			for i := range pods.Items {
+				gotPods[pods.Items[i].Name] = &pods.Items[i]
+			}
+			if diff := cmp.Diff(tc.wantPods, gotPods, cmpopts.EquateEmpty(),
+				cmpopts.IgnoreFields(metav1.ObjectMeta{}, "ResourceVersion")); diff != "" {
+				t.Errorf("Unexpected pods (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,434 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/constants"
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+// groupGVK is only used to name the workloads of the pod groups, so that
+// they don't collide with the workloads of single pods.
+var groupGVK = corev1.SchemeGroupVersion.WithKind("PodGroup")
+
+const roleHashLength = 8
+
+// reconcileGroup reconciles all the pods of a group together, as they share
+// a single workload. The workload is created once all the pods in the group
+// exist, with a pod set for each distinct role. All the pods own the
+// workload, so that it's garbage collected when the pods are gone.
+// The pods keep the PodFinalizer until the workload finishes, so that the
+// pods that terminated or were deleted are still listed.
+func (r *Reconciler) reconcileGroup(ctx context.Context, pod *corev1.Pod, groupName string) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx).WithValues("podGroup", klog.KRef(pod.Namespace, groupName))
+	ctx = ctrl.LoggerInto(ctx, log)
+	log.V(2).Info("Reconciling pod group")
+
+	totalCount, err := groupTotalCount(pod)
+	if err != nil {
+		// Invalid values are rejected by the webhook.
+		log.Error(err, "Reading the total count of the pod group")
+		return ctrl.Result{}, nil
+	}
+	pods, err := r.listGroupPods(ctx, pod.Namespace, groupName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	wl := &kueue.Workload{}
+	key := types.NamespacedName{Name: GetWorkloadNameForPodGroup(groupName), Namespace: pod.Namespace}
+	if err := r.client.Get(ctx, key, wl); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		// The pods deleted before the workload exists are not part of the
+		// group anymore.
+		if pods, err = r.releaseDeletedPods(ctx, pods); err != nil {
+			return ctrl.Result{}, err
+		}
+		if len(pods) < totalCount {
+			log.V(3).Info("Waiting for all the pods of the group", "pods", len(pods), "totalCount", totalCount)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, r.createGroupWorkload(ctx, pod, groupName, pods)
+	}
+
+	if apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadFinished) {
+		return ctrl.Result{}, r.finalizeGroup(ctx, pods)
+	}
+	if condition, finished := groupFinished(pods, totalCount); finished {
+		err := workload.UpdateStatus(ctx, r.client, wl, condition.Type, condition.Status, condition.Reason, condition.Message, constants.JobControllerName)
+		if err != nil {
+			log.Error(err, "Updating workload status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.finalizeGroup(ctx, pods)
+	}
+
+	if r.waitForPodsReady {
+		condition := groupPodsReadyCondition(pods, totalCount, wl)
+		if !apimeta.IsStatusConditionPresentAndEqual(wl.Status.Conditions, condition.Type, condition.Status) {
+			log.V(3).Info(fmt.Sprintf("Updating the PodsReady condition with status: %v", condition.Status))
+			if err := workload.UpdateStatus(ctx, r.client, wl, condition.Type, condition.Status, condition.Reason, condition.Message, constants.JobControllerName); err != nil {
+				log.Error(err, "Updating workload status")
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	if !workload.IsActive(wl) {
+		return ctrl.Result{}, r.stopGroup(ctx, wl, pods, "The workload is deactivated")
+	}
+	if evictedCond := apimeta.FindStatusCondition(wl.Status.Conditions, kueue.WorkloadEvicted); evictedCond != nil && evictedCond.Status == metav1.ConditionTrue {
+		return ctrl.Result{}, r.stopGroup(ctx, wl, pods, fmt.Sprintf("Evicted (%s): %s", evictedCond.Reason, evictedCond.Message))
+	}
+	if !workload.IsAdmitted(wl) {
+		return ctrl.Result{}, r.stopGroup(ctx, wl, pods, "Not admitted by cluster queue")
+	}
+	return ctrl.Result{}, r.startGroup(ctx, pods, wl)
+}
+
+// listGroupPods returns the managed pods of the group, sorted by name.
+func (r *Reconciler) listGroupPods(ctx context.Context, namespace, groupName string) ([]corev1.Pod, error) {
+	var podList corev1.PodList
+	if err := r.client.List(ctx, &podList, client.InNamespace(namespace),
+		client.MatchingLabels{GroupNameLabel: groupName, ManagedLabelKey: ManagedLabelValue}); err != nil {
+		return nil, err
+	}
+	pods := podList.Items
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+func (r *Reconciler) createGroupWorkload(ctx context.Context, pod *corev1.Pod, groupName string, pods []corev1.Pod) error {
+	wl, err := r.constructGroupWorkload(ctx, groupName, pods)
+	if err != nil {
+		return err
+	}
+	if err := r.client.Create(ctx, wl); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		r.record.Eventf(pod, corev1.EventTypeWarning, "FailedCreateWorkload",
+			"Failed to create the workload of the pod group %s: %v", groupName, err)
+		return err
+	}
+	r.record.Eventf(pod, corev1.EventTypeNormal, "CreatedWorkload",
+		"Created Workload: %v", workload.Key(wl))
+	return nil
+}
+
+// constructGroupWorkload derives the workload of a pod group. The queue name
+// and priority are taken from the first pod of the group.
+func (r *Reconciler) constructGroupWorkload(ctx context.Context, groupName string, pods []corev1.Pod) (*kueue.Workload, error) {
+	first := &Pod{Pod: pods[0]}
+	wl := &kueue.Workload{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetWorkloadNameForPodGroup(groupName),
+			Namespace: first.Namespace,
+			Labels:    map[string]string{GroupNameLabel: groupName},
+		},
+		Spec: kueue.WorkloadSpec{
+			PodSets:   groupPodSets(pods),
+			QueueName: jobframework.QueueName(first),
+		},
+	}
+	for i := range pods {
+		wl.OwnerReferences = append(wl.OwnerReferences, metav1.OwnerReference{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Name:       pods[i].Name,
+			UID:        pods[i].UID,
+		})
+	}
+
+	priorityClassName, source, p, err := jobframework.ExtractPriority(ctx, r.client, first)
+	if err != nil {
+		return nil, err
+	}
+	wl.Spec.PriorityClassName = priorityClassName
+	wl.Spec.PriorityClassSource = source
+	wl.Spec.Priority = &p
+	return wl, nil
+}
+
+// startGroup ungates the pods of an admitted group, injecting the node
+// selector of the pod set of their role.
+func (r *Reconciler) startGroup(ctx context.Context, pods []corev1.Pod, wl *kueue.Workload) error {
+	log := ctrl.LoggerFrom(ctx)
+	var infos map[string]jobframework.PodSetInfo
+	for i := range pods {
+		pod := &pods[i]
+		if !hasSchedulingGate(pod) {
+			continue
+		}
+		if infos == nil {
+			podSetsInfo, err := jobframework.GetPodSetsInfoFromAdmission(ctx, r.client, wl)
+			if err != nil {
+				return err
+			}
+			infos = make(map[string]jobframework.PodSetInfo, len(podSetsInfo))
+			for _, info := range podSetsInfo {
+				infos[info.Name] = info
+			}
+		}
+		info, found := infos[roleHash(pod)]
+		if !found {
+			// The pod was added to the group after the workload was created.
+			log.V(2).Info("Pod doesn't match any pod set of the workload, keeping it gated", "pod", klog.KObj(pod))
+			continue
+		}
+		ungatePod(pod)
+		injectNodeSelector(pod, info.NodeSelector)
+		if err := r.client.Update(ctx, pod); err != nil {
+			return err
+		}
+		r.record.Eventf(pod, corev1.EventTypeNormal, "Started",
+			"Admitted by clusterQueue %v", wl.Status.Admission.ClusterQueue)
+	}
+	return nil
+}
+
+// stopGroup stops the pods of the group. A gated pod doesn't take any
+// resources, so a group whose pods are all gated is left as is. Otherwise, as
+// the deleted pods won't come back, all the pods that didn't finish are
+// deleted and the workload is finished, instead of waiting for the missing
+// pods after the workload is admitted again.
+func (r *Reconciler) stopGroup(ctx context.Context, wl *kueue.Workload, pods []corev1.Pod, eventMsg string) error {
+	log := ctrl.LoggerFrom(ctx)
+	if !groupStarted(pods) {
+		return nil
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if isPodTerminated(pod) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if err := r.client.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		r.record.Eventf(pod, corev1.EventTypeNormal, "Stopped", eventMsg)
+	}
+	err := workload.UpdateStatus(ctx, r.client, wl, kueue.WorkloadFinished, metav1.ConditionTrue,
+		"PodsStopped", fmt.Sprintf("The pods of the group were stopped: %s", eventMsg), constants.JobControllerName)
+	if err != nil {
+		log.Error(err, "Updating workload status")
+		return err
+	}
+	return r.finalizeGroup(ctx, pods)
+}
+
+// groupStarted returns whether any pod of the group was ungated.
+func groupStarted(pods []corev1.Pod) bool {
+	for i := range pods {
+		if !hasSchedulingGate(&pods[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// finalizeGroup removes the PodFinalizer from the pods of a group whose
+// workload finished.
+func (r *Reconciler) finalizeGroup(ctx context.Context, pods []corev1.Pod) error {
+	for i := range pods {
+		if err := r.removeFinalizer(ctx, &pods[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseDeletedPods removes the PodFinalizer from the deleted pods and
+// returns the rest.
+func (r *Reconciler) releaseDeletedPods(ctx context.Context, pods []corev1.Pod) ([]corev1.Pod, error) {
+	remaining := make([]corev1.Pod, 0, len(pods))
+	for i := range pods {
+		if pods[i].DeletionTimestamp == nil {
+			remaining = append(remaining, pods[i])
+			continue
+		}
+		if err := r.removeFinalizer(ctx, &pods[i]); err != nil {
+			return nil, err
+		}
+	}
+	return remaining, nil
+}
+
+// removeFinalizer removes the PodFinalizer with a patch, as the pod might
+// have changed since it was listed, for example, if it was just deleted.
+func (r *Reconciler) removeFinalizer(ctx context.Context, pod *corev1.Pod) error {
+	if !controllerutil.ContainsFinalizer(pod, PodFinalizer) {
+		return nil
+	}
+	patch := client.MergeFrom(pod.DeepCopy())
+	controllerutil.RemoveFinalizer(pod, PodFinalizer)
+	return client.IgnoreNotFound(r.client.Patch(ctx, pod, patch))
+}
+
+// groupFinished returns whether all the pods of the group terminated. The
+// pods that were deleted before terminating count as failed.
+func groupFinished(pods []corev1.Pod, totalCount int) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:    kueue.WorkloadFinished,
+		Status:  metav1.ConditionTrue,
+		Reason:  "PodsFinished",
+		Message: "Pods finished successfully",
+	}
+	if len(pods) < totalCount {
+		return condition, false
+	}
+	failed := 0
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		if pod.Status.Phase != corev1.PodFailed && pod.DeletionTimestamp == nil {
+			return condition, false
+		}
+		failed++
+	}
+	if failed > 0 {
+		condition.Message = fmt.Sprintf("%d pods failed", failed)
+	}
+	return condition, true
+}
+
+func groupPodsReadyCondition(pods []corev1.Pod, totalCount int, wl *kueue.Workload) metav1.Condition {
+	ready := 0
+	for i := range pods {
+		if isPodReady(&pods[i]) {
+			ready++
+		}
+	}
+	condition := metav1.Condition{
+		Type:    kueue.WorkloadPodsReady,
+		Status:  metav1.ConditionFalse,
+		Reason:  "PodsReady",
+		Message: "Not all pods are ready or succeeded",
+	}
+	if wl.Status.Admission != nil && (ready >= totalCount || apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadPodsReady)) {
+		condition.Status = metav1.ConditionTrue
+		condition.Message = "All pods were ready or succeeded since the workload admission"
+	}
+	return condition
+}
+
+// groupPodSets returns a pod set for each role in the group, sorted by
+// name. Pods have the same role if they have the same scheduling
+// requirements, see roleHash.
+func groupPodSets(pods []corev1.Pod) []kueue.PodSet {
+	podSets := make(map[string]*kueue.PodSet)
+	for i := range pods {
+		role := roleHash(&pods[i])
+		if ps, found := podSets[role]; found {
+			ps.Count++
+			continue
+		}
+		podSets[role] = &kueue.PodSet{
+			Name:     role,
+			Count:    1,
+			Template: podTemplate(&pods[i]),
+		}
+	}
+	result := make([]kueue.PodSet, 0, len(podSets))
+	for _, ps := range podSets {
+		result = append(result, *ps)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// roleHash returns a hash of the fields of the pod spec that affect its
+// scheduling, so that pods with the same requirements share a pod set.
+func roleHash(pod *corev1.Pod) string {
+	shape := corev1.PodSpec{
+		InitContainers:    containersShape(pod.Spec.InitContainers),
+		Containers:        containersShape(pod.Spec.Containers),
+		NodeSelector:      pod.Spec.NodeSelector,
+		Affinity:          pod.Spec.Affinity,
+		Tolerations:       pod.Spec.Tolerations,
+		RuntimeClassName:  pod.Spec.RuntimeClassName,
+		PriorityClassName: pod.Spec.PriorityClassName,
+		Overhead:          pod.Spec.Overhead,
+	}
+	// Marshalling a PodSpec can't fail.
+	data, _ := json.Marshal(shape)
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])[:roleHashLength]
+}
+
+func containersShape(containers []corev1.Container) []corev1.Container {
+	if len(containers) == 0 {
+		return nil
+	}
+	shape := make([]corev1.Container, len(containers))
+	for i := range containers {
+		shape[i] = corev1.Container{
+			Name:      containers[i].Name,
+			Image:     containers[i].Image,
+			Ports:     containers[i].Ports,
+			Resources: containers[i].Resources,
+		}
+	}
+	return shape
+}
+
+// groupTotalCount returns the number of pods in the group of the pod.
+func groupTotalCount(pod *corev1.Pod) (int, error) {
+	strVal, found := pod.Annotations[GroupTotalCountAnnotation]
+	if !found {
+		return 0, fmt.Errorf("missing annotation %s", GroupTotalCountAnnotation)
+	}
+	count, err := strconv.Atoi(strVal)
+	if err != nil {
+		return 0, err
+	}
+	if count <= 0 {
+		return 0, fmt.Errorf("annotation %s must be greater than 0", GroupTotalCountAnnotation)
+	}
+	return count, nil
+}
+
+func GetWorkloadNameForPodGroup(groupName string) string {
+	return jobframework.GetWorkloadNameForOwnerWithGVK(groupName, groupGVK)
+}
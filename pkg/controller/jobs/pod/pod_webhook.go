@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+)
+
+var (
+	groupNameLabelPath            = field.NewPath("metadata", "labels").Key(GroupNameLabel)
+	groupTotalCountAnnotationPath = field.NewPath("metadata", "annotations").Key(GroupTotalCountAnnotation)
+)
+
+// PodWebhook gates the pods that set a queue name, so that they are only
+// scheduled once their workload is admitted. The webhook configuration only
+// selects the pods with the queue name label, hence the
+// manageJobsWithoutQueueName option doesn't apply to pods.
+type PodWebhook struct{}
+
+func WebhookType() runtime.Object {
+	return &corev1.Pod{}
+}
+
+// SetupWebhook configures the webhook for pods.
+func SetupWebhook(mgr ctrl.Manager, opts ...jobframework.Option) error {
+	wh := &PodWebhook{}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(WebhookType()).
+		WithDefaulter(wh).
+		WithValidator(wh).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=fail,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod.kb.io,admissionReviewVersions=v1
+
+var _ webhook.CustomDefaulter = &PodWebhook{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (w *PodWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	pod := obj.(*corev1.Pod)
+	log := ctrl.LoggerFrom(ctx).WithName("pod-webhook")
+	log.V(5).Info("Applying defaults", "pod", klog.KObj(pod))
+
+	if jobframework.QueueName(&Pod{Pod: *pod}) == "" {
+		return nil
+	}
+	// The pods of the jobs that Kueue manages are admitted through their job.
+	if owner := metav1.GetControllerOf(pod); owner != nil && (isBatchJob(owner) || jobframework.KnownWorkloadOwner(owner)) {
+		return nil
+	}
+	if pod.Labels == nil {
+		pod.Labels = make(map[string]string, 1)
+	}
+	pod.Labels[ManagedLabelKey] = ManagedLabelValue
+	gatePod(pod)
+	if pod.Labels[GroupNameLabel] != "" {
+		controllerutil.AddFinalizer(pod, PodFinalizer)
+	}
+	return nil
+}
+
+func isBatchJob(owner *metav1.OwnerReference) bool {
+	return owner.Kind == "Job" && owner.APIVersion == batchv1.SchemeGroupVersion.String()
+}
+
+// +kubebuilder:webhook:path=/validate--v1-pod,mutating=false,failurePolicy=fail,sideEffects=None,groups="",resources=pods,verbs=create;update,versions=v1,name=vpod.kb.io,admissionReviewVersions=v1
+
+var _ webhook.CustomValidator = &PodWebhook{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *PodWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	pod := obj.(*corev1.Pod)
+	if !isManagedPod(pod) {
+		return nil
+	}
+	log := ctrl.LoggerFrom(ctx).WithName("pod-webhook")
+	log.V(5).Info("Validating create", "pod", klog.KObj(pod))
+	return validateCreate(&Pod{Pod: *pod}).ToAggregate()
+}
+
+func validateCreate(pod *Pod) field.ErrorList {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, jobframework.ValidateCreateForQueueName(pod)...)
+	allErrs = append(allErrs, jobframework.ValidateCreateForWorkloadPriorityClassName(pod)...)
+	allErrs = append(allErrs, validatePodGroup(pod)...)
+	return allErrs
+}
+
+// validatePodGroup checks that the group name label and the total count
+// annotation are set together, and that the total count is a positive
+// number.
+func validatePodGroup(pod *Pod) field.ErrorList {
+	groupName := pod.Labels[GroupNameLabel]
+	strVal, found := pod.Annotations[GroupTotalCountAnnotation]
+	if groupName == "" {
+		if found {
+			return field.ErrorList{field.Forbidden(groupTotalCountAnnotationPath, "only allowed for pods in a group")}
+		}
+		return nil
+	}
+	if !found {
+		return field.ErrorList{field.Required(groupTotalCountAnnotationPath, "required for pods in a group")}
+	}
+	count, err := strconv.ParseInt(strVal, 10, 32)
+	if err != nil {
+		return field.ErrorList{field.Invalid(groupTotalCountAnnotationPath, strVal, err.Error())}
+	}
+	if count <= 0 {
+		return field.ErrorList{field.Invalid(groupTotalCountAnnotationPath, strVal, "should be greater than 0")}
+	}
+	return nil
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *PodWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldPod := oldObj.(*corev1.Pod)
+	newPod := newObj.(*corev1.Pod)
+	if !isManagedPod(newPod) {
+		return nil
+	}
+	log := ctrl.LoggerFrom(ctx).WithName("pod-webhook")
+	log.V(5).Info("Validating update", "pod", klog.KObj(newPod))
+	return validateUpdate(&Pod{Pod: *oldPod}, &Pod{Pod: *newPod}).ToAggregate()
+}
+
+func validateUpdate(oldPod, newPod *Pod) field.ErrorList {
+	allErrs := validateCreate(newPod)
+	allErrs = append(allErrs, jobframework.ValidateUpdateForOriginalNodeSelectors(oldPod, newPod)...)
+	allErrs = append(allErrs, jobframework.ValidateUpdateForQueueName(oldPod, newPod)...)
+	allErrs = append(allErrs, jobframework.ValidateUpdateForWorkloadPriorityClassName(oldPod, newPod)...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(newPod.Labels[GroupNameLabel], oldPod.Labels[GroupNameLabel], groupNameLabelPath)...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(newPod.Annotations[GroupTotalCountAnnotation], oldPod.Annotations[GroupTotalCountAnnotation], groupTotalCountAnnotationPath)...)
+	return allErrs
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *PodWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	testingpod "sigs.k8s.io/kueue/pkg/util/testingjobs/pod"
+)
+
+func TestDefault(t *testing.T) {
+	testcases := map[string]struct {
+		pod  *corev1.Pod
+		want *corev1.Pod
+	}{
+		"pod without queue name": {
+			pod:  testingpod.MakePod("pod", "ns").Obj(),
+			want: testingpod.MakePod("pod", "ns").Obj(),
+		},
+		"pod with queue name": {
+			pod: testingpod.MakePod("pod", "ns").Queue("queue").Obj(),
+			want: testingpod.MakePod("pod", "ns").
+				Queue("queue").
+				Label(ManagedLabelKey, ManagedLabelValue).
+				Gate(SchedulingGateName).
+				Obj(),
+		},
+		"pod in a group": {
+			pod: testingpod.MakePod("pod", "ns").
+				Queue("queue").
+				Label(GroupNameLabel, "group").
+				Obj(),
+			want: testingpod.MakePod("pod", "ns").
+				Queue("queue").
+				Label(GroupNameLabel, "group").
+				Label(ManagedLabelKey, ManagedLabelValue).
+				Gate(SchedulingGateName).
+				Finalizer(PodFinalizer).
+				Obj(),
+		},
+		"pod owned by a job": {
+			pod: testingpod.MakePod("pod", "ns").
+				Queue("queue").
+				OwnerReference("job", batchv1.SchemeGroupVersion.WithKind("Job"), true).
+				Obj(),
+			want: testingpod.MakePod("pod", "ns").
+				Queue("queue").
+				OwnerReference("job", batchv1.SchemeGroupVersion.WithKind("Job"), true).
+				Obj(),
+		},
+		"pod owned by an mpijob": {
+			pod: testingpod.MakePod("pod", "ns").
+				Queue("queue").
+				OwnerReference("mpijob", schema.GroupVersionKind{Group: "kubeflow.org", Version: "v2beta1", Kind: "MPIJob"}, true).
+				Obj(),
+			want: testingpod.MakePod("pod", "ns").
+				Queue("queue").
+				OwnerReference("mpijob", schema.GroupVersionKind{Group: "kubeflow.org", Version: "v2beta1", Kind: "MPIJob"}, true).
+				Obj(),
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			w := &PodWebhook{}
+			if err := w.Default(context.Background(), tc.pod); err != nil {
+				t.Errorf("set defaults to a pod: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, tc.pod); diff != "" {
+				t.Errorf("Default() mismatch (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	managedPod := func() *testingpod.PodWrapper {
+		return testingpod.MakePod("pod", "ns").
+			Queue("queue").
+			Label(ManagedLabelKey, ManagedLabelValue).
+			Gate(SchedulingGateName)
+	}
+	testcases := map[string]struct {
+		pod     *corev1.Pod
+		wantErr field.ErrorList
+	}{
+		"simple": {
+			pod: managedPod().Obj(),
+		},
+		"pod group": {
+			pod: managedPod().
+				Label(GroupNameLabel, "group").
+				Annotation(GroupTotalCountAnnotation, "2").
+				Obj(),
+		},
+		"pod group without total count": {
+			pod: managedPod().
+				Label(GroupNameLabel, "group").
+				Obj(),
+			wantErr: field.ErrorList{field.Required(groupTotalCountAnnotationPath, "")},
+		},
+		"pod group with invalid total count": {
+			pod: managedPod().
+				Label(GroupNameLabel, "group").
+				Annotation(GroupTotalCountAnnotation, "two").
+				Obj(),
+			wantErr: field.ErrorList{field.Invalid(groupTotalCountAnnotationPath, "two", "")},
+		},
+		"pod group with zero total count": {
+			pod: managedPod().
+				Label(GroupNameLabel, "group").
+				Annotation(GroupTotalCountAnnotation, "0").
+				Obj(),
+			wantErr: field.ErrorList{field.Invalid(groupTotalCountAnnotationPath, "0", "")},
+		},
+		"total count without pod group": {
+			pod: managedPod().
+				Annotation(GroupTotalCountAnnotation, "2").
+				Obj(),
+			wantErr: field.ErrorList{field.Forbidden(groupTotalCountAnnotationPath, "")},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			gotErr := validateCreate(&Pod{Pod: *tc.pod})
+			if diff := cmp.Diff(tc.wantErr, gotErr, cmpopts.IgnoreFields(field.Error{}, "Detail")); diff != "" {
+				t.Errorf("validateCreate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	groupPod := func() *testingpod.PodWrapper {
+		return testingpod.MakePod("pod", "ns").
+			Queue("queue").
+			Label(ManagedLabelKey, ManagedLabelValue).
+			Label(GroupNameLabel, "group").
+			Annotation(GroupTotalCountAnnotation, "2").
+			Gate(SchedulingGateName)
+	}
+	testcases := map[string]struct {
+		oldPod  *corev1.Pod
+		newPod  *corev1.Pod
+		wantErr field.ErrorList
+	}{
+		"ungating the pod": {
+			oldPod: groupPod().Obj(),
+			newPod: func() *corev1.Pod {
+				pod := groupPod().NodeSelector("pool", "spot").Obj()
+				ungatePod(pod)
+				return pod
+			}(),
+		},
+		"updating the group name": {
+			oldPod:  groupPod().Obj(),
+			newPod:  groupPod().Label(GroupNameLabel, "other").Obj(),
+			wantErr: field.ErrorList{field.Invalid(groupNameLabelPath, "other", "")},
+		},
+		"updating the total count": {
+			oldPod:  groupPod().Obj(),
+			newPod:  groupPod().Annotation(GroupTotalCountAnnotation, "3").Obj(),
+			wantErr: field.ErrorList{field.Invalid(groupTotalCountAnnotationPath, "3", "")},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			gotErr := validateUpdate(&Pod{Pod: *tc.oldPod}, &Pod{Pod: *tc.newPod})
+			if diff := cmp.Diff(tc.wantErr, gotErr, cmpopts.IgnoreFields(field.Error{}, "Detail")); diff != "" {
+				t.Errorf("validateUpdate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -46,10 +46,14 @@ const (
 	CQStatusActive ClusterQueueStatus = "active"
 	// CQStatusTerminating means the clusterQueue is in pending deletion.
 	CQStatusTerminating ClusterQueueStatus = "terminating"
+	// CQStatusStopped means the ClusterQueue was stopped through its stopPolicy.
+	// In this state, the ClusterQueue can't admit new workloads and its quota can't be borrowed
+	// by other active ClusterQueues in the cohort.
+	CQStatusStopped ClusterQueueStatus = "stopped"
 )
 
 var (
-	CQStatuses = []ClusterQueueStatus{CQStatusPending, CQStatusActive, CQStatusTerminating}
+	CQStatuses = []ClusterQueueStatus{CQStatusPending, CQStatusActive, CQStatusTerminating, CQStatusStopped}
 
 	admissionAttemptsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
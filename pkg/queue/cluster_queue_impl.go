@@ -18,6 +18,8 @@ package queue
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -36,12 +38,18 @@ import (
 // interface. It can be inherited and overwritten by other types.
 type clusterQueueBase struct {
 	heap              heap.Heap
+	lessFunc          func(a, b interface{}) bool
 	cohort            string
 	namespaceSelector labels.Selector
 
-	// inadmissibleWorkloads are workloads that have been tried at least once and couldn't be admitted.
+	// inadmissibleWorkloads are workloads that have been tried at least once and couldn't be admitted,
+	// or that are waiting for their requeuing backoff to expire.
 	inadmissibleWorkloads map[string]*workload.Info
 
+	// backoffWorkloads are the keys of the inadmissibleWorkloads that were
+	// put aside until their requeuing backoff expires.
+	backoffWorkloads sets.Set[string]
+
 	// popCycle identifies the last call to Pop. It's incremented when calling Pop.
 	// popCycle and queueInadmissibleCycle are used to track when there is a requeueing
 	// of inadmissible workloads while a workload is being scheduled.
@@ -55,7 +63,9 @@ type clusterQueueBase struct {
 func newClusterQueueImpl(keyFunc func(obj interface{}) string, lessFunc func(a, b interface{}) bool) *clusterQueueBase {
 	return &clusterQueueBase{
 		heap:                   heap.New(keyFunc, lessFunc),
+		lessFunc:               lessFunc,
 		inadmissibleWorkloads:  make(map[string]*workload.Info),
+		backoffWorkloads:       sets.New[string](),
 		queueInadmissibleCycle: -1,
 	}
 }
@@ -77,6 +87,10 @@ func (c *clusterQueueBase) Cohort() string {
 func (c *clusterQueueBase) AddFromLocalQueue(q *LocalQueue) bool {
 	added := false
 	for _, info := range q.items {
+		if !c.backoffWaitingTimeExpired(info) {
+			c.waitForBackoff(info)
+			continue
+		}
 		if c.heap.PushIfNotPresent(info) {
 			added = true
 		}
@@ -86,6 +100,10 @@ func (c *clusterQueueBase) AddFromLocalQueue(q *LocalQueue) bool {
 
 func (c *clusterQueueBase) PushOrUpdate(wInfo *workload.Info) {
 	key := workload.Key(wInfo.Obj)
+	if !c.backoffWaitingTimeExpired(wInfo) {
+		c.waitForBackoff(wInfo)
+		return
+	}
 	oldInfo := c.inadmissibleWorkloads[key]
 	if oldInfo != nil {
 		// update in place if the workload was inadmissible and didn't change
@@ -103,6 +121,7 @@ func (c *clusterQueueBase) PushOrUpdate(wInfo *workload.Info) {
 func (c *clusterQueueBase) Delete(w *kueue.Workload) {
 	key := workload.Key(w)
 	delete(c.inadmissibleWorkloads, key)
+	c.backoffWorkloads.Delete(key)
 	c.heap.Delete(key)
 }
 
@@ -148,7 +167,8 @@ func (c *clusterQueueBase) requeueIfNotPresent(wInfo *workload.Info, immediate b
 	return true
 }
 
-// QueueInadmissibleWorkloads moves all workloads from inadmissibleWorkloads to heap.
+// QueueInadmissibleWorkloads moves all workloads from inadmissibleWorkloads to heap,
+// except the ones whose requeuing backoff didn't expire yet.
 // If at least one workload is moved, returns true. Otherwise returns false.
 func (c *clusterQueueBase) QueueInadmissibleWorkloads(ctx context.Context, client client.Client) bool {
 	c.queueInadmissibleCycle = c.popCycle
@@ -159,11 +179,16 @@ func (c *clusterQueueBase) QueueInadmissibleWorkloads(ctx context.Context, clien
 	inadmissibleWorkloads := make(map[string]*workload.Info)
 	moved := false
 	for key, wInfo := range c.inadmissibleWorkloads {
+		if !c.backoffWaitingTimeExpired(wInfo) {
+			inadmissibleWorkloads[key] = wInfo
+			continue
+		}
 		ns := corev1.Namespace{}
 		err := client.Get(ctx, types.NamespacedName{Name: wInfo.Obj.Namespace}, &ns)
 		if err != nil || !c.namespaceSelector.Matches(labels.Set(ns.Labels)) {
 			inadmissibleWorkloads[key] = wInfo
 		} else {
+			c.backoffWorkloads.Delete(key)
 			moved = c.heap.PushIfNotPresent(wInfo) || moved
 		}
 	}
@@ -172,6 +197,31 @@ func (c *clusterQueueBase) QueueInadmissibleWorkloads(ctx context.Context, clien
 	return moved
 }
 
+func (c *clusterQueueBase) QueueWorkloadAfterBackoff(key string) bool {
+	wInfo := c.inadmissibleWorkloads[key]
+	if wInfo == nil || !c.backoffWorkloads.Has(key) || !c.backoffWaitingTimeExpired(wInfo) {
+		return false
+	}
+	delete(c.inadmissibleWorkloads, key)
+	c.backoffWorkloads.Delete(key)
+	return c.heap.PushIfNotPresent(wInfo)
+}
+
+// waitForBackoff puts the workload aside, so that it doesn't compete for
+// admission until its requeuing backoff expires.
+func (c *clusterQueueBase) waitForBackoff(wInfo *workload.Info) {
+	key := workload.Key(wInfo.Obj)
+	c.heap.Delete(key)
+	c.inadmissibleWorkloads[key] = wInfo
+	c.backoffWorkloads.Insert(key)
+}
+
+// backoffWaitingTimeExpired returns whether the workload is past the
+// requeuing backoff applied after its eviction, if any.
+func (c *clusterQueueBase) backoffWaitingTimeExpired(wInfo *workload.Info) bool {
+	return workload.RemainingRequeueBackoff(wInfo.Obj, time.Now()) == 0
+}
+
 func (c *clusterQueueBase) Pending() int {
 	return c.PendingActive() + c.PendingInadmissible()
 }
@@ -217,6 +267,33 @@ func (c *clusterQueueBase) DumpInadmissible() (sets.Set[string], bool) {
 	return elements, true
 }
 
+func (c *clusterQueueBase) SortedPending() ([]*workload.Info, []*workload.Info) {
+	active := make([]*workload.Info, 0, c.heap.Len())
+	for _, e := range c.heap.List() {
+		active = append(active, e.(*workload.Info))
+	}
+	inadmissible := make([]*workload.Info, 0, len(c.inadmissibleWorkloads))
+	for _, info := range c.inadmissibleWorkloads {
+		inadmissible = append(inadmissible, info)
+	}
+	c.sort(active)
+	c.sort(inadmissible)
+	return active, inadmissible
+}
+
+func (c *clusterQueueBase) sort(infos []*workload.Info) {
+	sort.Slice(infos, func(i, j int) bool {
+		if c.lessFunc(infos[i], infos[j]) {
+			return true
+		}
+		if c.lessFunc(infos[j], infos[i]) {
+			return false
+		}
+		// Break ties by key, so that the order is stable across calls.
+		return workload.Key(infos[i].Obj) < workload.Key(infos[j].Obj)
+	})
+}
+
 func (c *clusterQueueBase) Info(key string) *workload.Info {
 	info := c.heap.GetByKey(key)
 	if info == nil {
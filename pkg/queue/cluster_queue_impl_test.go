@@ -56,6 +56,35 @@ func Test_PushOrUpdate(t *testing.T) {
 	}
 }
 
+func Test_PushOrUpdateWaitingForBackoff(t *testing.T) {
+	cq := newClusterQueueImpl(keyFunc, byCreationTime)
+	now := time.Now()
+	wl := utiltesting.MakeWorkload("workload-1", defaultNamespace).RequeueState(1, now.Add(time.Hour)).Obj()
+	cq.PushOrUpdate(workload.NewInfo(wl))
+	if cq.PendingActive() != 0 || cq.PendingInadmissible() != 1 {
+		t.Errorf("Workload waiting for its backoff should be inadmissible, got %d active and %d inadmissible", cq.PendingActive(), cq.PendingInadmissible())
+	}
+	if cq.QueueInadmissibleWorkloads(context.Background(), nil) {
+		t.Error("Workload waiting for its backoff shouldn't be moved to the heap")
+	}
+	key := workload.Key(wl)
+	if cq.QueueWorkloadAfterBackoff(key) {
+		t.Error("Workload waiting for its backoff shouldn't be queued")
+	}
+
+	// The workload is updated after the backoff expired.
+	cq.PushOrUpdate(workload.NewInfo(utiltesting.MakeWorkload("workload-1", defaultNamespace).RequeueState(1, now.Add(-time.Second)).Obj()))
+	if !cq.QueueWorkloadAfterBackoff(key) {
+		t.Error("Workload should be queued after its backoff expired")
+	}
+	if cq.PendingActive() != 1 || cq.PendingInadmissible() != 0 {
+		t.Errorf("Workload should be active, got %d active and %d inadmissible", cq.PendingActive(), cq.PendingInadmissible())
+	}
+	if cq.QueueWorkloadAfterBackoff(key) {
+		t.Error("Workload shouldn't be queued twice")
+	}
+}
+
 func Test_Pop(t *testing.T) {
 	cq := newClusterQueueImpl(keyFunc, byCreationTime)
 	now := time.Now()
@@ -74,6 +74,10 @@ type ClusterQueue interface {
 	// to the ClusterQueue. If at least one workload is moved,
 	// returns true. Otherwise returns false.
 	QueueInadmissibleWorkloads(ctx context.Context, client client.Client) bool
+	// QueueWorkloadAfterBackoff moves the workload with the given key, if it
+	// was put aside until its requeuing backoff expired, to the ClusterQueue.
+	// Returns true if the workload was moved.
+	QueueWorkloadAfterBackoff(key string) bool
 
 	// Pending returns the total number of pending workloads.
 	Pending() int
@@ -91,6 +95,10 @@ type ClusterQueue interface {
 	// Otherwise returns true.
 	Dump() (sets.Set[string], bool)
 	DumpInadmissible() (sets.Set[string], bool)
+	// SortedPending returns the active and the inadmissible pending
+	// workloads, each in the order in which they would be popped.
+	// Users of this method should not modify the returned objects.
+	SortedPending() (active []*workload.Info, inadmissible []*workload.Info)
 	// Info returns workload.Info for the workload key.
 	// Users of this method should not modify the returned object.
 	Info(string) *workload.Info
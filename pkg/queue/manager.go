@@ -50,6 +50,8 @@ type Manager struct {
 
 	// Key is cohort's name. Value is a set of associated ClusterQueue names.
 	cohorts map[string]sets.Set[string]
+	// Key is cohort's name. Value is the name of its parent cohort.
+	cohortParents map[string]string
 }
 
 func NewManager(client client.Client, checker StatusChecker) *Manager {
@@ -59,6 +61,7 @@ func NewManager(client client.Client, checker StatusChecker) *Manager {
 		localQueues:   make(map[string]*LocalQueue),
 		clusterQueues: make(map[string]ClusterQueue),
 		cohorts:       make(map[string]sets.Set[string]),
+		cohortParents: make(map[string]string),
 	}
 	m.cond.L = &m.RWMutex
 	return m
@@ -169,7 +172,7 @@ func (m *Manager) AddLocalQueue(ctx context.Context, q *kueue.LocalQueue) error
 	}
 	for _, w := range workloads.Items {
 		w := w
-		if w.Status.Admission != nil {
+		if w.Status.Admission != nil || !workload.IsActive(&w) {
 			continue
 		}
 		qImpl.AddOrUpdate(workload.NewInfo(&w))
@@ -283,7 +286,8 @@ func (m *Manager) addOrUpdateWorkload(w *kueue.Workload) bool {
 }
 
 // RequeueWorkload requeues the workload ensuring that the queue and the
-// workload still exist in the client cache and it's not admitted. It won't
+// workload still exist in the client cache and it's neither admitted nor
+// deactivated. It won't
 // requeue if the workload is already in the queue (possible if the workload was updated).
 func (m *Manager) RequeueWorkload(ctx context.Context, info *workload.Info, reason RequeueReason) bool {
 	m.Lock()
@@ -293,7 +297,7 @@ func (m *Manager) RequeueWorkload(ctx context.Context, info *workload.Info, reas
 	// Always get the newest workload to avoid requeuing the out-of-date obj.
 	err := m.client.Get(ctx, client.ObjectKeyFromObject(info.Obj), &w)
 	// Since the client is cached, the only possible error is NotFound
-	if apierrors.IsNotFound(err) || w.Status.Admission != nil {
+	if apierrors.IsNotFound(err) || w.Status.Admission != nil || !workload.IsActive(&w) {
 		return false
 	}
 
@@ -362,6 +366,29 @@ func (m *Manager) QueueAssociatedInadmissibleWorkloadsAfter(ctx context.Context,
 	}
 }
 
+// QueueWorkloadAfterBackoff moves the workload, which was put aside until its
+// requeuing backoff expired, back to the heap of its ClusterQueue. Returns
+// whether the workload was moved.
+func (m *Manager) QueueWorkloadAfterBackoff(w *kueue.Workload) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	q := m.localQueues[workload.QueueKey(w)]
+	if q == nil {
+		return false
+	}
+	cq := m.clusterQueues[q.ClusterQueue]
+	if cq == nil {
+		return false
+	}
+	if !cq.QueueWorkloadAfterBackoff(workload.Key(w)) {
+		return false
+	}
+	m.reportPendingWorkloads(q.ClusterQueue, cq)
+	m.Broadcast()
+	return true
+}
+
 // QueueInadmissibleWorkloads moves all inadmissibleWorkloads in
 // corresponding ClusterQueues to heap. If at least one workload queued,
 // we will broadcast the event.
@@ -389,7 +416,7 @@ func (m *Manager) QueueInadmissibleWorkloads(ctx context.Context, cqNames sets.S
 }
 
 // queueAllInadmissibleWorkloadsInCohort moves all workloads in the same
-// cohort with this ClusterQueue from inadmissibleWorkloads to heap. If the
+// cohort tree with this ClusterQueue from inadmissibleWorkloads to heap. If the
 // cohort of this ClusterQueue is empty, it just moves all workloads in this
 // ClusterQueue. If at least one workload is moved, returns true. Otherwise
 // returns false.
@@ -403,16 +430,78 @@ func (m *Manager) queueAllInadmissibleWorkloadsInCohort(ctx context.Context, cq
 	if cohort == "" {
 		return cq.QueueInadmissibleWorkloads(ctx, m.client)
 	}
+	return m.queueAllInadmissibleWorkloadsInCohortTree(ctx, cohort)
+}
 
+// queueAllInadmissibleWorkloadsInCohortTree moves all workloads in the
+// ClusterQueues of the tree that the cohort belongs to from
+// inadmissibleWorkloads to heap. If at least one workload is moved, returns
+// true. Otherwise returns false.
+func (m *Manager) queueAllInadmissibleWorkloadsInCohortTree(ctx context.Context, cohort string) bool {
+	root := m.cohortRoot(cohort)
 	queued := false
-	for cqName := range m.cohorts[cohort] {
-		if clusterQueue, ok := m.clusterQueues[cqName]; ok {
-			queued = clusterQueue.QueueInadmissibleWorkloads(ctx, m.client) || queued
+	for name, cqNames := range m.cohorts {
+		if m.cohortRoot(name) != root {
+			continue
+		}
+		for cqName := range cqNames {
+			if clusterQueue, ok := m.clusterQueues[cqName]; ok {
+				queued = clusterQueue.QueueInadmissibleWorkloads(ctx, m.client) || queued
+			}
 		}
 	}
 	return queued
 }
 
+// cohortRoot returns the name of the cohort at the top of the tree that the
+// cohort belongs to. If the parents form a cycle, the smallest name in the
+// cycle is returned.
+func (m *Manager) cohortRoot(cohort string) string {
+	visited := sets.New[string]()
+	for {
+		parent := m.cohortParents[cohort]
+		if parent == "" {
+			return cohort
+		}
+		visited.Insert(cohort)
+		if visited.Has(parent) {
+			root := parent
+			for c := m.cohortParents[parent]; c != parent; c = m.cohortParents[c] {
+				if c < root {
+					root = c
+				}
+			}
+			return root
+		}
+		cohort = parent
+	}
+}
+
+// AddOrUpdateCohort records the parent of the cohort and requeues the
+// inadmissible workloads of its tree, as they could fit now.
+func (m *Manager) AddOrUpdateCohort(ctx context.Context, cohort *kueue.Cohort) {
+	m.Lock()
+	defer m.Unlock()
+	oldParent := m.cohortParents[cohort.Name]
+	if cohort.Spec.Parent == "" {
+		delete(m.cohortParents, cohort.Name)
+	} else {
+		m.cohortParents[cohort.Name] = cohort.Spec.Parent
+	}
+	if oldParent == cohort.Spec.Parent {
+		return
+	}
+	if m.queueAllInadmissibleWorkloadsInCohortTree(ctx, cohort.Name) {
+		m.Broadcast()
+	}
+}
+
+func (m *Manager) DeleteCohort(cohort *kueue.Cohort) {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.cohortParents, cohort.Name)
+}
+
 // UpdateWorkload updates the workload to the corresponding queue or adds it if
 // it didn't exist. Returns whether the queue existed.
 func (m *Manager) UpdateWorkload(oldW, w *kueue.Workload) bool {
@@ -453,6 +542,76 @@ func (m *Manager) Heads(ctx context.Context) []workload.Info {
 	}
 }
 
+// PendingWorkload is a pending workload and its position in the queues.
+type PendingWorkload struct {
+	Info *workload.Info
+	// PositionInClusterQueue is the 0-based position of the workload in its
+	// ClusterQueue. The inadmissible workloads go after the active ones.
+	PositionInClusterQueue int
+	// PositionInLocalQueue is the 0-based position of the workload among the
+	// workloads of its LocalQueue in the ClusterQueue.
+	PositionInLocalQueue int
+	// Inadmissible indicates that the workload was already tried and is
+	// waiting for cluster conditions to change to become admissible again.
+	Inadmissible bool
+}
+
+// PendingWorkloadsInClusterQueue returns the pending workloads of the
+// ClusterQueue in the order in which they would be tried for admission, and
+// whether the ClusterQueue exists.
+// Users of this method should not modify the returned objects.
+func (m *Manager) PendingWorkloadsInClusterQueue(cqName string) ([]PendingWorkload, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	cq, ok := m.clusterQueues[cqName]
+	if !ok {
+		return nil, false
+	}
+	return pendingWorkloads(cq, ""), true
+}
+
+// PendingWorkloadsInLocalQueue returns the pending workloads of the
+// LocalQueue in the order in which they would be tried for admission, and
+// whether the LocalQueue exists.
+// Users of this method should not modify the returned objects.
+func (m *Manager) PendingWorkloadsInLocalQueue(namespace, name string) ([]PendingWorkload, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	q, ok := m.localQueues[fmt.Sprintf("%s/%s", namespace, name)]
+	if !ok {
+		return nil, false
+	}
+	cq, ok := m.clusterQueues[q.ClusterQueue]
+	if !ok {
+		// The workloads are not queued until the ClusterQueue exists.
+		return nil, true
+	}
+	return pendingWorkloads(cq, q.Key), true
+}
+
+// pendingWorkloads returns the pending workloads of the ClusterQueue, only
+// including the ones in the LocalQueue with the given key, if not empty.
+func pendingWorkloads(cq ClusterQueue, qKey string) []PendingWorkload {
+	active, inadmissible := cq.SortedPending()
+	var result []PendingWorkload
+	positionsInLocalQueue := make(map[string]int)
+	for i, info := range append(active, inadmissible...) {
+		infoQKey := workload.QueueKey(info.Obj)
+		positionInLocalQueue := positionsInLocalQueue[infoQKey]
+		positionsInLocalQueue[infoQKey]++
+		if qKey != "" && infoQKey != qKey {
+			continue
+		}
+		result = append(result, PendingWorkload{
+			Info:                   info,
+			PositionInClusterQueue: i,
+			PositionInLocalQueue:   positionInLocalQueue,
+			Inadmissible:           i >= len(active),
+		})
+	}
+	return result
+}
+
 // Dump is a dump of the queues and it's elements (unordered).
 // Only use for testing purposes.
 func (m *Manager) Dump() map[string]sets.Set[string] {
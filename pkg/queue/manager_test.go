@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
 	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
 	"sigs.k8s.io/kueue/pkg/workload"
 )
@@ -182,6 +183,142 @@ func TestUpdateClusterQueue(t *testing.T) {
 	}
 }
 
+// TestUpdateClusterQueueStopPolicy tests that no workloads are popped from a
+// stopped ClusterQueue, and that they are popped again once the stopPolicy is
+// cleared.
+func TestUpdateClusterQueueStopPolicy(t *testing.T) {
+	cq := utiltesting.MakeClusterQueue("cq").Obj()
+	q := utiltesting.MakeLocalQueue("foo", defaultNamespace).ClusterQueue("cq").Obj()
+	now := time.Now()
+	workloads := []*kueue.Workload{
+		utiltesting.MakeWorkload("a", defaultNamespace).Queue("foo").Creation(now).Obj(),
+		utiltesting.MakeWorkload("b", defaultNamespace).Queue("foo").Creation(now.Add(time.Second)).Obj(),
+	}
+	ctx := context.Background()
+	cl := utiltesting.NewFakeClient(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: defaultNamespace}},
+	)
+	checker := &stoppedStatusChecker{stopped: sets.New[string]()}
+	manager := NewManager(cl, checker)
+	if err := manager.AddClusterQueue(ctx, cq); err != nil {
+		t.Fatalf("Failed adding clusterQueue %s: %v", cq.Name, err)
+	}
+	if err := manager.AddLocalQueue(ctx, q); err != nil {
+		t.Fatalf("Failed adding queue %s: %v", q.Name, err)
+	}
+	for _, w := range workloads {
+		if err := cl.Create(ctx, w); err != nil {
+			t.Fatalf("Failed adding workload to client: %v", err)
+		}
+		manager.AddOrUpdateWorkload(w)
+	}
+	heads := manager.heads()
+	if len(heads) != 1 || heads[0].Obj.Name != "a" {
+		t.Fatalf("Unexpected heads before stopping: %v", heads)
+	}
+
+	// Stop the ClusterQueue while a is being scheduled.
+	checker.stopped.Insert("cq")
+	hold := kueue.Hold
+	cq.Spec.StopPolicy = &hold
+	if err := manager.UpdateClusterQueue(ctx, cq); err != nil {
+		t.Fatalf("Failed to update ClusterQueue: %v", err)
+	}
+	manager.RequeueWorkload(ctx, &heads[0], RequeueReasonGeneric)
+	if heads := manager.heads(); len(heads) != 0 {
+		t.Errorf("Popped %d workloads from a stopped ClusterQueue", len(heads))
+	}
+	wantPending := map[string]sets.Set[string]{
+		"cq": sets.New("default/a", "default/b"),
+	}
+	if diff := cmp.Diff(wantPending, manager.Dump()); diff != "" {
+		t.Errorf("Unexpected active workloads while stopped (-want +got):\n%s", diff)
+	}
+
+	// Clear the stopPolicy.
+	checker.stopped.Delete("cq")
+	cq.Spec.StopPolicy = nil
+	if err := manager.UpdateClusterQueue(ctx, cq); err != nil {
+		t.Fatalf("Failed to update ClusterQueue: %v", err)
+	}
+	heads = manager.heads()
+	if len(heads) != 1 || heads[0].Obj.Name != "a" {
+		t.Errorf("Unexpected heads after resuming: %v", heads)
+	}
+}
+
+// TestAddOrUpdateCohort tests that inadmissible workloads are requeued when
+// their cohort joins a cohort tree.
+func TestAddOrUpdateCohort(t *testing.T) {
+	clusterQueues := []*kueue.ClusterQueue{
+		utiltesting.MakeClusterQueue("cq1").Cohort("alpha").Obj(),
+		utiltesting.MakeClusterQueue("cq2").Cohort("beta").Obj(),
+	}
+	queues := []*kueue.LocalQueue{
+		utiltesting.MakeLocalQueue("foo", defaultNamespace).ClusterQueue("cq1").Obj(),
+		utiltesting.MakeLocalQueue("bar", defaultNamespace).ClusterQueue("cq2").Obj(),
+	}
+	workloads := []*kueue.Workload{
+		utiltesting.MakeWorkload("a", defaultNamespace).Queue("foo").Obj(),
+		utiltesting.MakeWorkload("b", defaultNamespace).Queue("bar").Obj(),
+	}
+	// Setup.
+	ctx := context.Background()
+	cl := utiltesting.NewFakeClient(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: defaultNamespace}},
+	)
+	manager := NewManager(cl, nil)
+	for _, cq := range clusterQueues {
+		if err := manager.AddClusterQueue(ctx, cq); err != nil {
+			t.Fatalf("Failed adding clusterQueue %s: %v", cq.Name, err)
+		}
+	}
+	for _, q := range queues {
+		if err := manager.AddLocalQueue(ctx, q); err != nil {
+			t.Fatalf("Failed adding queue %s: %v", q.Name, err)
+		}
+	}
+	for _, w := range workloads {
+		if err := cl.Create(ctx, w); err != nil {
+			t.Fatalf("Failed adding workload to client: %v", err)
+		}
+		manager.AddOrUpdateWorkload(w)
+	}
+	// Pop the workloads and requeue them as inadmissible.
+	for _, head := range manager.Heads(ctx) {
+		head := head
+		manager.RequeueWorkload(ctx, &head, RequeueReasonGeneric)
+	}
+
+	// Put alpha under the company cohort.
+	manager.AddOrUpdateCohort(ctx, utiltesting.MakeCohort("alpha").Parent("company").Obj())
+	wantActiveWorkloads := map[string]sets.Set[string]{
+		"cq1": sets.New("default/a"),
+	}
+	if diff := cmp.Diff(wantActiveWorkloads, manager.Dump()); diff != "" {
+		t.Errorf("Unexpected active workloads after adding the parent of alpha (-want +got):\n%s", diff)
+	}
+
+	// Put beta under the same cohort.
+	manager.AddOrUpdateCohort(ctx, utiltesting.MakeCohort("beta").Parent("company").Obj())
+	if got := manager.cohortRoot("beta"); got != "company" {
+		t.Errorf("Unexpected root for cohort beta: %q, want %q", got, "company")
+	}
+	wantActiveWorkloads = map[string]sets.Set[string]{
+		"cq1": sets.New("default/a"),
+		"cq2": sets.New("default/b"),
+	}
+	if diff := cmp.Diff(wantActiveWorkloads, manager.Dump()); diff != "" {
+		t.Errorf("Unexpected active workloads after adding the parent of beta (-want +got):\n%s", diff)
+	}
+
+	// A cycle resolves to a single root.
+	manager.AddOrUpdateCohort(ctx, utiltesting.MakeCohort("company").Parent("beta").Obj())
+	if got := manager.cohortRoot("alpha"); got != "beta" {
+		t.Errorf("Unexpected root for cohort alpha in a cycle: %q, want %q", got, "beta")
+	}
+}
+
 // TestUpdateLocalQueue tests that workloads are transferred between clusterQueues
 // when the queue points to a different clusterQueue.
 func TestUpdateLocalQueue(t *testing.T) {
@@ -483,6 +620,16 @@ func TestRequeueWorkloadStrictFIFO(t *testing.T) {
 			inClient: true,
 			inQueue:  true,
 		},
+		{
+			workload: &kueue.Workload{
+				ObjectMeta: metav1.ObjectMeta{Name: "inactive"},
+				Spec: kueue.WorkloadSpec{
+					QueueName: "foo",
+					Active:    pointer.Bool(false),
+				},
+			},
+			inClient: true,
+		},
 		{
 			workload: &kueue.Workload{
 				ObjectMeta: metav1.ObjectMeta{Name: "already_admitted"},
@@ -528,6 +675,97 @@ func TestRequeueWorkloadStrictFIFO(t *testing.T) {
 	}
 }
 
+func TestPendingWorkloads(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	cq := utiltesting.MakeClusterQueue("cq").Obj()
+	queues := []*kueue.LocalQueue{
+		utiltesting.MakeLocalQueue("foo", "ns").ClusterQueue("cq").Obj(),
+		utiltesting.MakeLocalQueue("bar", "ns").ClusterQueue("cq").Obj(),
+		utiltesting.MakeLocalQueue("orphan", "ns").ClusterQueue("missing").Obj(),
+	}
+	workloads := []*kueue.Workload{
+		utiltesting.MakeWorkload("a", "ns").Queue("foo").Creation(now).Obj(),
+		utiltesting.MakeWorkload("b", "ns").Queue("bar").Creation(now.Add(time.Second)).Obj(),
+		utiltesting.MakeWorkload("c", "ns").Queue("foo").Creation(now.Add(2 * time.Second)).Obj(),
+		utiltesting.MakeWorkload("high", "ns").Queue("bar").Priority(10).Creation(now.Add(3 * time.Second)).Obj(),
+	}
+	ctx := context.Background()
+	cl := utiltesting.NewFakeClient()
+	manager := NewManager(cl, nil)
+	if err := manager.AddClusterQueue(ctx, cq); err != nil {
+		t.Fatalf("Failed adding clusterQueue %s: %v", cq.Name, err)
+	}
+	for _, q := range queues {
+		if err := manager.AddLocalQueue(ctx, q); err != nil {
+			t.Fatalf("Failed adding queue %s: %v", q.Name, err)
+		}
+	}
+	for _, w := range workloads {
+		if err := cl.Create(ctx, w); err != nil {
+			t.Fatalf("Failed adding workload to client: %v", err)
+		}
+		manager.AddOrUpdateWorkload(w)
+	}
+	// Make the head inadmissible.
+	head := manager.clusterQueues["cq"].Pop()
+	if !manager.RequeueWorkload(ctx, head, RequeueReasonGeneric) {
+		t.Fatalf("Failed requeueing workload %s", head.Obj.Name)
+	}
+
+	type pendingWorkload struct {
+		name                   string
+		positionInClusterQueue int
+		positionInLocalQueue   int
+		inadmissible           bool
+	}
+	summarize := func(pending []PendingWorkload) []pendingWorkload {
+		var result []pendingWorkload
+		for _, p := range pending {
+			result = append(result, pendingWorkload{
+				name:                   p.Info.Obj.Name,
+				positionInClusterQueue: p.PositionInClusterQueue,
+				positionInLocalQueue:   p.PositionInLocalQueue,
+				inadmissible:           p.Inadmissible,
+			})
+		}
+		return result
+	}
+	cmpOpts := cmp.AllowUnexported(pendingWorkload{})
+
+	gotCQ, found := manager.PendingWorkloadsInClusterQueue("cq")
+	if !found {
+		t.Fatal("ClusterQueue not found")
+	}
+	wantCQ := []pendingWorkload{
+		{name: "a", positionInClusterQueue: 0, positionInLocalQueue: 0},
+		{name: "b", positionInClusterQueue: 1, positionInLocalQueue: 0},
+		{name: "c", positionInClusterQueue: 2, positionInLocalQueue: 1},
+		{name: "high", positionInClusterQueue: 3, positionInLocalQueue: 1, inadmissible: true},
+	}
+	if diff := cmp.Diff(wantCQ, summarize(gotCQ), cmpOpts); diff != "" {
+		t.Errorf("Unexpected pending workloads in ClusterQueue (-want,+got):\n%s", diff)
+	}
+
+	gotLQ, found := manager.PendingWorkloadsInLocalQueue("ns", "bar")
+	if !found {
+		t.Fatal("LocalQueue not found")
+	}
+	wantLQ := []pendingWorkload{
+		{name: "b", positionInClusterQueue: 1, positionInLocalQueue: 0},
+		{name: "high", positionInClusterQueue: 3, positionInLocalQueue: 1, inadmissible: true},
+	}
+	if diff := cmp.Diff(wantLQ, summarize(gotLQ), cmpOpts); diff != "" {
+		t.Errorf("Unexpected pending workloads in LocalQueue (-want,+got):\n%s", diff)
+	}
+
+	if got, found := manager.PendingWorkloadsInLocalQueue("ns", "orphan"); !found || len(got) != 0 {
+		t.Errorf("PendingWorkloadsInLocalQueue for a queue without ClusterQueue returned %v, %t; want no workloads", got, found)
+	}
+	if _, found := manager.PendingWorkloadsInClusterQueue("missing"); found {
+		t.Error("PendingWorkloadsInClusterQueue found a missing ClusterQueue")
+	}
+}
+
 func TestUpdateWorkload(t *testing.T) {
 	scheme := runtime.NewScheme()
 	if err := kueue.AddToScheme(scheme); err != nil {
@@ -1055,3 +1293,11 @@ type fakeStatusChecker struct{}
 func (c *fakeStatusChecker) ClusterQueueActive(name string) bool {
 	return strings.Contains(name, "active-")
 }
+
+type stoppedStatusChecker struct {
+	stopped sets.Set[string]
+}
+
+func (c *stoppedStatusChecker) ClusterQueueActive(name string) bool {
+	return !c.stopped.Has(name)
+}
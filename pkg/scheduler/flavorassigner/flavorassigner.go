@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
 	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+	"k8s.io/utils/pointer"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	"sigs.k8s.io/kueue/pkg/cache"
@@ -44,10 +45,20 @@ type Assignment struct {
 	// flavors assigned.
 	usage cache.FlavorResourceQuantities
 
+	// topologyUsage is the accumulated usage of topology domains as pod sets
+	// get domains assigned.
+	topologyUsage map[kueue.ResourceFlavorReference]cache.TopologyDomainUsage
+
 	// representativeMode is the cached representative mode for this assignment.
 	representativeMode *FlavorAssignmentMode
 }
 
+// Usage returns the resources requested by the workload, by the flavors
+// assigned to them.
+func (a *Assignment) Usage() cache.FlavorResourceQuantities {
+	return a.usage
+}
+
 func (a *Assignment) Borrows() bool {
 	return len(a.TotalBorrow) > 0
 }
@@ -150,6 +161,18 @@ type PodSetAssignment struct {
 	Flavors  ResourceAssignment
 	Status   *Status
 	Requests corev1.ResourceList
+	// Count is the number of pods the assignment is for. It's only set when
+	// it's lower than the count in the workload spec.
+	Count *int32
+	// TopologyAssignment is the topology domain assigned to the pod set, if
+	// it requires a topology level.
+	TopologyAssignment *kueue.TopologyAssignment
+
+	// topologyFlavor is the flavor whose topology the domain belongs to.
+	topologyFlavor kueue.ResourceFlavorReference
+	// topologyRequests are the resources that the pod set takes from the
+	// Nodes in the domain.
+	topologyRequests workload.Requests
 }
 
 // RepresentativeMode calculates the representative mode for this assignment as
@@ -178,9 +201,46 @@ func (psa *PodSetAssignment) toAPI() kueue.PodSetAssignment {
 		flavors[res] = flvAssignment.Name
 	}
 	return kueue.PodSetAssignment{
-		Name:          psa.Name,
-		Flavors:       flavors,
-		ResourceUsage: psa.Requests,
+		Name:               psa.Name,
+		Flavors:            flavors,
+		ResourceUsage:      psa.Requests,
+		Count:              psa.Count,
+		TopologyAssignment: psa.TopologyAssignment,
+	}
+}
+
+// TopologyDomainsFit returns whether the topology domains assigned to the pod
+// sets still have enough unused capacity in the given topologies.
+func (a *Assignment) TopologyDomainsFit(topologies map[kueue.ResourceFlavorReference]*cache.FlavorTopology) bool {
+	usage := make(map[kueue.ResourceFlavorReference]cache.TopologyDomainUsage)
+	for i := range a.PodSets {
+		ps := &a.PodSets[i]
+		if ps.TopologyAssignment == nil {
+			continue
+		}
+		t := topologies[ps.topologyFlavor]
+		if t == nil || !t.Fits(ps.TopologyAssignment.Values, ps.topologyRequests, usage[ps.topologyFlavor]) {
+			return false
+		}
+		if usage[ps.topologyFlavor] == nil {
+			usage[ps.topologyFlavor] = make(cache.TopologyDomainUsage)
+		}
+		usage[ps.topologyFlavor].Add(ps.TopologyAssignment.Values, ps.topologyRequests)
+	}
+	return true
+}
+
+// AssumeTopologyDomains reduces the unused capacity of the topology domains
+// assigned to the pod sets.
+func (a *Assignment) AssumeTopologyDomains(topologies map[kueue.ResourceFlavorReference]*cache.FlavorTopology) {
+	for i := range a.PodSets {
+		ps := &a.PodSets[i]
+		if ps.TopologyAssignment == nil {
+			continue
+		}
+		if t := topologies[ps.topologyFlavor]; t != nil {
+			t.AddUsage(ps.TopologyAssignment.Values, ps.topologyRequests)
+		}
 	}
 }
 
@@ -220,23 +280,49 @@ type FlavorAssignment struct {
 	borrow int64
 }
 
+// topologyRequest is the topology level that a pod set requires, along with
+// the resources that it takes from the Nodes.
+type topologyRequest struct {
+	level    string
+	requests workload.Requests
+}
+
 // AssignFlavors assigns flavors for each of the resources requested in each pod set.
 // The result for each pod set is accompanied with reasons why the flavor can't
 // be assigned immediately. Each assigned flavor is accompanied with a
 // FlavorAssignmentMode.
-func AssignFlavors(log logr.Logger, wl *workload.Info, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, cq *cache.ClusterQueue) Assignment {
+// If counts is not nil, the pod sets are assigned flavors for the given
+// number of pods, instead of the number in their spec.
+// Pod sets that require a topology level are also assigned a domain of that
+// level, from the given topologies, in which all their pods fit.
+func AssignFlavors(log logr.Logger, wl *workload.Info, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, topologies map[kueue.ResourceFlavorReference]*cache.FlavorTopology, cq *cache.ClusterQueue, counts []int32) Assignment {
 	assignment := Assignment{
-		TotalBorrow: make(cache.FlavorResourceQuantities),
-		PodSets:     make([]PodSetAssignment, 0, len(wl.TotalRequests)),
-		usage:       make(cache.FlavorResourceQuantities),
+		TotalBorrow:   make(cache.FlavorResourceQuantities),
+		PodSets:       make([]PodSetAssignment, 0, len(wl.TotalRequests)),
+		usage:         make(cache.FlavorResourceQuantities),
+		topologyUsage: make(map[kueue.ResourceFlavorReference]cache.TopologyDomainUsage),
 	}
 	for i, podSet := range wl.TotalRequests {
+		var count *int32
+		if counts != nil && counts[i] != podSet.Count {
+			podSet = podSet.ScaledTo(counts[i])
+			count = pointer.Int32(counts[i])
+		}
+		requests := dropUncoveredSyntheticResources(podSet.Requests, cq)
 		psAssignment := PodSetAssignment{
 			Name:     podSet.Name,
-			Flavors:  make(ResourceAssignment, len(podSet.Requests)),
-			Requests: podSet.Requests.ToResourceList(),
+			Flavors:  make(ResourceAssignment, len(requests)),
+			Requests: requests.ToResourceList(),
+			Count:    count,
+		}
+		var topoRequest *topologyRequest
+		if level := wl.Obj.Spec.PodSets[i].Template.Annotations[kueue.PodSetRequiredTopologyAnnotation]; level != "" {
+			topoRequest = &topologyRequest{
+				level:    level,
+				requests: cache.TopologyRequests(&podSet),
+			}
 		}
-		for resName := range podSet.Requests {
+		for resName := range requests {
 			if _, found := psAssignment.Flavors[resName]; found {
 				// This resource got assigned the same flavor as its resource group.
 				// No need to compute again.
@@ -250,17 +336,32 @@ func AssignFlavors(log logr.Logger, wl *workload.Info, resourceFlavors map[kueue
 				}
 				break
 			}
-			flavors, status := assignment.findFlavorForResourceGroup(log, rg, podSet.Requests, resourceFlavors, cq, &wl.Obj.Spec.PodSets[i].Template.Spec)
+			if psAssignment.TopologyAssignment != nil {
+				// The domain was assigned with the flavor of another resource group.
+				topoRequest = nil
+			}
+			flavors, domain, status := assignment.findFlavorForResourceGroup(log, rg, requests, resourceFlavors, topologies, topoRequest, cq, &wl.Obj.Spec.PodSets[i].Template.Spec)
 			if status.IsError() || len(flavors) == 0 {
 				psAssignment.Flavors = nil
 				psAssignment.Status = status
 				break
 			}
 			psAssignment.append(flavors, status)
+			if domain != nil {
+				psAssignment.TopologyAssignment = domain.assignment
+				psAssignment.topologyFlavor = domain.flavor
+				psAssignment.topologyRequests = topoRequest.requests
+			}
+		}
+		if topoRequest != nil && psAssignment.TopologyAssignment == nil && len(psAssignment.Flavors) > 0 {
+			psAssignment.Flavors = nil
+			psAssignment.Status = &Status{
+				reasons: []string{fmt.Sprintf("no flavor with topology level %s assigned", topoRequest.level)},
+			}
 		}
 
-		assignment.append(podSet.Requests, &psAssignment)
-		if psAssignment.Status.IsError() || (len(podSet.Requests) > 0 && len(psAssignment.Flavors) == 0) {
+		assignment.append(requests, &psAssignment)
+		if psAssignment.Status.IsError() || (len(requests) > 0 && len(psAssignment.Flavors) == 0) {
 			// This assignment failed, no need to continue tracking.
 			assignment.TotalBorrow = nil
 			return assignment
@@ -272,6 +373,20 @@ func AssignFlavors(log logr.Logger, wl *workload.Info, resourceFlavors map[kueue
 	return assignment
 }
 
+// dropUncoveredSyntheticResources returns the requests without the synthetic
+// resources, like pods and workloads, that the ClusterQueue doesn't cover.
+// Those are only accounted for in the ClusterQueues that limit them.
+func dropUncoveredSyntheticResources(requests workload.Requests, cq *cache.ClusterQueue) workload.Requests {
+	filtered := make(workload.Requests, len(requests))
+	for name, v := range requests {
+		if _, found := cq.RGByResource[name]; !found && (name == kueue.ResourcePods || name == kueue.ResourceWorkloads) {
+			continue
+		}
+		filtered[name] = v
+	}
+	return filtered
+}
+
 func (psa *PodSetAssignment) append(flavors ResourceAssignment, status *Status) {
 	for resource, assignment := range flavors {
 		psa.Flavors[resource] = assignment
@@ -285,6 +400,12 @@ func (psa *PodSetAssignment) append(flavors ResourceAssignment, status *Status)
 
 func (a *Assignment) append(requests workload.Requests, psAssignment *PodSetAssignment) {
 	a.PodSets = append(a.PodSets, *psAssignment)
+	if psAssignment.TopologyAssignment != nil {
+		if a.topologyUsage[psAssignment.topologyFlavor] == nil {
+			a.topologyUsage[psAssignment.topologyFlavor] = make(cache.TopologyDomainUsage)
+		}
+		a.topologyUsage[psAssignment.topologyFlavor].Add(psAssignment.TopologyAssignment.Values, psAssignment.topologyRequests)
+	}
 	for resource, flvAssignment := range psAssignment.Flavors {
 		if flvAssignment.borrow > 0 {
 			if a.TotalBorrow[flvAssignment.Name] == nil {
@@ -301,8 +422,16 @@ func (a *Assignment) append(requests workload.Requests, psAssignment *PodSetAssi
 	}
 }
 
+// topologyDomain is a domain of the topology of a flavor.
+type topologyDomain struct {
+	flavor     kueue.ResourceFlavorReference
+	assignment *kueue.TopologyAssignment
+}
+
 // findFlavorForResourceGroup finds the flavor which can satisfy the resource
 // request, along with the information about resources that need to be borrowed.
+// If the flavor has topology levels and a topology level is requested, it also
+// returns a domain of the flavor in which the pods fit.
 // If the flavor cannot be immediately assigned, it returns a status with
 // reasons or failure.
 func (a *Assignment) findFlavorForResourceGroup(
@@ -310,12 +439,15 @@ func (a *Assignment) findFlavorForResourceGroup(
 	rg *cache.ResourceGroup,
 	requests workload.Requests,
 	resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor,
+	topologies map[kueue.ResourceFlavorReference]*cache.FlavorTopology,
+	topoRequest *topologyRequest,
 	cq *cache.ClusterQueue,
-	spec *corev1.PodSpec) (ResourceAssignment, *Status) {
+	spec *corev1.PodSpec) (ResourceAssignment, *topologyDomain, *Status) {
 	status := &Status{}
 	requests = filterRequestedResources(requests, rg.CoveredResources)
 
 	var bestAssignment ResourceAssignment
+	var bestDomain *topologyDomain
 	bestAssignmentMode := NoFit
 
 	// We will only check against the flavors' labels for the resource.
@@ -337,15 +469,20 @@ func (a *Assignment) findFlavorForResourceGroup(
 		if match, err := selector.Match(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: flavor.Spec.NodeLabels}}); !match || err != nil {
 			if err != nil {
 				status.err = err
-				return nil, status
+				return nil, nil, status
 			}
 			status.append(fmt.Sprintf("flavor %s doesn't match node affinity", flvQuotas.Name))
 			continue
 		}
+		if topoRequest != nil && len(flavor.Spec.TopologyLevels) > 0 && !hasTopologyLevel(flavor, topoRequest.level) {
+			status.append(fmt.Sprintf("flavor %s doesn't have topology level %s", flvQuotas.Name, topoRequest.level))
+			continue
+		}
 
 		assignments := make(ResourceAssignment, len(requests))
 		// Calculate representativeMode for this assignment as the worst mode among all requests.
 		representativeMode := Fit
+		borrows := false
 		for rName, val := range requests {
 			resQuota := flvQuotas.Resources[rName]
 			// Check considering the flavor usage by previous pod sets.
@@ -366,18 +503,67 @@ func (a *Assignment) findFlavorForResourceGroup(
 				Mode:   mode,
 				borrow: borrow,
 			}
+			borrows = borrows || borrow > 0
 		}
 
+		var domain *topologyDomain
+		if representativeMode != NoFit && topoRequest != nil && len(flavor.Spec.TopologyLevels) > 0 {
+			domain = a.findTopologyDomain(flvQuotas.Name, topologies[flvQuotas.Name], topoRequest)
+			if domain == nil {
+				status.append(fmt.Sprintf("no domain of topology level %s in flavor %s has enough capacity", topoRequest.level, flvQuotas.Name))
+				continue
+			}
+		}
+
+		if representativeMode == Fit && (!borrows || cq.FlavorFungibility.WhenCanBorrow != kueue.TryNextFlavor) {
+			// All the resources fit in the cohort, no need to check more flavors.
+			return assignments, domain, nil
+		}
+		if representativeMode == Preempt && bestAssignmentMode < Fit && cq.FlavorFungibility.WhenCanPreempt == kueue.Preempt {
+			// Preempt in this flavor rather than trying the next ones.
+			return assignments, domain, status
+		}
 		if representativeMode > bestAssignmentMode {
 			bestAssignment = assignments
+			bestDomain = domain
 			bestAssignmentMode = representativeMode
-			if bestAssignmentMode == Fit {
-				// All the resources fit in the cohort, no need to check more flavors.
-				return bestAssignment, nil
-			}
 		}
 	}
-	return bestAssignment, status
+	if bestAssignmentMode == Fit {
+		// No flavor fits without borrowing; borrow in the first one that fits.
+		return bestAssignment, bestDomain, nil
+	}
+	return bestAssignment, bestDomain, status
+}
+
+func hasTopologyLevel(flavor *kueue.ResourceFlavor, nodeLabel string) bool {
+	for _, level := range flavor.Spec.TopologyLevels {
+		if level.NodeLabel == nodeLabel {
+			return true
+		}
+	}
+	return false
+}
+
+// findTopologyDomain returns a domain of the requested level in the topology
+// of the flavor that has enough capacity for the pods, considering the
+// domains assigned to previous pod sets, or nil if there is none.
+func (a *Assignment) findTopologyDomain(fName kueue.ResourceFlavorReference, topology *cache.FlavorTopology, topoRequest *topologyRequest) *topologyDomain {
+	if topology == nil {
+		return nil
+	}
+	values, found := topology.FindDomain(topoRequest.level, topoRequest.requests, a.topologyUsage[fName])
+	if !found {
+		return nil
+	}
+	levelIdx := topology.LevelIndex(topoRequest.level)
+	return &topologyDomain{
+		flavor: fName,
+		assignment: &kueue.TopologyAssignment{
+			Levels: append([]string(nil), topology.Levels[:levelIdx+1]...),
+			Values: append([]string(nil), values...),
+		},
+	}
 }
 
 func flavorSelector(spec *corev1.PodSpec, allowedKeys sets.Set[string]) nodeaffinity.RequiredNodeAffinity {
@@ -446,14 +632,27 @@ func fitsResourceQuota(fName kueue.ResourceFlavorReference, rName corev1.Resourc
 		return mode, 0, &status
 	}
 
-	cohortUsed := used
+	lack := used + val - rQuota.Nominal
 	cohortAvailable := rQuota.Nominal
 	if cq.Cohort != nil {
-		cohortUsed = cq.Cohort.Usage[fName][rName]
-		cohortAvailable = cq.Cohort.RequestableResources[fName][rName]
+		// The unused quota of any ClusterQueue in the cohort tree can be borrowed.
+		// The cohort doesn't account for the guaranteed quota of the
+		// ClusterQueue, which is only available to the ClusterQueue itself.
+		lack = cq.CohortLack(fName, rName, val)
+		cohortAvailable = cq.CohortCapacity(fName, rName)
+	}
+
+	if mode == NoFit && cq.Cohort != nil && cq.FairSharingEnabled && cq.Preemption.ReclaimWithinCohort == kueue.PreemptionPolicyFairSharing && val <= cohortAvailable {
+		// The request can be satisfied by the quota of the cohort, assuming
+		// the ClusterQueues above their fair share are preempted.
+		mode = Preempt
+	}
+	if mode == NoFit && cq.Cohort != nil && canBorrowWithinCohort(cq) && val <= cohortAvailable {
+		// The request can be satisfied by borrowing from the cohort, assuming
+		// lower priority workloads in the cohort are preempted.
+		mode = Preempt
 	}
 
-	lack := cohortUsed + val - cohortAvailable
 	if lack <= 0 {
 		borrow := used + val - rQuota.Nominal
 		if borrow < 0 {
@@ -475,6 +674,12 @@ func fitsResourceQuota(fName kueue.ResourceFlavorReference, rName corev1.Resourc
 	return mode, 0, &status
 }
 
+// canBorrowWithinCohort returns whether the ClusterQueue can borrow quota
+// while preempting workloads from other ClusterQueues in the cohort.
+func canBorrowWithinCohort(cq *cache.ClusterQueue) bool {
+	return cq.Preemption.BorrowWithinCohort != nil && cq.Preemption.BorrowWithinCohort.Policy != kueue.BorrowWithinCohortPolicyNever
+}
+
 func filterRequestedResources(req workload.Requests, allowList sets.Set[corev1.ResourceName]) workload.Requests {
 	filtered := make(workload.Requests)
 	for n, v := range req {
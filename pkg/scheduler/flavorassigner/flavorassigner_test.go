@@ -49,11 +49,51 @@ func TestAssignFlavors(t *testing.T) {
 				Value:  "spot",
 				Effect: corev1.TaintEffectNoSchedule,
 			}).Obj(),
+		"topology": utiltesting.MakeResourceFlavor("topology").
+			Label("type", "topology").
+			TopologyLevels("block", "rack").
+			Obj(),
+	}
+	topologyNodes := []*corev1.Node{
+		utiltesting.MakeNode("b1-r1-n1").
+			Label("type", "topology").Label("block", "b1").Label("rack", "r1").
+			Allocatable(corev1.ResourceCPU, "2").Allocatable(corev1.ResourcePods, "10").
+			Obj(),
+		utiltesting.MakeNode("b1-r2-n1").
+			Label("type", "topology").Label("block", "b1").Label("rack", "r2").
+			Allocatable(corev1.ResourceCPU, "2").Allocatable(corev1.ResourcePods, "10").
+			Obj(),
+		utiltesting.MakeNode("b1-r2-n2").
+			Label("type", "topology").Label("block", "b1").Label("rack", "r2").
+			Allocatable(corev1.ResourceCPU, "2").Allocatable(corev1.ResourcePods, "10").
+			Obj(),
+		utiltesting.MakeNode("b2-r1-n1").
+			Label("type", "topology").Label("block", "b2").Label("rack", "r1").
+			Allocatable(corev1.ResourceCPU, "8").Allocatable(corev1.ResourcePods, "10").
+			Unschedulable().
+			Obj(),
+		utiltesting.MakeNode("other").
+			Label("block", "b1").Label("rack", "r1").
+			Allocatable(corev1.ResourceCPU, "8").Allocatable(corev1.ResourcePods, "10").
+			Obj(),
+	}
+	topologyClusterQueue := cache.ClusterQueue{
+		ResourceGroups: []cache.ResourceGroup{{
+			CoveredResources: sets.New(corev1.ResourceCPU),
+			Flavors: []cache.FlavorQuotas{{
+				Name: "topology",
+				Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+					corev1.ResourceCPU: {Nominal: 20_000},
+				},
+			}},
+		}},
 	}
 
 	cases := map[string]struct {
 		wlPods         []kueue.PodSet
+		counts         []int32
 		clusterQueue   cache.ClusterQueue
+		nodes          []*corev1.Node
 		wantRepMode    FlavorAssignmentMode
 		wantAssignment Assignment
 	}{
@@ -91,6 +131,84 @@ func TestAssignFlavors(t *testing.T) {
 				}},
 			},
 		},
+		"single flavor, pods and workloads quota": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 3).
+					Request(corev1.ResourceCPU, "1").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU, kueue.ResourcePods, kueue.ResourceWorkloads),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU:      {Nominal: 10_000},
+							kueue.ResourcePods:      {Nominal: 4},
+							kueue.ResourceWorkloads: {Nominal: 2},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"default": {kueue.ResourcePods: 2, kueue.ResourceWorkloads: 1},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU:      {Name: "default", Mode: Fit},
+						kueue.ResourcePods:      {Name: "default", Mode: Preempt},
+						kueue.ResourceWorkloads: {Name: "default", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:      resource.MustParse("3000m"),
+						kueue.ResourcePods:      resource.MustParse("3"),
+						kueue.ResourceWorkloads: resource.MustParse("1"),
+					},
+					Status: &Status{
+						reasons: []string{"insufficient unused quota for pods in flavor default, 1 more needed"},
+					},
+				}},
+			},
+		},
+		"single flavor, fits with reduced count": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 5).
+					SetMinimumCount(2).
+					Request(corev1.ResourceCPU, "1").
+					Obj(),
+			},
+			counts: []int32{3},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU, kueue.ResourcePods),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 3000},
+							kueue.ResourcePods: {Nominal: 3},
+						},
+					}},
+				}},
+			},
+			wantRepMode: Fit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "default", Mode: Fit},
+						kueue.ResourcePods: {Name: "default", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("3000m"),
+						kueue.ResourcePods: resource.MustParse("3"),
+					},
+					Count: pointer.Int32(3),
+				}},
+			},
+		},
 		"single flavor, fits tainted flavor": {
 			wlPods: []kueue.PodSet{
 				*utiltesting.MakePodSet("main", 1).
@@ -989,7 +1107,7 @@ func TestAssignFlavors(t *testing.T) {
 				}},
 			},
 		},
-		"past max, but can preempt in ClusterQueue": {
+		"not enough space to borrow, can preempt with fair sharing": {
 			wlPods: []kueue.PodSet{
 				*utiltesting.MakePodSet("main", 1).
 					Request(corev1.ResourceCPU, "2").
@@ -1001,21 +1119,22 @@ func TestAssignFlavors(t *testing.T) {
 					Flavors: []cache.FlavorQuotas{{
 						Name: "one",
 						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
-							corev1.ResourceCPU: {Nominal: 2000, BorrowingLimit: pointer.Int64(8_000)},
+							corev1.ResourceCPU: {Nominal: 1000},
 						},
 					}},
 				}},
-				Usage: cache.FlavorResourceQuantities{
-					"one": {corev1.ResourceCPU: 9_000},
-				},
 				Cohort: &cache.Cohort{
 					RequestableResources: cache.FlavorResourceQuantities{
-						"one": {corev1.ResourceCPU: 100_000},
+						"one": {corev1.ResourceCPU: 10_000},
 					},
 					Usage: cache.FlavorResourceQuantities{
 						"one": {corev1.ResourceCPU: 9_000},
 					},
 				},
+				Preemption: kueue.ClusterQueuePreemption{
+					ReclaimWithinCohort: kueue.PreemptionPolicyFairSharing,
+				},
+				FairSharingEnabled: true,
 			},
 			wantRepMode: Preempt,
 			wantAssignment: Assignment{
@@ -1027,14 +1146,13 @@ func TestAssignFlavors(t *testing.T) {
 					Requests: corev1.ResourceList{
 						corev1.ResourceCPU: resource.MustParse("2000m"),
 					},
-
 					Status: &Status{
-						reasons: []string{"borrowing limit for cpu in flavor one exceeded"},
+						reasons: []string{"insufficient unused quota in cohort for cpu in flavor one, 1 more needed"},
 					},
 				}},
 			},
 		},
-		"past min, but can preempt in ClusterQueue": {
+		"not enough space to borrow, fair sharing disabled": {
 			wlPods: []kueue.PodSet{
 				*utiltesting.MakePodSet("main", 1).
 					Request(corev1.ResourceCPU, "2").
@@ -1046,88 +1164,90 @@ func TestAssignFlavors(t *testing.T) {
 					Flavors: []cache.FlavorQuotas{{
 						Name: "one",
 						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
-							corev1.ResourceCPU: {Nominal: 2000},
+							corev1.ResourceCPU: {Nominal: 1000},
 						},
 					}},
 				}},
-				Usage: cache.FlavorResourceQuantities{
-					"one": {corev1.ResourceCPU: 1_000},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+					},
+					Usage: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 9_000},
+					},
+				},
+				Preemption: kueue.ClusterQueuePreemption{
+					ReclaimWithinCohort: kueue.PreemptionPolicyFairSharing,
 				},
 			},
-			wantRepMode: Preempt,
 			wantAssignment: Assignment{
 				PodSets: []PodSetAssignment{{
 					Name: "main",
-					Flavors: ResourceAssignment{
-						corev1.ResourceCPU: {Name: "one", Mode: Preempt},
-					},
 					Requests: corev1.ResourceList{
 						corev1.ResourceCPU: resource.MustParse("2000m"),
 					},
 					Status: &Status{
-						reasons: []string{"insufficient unused quota for cpu in flavor one, 1 more needed"},
+						reasons: []string{"insufficient unused quota in cohort for cpu in flavor one, 1 more needed"},
 					},
 				}},
 			},
 		},
-		"past min, but can preempt in cohort and ClusterQueue": {
+		"can borrow in first flavor, borrow": {
 			wlPods: []kueue.PodSet{
 				*utiltesting.MakePodSet("main", 1).
-					Request(corev1.ResourceCPU, "2").
+					Request(corev1.ResourceCPU, "3").
 					Obj(),
 			},
 			clusterQueue: cache.ClusterQueue{
 				ResourceGroups: []cache.ResourceGroup{{
 					CoveredResources: sets.New(corev1.ResourceCPU),
-					Flavors: []cache.FlavorQuotas{{
-						Name: "one",
-						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
-							corev1.ResourceCPU: {Nominal: 3000},
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 2000},
+							},
 						},
-					}},
+						{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4000},
+							},
+						},
+					},
 				}},
-				Usage: cache.FlavorResourceQuantities{
-					"one": {corev1.ResourceCPU: 2_000},
+				FlavorFungibility: kueue.FlavorFungibility{
+					WhenCanBorrow:  kueue.Borrow,
+					WhenCanPreempt: kueue.TryNextFlavor,
 				},
 				Cohort: &cache.Cohort{
 					RequestableResources: cache.FlavorResourceQuantities{
 						"one": {corev1.ResourceCPU: 10_000},
-					},
-					Usage: cache.FlavorResourceQuantities{
-						"one": {corev1.ResourceCPU: 10_000},
+						"two": {corev1.ResourceCPU: 4_000},
 					},
 				},
 			},
-			wantRepMode: Preempt,
+			wantRepMode: Fit,
 			wantAssignment: Assignment{
 				PodSets: []PodSetAssignment{{
 					Name: "main",
 					Flavors: ResourceAssignment{
-						corev1.ResourceCPU: {Name: "one", Mode: Preempt},
+						corev1.ResourceCPU: {Name: "one", Mode: Fit},
 					},
 					Requests: corev1.ResourceList{
-						corev1.ResourceCPU: resource.MustParse("2000m"),
-					},
-					Status: &Status{
-						reasons: []string{"insufficient unused quota in cohort for cpu in flavor one, 2 more needed"},
+						corev1.ResourceCPU: resource.MustParse("3000m"),
 					},
 				}},
+				TotalBorrow: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 1_000},
+				},
 			},
 		},
-		"can only preempt flavors that match affinity": {
+		"can borrow in first flavor, try next flavor": {
 			wlPods: []kueue.PodSet{
-				{
-					Count: 1,
-					Name:  "main",
-					Template: corev1.PodTemplateSpec{
-						Spec: corev1.PodSpec{
-							Containers: utiltesting.SingleContainerForRequest(map[corev1.ResourceName]string{
-								corev1.ResourceCPU: "2",
-							}),
-							NodeSelector: map[string]string{"type": "two"},
-						},
-					},
-				},
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "3").
+					Obj(),
 			},
 			clusterQueue: cache.ClusterQueue{
 				ResourceGroups: []cache.ResourceGroup{{
@@ -1136,7 +1256,7 @@ func TestAssignFlavors(t *testing.T) {
 						{
 							Name: "one",
 							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
-								corev1.ResourceCPU: {Nominal: 4000},
+								corev1.ResourceCPU: {Nominal: 2000},
 							},
 						},
 						{
@@ -1147,43 +1267,34 @@ func TestAssignFlavors(t *testing.T) {
 						},
 					},
 				}},
-				Usage: cache.FlavorResourceQuantities{
-					"one": {corev1.ResourceCPU: 3000},
-					"two": {corev1.ResourceCPU: 3000},
+				FlavorFungibility: kueue.FlavorFungibility{
+					WhenCanBorrow:  kueue.TryNextFlavor,
+					WhenCanPreempt: kueue.TryNextFlavor,
+				},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+						"two": {corev1.ResourceCPU: 4_000},
+					},
 				},
 			},
-			wantRepMode: Preempt,
+			wantRepMode: Fit,
 			wantAssignment: Assignment{
 				PodSets: []PodSetAssignment{{
 					Name: "main",
 					Flavors: ResourceAssignment{
-						corev1.ResourceCPU: {Name: "two", Mode: Preempt},
+						corev1.ResourceCPU: {Name: "two", Mode: Fit},
 					},
 					Requests: corev1.ResourceList{
-						corev1.ResourceCPU: resource.MustParse("2000m"),
-					},
-					Status: &Status{
-						reasons: []string{
-							"flavor one doesn't match node affinity",
-							"insufficient unused quota for cpu in flavor two, 1 more needed",
-						},
+						corev1.ResourceCPU: resource.MustParse("3000m"),
 					},
 				}},
 			},
 		},
-		"each podset requires preemption on a different flavor": {
+		"can only borrow, try next flavor": {
 			wlPods: []kueue.PodSet{
-				*utiltesting.MakePodSet("launcher", 1).
-					Request(corev1.ResourceCPU, "2").
-					Obj(),
-				*utiltesting.MakePodSet("workers", 10).
-					Request(corev1.ResourceCPU, "1").
-					Toleration(corev1.Toleration{
-						Key:      "instance",
-						Operator: corev1.TolerationOpEqual,
-						Value:    "spot",
-						Effect:   corev1.TaintEffectNoSchedule,
-					}).
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "3").
 					Obj(),
 			},
 			clusterQueue: cache.ClusterQueue{
@@ -1193,80 +1304,641 @@ func TestAssignFlavors(t *testing.T) {
 						{
 							Name: "one",
 							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
-								corev1.ResourceCPU: {Nominal: 4000},
+								corev1.ResourceCPU: {Nominal: 2000},
 							},
 						},
 						{
-							Name: "tainted",
+							Name: "two",
 							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
-								corev1.ResourceCPU: {Nominal: 10_000},
+								corev1.ResourceCPU: {Nominal: 2000},
 							},
 						},
 					},
 				}},
-				Usage: cache.FlavorResourceQuantities{
-					"one":     {corev1.ResourceCPU: 3000},
-					"tainted": {corev1.ResourceCPU: 3000},
+				FlavorFungibility: kueue.FlavorFungibility{
+					WhenCanBorrow:  kueue.TryNextFlavor,
+					WhenCanPreempt: kueue.TryNextFlavor,
+				},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+						"two": {corev1.ResourceCPU: 10_000},
+					},
 				},
 			},
-			wantRepMode: Preempt,
+			wantRepMode: Fit,
 			wantAssignment: Assignment{
-				PodSets: []PodSetAssignment{
-					{
-						Name: "launcher",
-						Flavors: ResourceAssignment{
-							corev1.ResourceCPU: {Name: "one", Mode: Preempt},
-						},
-						Requests: corev1.ResourceList{
-							corev1.ResourceCPU: resource.MustParse("2000m"),
-						},
-						Status: &Status{
-							reasons: []string{
-								"insufficient unused quota for cpu in flavor one, 1 more needed",
-								"untolerated taint {instance spot NoSchedule <nil>} in flavor tainted",
-							},
-						},
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Fit},
 					},
-					{
-						Name: "workers",
-						Flavors: ResourceAssignment{
-							corev1.ResourceCPU: {Name: "tainted", Mode: Preempt},
-						},
-						Requests: corev1.ResourceList{
-							corev1.ResourceCPU: resource.MustParse("10000m"),
-						},
-						Status: &Status{
-							reasons: []string{
-								"insufficient quota for cpu in flavor one in ClusterQueue",
-								"insufficient unused quota for cpu in flavor tainted, 3 more needed",
-							},
-						},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("3000m"),
 					},
+				}},
+				TotalBorrow: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 1_000},
 				},
 			},
 		},
-		"resource not listed in clusterQueue": {
+		"can preempt in first flavor, try next flavor": {
 			wlPods: []kueue.PodSet{
 				*utiltesting.MakePodSet("main", 1).
-					Request("example.com/gpu", "2").
+					Request(corev1.ResourceCPU, "2").
 					Obj(),
 			},
 			clusterQueue: cache.ClusterQueue{
 				ResourceGroups: []cache.ResourceGroup{{
 					CoveredResources: sets.New(corev1.ResourceCPU),
-					Flavors: []cache.FlavorQuotas{{
-						Name: "one",
-						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
-							corev1.ResourceCPU: {Nominal: 4000},
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 2000},
+							},
 						},
-					}},
+						{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 2000},
+							},
+						},
+					},
 				}},
+				FlavorFungibility: kueue.FlavorFungibility{
+					WhenCanBorrow:  kueue.Borrow,
+					WhenCanPreempt: kueue.TryNextFlavor,
+				},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 1_000},
+				},
 			},
+			wantRepMode: Fit,
 			wantAssignment: Assignment{
 				PodSets: []PodSetAssignment{{
 					Name: "main",
-					Requests: corev1.ResourceList{
-						"example.com/gpu": resource.MustParse("2"),
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "two", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+				}},
+			},
+		},
+		"can preempt in first flavor, preempt": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 2000},
+							},
+						},
+						{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 2000},
+							},
+						},
+					},
+				}},
+				FlavorFungibility: kueue.FlavorFungibility{
+					WhenCanBorrow:  kueue.Borrow,
+					WhenCanPreempt: kueue.Preempt,
+				},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 1_000},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Preempt},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+					Status: &Status{
+						reasons: []string{"insufficient unused quota for cpu in flavor one, 1 more needed"},
+					},
+				}},
+			},
+		},
+		"borrow from the parent cohort": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 1000},
+						},
+					}},
+				}},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 4_000},
+					},
+					Usage: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 4_000},
+					},
+					Parent: &cache.Cohort{
+						RequestableResources: cache.FlavorResourceQuantities{
+							"one": {corev1.ResourceCPU: 10_000},
+						},
+						Usage: cache.FlavorResourceQuantities{
+							"one": {corev1.ResourceCPU: 4_000},
+						},
+					},
+				},
+			},
+			wantRepMode: Fit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+				}},
+				TotalBorrow: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 1_000},
+				},
+			},
+		},
+		"not enough space to borrow in the cohort tree": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 1000},
+						},
+					}},
+				}},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 4_000},
+					},
+					Usage: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 1_000},
+					},
+					Parent: &cache.Cohort{
+						RequestableResources: cache.FlavorResourceQuantities{
+							"one": {corev1.ResourceCPU: 10_000},
+						},
+						Usage: cache.FlavorResourceQuantities{
+							"one": {corev1.ResourceCPU: 9_000},
+						},
+					},
+				},
+			},
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+					Status: &Status{
+						reasons: []string{"insufficient unused quota in cohort for cpu in flavor one, 1 more needed"},
+					},
+				}},
+			},
+		},
+		"guaranteed quota is available when the cohort is fully used": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4_000, LendingLimit: pointer.Int64(0)},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 1_000},
+				},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+					},
+					Usage: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+					},
+				},
+			},
+			wantRepMode: Fit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+				}},
+			},
+		},
+		"lent quota is used by the cohort": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4_000, LendingLimit: pointer.Int64(2_000)},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 2_000},
+				},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 12_000},
+					},
+					Usage: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 11_000},
+					},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Preempt},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+					Status: &Status{
+						reasons: []string{"insufficient unused quota in cohort for cpu in flavor one, 1 more needed"},
+					},
+				}},
+			},
+		},
+		"past max, but can preempt in ClusterQueue": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2000, BorrowingLimit: pointer.Int64(8_000)},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 9_000},
+				},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 100_000},
+					},
+					Usage: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 9_000},
+					},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Preempt},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+
+					Status: &Status{
+						reasons: []string{"borrowing limit for cpu in flavor one exceeded"},
+					},
+				}},
+			},
+		},
+		"past min, but can preempt in ClusterQueue": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2000},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 1_000},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Preempt},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+					Status: &Status{
+						reasons: []string{"insufficient unused quota for cpu in flavor one, 1 more needed"},
+					},
+				}},
+			},
+		},
+		"past min, but can preempt in cohort and ClusterQueue": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 3000},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 2_000},
+				},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+					},
+					Usage: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+					},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Preempt},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+					Status: &Status{
+						reasons: []string{"insufficient unused quota in cohort for cpu in flavor one, 2 more needed"},
+					},
+				}},
+			},
+		},
+		"past nominal, can preempt in cohort while borrowing": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "4").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 3000},
+						},
+					}},
+				}},
+				Preemption: kueue.ClusterQueuePreemption{
+					ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
+					BorrowWithinCohort: &kueue.BorrowWithinCohort{
+						Policy: kueue.BorrowWithinCohortPolicyLowerPriority,
+					},
+				},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+					},
+					Usage: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+					},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Preempt},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("4000m"),
+					},
+					Status: &Status{
+						reasons: []string{"insufficient unused quota in cohort for cpu in flavor one, 4 more needed"},
+					},
+				}},
+			},
+		},
+		"can only preempt flavors that match affinity": {
+			wlPods: []kueue.PodSet{
+				{
+					Count: 1,
+					Name:  "main",
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: utiltesting.SingleContainerForRequest(map[corev1.ResourceName]string{
+								corev1.ResourceCPU: "2",
+							}),
+							NodeSelector: map[string]string{"type": "two"},
+						},
+					},
+				},
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4000},
+							},
+						},
+						{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4000},
+							},
+						},
+					},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 3000},
+					"two": {corev1.ResourceCPU: 3000},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "two", Mode: Preempt},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+					Status: &Status{
+						reasons: []string{
+							"flavor one doesn't match node affinity",
+							"insufficient unused quota for cpu in flavor two, 1 more needed",
+						},
+					},
+				}},
+			},
+		},
+		"each podset requires preemption on a different flavor": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("launcher", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+				*utiltesting.MakePodSet("workers", 10).
+					Request(corev1.ResourceCPU, "1").
+					Toleration(corev1.Toleration{
+						Key:      "instance",
+						Operator: corev1.TolerationOpEqual,
+						Value:    "spot",
+						Effect:   corev1.TaintEffectNoSchedule,
+					}).
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4000},
+							},
+						},
+						{
+							Name: "tainted",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 10_000},
+							},
+						},
+					},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one":     {corev1.ResourceCPU: 3000},
+					"tainted": {corev1.ResourceCPU: 3000},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{
+					{
+						Name: "launcher",
+						Flavors: ResourceAssignment{
+							corev1.ResourceCPU: {Name: "one", Mode: Preempt},
+						},
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("2000m"),
+						},
+						Status: &Status{
+							reasons: []string{
+								"insufficient unused quota for cpu in flavor one, 1 more needed",
+								"untolerated taint {instance spot NoSchedule <nil>} in flavor tainted",
+							},
+						},
+					},
+					{
+						Name: "workers",
+						Flavors: ResourceAssignment{
+							corev1.ResourceCPU: {Name: "tainted", Mode: Preempt},
+						},
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("10000m"),
+						},
+						Status: &Status{
+							reasons: []string{
+								"insufficient quota for cpu in flavor one in ClusterQueue",
+								"insufficient unused quota for cpu in flavor tainted, 3 more needed",
+							},
+						},
+					},
+				},
+			},
+		},
+		"resource not listed in clusterQueue": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request("example.com/gpu", "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4000},
+						},
+					}},
+				}},
+			},
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Requests: corev1.ResourceList{
+						"example.com/gpu": resource.MustParse("2"),
 					},
 					Status: &Status{
 						reasons: []string{"resource example.com/gpu unavailable in ClusterQueue"},
@@ -1303,12 +1975,191 @@ func TestAssignFlavors(t *testing.T) {
 				}},
 			},
 		},
+		"topology, fits in a rack": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 4).
+					Request(corev1.ResourceCPU, "1").
+					RequiredTopology("rack").
+					Obj(),
+			},
+			clusterQueue: topologyClusterQueue,
+			nodes:        topologyNodes,
+			wantRepMode:  Fit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "topology", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("4000m"),
+					},
+					TopologyAssignment: &kueue.TopologyAssignment{
+						Levels: []string{"block", "rack"},
+						Values: []string{"b1", "r2"},
+					},
+				}},
+			},
+		},
+		"topology, fits in a block": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 6).
+					Request(corev1.ResourceCPU, "1").
+					RequiredTopology("block").
+					Obj(),
+			},
+			clusterQueue: topologyClusterQueue,
+			nodes:        topologyNodes,
+			wantRepMode:  Fit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "topology", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("6000m"),
+					},
+					TopologyAssignment: &kueue.TopologyAssignment{
+						Levels: []string{"block"},
+						Values: []string{"b1"},
+					},
+				}},
+			},
+		},
+		"topology, doesn't fit in any rack": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 5).
+					Request(corev1.ResourceCPU, "1").
+					RequiredTopology("rack").
+					Obj(),
+			},
+			clusterQueue: topologyClusterQueue,
+			nodes:        topologyNodes,
+			wantRepMode:  NoFit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("5000m"),
+					},
+					Status: &Status{
+						reasons: []string{"no domain of topology level rack in flavor topology has enough capacity"},
+					},
+				}},
+			},
+		},
+		"topology, level not in the flavor": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "1").
+					RequiredTopology("zone").
+					Obj(),
+			},
+			clusterQueue: topologyClusterQueue,
+			nodes:        topologyNodes,
+			wantRepMode:  NoFit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("1000m"),
+					},
+					Status: &Status{
+						reasons: []string{"flavor topology doesn't have topology level zone"},
+					},
+				}},
+			},
+		},
+		"topology, flavor without topology levels": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "1").
+					RequiredTopology("rack").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 1000},
+						},
+					}},
+				}},
+			},
+			wantRepMode: NoFit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("1000m"),
+					},
+					Status: &Status{
+						reasons: []string{"no flavor with topology level rack assigned"},
+					},
+				}},
+			},
+		},
+		"topology, pod sets share the capacity of the domains": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("driver", 2).
+					Request(corev1.ResourceCPU, "1").
+					RequiredTopology("rack").
+					Obj(),
+				*utiltesting.MakePodSet("workers", 2).
+					Request(corev1.ResourceCPU, "1").
+					RequiredTopology("rack").
+					Obj(),
+			},
+			clusterQueue: topologyClusterQueue,
+			nodes:        topologyNodes,
+			wantRepMode:  Fit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{
+					{
+						Name: "driver",
+						Flavors: ResourceAssignment{
+							corev1.ResourceCPU: {Name: "topology", Mode: Fit},
+						},
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("2000m"),
+						},
+						TopologyAssignment: &kueue.TopologyAssignment{
+							Levels: []string{"block", "rack"},
+							Values: []string{"b1", "r1"},
+						},
+					},
+					{
+						Name: "workers",
+						Flavors: ResourceAssignment{
+							corev1.ResourceCPU: {Name: "topology", Mode: Fit},
+						},
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("2000m"),
+						},
+						TopologyAssignment: &kueue.TopologyAssignment{
+							Levels: []string{"block", "rack"},
+							Values: []string{"b1", "r2"},
+						},
+					},
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			log := testr.NewWithOptions(t, testr.Options{
 				Verbosity: 2,
 			})
+			cqCache := cache.New(utiltesting.NewFakeClient())
+			for _, rf := range resourceFlavors {
+				cqCache.AddOrUpdateResourceFlavor(rf)
+			}
+			for _, node := range tc.nodes {
+				cqCache.AddOrUpdateNode(node)
+			}
 			wlInfo := workload.NewInfo(&kueue.Workload{
 				Spec: kueue.WorkloadSpec{
 					PodSets: tc.wlPods,
@@ -1316,11 +2167,11 @@ func TestAssignFlavors(t *testing.T) {
 			})
 			tc.clusterQueue.UpdateWithFlavors(resourceFlavors)
 			tc.clusterQueue.UpdateRGByResource()
-			assignment := AssignFlavors(log, wlInfo, resourceFlavors, &tc.clusterQueue)
+			assignment := AssignFlavors(log, wlInfo, resourceFlavors, cqCache.Snapshot().Topologies, &tc.clusterQueue, tc.counts)
 			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
 				t.Errorf("e.assignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
 			}
-			if diff := cmp.Diff(tc.wantAssignment, assignment, cmpopts.IgnoreUnexported(Assignment{}, FlavorAssignment{})); diff != "" {
+			if diff := cmp.Diff(tc.wantAssignment, assignment, cmpopts.IgnoreUnexported(Assignment{}, PodSetAssignment{}, FlavorAssignment{})); diff != "" {
 				t.Errorf("Unexpected assignment (-want,+got):\n%s", diff)
 			}
 		})
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flavorassigner
+
+import (
+	"sort"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+)
+
+// PodSetReducer searches for the largest pod counts, between the minimum
+// and the full counts of the pod sets, for which a workload can be admitted.
+// The pod sets are reduced proportionally to the range between their
+// minimum and full counts.
+type PodSetReducer[R any] struct {
+	fullCounts []int32
+	deltas     []int64
+	totalDelta int64
+	fits       func([]int32) (R, bool)
+}
+
+// NewPodSetReducer returns a reducer for the podSets. fits is called with
+// the counts to evaluate, in the order of the podSets, and returns whether
+// the workload can be admitted with them, along with a result that is
+// returned by Search.
+func NewPodSetReducer[R any](podSets []kueue.PodSet, fits func([]int32) (R, bool)) *PodSetReducer[R] {
+	psr := &PodSetReducer[R]{
+		fullCounts: make([]int32, len(podSets)),
+		deltas:     make([]int64, len(podSets)),
+		fits:       fits,
+	}
+	for i := range podSets {
+		ps := &podSets[i]
+		psr.fullCounts[i] = ps.Count
+		if ps.MinCount != nil && *ps.MinCount < ps.Count {
+			psr.deltas[i] = int64(ps.Count - *ps.MinCount)
+			psr.totalDelta += psr.deltas[i]
+		}
+	}
+	return psr
+}
+
+// countsFor returns the counts of the pod sets after removing up to
+// reduction pods in total.
+func (psr *PodSetReducer[R]) countsFor(reduction int64) []int32 {
+	counts := make([]int32, len(psr.fullCounts))
+	for i, full := range psr.fullCounts {
+		counts[i] = full - int32(psr.deltas[i]*reduction/psr.totalDelta)
+	}
+	return counts
+}
+
+// Search returns the result of fits for the smallest reduction of the pod
+// counts with which the workload can be admitted, and whether such a
+// reduction was found. The full counts are not evaluated.
+func (psr *PodSetReducer[R]) Search() (R, bool) {
+	var result R
+	if psr.totalDelta == 0 {
+		return result, false
+	}
+	results := make(map[int64]R)
+	idx := sort.Search(int(psr.totalDelta), func(i int) bool {
+		reduction := int64(i) + 1
+		r, ok := psr.fits(psr.countsFor(reduction))
+		if ok {
+			results[reduction] = r
+		}
+		return ok
+	})
+	result, found := results[int64(idx)+1]
+	return result, found
+}
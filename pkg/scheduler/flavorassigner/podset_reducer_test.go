@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flavorassigner
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+)
+
+func TestPodSetReducer(t *testing.T) {
+	cases := map[string]struct {
+		podSets    []kueue.PodSet
+		maxPods    int32
+		wantCounts []int32
+		wantFound  bool
+	}{
+		"no reducible pod sets": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("ps1", 4).Obj(),
+			},
+			maxPods: 2,
+		},
+		"single pod set": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("ps1", 10).SetMinimumCount(2).Obj(),
+			},
+			maxPods:    7,
+			wantCounts: []int32{7},
+			wantFound:  true,
+		},
+		"single pod set doesn't fit with its minimum count": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("ps1", 10).SetMinimumCount(5).Obj(),
+			},
+			maxPods: 4,
+		},
+		"pod sets are reduced proportionally": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("ps1", 1).Obj(),
+				*utiltesting.MakePodSet("ps2", 10).SetMinimumCount(2).Obj(),
+				*utiltesting.MakePodSet("ps3", 20).SetMinimumCount(4).Obj(),
+			},
+			maxPods:    19,
+			wantCounts: []int32{1, 6, 12},
+			wantFound:  true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			reducer := NewPodSetReducer(tc.podSets, func(counts []int32) ([]int32, bool) {
+				var total int32
+				for _, c := range counts {
+					total += c
+				}
+				return counts, total <= tc.maxPods
+			})
+			counts, found := reducer.Search()
+			if found != tc.wantFound {
+				t.Errorf("Search() found=%t, want %t", found, tc.wantFound)
+			}
+			if diff := cmp.Diff(tc.wantCounts, counts); diff != "" {
+				t.Errorf("Unexpected counts (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -18,6 +18,8 @@ package preemption
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"sort"
 	"sync/atomic"
 	"time"
@@ -34,7 +36,6 @@ import (
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	"sigs.k8s.io/kueue/pkg/cache"
-	"sigs.k8s.io/kueue/pkg/constants"
 	"sigs.k8s.io/kueue/pkg/scheduler/flavorassigner"
 	"sigs.k8s.io/kueue/pkg/util/priority"
 	"sigs.k8s.io/kueue/pkg/util/routine"
@@ -74,21 +75,23 @@ func candidatesOnlyFromQueue(candidates []*workload.Info, clusterQueue string) [
 	return result
 }
 
-func (p *Preemptor) Do(ctx context.Context, wl workload.Info, assignment flavorassigner.Assignment, snapshot *cache.Snapshot) (int, error) {
-	log := ctrl.LoggerFrom(ctx)
-
+// GetTargets returns the list of workloads that should be evicted in order to
+// make room for wl.
+func (p *Preemptor) GetTargets(wl workload.Info, assignment flavorassigner.Assignment, snapshot *cache.Snapshot) []*workload.Info {
 	resPerFlv := resourcesRequiringPreemption(assignment)
 	cq := snapshot.ClusterQueues[wl.ClusterQueue]
 
 	candidates := findCandidates(wl.Obj, cq, resPerFlv)
 	if len(candidates) == 0 {
-		log.V(2).Info("Workload requires preemption, but there are no candidate workloads allowed for preemption", "preemptionReclaimWithinCohort", cq.Preemption.ReclaimWithinCohort, "preemptionWithinClusterQueue", cq.Preemption.WithinClusterQueue)
-		return 0, nil
+		return nil
+	}
+	if cq.Cohort != nil && cq.Preemption.ReclaimWithinCohort == kueue.PreemptionPolicyFairSharing {
+		return fairPreemptions(&wl, assignment, snapshot, resPerFlv, candidates)
 	}
-	sort.Slice(candidates, candidatesOrdering(candidates, cq.Name, time.Now()))
+
+	sort.Slice(candidates, candidatesOrdering(candidates, cq.Name, cohortDistances(cq, candidates, snapshot), time.Now()))
 
 	sameQueueCandidates := candidatesOnlyFromQueue(candidates, wl.ClusterQueue)
-	var targets []*workload.Info
 
 	// To avoid flapping, Kueue only allows preemption of workloads from the same
 	// queue if borrowing. Preemption of workloads from queues can happen only
@@ -99,28 +102,46 @@ func (p *Preemptor) Do(ctx context.Context, wl workload.Info, assignment flavora
 	if len(sameQueueCandidates) == len(candidates) {
 		// There is no risk of preemption of workloads from the other queue,
 		// so we can try borrowing.
-		targets = minimalPreemptions(&wl, assignment, snapshot, resPerFlv, candidates, true)
+		return minimalPreemptions(&wl, assignment, snapshot, resPerFlv, candidates, true, nil)
+	}
+
+	var targets []*workload.Info
+	if borrowWithinCohort, thresholdPrio := canBorrowWithinCohort(cq, wl.Obj); borrowWithinCohort {
+		// The borrowWithinCohort policy allows borrowing while preempting
+		// workloads from the other queues in the cohort, as long as the
+		// preempted workloads have a priority below the threshold.
+		targets = minimalPreemptions(&wl, assignment, snapshot, resPerFlv, candidates, true, &thresholdPrio)
 	} else {
 		// There is a risk of preemption of workloads from the other queue in the
 		// cohort, proceeding without borrowing.
-		targets = minimalPreemptions(&wl, assignment, snapshot, resPerFlv, candidates, false)
-		if len(targets) == 0 {
-			// Another attempt. This time only candidates from the same queue, but
-			// with borrowing. The previous attempt didn't try borrowing and had broader
-			// scope of preemption.
-			targets = minimalPreemptions(&wl, assignment, snapshot, resPerFlv, sameQueueCandidates, true)
-		}
+		targets = minimalPreemptions(&wl, assignment, snapshot, resPerFlv, candidates, false, nil)
 	}
-
 	if len(targets) == 0 {
-		log.V(2).Info("Workload requires preemption, but there are not enough candidate workloads allowed for preemption")
-		return 0, nil
+		// Another attempt. This time only candidates from the same queue, but
+		// with borrowing. The previous attempt didn't try borrowing and had broader
+		// scope of preemption.
+		targets = minimalPreemptions(&wl, assignment, snapshot, resPerFlv, sameQueueCandidates, true, nil)
 	}
+	return targets
+}
 
-	return p.issuePreemptions(ctx, targets, cq)
+// canBorrowWithinCohort returns whether the ClusterQueue allows borrowing while
+// preempting workloads from other ClusterQueues in the cohort and, if so, the
+// priority below which the preempted workloads must be.
+func canBorrowWithinCohort(cq *cache.ClusterQueue, wl *kueue.Workload) (bool, int32) {
+	borrowWithinCohort := cq.Preemption.BorrowWithinCohort
+	if borrowWithinCohort == nil || borrowWithinCohort.Policy == kueue.BorrowWithinCohortPolicyNever {
+		return false, 0
+	}
+	threshold := priority.Priority(wl)
+	if borrowWithinCohort.MaxPriorityThreshold != nil && *borrowWithinCohort.MaxPriorityThreshold < threshold {
+		threshold = *borrowWithinCohort.MaxPriorityThreshold + 1
+	}
+	return true, threshold
 }
 
-func (p *Preemptor) issuePreemptions(ctx context.Context, targets []*workload.Info, cq *cache.ClusterQueue) (int, error) {
+// IssuePreemptions marks the target workloads as evicted.
+func (p *Preemptor) IssuePreemptions(ctx context.Context, targets []*workload.Info, cq *cache.ClusterQueue) (int, error) {
 	log := ctrl.LoggerFrom(ctx)
 	errCh := routine.NewErrorChannel()
 	ctx, cancel := context.WithCancel(ctx)
@@ -128,23 +149,16 @@ func (p *Preemptor) issuePreemptions(ctx context.Context, targets []*workload.In
 	defer cancel()
 	workqueue.ParallelizeUntil(ctx, parallelPreemptions, len(targets), func(i int) {
 		target := targets[i]
-		patch := workload.BaseSSAWorkload(target.Obj)
-		patch.Status.Conditions = []metav1.Condition{{
-			Type:               kueue.WorkloadAdmitted,
-			Status:             metav1.ConditionFalse,
-			LastTransitionTime: metav1.Now(),
-			Reason:             "Preempted",
-			Message:            "Preempted to accommodate a higher priority Workload",
-		}}
+		origin := "ClusterQueue"
+		if cq.Name != target.ClusterQueue {
+			origin = "cohort"
+		}
+		patch := workload.EvictionPatch(target.Obj, kueue.WorkloadEvictedByPreemption, fmt.Sprintf("Preempted to accommodate a higher priority Workload in the %s", origin))
 		err := p.applyPreemption(ctx, patch)
 		if err != nil {
 			errCh.SendErrorWithCancel(err, cancel)
 			return
 		}
-		origin := "ClusterQueue"
-		if cq.Name != target.ClusterQueue {
-			origin = "cohort"
-		}
 		log.V(3).Info("Preempted", "targetWorkload", klog.KObj(target.Obj))
 		p.recorder.Eventf(target.Obj, corev1.EventTypeNormal, "Preempted", "Preempted by another workload in the %s", origin)
 		atomic.AddInt64(&successfullyPreempted, 1)
@@ -153,7 +167,7 @@ func (p *Preemptor) issuePreemptions(ctx context.Context, targets []*workload.In
 }
 
 func (p *Preemptor) applyPreemptionWithSSA(ctx context.Context, w *kueue.Workload) error {
-	return p.client.Status().Patch(ctx, w, client.Apply, client.FieldOwner(constants.AdmissionName))
+	return workload.ApplyUnsetAdmission(ctx, p.client, w)
 }
 
 // minimalPreemptions implements a heuristic to find a minimal set of Workloads
@@ -164,7 +178,10 @@ func (p *Preemptor) applyPreemptionWithSSA(ctx context.Context, w *kueue.Workloa
 // Once the Worklod fits, the heuristic tries to add Workloads back, in the
 // reverse order in which they were removed, while the incoming Workload still
 // fits.
-func minimalPreemptions(wl *workload.Info, assignment flavorassigner.Assignment, snapshot *cache.Snapshot, resPerFlv resourcesPerFlavor, candidates []*workload.Info, allowBorrowing bool) []*workload.Info {
+// When allowBorrowingBelowPriority is not nil, borrowing is only allowed as
+// long as the Workloads removed from other ClusterQueues have a priority
+// below it.
+func minimalPreemptions(wl *workload.Info, assignment flavorassigner.Assignment, snapshot *cache.Snapshot, resPerFlv resourcesPerFlavor, candidates []*workload.Info, allowBorrowing bool, allowBorrowingBelowPriority *int32) []*workload.Info {
 	wlReq := totalRequestsForAssignment(wl, assignment)
 	cq := snapshot.ClusterQueues[wl.ClusterQueue]
 	// Simulate removing all candidates from the ClusterQueue and cohort.
@@ -175,6 +192,11 @@ func minimalPreemptions(wl *workload.Info, assignment flavorassigner.Assignment,
 		if cq != candCQ && !cqIsBorrowing(candCQ, resPerFlv) {
 			continue
 		}
+		if cq != candCQ && allowBorrowingBelowPriority != nil && priority.Priority(candWl.Obj) >= *allowBorrowingBelowPriority {
+			// Preempting a workload at or above the threshold is only
+			// allowed without borrowing.
+			allowBorrowing = false
+		}
 		snapshot.RemoveWorkload(candWl)
 		targets = append(targets, candWl)
 		if workloadFits(wlReq, cq, allowBorrowing) {
@@ -189,6 +211,73 @@ func minimalPreemptions(wl *workload.Info, assignment flavorassigner.Assignment,
 		}
 		return nil
 	}
+	return fillBackWorkloads(targets, wlReq, cq, snapshot, allowBorrowing)
+}
+
+// fairPreemptions implements a heuristic to find a set of Workloads to
+// preempt, based on the weighted share of the ClusterQueues in the cohort.
+// The heuristic removes candidates from the ClusterQueues with the highest
+// share first, as long as the share of the target ClusterQueue after the
+// preemption is greater than or equal to the share of the ClusterQueue of the
+// incoming Workload, and until the incoming Workload fits.
+// Once the Workload fits, the heuristic tries to add Workloads back, in the
+// reverse order in which they were removed, while the incoming Workload still
+// fits.
+func fairPreemptions(wl *workload.Info, assignment flavorassigner.Assignment, snapshot *cache.Snapshot, resPerFlv resourcesPerFlavor, candidates []*workload.Info) []*workload.Info {
+	wlReq := totalRequestsForAssignment(wl, assignment)
+	cq := snapshot.ClusterQueues[wl.ClusterQueue]
+	now := time.Now()
+	remaining := make([]*workload.Info, len(candidates))
+	copy(remaining, candidates)
+	var targets []*workload.Info
+	fits := false
+	for len(remaining) > 0 {
+		// The shares change as workloads are removed, so the order of the
+		// candidates needs to be recalculated.
+		shares := make(map[string]int)
+		for _, candWl := range remaining {
+			if _, found := shares[candWl.ClusterQueue]; !found {
+				shares[candWl.ClusterQueue], _ = snapshot.ClusterQueues[candWl.ClusterQueue].DominantResourceShare()
+			}
+		}
+		sort.Slice(remaining, fairSharingCandidatesOrdering(remaining, cq.Name, shares, now))
+		candWl := remaining[0]
+		remaining = remaining[1:]
+		candCQ := snapshot.ClusterQueues[candWl.ClusterQueue]
+		if cq != candCQ {
+			if !cqIsBorrowing(candCQ, resPerFlv) {
+				continue
+			}
+			preemptorShare, _ := cq.DominantResourceShareWith(wlReq)
+			targetShare, _ := candCQ.DominantResourceShareWithout(workloadUsage(candWl))
+			if targetShare < preemptorShare {
+				// Preempting the workload would leave the target ClusterQueue
+				// below the share of the incoming workload's ClusterQueue.
+				continue
+			}
+		}
+		snapshot.RemoveWorkload(candWl)
+		targets = append(targets, candWl)
+		if workloadFits(wlReq, cq, true) {
+			fits = true
+			break
+		}
+	}
+	if !fits {
+		// Reset changes to the snapshot.
+		for _, t := range targets {
+			snapshot.AddWorkload(t)
+		}
+		return nil
+	}
+	return fillBackWorkloads(targets, wlReq, cq, snapshot, true)
+}
+
+// fillBackWorkloads tries to add the targets back, in the reverse order in
+// which they were removed, while the incoming workload still fits. All the
+// targets, except the last one, are expected to be removed from the snapshot.
+// It returns the targets that can't be added back and resets the snapshot.
+func fillBackWorkloads(targets []*workload.Info, wlReq cache.FlavorResourceQuantities, cq *cache.ClusterQueue, snapshot *cache.Snapshot, allowBorrowing bool) []*workload.Info {
 	// In the reverse order, check if any of the workloads can be added back.
 	for i := len(targets) - 2; i >= 0; i-- {
 		snapshot.AddWorkload(targets[i])
@@ -234,7 +323,7 @@ func findCandidates(wl *kueue.Workload, cq *cache.ClusterQueue, resPerFlv resour
 	var candidates []*workload.Info
 	cqs := sets.New(cq)
 	if cq.Cohort != nil && cq.Preemption.ReclaimWithinCohort != kueue.PreemptionPolicyNever {
-		cqs = cq.Cohort.Members
+		cqs = cq.Cohort.Root().AllMembers()
 	}
 	if cq.Preemption.WithinClusterQueue == kueue.PreemptionPolicyNever {
 		cqs.Delete(cq)
@@ -246,7 +335,7 @@ func findCandidates(wl *kueue.Workload, cq *cache.ClusterQueue, resPerFlv resour
 				// Can't reclaim quota from ClusterQueues that are not borrowing.
 				continue
 			}
-			if cq.Preemption.ReclaimWithinCohort == kueue.PreemptionPolicyAny {
+			if cq.Preemption.ReclaimWithinCohort == kueue.PreemptionPolicyAny || cq.Preemption.ReclaimWithinCohort == kueue.PreemptionPolicyFairSharing {
 				onlyLowerPrio = false
 			}
 		}
@@ -291,14 +380,36 @@ func workloadUsesResources(wl *workload.Info, resPerFlv resourcesPerFlavor) bool
 func totalRequestsForAssignment(wl *workload.Info, assignment flavorassigner.Assignment) cache.FlavorResourceQuantities {
 	usage := make(cache.FlavorResourceQuantities)
 	for i, ps := range wl.TotalRequests {
-		for res, q := range ps.Requests {
-			flv := assignment.PodSets[i].Flavors[res].Name
+		// The assignment doesn't include the synthetic resources that the
+		// ClusterQueue doesn't cover.
+		if assignment.PodSets[i].Count != nil {
+			ps = ps.ScaledTo(*assignment.PodSets[i].Count)
+		}
+		for res, flvAssignment := range assignment.PodSets[i].Flavors {
+			flv := flvAssignment.Name
+			resUsage := usage[flv]
+			if resUsage == nil {
+				resUsage = make(map[corev1.ResourceName]int64)
+				usage[flv] = resUsage
+			}
+			resUsage[res] += ps.Requests[res]
+		}
+	}
+	return usage
+}
+
+// workloadUsage returns the resources used by an admitted workload, by the
+// flavors assigned to them.
+func workloadUsage(wl *workload.Info) cache.FlavorResourceQuantities {
+	usage := make(cache.FlavorResourceQuantities)
+	for _, ps := range wl.TotalRequests {
+		for res, flv := range ps.Flavors {
 			resUsage := usage[flv]
 			if resUsage == nil {
 				resUsage = make(map[corev1.ResourceName]int64)
 				usage[flv] = resUsage
 			}
-			resUsage[res] += q
+			resUsage[res] += ps.Requests[res]
 		}
 	}
 	return usage
@@ -316,20 +427,19 @@ func workloadFits(wlReq cache.FlavorResourceQuantities, cq *cache.ClusterQueue,
 				continue
 			}
 			cqResUsage := cq.Usage[flvQuotas.Name]
-			var cohortResUsage, cohortResRequestable map[corev1.ResourceName]int64
-			if cq.Cohort != nil {
-				cohortResUsage = cq.Cohort.Usage[flvQuotas.Name]
-				cohortResRequestable = cq.Cohort.RequestableResources[flvQuotas.Name]
-			}
 			for rName, rReq := range flvReq {
 				limit := flvQuotas.Resources[rName].Nominal
-				if flvQuotas.Resources[rName].BorrowingLimit != nil && allowBorrowing {
+				if allowBorrowing && flvQuotas.Resources[rName].BorrowingLimit != nil {
 					limit += *flvQuotas.Resources[rName].BorrowingLimit
+				} else if allowBorrowing && cq.Cohort != nil && borrowsWhilePreempting(cq) {
+					// The ClusterQueue can borrow up to the quota of the cohort,
+					// which is checked below.
+					limit = math.MaxInt64
 				}
 				if cqResUsage[rName]+rReq > limit {
 					return false
 				}
-				if cq.Cohort != nil && cohortResUsage[rName]+rReq > cohortResRequestable[rName] {
+				if cq.Cohort != nil && cq.CohortLack(flvQuotas.Name, rName, rReq) > 0 {
 					return false
 				}
 			}
@@ -338,12 +448,43 @@ func workloadFits(wlReq cache.FlavorResourceQuantities, cq *cache.ClusterQueue,
 	return true
 }
 
+// borrowsWhilePreempting returns whether the preemption policies of the
+// ClusterQueue let it borrow quota from the cohort, beyond its nominal quota,
+// while preempting workloads when it doesn't set a borrowingLimit.
+func borrowsWhilePreempting(cq *cache.ClusterQueue) bool {
+	if cq.Preemption.ReclaimWithinCohort == kueue.PreemptionPolicyFairSharing {
+		return true
+	}
+	borrowWithinCohort := cq.Preemption.BorrowWithinCohort
+	return borrowWithinCohort != nil && borrowWithinCohort.Policy != kueue.BorrowWithinCohortPolicyNever
+}
+
+// cohortDistances returns, for the ClusterQueue of each candidate, the number
+// of levels to go up from the cohort of cq to find a cohort that also
+// contains the candidate's ClusterQueue.
+func cohortDistances(cq *cache.ClusterQueue, candidates []*workload.Info, snapshot *cache.Snapshot) map[string]int {
+	distances := make(map[string]int)
+	for _, candWl := range candidates {
+		if _, found := distances[candWl.ClusterQueue]; found {
+			continue
+		}
+		candCQ := snapshot.ClusterQueues[candWl.ClusterQueue]
+		d := 0
+		for cohort := cq.Cohort; cohort != nil && !cohort.IsAncestorOf(candCQ.Cohort); cohort = cohort.Parent {
+			d++
+		}
+		distances[candWl.ClusterQueue] = d
+	}
+	return distances
+}
+
 // candidatesOrdering criteria:
 // 1. Workloads from other ClusterQueues in the cohort before the ones in the
 // same ClusterQueue as the preemptor.
-// 2. Workloads with lower priority first.
-// 3. Workloads admited more recently first.
-func candidatesOrdering(candidates []*workload.Info, cq string, now time.Time) func(int, int) bool {
+// 2. Workloads from ClusterQueues in the nearest cohorts of the tree first.
+// 3. Workloads with lower priority first.
+// 4. Workloads admited more recently first.
+func candidatesOrdering(candidates []*workload.Info, cq string, cohortDistances map[string]int, now time.Time) func(int, int) bool {
 	return func(i, j int) bool {
 		a := candidates[i]
 		b := candidates[j]
@@ -352,6 +493,40 @@ func candidatesOrdering(candidates []*workload.Info, cq string, now time.Time) f
 		if aInCQ != bInCQ {
 			return !aInCQ
 		}
+		aDistance := cohortDistances[a.ClusterQueue]
+		bDistance := cohortDistances[b.ClusterQueue]
+		if aDistance != bDistance {
+			return aDistance < bDistance
+		}
+		pa := priority.Priority(a.Obj)
+		pb := priority.Priority(b.Obj)
+		if pa != pb {
+			return pa < pb
+		}
+		return admisionTime(a.Obj, now).Before(admisionTime(b.Obj, now))
+	}
+}
+
+// fairSharingCandidatesOrdering criteria:
+// 1. Workloads from other ClusterQueues in the cohort before the ones in the
+// same ClusterQueue as the preemptor.
+// 2. Workloads from ClusterQueues with a higher share first.
+// 3. Workloads with lower priority first.
+// 4. Workloads admited more recently first.
+func fairSharingCandidatesOrdering(candidates []*workload.Info, cq string, shares map[string]int, now time.Time) func(int, int) bool {
+	return func(i, j int) bool {
+		a := candidates[i]
+		b := candidates[j]
+		aInCQ := a.ClusterQueue == cq
+		bInCQ := b.ClusterQueue == cq
+		if aInCQ != bInCQ {
+			return !aInCQ
+		}
+		aShare := shares[a.ClusterQueue]
+		bShare := shares[b.ClusterQueue]
+		if aShare != bShare {
+			return aShare > bShare
+		}
 		pa := priority.Priority(a.Obj)
 		pb := priority.Priority(b.Obj)
 		if pa != pb {
@@ -362,7 +537,11 @@ func candidatesOrdering(candidates []*workload.Info, cq string, now time.Time) f
 }
 
 func admisionTime(wl *kueue.Workload, now time.Time) time.Time {
-	cond := meta.FindStatusCondition(wl.Status.Conditions, kueue.WorkloadAdmitted)
+	cond := meta.FindStatusCondition(wl.Status.Conditions, kueue.WorkloadQuotaReserved)
+	if cond == nil {
+		// Workloads admitted before the QuotaReserved condition existed.
+		cond = meta.FindStatusCondition(wl.Status.Conditions, kueue.WorkloadAdmitted)
+	}
 	if cond == nil || cond.Status != metav1.ConditionTrue {
 		// The condition wasn't populated yet, use the current time.
 		return now
@@ -27,10 +27,12 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
@@ -101,6 +103,89 @@ func TestPreemption(t *testing.T) {
 				ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
 			}).
 			Obj(),
+		utiltesting.MakeClusterQueue("ta1").
+			Cohort("team-a").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6").
+				Obj(),
+			).
+			Preemption(kueue.ClusterQueuePreemption{
+				WithinClusterQueue:  kueue.PreemptionPolicyLowerPriority,
+				ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
+			}).
+			Obj(),
+		utiltesting.MakeClusterQueue("ta2").
+			Cohort("team-a").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6").
+				Obj(),
+			).
+			Obj(),
+		utiltesting.MakeClusterQueue("tb1").
+			Cohort("team-b").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6").
+				Obj(),
+			).
+			Obj(),
+		utiltesting.MakeClusterQueue("fa").
+			Cohort("fair").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6").
+				Obj(),
+			).
+			Preemption(kueue.ClusterQueuePreemption{
+				WithinClusterQueue:  kueue.PreemptionPolicyLowerPriority,
+				ReclaimWithinCohort: kueue.PreemptionPolicyFairSharing,
+			}).
+			Obj(),
+		utiltesting.MakeClusterQueue("fb").
+			Cohort("fair").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6").
+				Obj(),
+			).
+			Obj(),
+		utiltesting.MakeClusterQueue("fc").
+			Cohort("fair").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6").
+				Obj(),
+			).
+			Obj(),
+		utiltesting.MakeClusterQueue("b1").
+			Cohort("with-borrowing").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6").
+				Obj(),
+			).
+			Preemption(kueue.ClusterQueuePreemption{
+				WithinClusterQueue:  kueue.PreemptionPolicyLowerPriority,
+				ReclaimWithinCohort: kueue.PreemptionPolicyAny,
+				BorrowWithinCohort: &kueue.BorrowWithinCohort{
+					Policy:               kueue.BorrowWithinCohortPolicyLowerPriority,
+					MaxPriorityThreshold: pointer.Int32(100),
+				},
+			}).
+			Obj(),
+		utiltesting.MakeClusterQueue("b2").
+			Cohort("with-borrowing").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6").
+				Obj(),
+			).
+			Obj(),
+		utiltesting.MakeClusterQueue("b3").
+			Cohort("with-borrowing").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6").
+				Obj(),
+			).
+			Obj(),
+	}
+	cohorts := []*kueue.Cohort{
+		utiltesting.MakeCohort("team-a").Parent("department").Obj(),
+		utiltesting.MakeCohort("team-b").Parent("department").Obj(),
 	}
 	cases := map[string]struct {
 		admitted      []kueue.Workload
@@ -638,6 +723,207 @@ func TestPreemption(t *testing.T) {
 				},
 			}),
 		},
+		"reclaim from the nearest cohort first": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("ta2-low", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "8").
+					Admit(utiltesting.MakeAdmission("ta2").Assignment(corev1.ResourceCPU, "default", "8000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("tb1-lowest", "").
+					Priority(-2).
+					Request(corev1.ResourceCPU, "10").
+					Admit(utiltesting.MakeAdmission("tb1").Assignment(corev1.ResourceCPU, "default", "10000m").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Request(corev1.ResourceCPU, "4").
+				Obj(),
+			targetCQ: "ta1",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			wantPreempted: sets.New("/ta2-low"),
+		},
+		"reclaim from another cohort in the tree": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("ta2", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "6").
+					Admit(utiltesting.MakeAdmission("ta2").Assignment(corev1.ResourceCPU, "default", "6000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("tb1-low", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "12").
+					Admit(utiltesting.MakeAdmission("tb1").Assignment(corev1.ResourceCPU, "default", "12000m").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Request(corev1.ResourceCPU, "4").
+				Obj(),
+			targetCQ: "ta1",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			wantPreempted: sets.New("/tb1-low"),
+		},
+		"fair sharing: preempt from the ClusterQueue with the highest share": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("fb-low", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "4000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-mid", "").
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "4000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-high", "").
+					Priority(1).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "4000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fc-1", "").
+					Request(corev1.ResourceCPU, "3").
+					Admit(utiltesting.MakeAdmission("fc").Assignment(corev1.ResourceCPU, "default", "3000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fc-2", "").
+					Request(corev1.ResourceCPU, "3").
+					Admit(utiltesting.MakeAdmission("fc").Assignment(corev1.ResourceCPU, "default", "3000m").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Request(corev1.ResourceCPU, "4").
+				Obj(),
+			targetCQ: "fa",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			wantPreempted: sets.New("/fb-low"),
+		},
+		"fair sharing: preempt while the share of the target stays higher": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("fa-1", "").
+					Request(corev1.ResourceCPU, "6").
+					Admit(utiltesting.MakeAdmission("fa").Assignment(corev1.ResourceCPU, "default", "6000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-1", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-2", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-3", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-4", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-5", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-6", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Request(corev1.ResourceCPU, "2").
+				Obj(),
+			targetCQ: "fa",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			wantPreempted: sets.New("/fb-1"),
+		},
+		"fair sharing: don't preempt when it would leave the target below the preemptor share": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("fa-1", "").
+					Request(corev1.ResourceCPU, "6").
+					Admit(utiltesting.MakeAdmission("fa").Assignment(corev1.ResourceCPU, "default", "6000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-1", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-2", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-3", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-4", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-5", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-6", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Request(corev1.ResourceCPU, "4").
+				Obj(),
+			targetCQ: "fa",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+		},
+		"fair sharing: don't preempt from a ClusterQueue with a lower share": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("fa-1", "").
+					Request(corev1.ResourceCPU, "8").
+					Admit(utiltesting.MakeAdmission("fa").Assignment(corev1.ResourceCPU, "default", "8000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-1", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "4000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fb-2", "").
+					Request(corev1.ResourceCPU, "6").
+					Admit(utiltesting.MakeAdmission("fb").Assignment(corev1.ResourceCPU, "default", "6000m").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Request(corev1.ResourceCPU, "2").
+				Obj(),
+			targetCQ: "fa",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+		},
 		"each podset preempts a different flavor": {
 			admitted: []kueue.Workload{
 				*utiltesting.MakeWorkload("low-alpha", "").
@@ -684,6 +970,132 @@ func TestPreemption(t *testing.T) {
 			},
 			wantPreempted: sets.New("/low-alpha", "/low-beta"),
 		},
+		"nominal quota is the limit when preempting without a borrowingLimit": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("ta1-low", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("ta1").Assignment(corev1.ResourceCPU, "default", "4").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("ta1-mid", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("ta1").Assignment(corev1.ResourceCPU, "default", "2").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Priority(1).
+				Request(corev1.ResourceCPU, "8").
+				Obj(),
+			targetCQ: "ta1",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+		},
+		"borrow up to the cohort quota when preempting with fair sharing without a borrowingLimit": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("fa-low", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("fa").Assignment(corev1.ResourceCPU, "default", "4").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("fa-mid", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("fa").Assignment(corev1.ResourceCPU, "default", "2").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Priority(1).
+				Request(corev1.ResourceCPU, "8").
+				Obj(),
+			targetCQ: "fa",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			wantPreempted: sets.New("/fa-low"),
+		},
+		"preempt lower priority workloads in the cohort while borrowing": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("b2-low", "").
+					Priority(50).
+					Request(corev1.ResourceCPU, "10").
+					Admit(utiltesting.MakeAdmission("b2").Assignment(corev1.ResourceCPU, "default", "10").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("b3-mid", "").
+					Priority(150).
+					Request(corev1.ResourceCPU, "6").
+					Admit(utiltesting.MakeAdmission("b3").Assignment(corev1.ResourceCPU, "default", "6").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Priority(200).
+				Request(corev1.ResourceCPU, "8").
+				Obj(),
+			targetCQ: "b1",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			wantPreempted: sets.New("/b2-low"),
+		},
+		"don't borrow while preempting workloads above the priority threshold": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("b2-mid", "").
+					Priority(150).
+					Request(corev1.ResourceCPU, "10").
+					Admit(utiltesting.MakeAdmission("b2").Assignment(corev1.ResourceCPU, "default", "10").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("b3-mid", "").
+					Priority(150).
+					Request(corev1.ResourceCPU, "6").
+					Admit(utiltesting.MakeAdmission("b3").Assignment(corev1.ResourceCPU, "default", "6").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Priority(200).
+				Request(corev1.ResourceCPU, "8").
+				Obj(),
+			targetCQ: "b1",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+		},
+		"preempt above the priority threshold without borrowing": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("b2-mid", "").
+					Priority(150).
+					Request(corev1.ResourceCPU, "10").
+					Admit(utiltesting.MakeAdmission("b2").Assignment(corev1.ResourceCPU, "default", "10").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("b3-mid", "").
+					Priority(150).
+					Request(corev1.ResourceCPU, "6").
+					Admit(utiltesting.MakeAdmission("b3").Assignment(corev1.ResourceCPU, "default", "6").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Priority(200).
+				Request(corev1.ResourceCPU, "4").
+				Obj(),
+			targetCQ: "b1",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			wantPreempted: sets.New("/b2-mid"),
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -699,6 +1111,11 @@ func TestPreemption(t *testing.T) {
 			for _, flv := range flavors {
 				cqCache.AddOrUpdateResourceFlavor(flv)
 			}
+			for _, cohort := range cohorts {
+				if err := cqCache.AddOrUpdateCohort(cohort); err != nil {
+					t.Fatalf("Couldn't add Cohort to cache: %v", err)
+				}
+			}
 			for _, cq := range clusterQueues {
 				if err := cqCache.AddClusterQueue(ctx, cq); err != nil {
 					t.Fatalf("Couldn't add ClusterQueue to cache: %v", err)
@@ -712,6 +1129,9 @@ func TestPreemption(t *testing.T) {
 			recorder := broadcaster.NewRecorder(scheme, corev1.EventSource{Component: constants.AdmissionName})
 			preemptor := New(cl, recorder)
 			preemptor.applyPreemption = func(ctx context.Context, w *kueue.Workload) error {
+				if cond := apimeta.FindStatusCondition(w.Status.Conditions, kueue.WorkloadEvicted); cond == nil || cond.Reason != kueue.WorkloadEvictedByPreemption {
+					t.Errorf("Workload %s evicted with condition %v, want reason %s", workload.Key(w), cond, kueue.WorkloadEvictedByPreemption)
+				}
 				lock.Lock()
 				gotPreempted.Insert(workload.Key(w))
 				lock.Unlock()
@@ -721,7 +1141,8 @@ func TestPreemption(t *testing.T) {
 			snapshot := cqCache.Snapshot()
 			wlInfo := workload.NewInfo(tc.incoming)
 			wlInfo.ClusterQueue = tc.targetCQ
-			preempted, err := preemptor.Do(ctx, *wlInfo, tc.assignment, &snapshot)
+			targets := preemptor.GetTargets(*wlInfo, tc.assignment, &snapshot)
+			preempted, err := preemptor.IssuePreemptions(ctx, targets, snapshot.ClusterQueues[wlInfo.ClusterQueue])
 			if err != nil {
 				t.Fatalf("Failed doing preemption")
 			}
@@ -751,6 +1172,10 @@ func TestCandidatesOrdering(t *testing.T) {
 			Admit(utiltesting.MakeAdmission("other").Obj()).
 			Priority(10).
 			Obj()),
+		workload.NewInfo(utiltesting.MakeWorkload("other-in-parent-cohort", "").
+			Admit(utiltesting.MakeAdmission("far").Obj()).
+			Priority(-20).
+			Obj()),
 		workload.NewInfo(utiltesting.MakeWorkload("old", "").
 			Admit(utiltesting.MakeAdmission("self").Obj()).
 			Condition(metav1.Condition{
@@ -763,12 +1188,13 @@ func TestCandidatesOrdering(t *testing.T) {
 			Admit(utiltesting.MakeAdmission("self").Obj()).
 			Obj()),
 	}
-	sort.Slice(candidates, candidatesOrdering(candidates, "self", now))
+	distances := map[string]int{"self": 0, "other": 0, "far": 1}
+	sort.Slice(candidates, candidatesOrdering(candidates, "self", distances, now))
 	gotNames := make([]string, len(candidates))
 	for i, c := range candidates {
 		gotNames[i] = workload.Key(c.Obj)
 	}
-	wantCandidates := []string{"/other", "/low", "/current", "/old", "/high"}
+	wantCandidates := []string{"/other", "/other-in-parent-cohort", "/low", "/current", "/old", "/high"}
 	if diff := cmp.Diff(wantCandidates, gotNames); diff != "" {
 		t.Errorf("Sorted with wrong order (-want,+got):\n%s", diff)
 	}
@@ -26,6 +26,7 @@ import (
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
@@ -37,6 +38,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	config "sigs.k8s.io/kueue/apis/config/v1beta1"
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	"sigs.k8s.io/kueue/pkg/cache"
 	"sigs.k8s.io/kueue/pkg/constants"
@@ -63,12 +65,14 @@ type Scheduler struct {
 	admissionRoutineWrapper routine.Wrapper
 	preemptor               *preemption.Preemptor
 	waitForPodsReady        bool
+	fairSharing             config.FairSharing
 	// Stubs.
 	applyAdmission func(context.Context, *kueue.Workload) error
 }
 
 type options struct {
 	waitForPodsReady bool
+	fairSharing      config.FairSharing
 }
 
 // Option configures the reconciler.
@@ -82,6 +86,15 @@ func WithWaitForPodsReady(f bool) Option {
 	}
 }
 
+// WithFairSharing sets the fair sharing semantics of the scheduler.
+func WithFairSharing(fs *config.FairSharing) Option {
+	return func(o *options) {
+		if fs != nil {
+			o.fairSharing = *fs
+		}
+	}
+}
+
 var defaultOptions = options{}
 
 func New(queues *queue.Manager, cache *cache.Cache, cl client.Client, recorder record.EventRecorder, opts ...Option) *Scheduler {
@@ -97,6 +110,7 @@ func New(queues *queue.Manager, cache *cache.Cache, cl client.Client, recorder r
 		preemptor:               preemption.New(cl, recorder),
 		admissionRoutineWrapper: routine.DefaultWrapper,
 		waitForPodsReady:        options.waitForPodsReady,
+		fairSharing:             options.fairSharing,
 	}
 	s.applyAdmission = s.applyAdmissionWithSSA
 	return s
@@ -138,14 +152,19 @@ func (s *Scheduler) schedule(ctx context.Context) {
 	// 3. Calculate requirements (resource flavors, borrowing) for admitting workloads.
 	entries := s.nominate(ctx, headWorkloads, snapshot)
 
-	// 4. Sort entries based on borrowing and timestamps.
-	sort.Sort(entryOrdering(entries))
+	// 4. Sort entries based on borrowing, fair sharing and timestamps.
+	sort.Sort(entryOrdering{
+		entries:           entries,
+		enableFairSharing: s.fairSharing.Enable,
+	})
 
 	// 5. Admit entries, ensuring that no more than one workload gets
 	// admitted by a cohort (if borrowing).
 	// This is because there can be other workloads deeper in a clusterQueue whose
 	// head got admitted that should be scheduled in the cohort before the heads
 	// of other clusterQueues.
+	// The workloads of other cohorts in the same tree can still borrow, as long
+	// as they fit in the quota left by the workloads admitted before them.
 	usedCohorts := sets.New[string]()
 	for i := range entries {
 		e := &entries[i]
@@ -153,10 +172,17 @@ func (s *Scheduler) schedule(ctx context.Context) {
 			continue
 		}
 		cq := snapshot.ClusterQueues[e.ClusterQueue]
-		if e.assignment.Borrows() && cq.Cohort != nil && usedCohorts.Has(cq.Cohort.Name) {
-			e.status = skipped
-			e.inadmissibleMsg = "workloads in the cohort that don't require borrowing were prioritized and admitted first"
-			continue
+		if e.assignment.Borrows() && cq.Cohort != nil {
+			if usedCohorts.Has(cq.Cohort.Name) {
+				e.status = skipped
+				e.inadmissibleMsg = "workloads in the cohort that don't require borrowing were prioritized and admitted first"
+				continue
+			}
+			if e.assignment.RepresentativeMode() == flavorassigner.Fit && !cq.FitsInCohort(e.assignment.Usage()) {
+				e.status = skipped
+				e.inadmissibleMsg = "the unused quota of the cohort tree was taken by workloads admitted in this cycle"
+				continue
+			}
 		}
 		// Even if there was a failure, we shouldn't admit other workloads to this
 		// cohort.
@@ -166,7 +192,11 @@ func (s *Scheduler) schedule(ctx context.Context) {
 		log := log.WithValues("workload", klog.KObj(e.Obj), "clusterQueue", klog.KRef("", e.ClusterQueue))
 		ctx := ctrl.LoggerInto(ctx, log)
 		if e.assignment.RepresentativeMode() != flavorassigner.Fit {
-			preempted, err := s.preemptor.Do(ctx, e.Info, e.assignment, &snapshot)
+			if len(e.preemptionTargets) == 0 {
+				log.V(2).Info("Workload requires preemption, but there are no candidate workloads allowed for preemption", "preemptionReclaimWithinCohort", cq.Preemption.ReclaimWithinCohort, "preemptionWithinClusterQueue", cq.Preemption.WithinClusterQueue)
+				continue
+			}
+			preempted, err := s.preemptor.IssuePreemptions(ctx, e.preemptionTargets, cq)
 			if err != nil {
 				log.Error(err, "Failed to preempt workloads")
 			}
@@ -187,9 +217,19 @@ func (s *Scheduler) schedule(ctx context.Context) {
 				log.V(5).Info("Finished waiting for all admitted workloads to be in the PodsReady condition")
 			}
 		}
+		if !e.assignment.TopologyDomainsFit(snapshot.Topologies) {
+			e.status = skipped
+			e.inadmissibleMsg = "the assigned topology domains were taken by other workloads admitted in this cycle"
+			continue
+		}
 		e.status = nominated
-		if err := s.admit(ctx, e); err != nil {
+		if err := s.admit(ctx, e, cq.AdmissionChecks); err != nil {
 			e.inadmissibleMsg = fmt.Sprintf("Failed to admit workload: %v", err)
+		} else {
+			if cq.Cohort != nil {
+				snapshot.AddUsage(cq.Name, e.assignment.Usage())
+			}
+			e.assignment.AssumeTopologyDomains(snapshot.Topologies)
 		}
 	}
 
@@ -228,10 +268,19 @@ type entry struct {
 	// workload.Info holds the workload from the API as well as resource usage
 	// and flavors assigned.
 	workload.Info
-	assignment      flavorassigner.Assignment
-	status          entryStatus
-	inadmissibleMsg string
-	requeueReason   queue.RequeueReason
+	assignment flavorassigner.Assignment
+	// borrowingDepth is how far up the cohort tree the workload has to go
+	// to borrow quota, see cache.ClusterQueue.BorrowingDepth.
+	borrowingDepth int
+	// preemptionTargets are the workloads to preempt in order to admit the
+	// workload with the assignment.
+	preemptionTargets []*workload.Info
+	status            entryStatus
+	inadmissibleMsg   string
+	requeueReason     queue.RequeueReason
+	// dominantResourceShare is the share of the ClusterQueue, including the
+	// workload, used for fair sharing.
+	dominantResourceShare int
 }
 
 // nominate returns the workloads with their requirements (resource flavors, borrowing) if
@@ -261,14 +310,69 @@ func (s *Scheduler) nominate(ctx context.Context, workloads []workload.Info, sna
 		} else if err := s.validateLimitRange(ctx, &w); err != nil {
 			e.inadmissibleMsg = err.Error()
 		} else {
-			e.assignment = flavorassigner.AssignFlavors(log, &e.Info, snap.ResourceFlavors, cq)
+			e.assignment, e.preemptionTargets = s.getAssignments(log, &e.Info, &snap)
 			e.inadmissibleMsg = e.assignment.Message()
+			if e.assignment.RepresentativeMode() != flavorassigner.NoFit {
+				if err := cq.CheckLocalQueueLimits(workload.QueueKey(w.Obj), e.assignment.Usage()); err != nil {
+					e.assignment = flavorassigner.Assignment{}
+					e.inadmissibleMsg = err.Error()
+				}
+			}
+			if e.assignment.Borrows() && cq.Cohort != nil {
+				e.borrowingDepth = cq.BorrowingDepth(e.assignment.Usage())
+			}
+			if s.fairSharing.Enable && e.assignment.RepresentativeMode() != flavorassigner.NoFit {
+				e.dominantResourceShare, _ = cq.DominantResourceShareWith(e.assignment.Usage())
+			}
 		}
 		entries = append(entries, e)
 	}
 	return entries
 }
 
+// getAssignments returns the flavor assignment for the workload and the
+// workloads to preempt for it, if needed. If the workload doesn't fit with
+// the full counts of its pod sets but can be partially admitted, the largest
+// counts for which it fits, or for which it fits after preempting other
+// workloads, are assigned.
+func (s *Scheduler) getAssignments(log logr.Logger, wl *workload.Info, snap *cache.Snapshot) (flavorassigner.Assignment, []*workload.Info) {
+	cq := snap.ClusterQueues[wl.ClusterQueue]
+	fullAssignment := flavorassigner.AssignFlavors(log, wl, snap.ResourceFlavors, snap.Topologies, cq, nil)
+	var fullTargets []*workload.Info
+	switch fullAssignment.RepresentativeMode() {
+	case flavorassigner.Fit:
+		return fullAssignment, nil
+	case flavorassigner.Preempt:
+		fullTargets = s.preemptor.GetTargets(*wl, fullAssignment, snap)
+		if len(fullTargets) > 0 {
+			return fullAssignment, fullTargets
+		}
+	}
+
+	if workload.CanBePartiallyAdmitted(wl.Obj) {
+		type result struct {
+			assignment flavorassigner.Assignment
+			targets    []*workload.Info
+		}
+		reducer := flavorassigner.NewPodSetReducer(wl.Obj.Spec.PodSets, func(counts []int32) (*result, bool) {
+			assignment := flavorassigner.AssignFlavors(log, wl, snap.ResourceFlavors, snap.Topologies, cq, counts)
+			switch assignment.RepresentativeMode() {
+			case flavorassigner.Fit:
+				return &result{assignment: assignment}, true
+			case flavorassigner.Preempt:
+				if targets := s.preemptor.GetTargets(*wl, assignment, snap); len(targets) > 0 {
+					return &result{assignment: assignment, targets: targets}, true
+				}
+			}
+			return nil, false
+		})
+		if r, found := reducer.Search(); found {
+			return r.assignment, r.targets
+		}
+	}
+	return fullAssignment, fullTargets
+}
+
 // validateResources validates that requested resources are less or equal
 // to limits.
 func (s *Scheduler) validateResources(wi *workload.Info) error {
@@ -330,8 +434,9 @@ func (s *Scheduler) validateLimitRange(ctx context.Context, wi *workload.Info) e
 
 // admit sets the admitting clusterQueue and flavors into the workload of
 // the entry, and asynchronously updates the object in the apiserver after
-// assuming it in the cache.
-func (s *Scheduler) admit(ctx context.Context, e *entry) error {
+// assuming it in the cache. The workload reserves quota and, if the
+// clusterQueue doesn't have admission checks, it is also admitted.
+func (s *Scheduler) admit(ctx context.Context, e *entry, admissionChecks []string) error {
 	log := ctrl.LoggerFrom(ctx)
 	newWorkload := e.Obj.DeepCopy()
 	admission := &kueue.Admission{
@@ -346,18 +451,36 @@ func (s *Scheduler) admit(ctx context.Context, e *entry) error {
 	log.V(2).Info("Workload assumed in the cache")
 
 	s.admissionRoutineWrapper.Run(func() {
-		patch := workload.AdmissionPatch(newWorkload)
-		patch.Status.Conditions = []metav1.Condition{{
-			Type:               kueue.WorkloadAdmitted,
+		now := metav1.Now()
+		msg := fmt.Sprintf("Quota reserved in ClusterQueue %s", admission.ClusterQueue)
+		apimeta.SetStatusCondition(&newWorkload.Status.Conditions, metav1.Condition{
+			Type:               kueue.WorkloadQuotaReserved,
 			Status:             metav1.ConditionTrue,
-			LastTransitionTime: metav1.Now(),
-			Reason:             "Admitted",
-			Message:            fmt.Sprintf("Admitted by ClusterQueue %s", newWorkload.Status.Admission.ClusterQueue),
-		}}
+			LastTransitionTime: now,
+			Reason:             "QuotaReserved",
+			Message:            msg,
+		})
+		if len(admissionChecks) == 0 {
+			apimeta.SetStatusCondition(&newWorkload.Status.Conditions, metav1.Condition{
+				Type:               kueue.WorkloadAdmitted,
+				Status:             metav1.ConditionTrue,
+				LastTransitionTime: now,
+				Reason:             "Admitted",
+				Message:            fmt.Sprintf("Admitted by ClusterQueue %s", admission.ClusterQueue),
+			})
+		}
+		if apimeta.FindStatusCondition(newWorkload.Status.Conditions, kueue.WorkloadEvicted) != nil {
+			workload.SetEvictedCondition(newWorkload, metav1.ConditionFalse, "QuotaReserved", msg)
+		}
+		patch := workload.AdmissionStatusPatch(newWorkload)
 		err := s.applyAdmission(ctx, patch)
 		if err == nil {
 			waitTime := time.Since(e.Obj.CreationTimestamp.Time)
-			s.recorder.Eventf(newWorkload, corev1.EventTypeNormal, "Admitted", "Admitted by ClusterQueue %v, wait time was %.0fs", admission.ClusterQueue, waitTime.Seconds())
+			if len(admissionChecks) == 0 {
+				s.recorder.Eventf(newWorkload, corev1.EventTypeNormal, "Admitted", "Admitted by ClusterQueue %v, wait time was %.0fs", admission.ClusterQueue, waitTime.Seconds())
+			} else {
+				s.recorder.Eventf(newWorkload, corev1.EventTypeNormal, "QuotaReserved", "%s, wait time was %.0fs; waiting for the admission checks", msg, waitTime.Seconds())
+			}
 			metrics.AdmittedWorkload(admission.ClusterQueue, waitTime)
 			log.V(2).Info("Workload successfully admitted and assigned flavors")
 			return
@@ -381,29 +504,43 @@ func (s *Scheduler) applyAdmissionWithSSA(ctx context.Context, w *kueue.Workload
 	return s.client.Status().Patch(ctx, w, client.Apply, client.FieldOwner(constants.AdmissionName))
 }
 
-type entryOrdering []entry
+type entryOrdering struct {
+	entries           []entry
+	enableFairSharing bool
+}
 
 func (e entryOrdering) Len() int {
-	return len(e)
+	return len(e.entries)
 }
 
 func (e entryOrdering) Swap(i, j int) {
-	e[i], e[j] = e[j], e[i]
+	e.entries[i], e.entries[j] = e.entries[j], e.entries[i]
 }
 
 // Less is the ordering criteria:
 // 1. request under min quota before borrowing.
-// 2. FIFO on creation timestamp.
+// 2. borrowing from a nearer cohort of the tree before a farther one.
+// 3. lower dominant resource share of the ClusterQueue first, if fair
+// sharing is enabled.
+// 4. FIFO on creation timestamp.
 func (e entryOrdering) Less(i, j int) bool {
-	a := e[i]
-	b := e[j]
+	a := e.entries[i]
+	b := e.entries[j]
 	// 1. Request under min quota.
 	aBorrows := a.assignment.Borrows()
 	bBorrows := b.assignment.Borrows()
 	if aBorrows != bBorrows {
 		return !aBorrows
 	}
-	// 2. FIFO.
+	// 2. Borrowing from a nearer cohort.
+	if a.borrowingDepth != b.borrowingDepth {
+		return a.borrowingDepth < b.borrowingDepth
+	}
+	// 3. Fair sharing.
+	if e.enableFairSharing && a.dominantResourceShare != b.dominantResourceShare {
+		return a.dominantResourceShare < b.dominantResourceShare
+	}
+	// 4. FIFO.
 	return a.Obj.CreationTimestamp.Before(&b.Obj.CreationTimestamp)
 }
 
@@ -28,6 +28,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -41,6 +42,7 @@ import (
 	"sigs.k8s.io/kueue/pkg/constants"
 	"sigs.k8s.io/kueue/pkg/queue"
 	"sigs.k8s.io/kueue/pkg/scheduler/flavorassigner"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
 	"sigs.k8s.io/kueue/pkg/util/routine"
 	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
 	"sigs.k8s.io/kueue/pkg/workload"
@@ -173,6 +175,10 @@ func TestSchedule(t *testing.T) {
 				ClusterQueue: "nonexistent-cq",
 			},
 		},
+		*utiltesting.MakeLocalQueue("limited", "sales").
+			ClusterQueue("sales").
+			MaxUsage("default", corev1.ResourceCPU, "10").
+			Obj(),
 	}
 	cases := map[string]struct {
 		workloads      []kueue.Workload
@@ -264,6 +270,91 @@ func TestSchedule(t *testing.T) {
 				"sales": sets.New("sales/new"),
 			},
 		},
+		"partially admitted when the clusterQueue can't fit the full count": {
+			workloads: []kueue.Workload{
+				*utiltesting.MakeWorkload("new", "sales").
+					Queue("main").
+					PodSets(*utiltesting.MakePodSet("one", 20).
+						SetMinimumCount(5).
+						Request(corev1.ResourceCPU, "1").
+						Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("assigned", "sales").
+					PodSets(*utiltesting.MakePodSet("one", 40).
+						Request(corev1.ResourceCPU, "1").
+						Obj()).
+					Admit(utiltesting.MakeAdmission("sales", "one").Assignment(corev1.ResourceCPU, "default", "40000m").Obj()).
+					Obj(),
+			},
+			wantAssignments: map[string]kueue.Admission{
+				"sales/assigned": {
+					ClusterQueue: "sales",
+					PodSetAssignments: []kueue.PodSetAssignment{
+						{
+							Name: "one",
+							Flavors: map[corev1.ResourceName]kueue.ResourceFlavorReference{
+								corev1.ResourceCPU: "default",
+							},
+							ResourceUsage: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("40000m"),
+							},
+						},
+					},
+				},
+				"sales/new": {
+					ClusterQueue: "sales",
+					PodSetAssignments: []kueue.PodSetAssignment{
+						{
+							Name: "one",
+							Flavors: map[corev1.ResourceName]kueue.ResourceFlavorReference{
+								corev1.ResourceCPU: "default",
+							},
+							ResourceUsage: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("10000m"),
+							},
+							Count: pointer.Int32(10),
+						},
+					},
+				},
+			},
+			wantScheduled: []string{"sales/new"},
+		},
+		"localQueue limit exceeded": {
+			workloads: []kueue.Workload{
+				*utiltesting.MakeWorkload("new", "sales").
+					Queue("limited").
+					PodSets(*utiltesting.MakePodSet("one", 5).
+						Request(corev1.ResourceCPU, "1").
+						Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("assigned", "sales").
+					Queue("limited").
+					PodSets(*utiltesting.MakePodSet("one", 6).
+						Request(corev1.ResourceCPU, "1").
+						Obj()).
+					Admit(utiltesting.MakeAdmission("sales", "one").Assignment(corev1.ResourceCPU, "default", "6000m").Obj()).
+					Obj(),
+			},
+			wantAssignments: map[string]kueue.Admission{
+				"sales/assigned": {
+					ClusterQueue: "sales",
+					PodSetAssignments: []kueue.PodSetAssignment{
+						{
+							Name: "one",
+							Flavors: map[corev1.ResourceName]kueue.ResourceFlavorReference{
+								corev1.ResourceCPU: "default",
+							},
+							ResourceUsage: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("6000m"),
+							},
+						},
+					},
+				},
+			},
+			wantLeft: map[string]sets.Set[string]{
+				"sales": sets.New("sales/new"),
+			},
+		},
 		"failed to match clusterQueue selector": {
 			workloads: []kueue.Workload{
 				*utiltesting.MakeWorkload("new", "sales").
@@ -709,6 +800,251 @@ func TestSchedule(t *testing.T) {
 	}
 }
 
+func TestScheduleCohortTree(t *testing.T) {
+	now := time.Now()
+	cohorts := []*kueue.Cohort{
+		utiltesting.MakeCohort("team-a").Parent("company").Obj(),
+		utiltesting.MakeCohort("team-b").Parent("company").Obj(),
+	}
+	clusterQueues := []*kueue.ClusterQueue{
+		utiltesting.MakeClusterQueue("a1").
+			Cohort("team-a").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "10").Obj()).
+			Obj(),
+		utiltesting.MakeClusterQueue("a2").
+			Cohort("team-a").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "10").Obj()).
+			Obj(),
+		utiltesting.MakeClusterQueue("b1").
+			Cohort("team-b").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "10").Obj()).
+			Obj(),
+	}
+	queues := []*kueue.LocalQueue{
+		utiltesting.MakeLocalQueue("a1", "default").ClusterQueue("a1").Obj(),
+		utiltesting.MakeLocalQueue("a2", "default").ClusterQueue("a2").Obj(),
+		utiltesting.MakeLocalQueue("b1", "default").ClusterQueue("b1").Obj(),
+	}
+	cases := map[string]struct {
+		workloads     []kueue.Workload
+		wantScheduled []string
+		wantLeft      map[string]sets.Set[string]
+	}{
+		"borrow in different cohorts of the tree": {
+			workloads: []kueue.Workload{
+				*utiltesting.MakeWorkload("a1", "default").
+					Queue("a1").
+					Creation(now).
+					Request(corev1.ResourceCPU, "15").
+					Obj(),
+				*utiltesting.MakeWorkload("b1", "default").
+					Queue("b1").
+					Creation(now.Add(time.Second)).
+					Request(corev1.ResourceCPU, "12").
+					Obj(),
+			},
+			wantScheduled: []string{"default/a1", "default/b1"},
+		},
+		"cannot borrow quota taken by another cohort of the tree in the cycle": {
+			workloads: []kueue.Workload{
+				*utiltesting.MakeWorkload("a1", "default").
+					Queue("a1").
+					Creation(now).
+					Request(corev1.ResourceCPU, "15").
+					Obj(),
+				*utiltesting.MakeWorkload("b1", "default").
+					Queue("b1").
+					Creation(now.Add(time.Second)).
+					Request(corev1.ResourceCPU, "14").
+					Obj(),
+				*utiltesting.MakeWorkload("a2-running", "default").
+					Request(corev1.ResourceCPU, "10").
+					Admit(utiltesting.MakeAdmission("a2").Assignment(corev1.ResourceCPU, "default", "10").Obj()).
+					Obj(),
+			},
+			wantScheduled: []string{"default/a1"},
+			wantLeft: map[string]sets.Set[string]{
+				"b1": sets.New("default/b1"),
+			},
+		},
+		"the nearest cohort borrows its unused quota first": {
+			workloads: []kueue.Workload{
+				*utiltesting.MakeWorkload("b1", "default").
+					Queue("b1").
+					Creation(now).
+					Request(corev1.ResourceCPU, "12").
+					Obj(),
+				*utiltesting.MakeWorkload("a2", "default").
+					Queue("a2").
+					Creation(now.Add(time.Second)).
+					Request(corev1.ResourceCPU, "12").
+					Obj(),
+				*utiltesting.MakeWorkload("a1-running", "default").
+					Request(corev1.ResourceCPU, "8").
+					Admit(utiltesting.MakeAdmission("a1").Assignment(corev1.ResourceCPU, "default", "8").Obj()).
+					Obj(),
+			},
+			wantScheduled: []string{"default/a2"},
+			wantLeft: map[string]sets.Set[string]{
+				"b1": sets.New("default/b1"),
+			},
+		},
+		"cannot borrow twice in the same cohort": {
+			workloads: []kueue.Workload{
+				*utiltesting.MakeWorkload("a1", "default").
+					Queue("a1").
+					Creation(now).
+					Request(corev1.ResourceCPU, "12").
+					Obj(),
+				*utiltesting.MakeWorkload("a2", "default").
+					Queue("a2").
+					Creation(now.Add(time.Second)).
+					Request(corev1.ResourceCPU, "12").
+					Obj(),
+			},
+			wantScheduled: []string{"default/a1"},
+			wantLeft: map[string]sets.Set[string]{
+				"a2": sets.New("default/a2"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := ctrl.LoggerInto(context.Background(), testr.New(t))
+			cl := utiltesting.NewClientBuilder().
+				WithLists(&kueue.WorkloadList{Items: tc.workloads}).
+				WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}).
+				Build()
+			recorder := record.NewBroadcaster().NewRecorder(runtime.NewScheme(), corev1.EventSource{Component: constants.AdmissionName})
+			cqCache := cache.New(cl)
+			qManager := queue.NewManager(cl, cqCache)
+			cqCache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+			for _, c := range cohorts {
+				if err := cqCache.AddOrUpdateCohort(c); err != nil {
+					t.Fatalf("Inserting cohort %s in cache: %v", c.Name, err)
+				}
+			}
+			for _, cq := range clusterQueues {
+				if err := cqCache.AddClusterQueue(ctx, cq); err != nil {
+					t.Fatalf("Inserting clusterQueue %s in cache: %v", cq.Name, err)
+				}
+				if err := qManager.AddClusterQueue(ctx, cq); err != nil {
+					t.Fatalf("Inserting clusterQueue %s in manager: %v", cq.Name, err)
+				}
+			}
+			for _, q := range queues {
+				if err := qManager.AddLocalQueue(ctx, q); err != nil {
+					t.Fatalf("Inserting queue %s/%s in manager: %v", q.Namespace, q.Name, err)
+				}
+			}
+			scheduler := New(qManager, cqCache, cl, recorder)
+			gotScheduled := sets.New[string]()
+			var mu sync.Mutex
+			scheduler.applyAdmission = func(ctx context.Context, w *kueue.Workload) error {
+				mu.Lock()
+				gotScheduled.Insert(workload.Key(w))
+				mu.Unlock()
+				return nil
+			}
+			wg := sync.WaitGroup{}
+			scheduler.setAdmissionRoutineWrapper(routine.NewWrapper(
+				func() { wg.Add(1) },
+				func() { wg.Done() },
+			))
+
+			ctx, cancel := context.WithTimeout(ctx, queueingTimeout)
+			go qManager.CleanUpOnContext(ctx)
+			defer cancel()
+
+			scheduler.schedule(ctx)
+			wg.Wait()
+
+			if diff := cmp.Diff(sets.New(tc.wantScheduled...), gotScheduled); diff != "" {
+				t.Errorf("Unexpected scheduled workloads (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantLeft, qManager.Dump()); diff != "" {
+				t.Errorf("Unexpected elements left in the queue (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestScheduleEvictedWorkload tests that the admission of a workload that was
+// evicted, applied with the field manager that owns the Evicted condition,
+// sets the condition back to False.
+func TestScheduleEvictedWorkload(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), testr.New(t))
+	cq := utiltesting.MakeClusterQueue("cq").
+		ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+			Resource(corev1.ResourceCPU, "10").Obj()).
+		Obj()
+	lq := utiltesting.MakeLocalQueue("lq", "default").ClusterQueue("cq").Obj()
+	wl := utiltesting.MakeWorkload("wl", "default").
+		Queue("lq").
+		Request(corev1.ResourceCPU, "1").
+		Condition(metav1.Condition{
+			Type:   kueue.WorkloadQuotaReserved,
+			Status: metav1.ConditionFalse,
+			Reason: "Evicted",
+		}).
+		Condition(metav1.Condition{
+			Type:   kueue.WorkloadEvicted,
+			Status: metav1.ConditionTrue,
+			Reason: kueue.WorkloadEvictedByPodsReadyTimeout,
+		}).
+		Obj()
+	cl := utiltesting.NewClientBuilder().
+		WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}, wl).
+		Build()
+	recorder := record.NewBroadcaster().NewRecorder(runtime.NewScheme(), corev1.EventSource{Component: constants.AdmissionName})
+	cqCache := cache.New(cl)
+	qManager := queue.NewManager(cl, cqCache)
+	cqCache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+	if err := cqCache.AddClusterQueue(ctx, cq); err != nil {
+		t.Fatalf("Inserting clusterQueue in cache: %v", err)
+	}
+	if err := qManager.AddClusterQueue(ctx, cq); err != nil {
+		t.Fatalf("Inserting clusterQueue in manager: %v", err)
+	}
+	if err := qManager.AddLocalQueue(ctx, lq); err != nil {
+		t.Fatalf("Inserting queue in manager: %v", err)
+	}
+	scheduler := New(qManager, cqCache, cl, recorder)
+	wg := sync.WaitGroup{}
+	scheduler.setAdmissionRoutineWrapper(routine.NewWrapper(
+		func() { wg.Add(1) },
+		func() { wg.Done() },
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, queueingTimeout)
+	go qManager.CleanUpOnContext(ctx)
+	defer cancel()
+
+	scheduler.schedule(ctx)
+	wg.Wait()
+
+	var gotWorkload kueue.Workload
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(wl), &gotWorkload); err != nil {
+		t.Fatalf("Getting the workload: %v", err)
+	}
+	if !workload.HasQuotaReservation(&gotWorkload) {
+		t.Errorf("Workload didn't reserve quota, conditions: %v", gotWorkload.Status.Conditions)
+	}
+	wantEvicted := &metav1.Condition{
+		Type:    kueue.WorkloadEvicted,
+		Status:  metav1.ConditionFalse,
+		Reason:  "QuotaReserved",
+		Message: "Quota reserved in ClusterQueue cq",
+	}
+	if diff := cmp.Diff(wantEvicted, apimeta.FindStatusCondition(gotWorkload.Status.Conditions, kueue.WorkloadEvicted),
+		cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")); diff != "" {
+		t.Errorf("Unexpected Evicted condition (-want,+got):\n%s", diff)
+	}
+}
+
 func TestEntryOrdering(t *testing.T) {
 	now := time.Now()
 	input := []entry{
@@ -724,6 +1060,7 @@ func TestEntryOrdering(t *testing.T) {
 					"flavor": {},
 				},
 			},
+			dominantResourceShare: 100,
 		},
 		{
 			Info: workload.Info{
@@ -732,6 +1069,7 @@ func TestEntryOrdering(t *testing.T) {
 					CreationTimestamp: metav1.NewTime(now.Add(time.Second)),
 				}},
 			},
+			dominantResourceShare: 20,
 		},
 		{
 			Info: workload.Info{
@@ -740,6 +1078,7 @@ func TestEntryOrdering(t *testing.T) {
 					CreationTimestamp: metav1.NewTime(now.Add(2 * time.Second)),
 				}},
 			},
+			dominantResourceShare: 10,
 		},
 		{
 			Info: workload.Info{
@@ -753,16 +1092,37 @@ func TestEntryOrdering(t *testing.T) {
 					"flavor": {},
 				},
 			},
+			dominantResourceShare: 50,
 		},
 	}
-	sort.Sort(entryOrdering(input))
-	order := make([]string, len(input))
-	for i, e := range input {
-		order[i] = e.Obj.Name
+	cases := map[string]struct {
+		enableFairSharing bool
+		wantOrder         []string
+	}{
+		"fair sharing disabled": {
+			wantOrder: []string{"beta", "gamma", "alpha", "delta"},
+		},
+		"fair sharing enabled": {
+			enableFairSharing: true,
+			wantOrder:         []string{"gamma", "beta", "delta", "alpha"},
+		},
 	}
-	wantOrder := []string{"beta", "gamma", "alpha", "delta"}
-	if diff := cmp.Diff(wantOrder, order); diff != "" {
-		t.Errorf("Unexpected order (-want,+got):\n%s", diff)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			entries := make([]entry, len(input))
+			copy(entries, input)
+			sort.Sort(entryOrdering{
+				entries:           entries,
+				enableFairSharing: tc.enableFairSharing,
+			})
+			order := make([]string, len(entries))
+			for i, e := range entries {
+				order[i] = e.Obj.Name
+			}
+			if diff := cmp.Diff(tc.wantOrder, order); diff != "" {
+				t.Errorf("Unexpected order (-want,+got):\n%s", diff)
+			}
+		})
 	}
 }
 
@@ -787,6 +1147,12 @@ func TestRequeueAndUpdate(t *testing.T) {
 			},
 			wantStatus: kueue.WorkloadStatus{
 				Conditions: []metav1.Condition{
+					{
+						Type:    kueue.WorkloadQuotaReserved,
+						Status:  metav1.ConditionFalse,
+						Reason:  "Pending",
+						Message: "didn't fit",
+					},
 					{
 						Type:    kueue.WorkloadAdmitted,
 						Status:  metav1.ConditionFalse,
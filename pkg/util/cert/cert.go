@@ -32,17 +32,42 @@ const (
 	mwcName        = "kueue-mutating-webhook-configuration"
 	caName         = "kueue-ca"
 	caOrganization = "kueue"
+
+	// The names of the visibility APIService and Service aren't prefixed, see
+	// config/components/visibility.
+	visibilityAPIServiceName = "v1alpha1.visibility.kueue.x-k8s.io"
+	visibilityServiceName    = "kueue-visibility-server"
 )
 
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update
 //+kubebuilder:rbac:groups="admissionregistration.k8s.io",resources=mutatingwebhookconfigurations,verbs=get;list;watch;update
 //+kubebuilder:rbac:groups="admissionregistration.k8s.io",resources=validatingwebhookconfigurations,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups="apiregistration.k8s.io",resources=apiservices,verbs=get;list;watch;update
 
 // ManageCerts creates all certs for webhooks. This function is called from main.go.
 func ManageCerts(mgr ctrl.Manager, cfg config.Configuration, setupFinished chan struct{}) error {
 	// DNSName is <service name>.<namespace>.svc
 	var dnsName = fmt.Sprintf("%s.%s.svc", *cfg.InternalCertManagement.WebhookServiceName, *cfg.Namespace)
 
+	webhooks := []cert.WebhookInfo{{
+		Type: cert.Validating,
+		Name: vwcName,
+	}, {
+		Type: cert.Mutating,
+		Name: mwcName,
+	}}
+	var extraDNSNames []string
+	// The visibility server uses the certificate of the webhook server, so
+	// the certificate also needs to be valid for the visibility service, and
+	// the CA is injected in the APIService.
+	if cfg.Visibility != nil && cfg.Visibility.Enable {
+		webhooks = append(webhooks, cert.WebhookInfo{
+			Type: cert.APIService,
+			Name: visibilityAPIServiceName,
+		})
+		extraDNSNames = append(extraDNSNames, fmt.Sprintf("%s.%s.svc", visibilityServiceName, *cfg.Namespace))
+	}
+
 	return cert.AddRotator(mgr, &cert.CertRotator{
 		SecretKey: types.NamespacedName{
 			Namespace: *cfg.Namespace,
@@ -52,14 +77,9 @@ func ManageCerts(mgr ctrl.Manager, cfg config.Configuration, setupFinished chan
 		CAName:         caName,
 		CAOrganization: caOrganization,
 		DNSName:        dnsName,
+		ExtraDNSNames:  extraDNSNames,
 		IsReady:        setupFinished,
-		Webhooks: []cert.WebhookInfo{{
-			Type: cert.Validating,
-			Name: vwcName,
-		}, {
-			Type: cert.Mutating,
-			Name: mwcName,
-		}},
+		Webhooks:       webhooks,
 		// When kueue is running in the leader election mode,
 		// we expect webhook server will run in primary and secondary instance
 		RequireLeaderElection: false,
@@ -163,3 +163,67 @@ func TestGetPriorityFromPriorityClass(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPriorityFromWorkloadPriorityClass(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := kueue.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed adding kueue scheme: %v", err)
+	}
+
+	tests := map[string]struct {
+		workloadPriorityClassList      *kueue.WorkloadPriorityClassList
+		workloadPriorityClassName      string
+		wantWorkloadPriorityClassName  string
+		wantWorkloadPriorityClassValue int32
+		wantErr                        string
+	}{
+		"workloadPriorityClass is specified and it exists": {
+			workloadPriorityClassList: &kueue.WorkloadPriorityClassList{
+				Items: []kueue.WorkloadPriorityClass{
+					*utiltesting.MakeWorkloadPriorityClass("test").PriorityValue(50).Obj(),
+				},
+			},
+			workloadPriorityClassName:      "test",
+			wantWorkloadPriorityClassName:  "test",
+			wantWorkloadPriorityClassValue: 50,
+		},
+		"workloadPriorityClass is specified and it does not exist": {
+			workloadPriorityClassList: &kueue.WorkloadPriorityClassList{},
+			workloadPriorityClassName: "test",
+			wantErr:                   `workloadpriorityclasses.kueue.x-k8s.io "test" not found`,
+		},
+	}
+
+	for desc, tt := range tests {
+		tt := tt
+		t.Run(desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithLists(tt.workloadPriorityClassList).Build()
+
+			name, value, err := GetPriorityFromWorkloadPriorityClass(context.Background(), client, tt.workloadPriorityClassName)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+
+				if diff := cmp.Diff(tt.wantErr, err.Error()); diff != "" {
+					t.Errorf("unexpected error (-want,+got):\n%s", diff)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if name != tt.wantWorkloadPriorityClassName {
+				t.Errorf("unexpected name: got: %s, expected: %s", name, tt.wantWorkloadPriorityClassName)
+			}
+
+			if value != tt.wantWorkloadPriorityClassValue {
+				t.Errorf("unexpected value: got: %d, expected: %d", value, tt.wantWorkloadPriorityClassValue)
+			}
+		})
+	}
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package testing
 
 import (
+	"context"
+
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -30,17 +33,42 @@ func NewFakeClient(objs ...client.Object) client.Client {
 	return NewClientBuilder().WithObjects(objs...).Build()
 }
 
-func NewClientBuilder() *fake.ClientBuilder {
+// NewClientBuilder returns a fake client builder with the core, scheduling
+// and Kueue APIs, and the given APIs, in its scheme.
+func NewClientBuilder(addToSchemes ...func(s *runtime.Scheme) error) *fake.ClientBuilder {
 	scheme := runtime.NewScheme()
 	if err := corev1.AddToScheme(scheme); err != nil {
 		panic(err)
 	}
+	if err := schedulingv1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
 	if err := kueue.AddToScheme(scheme); err != nil {
 		panic(err)
 	}
+	for i := range addToSchemes {
+		if err := addToSchemes[i](scheme); err != nil {
+			panic(err)
+		}
+	}
 
 	return fake.NewClientBuilder().WithScheme(scheme).
 		WithIndex(&kueue.LocalQueue{}, indexer.QueueClusterQueueKey, indexer.IndexQueueClusterQueue).
 		WithIndex(&kueue.Workload{}, indexer.WorkloadQueueKey, indexer.IndexWorkloadQueue).
 		WithIndex(&kueue.Workload{}, indexer.WorkloadClusterQueueKey, indexer.IndexWorkloadClusterQueue)
 }
+
+type builderIndexer struct {
+	*fake.ClientBuilder
+}
+
+func (b *builderIndexer) IndexField(_ context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	b.ClientBuilder = b.ClientBuilder.WithIndex(obj, field, extractValue)
+	return nil
+}
+
+// AsIndexer returns a FieldIndexer that adds the indexes to the fake client
+// builder, to set up the indexes of the integrations in tests.
+func AsIndexer(builder *fake.ClientBuilder) client.FieldIndexer {
+	return &builderIndexer{ClientBuilder: builder}
+}
@@ -25,11 +25,38 @@ import (
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	"sigs.k8s.io/kueue/pkg/util/pointer"
 )
 
+// WorkloadPriorityClassWrapper wraps a WorkloadPriorityClass.
+type WorkloadPriorityClassWrapper struct {
+	kueue.WorkloadPriorityClass
+}
+
+// MakeWorkloadPriorityClass creates a wrapper for a WorkloadPriorityClass.
+func MakeWorkloadPriorityClass(name string) *WorkloadPriorityClassWrapper {
+	return &WorkloadPriorityClassWrapper{kueue.WorkloadPriorityClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		}},
+	}
+}
+
+// PriorityValue updates value of WorkloadPriorityClass.
+func (p *WorkloadPriorityClassWrapper) PriorityValue(v int32) *WorkloadPriorityClassWrapper {
+	p.Value = v
+	return p
+}
+
+// Obj returns the inner WorkloadPriorityClass.
+func (p *WorkloadPriorityClassWrapper) Obj() *kueue.WorkloadPriorityClass {
+	return &p.WorkloadPriorityClass
+}
+
 // PriorityClassWrapper wraps a PriorityClass.
 type PriorityClassWrapper struct {
 	schedulingv1.PriorityClass
@@ -111,6 +138,11 @@ func (w *WorkloadWrapper) PriorityClass(priorityClassName string) *WorkloadWrapp
 	return w
 }
 
+func (w *WorkloadWrapper) PriorityClassSource(source string) *WorkloadWrapper {
+	w.Spec.PriorityClassSource = source
+	return w
+}
+
 func (w *WorkloadWrapper) RuntimeClass(name string) *WorkloadWrapper {
 	for i := range w.Spec.PodSets {
 		w.Spec.PodSets[i].Template.Spec.RuntimeClassName = &name
@@ -143,6 +175,57 @@ func (w *WorkloadWrapper) Condition(condition metav1.Condition) *WorkloadWrapper
 	return w
 }
 
+// ControllerReference sets the controller of the workload.
+func (w *WorkloadWrapper) ControllerReference(gvk schema.GroupVersionKind, name, uid string) *WorkloadWrapper {
+	isController := true
+	w.OwnerReferences = append(w.OwnerReferences, metav1.OwnerReference{
+		APIVersion:         gvk.GroupVersion().String(),
+		Kind:               gvk.Kind,
+		Name:               name,
+		UID:                types.UID(uid),
+		Controller:         &isController,
+		BlockOwnerDeletion: &isController,
+	})
+	return w
+}
+
+// ReclaimablePods sets the reclaimable pods of the workload.
+func (w *WorkloadWrapper) ReclaimablePods(rps ...kueue.ReclaimablePod) *WorkloadWrapper {
+	w.Status.ReclaimablePods = rps
+	return w
+}
+
+// Active sets .spec.active.
+func (w *WorkloadWrapper) Active(a bool) *WorkloadWrapper {
+	w.Spec.Active = &a
+	return w
+}
+
+// RequeueState sets the number of times the workload was requeued and the
+// time after which it can be requeued again.
+func (w *WorkloadWrapper) RequeueState(count int32, requeueAt time.Time) *WorkloadWrapper {
+	w.Status.RequeueState = &kueue.RequeueState{
+		Count:     &count,
+		RequeueAt: &metav1.Time{Time: requeueAt},
+	}
+	return w
+}
+
+// AdmissionCheck adds or replaces the state of an admission check.
+func (w *WorkloadWrapper) AdmissionCheck(name string, state kueue.CheckState) *WorkloadWrapper {
+	for i := range w.Status.AdmissionChecks {
+		if w.Status.AdmissionChecks[i].Name == name {
+			w.Status.AdmissionChecks[i].State = state
+			return w
+		}
+	}
+	w.Status.AdmissionChecks = append(w.Status.AdmissionChecks, kueue.AdmissionCheckState{
+		Name:  name,
+		State: state,
+	})
+	return w
+}
+
 type PodSetWrapper struct{ kueue.PodSet }
 
 func MakePodSet(name string, count int) *PodSetWrapper {
@@ -175,6 +258,23 @@ func (p *PodSetWrapper) Request(r corev1.ResourceName, q string) *PodSetWrapper
 	return p
 }
 
+// SetMinimumCount sets the minimum number of pods the podSet can be
+// admitted with.
+// RequiredTopology sets the topology level that the pods of the podSet
+// require.
+func (p *PodSetWrapper) RequiredTopology(nodeLabel string) *PodSetWrapper {
+	if p.Template.Annotations == nil {
+		p.Template.Annotations = make(map[string]string)
+	}
+	p.Template.Annotations[kueue.PodSetRequiredTopologyAnnotation] = nodeLabel
+	return p
+}
+
+func (p *PodSetWrapper) SetMinimumCount(mc int32) *PodSetWrapper {
+	p.MinCount = &mc
+	return p
+}
+
 func (p *PodSetWrapper) Toleration(t corev1.Toleration) *PodSetWrapper {
 	p.Template.Spec.Tolerations = append(p.Template.Spec.Tolerations, t)
 	return p
@@ -215,6 +315,21 @@ func (w *AdmissionWrapper) Obj() *kueue.Admission {
 	return &w.Admission
 }
 
+// AssignmentPodCount sets the number of pods admitted for the first podSet.
+func (w *AdmissionWrapper) AssignmentPodCount(value int32) *AdmissionWrapper {
+	w.PodSetAssignments[0].Count = &value
+	return w
+}
+
+// AssignmentTopology sets the topology domain assigned to the first podSet.
+func (w *AdmissionWrapper) AssignmentTopology(levels, values []string) *AdmissionWrapper {
+	w.PodSetAssignments[0].TopologyAssignment = &kueue.TopologyAssignment{
+		Levels: levels,
+		Values: values,
+	}
+	return w
+}
+
 func (w *AdmissionWrapper) Assignment(r corev1.ResourceName, f kueue.ResourceFlavorReference, value string) *AdmissionWrapper {
 	w.PodSetAssignments[0].Flavors[r] = f
 	w.PodSetAssignments[0].ResourceUsage[r] = resource.MustParse(value)
@@ -256,6 +371,34 @@ func (q *LocalQueueWrapper) PendingWorkloads(n int32) *LocalQueueWrapper {
 	return q
 }
 
+// MaxUsage adds a limit for the usage of a resource in a flavor.
+func (q *LocalQueueWrapper) MaxUsage(flavor string, name corev1.ResourceName, maxUsage string) *LocalQueueWrapper {
+	if q.Spec.Limits == nil {
+		q.Spec.Limits = &kueue.LocalQueueLimits{}
+	}
+	limit := kueue.LocalQueueResourceLimit{Name: name, MaxUsage: resource.MustParse(maxUsage)}
+	for i := range q.Spec.Limits.Flavors {
+		if q.Spec.Limits.Flavors[i].Name == kueue.ResourceFlavorReference(flavor) {
+			q.Spec.Limits.Flavors[i].Resources = append(q.Spec.Limits.Flavors[i].Resources, limit)
+			return q
+		}
+	}
+	q.Spec.Limits.Flavors = append(q.Spec.Limits.Flavors, kueue.LocalQueueFlavorLimits{
+		Name:      kueue.ResourceFlavorReference(flavor),
+		Resources: []kueue.LocalQueueResourceLimit{limit},
+	})
+	return q
+}
+
+// MaxAdmittedWorkloads limits the number of admitted workloads.
+func (q *LocalQueueWrapper) MaxAdmittedWorkloads(n int32) *LocalQueueWrapper {
+	if q.Spec.Limits == nil {
+		q.Spec.Limits = &kueue.LocalQueueLimits{}
+	}
+	q.Spec.Limits.MaxAdmittedWorkloads = &n
+	return q
+}
+
 // ClusterQueueWrapper wraps a ClusterQueue.
 type ClusterQueueWrapper struct{ kueue.ClusterQueue }
 
@@ -286,6 +429,13 @@ func (c *ClusterQueueWrapper) Cohort(cohort string) *ClusterQueueWrapper {
 
 // ResourceGroup adds a ResourceGroup with flavors.
 func (c *ClusterQueueWrapper) ResourceGroup(flavors ...kueue.FlavorQuotas) *ClusterQueueWrapper {
+	c.Spec.ResourceGroups = append(c.Spec.ResourceGroups, resourceGroup(flavors...))
+	return c
+}
+
+// resourceGroup creates a ResourceGroup with flavors, covering the resources
+// listed by the flavors.
+func resourceGroup(flavors ...kueue.FlavorQuotas) kueue.ResourceGroup {
 	rg := kueue.ResourceGroup{
 		Flavors: flavors,
 	}
@@ -306,8 +456,7 @@ func (c *ClusterQueueWrapper) ResourceGroup(flavors ...kueue.FlavorQuotas) *Clus
 		}
 		rg.CoveredResources = resources
 	}
-	c.Spec.ResourceGroups = append(c.Spec.ResourceGroups, rg)
-	return c
+	return rg
 }
 
 // QueueingStrategy sets the queueing strategy in this ClusterQueue.
@@ -328,6 +477,24 @@ func (c *ClusterQueueWrapper) Preemption(p kueue.ClusterQueuePreemption) *Cluste
 	return c
 }
 
+// FairWeight sets the weight of the ClusterQueue for fair sharing.
+func (c *ClusterQueueWrapper) FairWeight(w resource.Quantity) *ClusterQueueWrapper {
+	c.Spec.FairSharing = &kueue.FairSharing{Weight: &w}
+	return c
+}
+
+// StopPolicy sets the stop policy of the ClusterQueue.
+func (c *ClusterQueueWrapper) StopPolicy(p kueue.StopPolicy) *ClusterQueueWrapper {
+	c.Spec.StopPolicy = &p
+	return c
+}
+
+// AdmissionChecks sets the admission checks of the ClusterQueue.
+func (c *ClusterQueueWrapper) AdmissionChecks(checks ...string) *ClusterQueueWrapper {
+	c.Spec.AdmissionChecks = checks
+	return c
+}
+
 // FlavorQuotasWrapper wraps a FlavorQuotas object.
 type FlavorQuotasWrapper struct{ kueue.FlavorQuotas }
 
@@ -350,16 +517,74 @@ func (f *FlavorQuotasWrapper) Resource(name corev1.ResourceName, qs ...string) *
 	if len(qs) > 0 {
 		rq.NominalQuota = resource.MustParse(qs[0])
 	}
-	if len(qs) > 1 {
+	if len(qs) > 1 && len(qs[1]) > 0 {
 		rq.BorrowingLimit = pointer.Quantity(resource.MustParse(qs[1]))
 	}
-	if len(qs) > 2 {
-		panic("Must have at most 2 quantities for nominalquota and borrowingLimit")
+	if len(qs) > 2 && len(qs[2]) > 0 {
+		rq.LendingLimit = pointer.Quantity(resource.MustParse(qs[2]))
+	}
+	if len(qs) > 3 {
+		panic("Must have at most 3 quantities for nominalquota, borrowingLimit and lendingLimit")
 	}
 	f.Resources = append(f.Resources, rq)
 	return f
 }
 
+// Schedule adds a quota schedule to the resource, which must be already
+// present in the flavor quotas.
+func (f *FlavorQuotasWrapper) Schedule(name corev1.ResourceName, qs kueue.QuotaSchedule) *FlavorQuotasWrapper {
+	for i := range f.Resources {
+		if f.Resources[i].Name == name {
+			f.Resources[i].Schedules = append(f.Resources[i].Schedules, qs)
+			return f
+		}
+	}
+	panic("Resource must be added before its schedules")
+}
+
+// NominalQuotaPercent sets the nominal quota of the resource, which must be
+// already present in the flavor quotas, as a share of the capacity of the
+// Nodes of the flavor.
+func (f *FlavorQuotasWrapper) NominalQuotaPercent(name corev1.ResourceName, percent int32) *FlavorQuotasWrapper {
+	for i := range f.Resources {
+		if f.Resources[i].Name == name {
+			f.Resources[i].NominalQuotaPercent = &percent
+			return f
+		}
+	}
+	panic("Resource must be added before its nominalQuotaPercent")
+}
+
+// QuotaScheduleWrapper wraps a QuotaSchedule.
+type QuotaScheduleWrapper struct{ kueue.QuotaSchedule }
+
+// MakeQuotaSchedule creates a wrapper for a QuotaSchedule.
+func MakeQuotaSchedule(name, schedule string, duration time.Duration, nominalQuota string) *QuotaScheduleWrapper {
+	return &QuotaScheduleWrapper{kueue.QuotaSchedule{
+		Name:         name,
+		Schedule:     schedule,
+		Duration:     metav1.Duration{Duration: duration},
+		NominalQuota: resource.MustParse(nominalQuota),
+	}}
+}
+
+// Obj returns the inner QuotaSchedule.
+func (q *QuotaScheduleWrapper) Obj() *kueue.QuotaSchedule {
+	return &q.QuotaSchedule
+}
+
+// TimeZone sets the time zone of the schedule.
+func (q *QuotaScheduleWrapper) TimeZone(tz string) *QuotaScheduleWrapper {
+	q.QuotaSchedule.TimeZone = &tz
+	return q
+}
+
+// BorrowingLimit sets the borrowing limit of the schedule.
+func (q *QuotaScheduleWrapper) BorrowingLimit(limit string) *QuotaScheduleWrapper {
+	q.QuotaSchedule.BorrowingLimit = pointer.Quantity(resource.MustParse(limit))
+	return q
+}
+
 // ResourceFlavorWrapper wraps a ResourceFlavor.
 type ResourceFlavorWrapper struct{ kueue.ResourceFlavor }
 
@@ -392,6 +617,124 @@ func (rf *ResourceFlavorWrapper) Taint(t corev1.Taint) *ResourceFlavorWrapper {
 	return rf
 }
 
+// TopologyLevels sets the node labels of the topology levels of the
+// ResourceFlavor.
+func (rf *ResourceFlavorWrapper) TopologyLevels(nodeLabels ...string) *ResourceFlavorWrapper {
+	rf.Spec.TopologyLevels = nil
+	for _, l := range nodeLabels {
+		rf.Spec.TopologyLevels = append(rf.Spec.TopologyLevels, kueue.TopologyLevel{NodeLabel: l})
+	}
+	return rf
+}
+
+// CapacityFromNodes makes the capacity of the ResourceFlavor be computed
+// from its Nodes.
+func (rf *ResourceFlavorWrapper) CapacityFromNodes() *ResourceFlavorWrapper {
+	rf.Spec.CapacityFromNodes = true
+	return rf
+}
+
+// CohortWrapper wraps a Cohort.
+type CohortWrapper struct{ kueue.Cohort }
+
+// MakeCohort creates a wrapper for a Cohort.
+func MakeCohort(name string) *CohortWrapper {
+	return &CohortWrapper{kueue.Cohort{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}}
+}
+
+// Obj returns the inner Cohort.
+func (c *CohortWrapper) Obj() *kueue.Cohort {
+	return &c.Cohort
+}
+
+// Parent sets the parent of the Cohort.
+func (c *CohortWrapper) Parent(parent string) *CohortWrapper {
+	c.Spec.Parent = parent
+	return c
+}
+
+// ResourceGroup adds a ResourceGroup with flavors to the Cohort.
+func (c *CohortWrapper) ResourceGroup(flavors ...kueue.FlavorQuotas) *CohortWrapper {
+	c.Spec.ResourceGroups = append(c.Spec.ResourceGroups, resourceGroup(flavors...))
+	return c
+}
+
+// AdmissionCheckWrapper wraps an AdmissionCheck.
+type AdmissionCheckWrapper struct{ kueue.AdmissionCheck }
+
+// MakeAdmissionCheck creates a wrapper for an AdmissionCheck evaluated by
+// the given controller.
+func MakeAdmissionCheck(name, controllerName string) *AdmissionCheckWrapper {
+	return &AdmissionCheckWrapper{kueue.AdmissionCheck{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: kueue.AdmissionCheckSpec{
+			ControllerName: controllerName,
+		},
+	}}
+}
+
+// Obj returns the inner AdmissionCheck.
+func (ac *AdmissionCheckWrapper) Obj() *kueue.AdmissionCheck {
+	return &ac.AdmissionCheck
+}
+
+// Active sets the Active condition of the AdmissionCheck.
+func (ac *AdmissionCheckWrapper) Active(status metav1.ConditionStatus) *AdmissionCheckWrapper {
+	apimeta.SetStatusCondition(&ac.Status.Conditions, metav1.Condition{
+		Type:    kueue.AdmissionCheckActive,
+		Status:  status,
+		Reason:  "ByTest",
+		Message: "by test",
+	})
+	return ac
+}
+
+// Parameters sets the reference to the parameters of the AdmissionCheck.
+func (ac *AdmissionCheckWrapper) Parameters(apiGroup, kind, name string) *AdmissionCheckWrapper {
+	ac.Spec.Parameters = &kueue.AdmissionCheckParametersReference{
+		APIGroup: apiGroup,
+		Kind:     kind,
+		Name:     name,
+	}
+	return ac
+}
+
+// MultiKueueConfigWrapper wraps a MultiKueueConfig.
+type MultiKueueConfigWrapper struct{ kueue.MultiKueueConfig }
+
+// MakeMultiKueueConfig creates a wrapper for a MultiKueueConfig.
+func MakeMultiKueueConfig(name string) *MultiKueueConfigWrapper {
+	return &MultiKueueConfigWrapper{kueue.MultiKueueConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}}
+}
+
+// Obj returns the inner MultiKueueConfig.
+func (c *MultiKueueConfigWrapper) Obj() *kueue.MultiKueueConfig {
+	return &c.MultiKueueConfig
+}
+
+// Cluster adds a worker cluster whose kubeconfig is stored in the given
+// Secret.
+func (c *MultiKueueConfigWrapper) Cluster(name, secretName string) *MultiKueueConfigWrapper {
+	c.Spec.Clusters = append(c.Spec.Clusters, kueue.MultiKueueCluster{
+		Name: name,
+		KubeconfigRef: kueue.KubeconfigRef{
+			LocationType: kueue.SecretLocationType,
+			Location:     secretName,
+		},
+	})
+	return c
+}
+
 // RuntimeClassWrapper wraps a RuntimeClass.
 type RuntimeClassWrapper struct{ nodev1.RuntimeClass }
 
@@ -469,3 +812,46 @@ func (lr *LimitRangeWrapper) WithValue(member string, t corev1.ResourceName, q s
 func (lr *LimitRangeWrapper) Obj() *corev1.LimitRange {
 	return &lr.LimitRange
 }
+
+// NodeWrapper wraps a Node.
+type NodeWrapper struct{ corev1.Node }
+
+// MakeNode creates a wrapper for a Ready Node.
+func MakeNode(name string) *NodeWrapper {
+	return &NodeWrapper{corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: make(map[string]string),
+		},
+		Status: corev1.NodeStatus{
+			Allocatable: make(corev1.ResourceList),
+			Conditions: []corev1.NodeCondition{{
+				Type:   corev1.NodeReady,
+				Status: corev1.ConditionTrue,
+			}},
+		},
+	}}
+}
+
+// Obj returns the inner Node.
+func (n *NodeWrapper) Obj() *corev1.Node {
+	return &n.Node
+}
+
+// Label adds a label to the Node.
+func (n *NodeWrapper) Label(k, v string) *NodeWrapper {
+	n.Labels[k] = v
+	return n
+}
+
+// Allocatable sets the allocatable quantity of a resource in the Node.
+func (n *NodeWrapper) Allocatable(r corev1.ResourceName, q string) *NodeWrapper {
+	n.Status.Allocatable[r] = resource.MustParse(q)
+	return n
+}
+
+// Unschedulable marks the Node as unschedulable.
+func (n *NodeWrapper) Unschedulable() *NodeWrapper {
+	n.Spec.Unschedulable = true
+	return n
+}
@@ -21,6 +21,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/kueue/pkg/controller/jobframework"
 	"sigs.k8s.io/kueue/pkg/util/pointer"
@@ -75,12 +76,33 @@ func (j *JobWrapper) Parallelism(p int32) *JobWrapper {
 	return j
 }
 
+// Completions updates job completions.
+func (j *JobWrapper) Completions(c int32) *JobWrapper {
+	j.Spec.Completions = pointer.Int32(c)
+	return j
+}
+
+// Succeeded sets the number of succeeded pods in the job status.
+func (j *JobWrapper) Succeeded(s int32) *JobWrapper {
+	j.Status.Succeeded = s
+	return j
+}
+
 // PriorityClass updates job priorityclass.
 func (j *JobWrapper) PriorityClass(pc string) *JobWrapper {
 	j.Spec.Template.Spec.PriorityClassName = pc
 	return j
 }
 
+// WorkloadPriorityClass updates the WorkloadPriorityClass of the job.
+func (j *JobWrapper) WorkloadPriorityClass(wpc string) *JobWrapper {
+	if j.Labels == nil {
+		j.Labels = make(map[string]string)
+	}
+	j.Labels[jobframework.WorkloadPriorityClassLabel] = wpc
+	return j
+}
+
 // Queue updates the queue name of the job
 func (j *JobWrapper) Queue(queue string) *JobWrapper {
 	if j.Labels == nil {
@@ -90,6 +112,21 @@ func (j *JobWrapper) Queue(queue string) *JobWrapper {
 	return j
 }
 
+// Label sets the label key and value
+func (j *JobWrapper) Label(key, value string) *JobWrapper {
+	if j.Labels == nil {
+		j.Labels = make(map[string]string)
+	}
+	j.Labels[key] = value
+	return j
+}
+
+// UID updates the uid of the job
+func (j *JobWrapper) UID(uid string) *JobWrapper {
+	j.ObjectMeta.UID = types.UID(uid)
+	return j
+}
+
 // QueueNameAnnotation updates the queue name of the job by annotation (deprecated)
 func (j *JobWrapper) QueueNameAnnotation(queue string) *JobWrapper {
 	j.Annotations[jobframework.QueueAnnotation] = queue
@@ -102,6 +139,12 @@ func (j *JobWrapper) ParentWorkload(parentWorkload string) *JobWrapper {
 	return j
 }
 
+// SetAnnotation sets the annotation with the key to the value.
+func (j *JobWrapper) SetAnnotation(key, content string) *JobWrapper {
+	j.Annotations[key] = content
+	return j
+}
+
 func (j *JobWrapper) OriginalNodeSelectorsAnnotation(content string) *JobWrapper {
 	j.Annotations[jobframework.OriginalNodeSelectorsAnnotation] = content
 	return j
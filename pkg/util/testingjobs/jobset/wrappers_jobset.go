@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	jobsetapi "sigs.k8s.io/jobset/api/v1alpha1"
+
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
+)
+
+// JobSetWrapper wraps a JobSet.
+type JobSetWrapper struct{ jobsetapi.JobSet }
+
+// ReplicatedJobRequirements are the parameters of a replicated job.
+type ReplicatedJobRequirements struct {
+	Name        string
+	Replicas    int
+	Parallelism int32
+}
+
+// MakeJobSet creates a wrapper for a suspended JobSet without replicated jobs.
+func MakeJobSet(name, ns string) *JobSetWrapper {
+	return &JobSetWrapper{jobsetapi.JobSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   ns,
+			Annotations: make(map[string]string, 1),
+		},
+		Spec: jobsetapi.JobSetSpec{
+			Suspend: pointer.Bool(true),
+		},
+	}}
+}
+
+// ReplicatedJobs adds replicated jobs with a single container.
+func (j *JobSetWrapper) ReplicatedJobs(replicatedJobs ...ReplicatedJobRequirements) *JobSetWrapper {
+	for _, req := range replicatedJobs {
+		j.Spec.ReplicatedJobs = append(j.Spec.ReplicatedJobs, jobsetapi.ReplicatedJob{
+			Name:     req.Name,
+			Replicas: req.Replicas,
+			Template: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Parallelism: pointer.Int32(req.Parallelism),
+					Completions: pointer.Int32(req.Parallelism),
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers: []corev1.Container{
+								{
+									Name:      "c",
+									Image:     "pause",
+									Command:   []string{},
+									Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{}},
+								},
+							},
+							NodeSelector: map[string]string{},
+						},
+					},
+				},
+			},
+		})
+	}
+	return j
+}
+
+// Obj returns the inner JobSet.
+func (j *JobSetWrapper) Obj() *jobsetapi.JobSet {
+	return &j.JobSet
+}
+
+// Queue updates the queue name of the JobSet.
+func (j *JobSetWrapper) Queue(queue string) *JobSetWrapper {
+	if j.Labels == nil {
+		j.Labels = make(map[string]string)
+	}
+	j.Labels[jobframework.QueueLabel] = queue
+	return j
+}
+
+// Request adds a resource request to the default container of a replicated job.
+func (j *JobSetWrapper) Request(replicatedJobName string, r corev1.ResourceName, v string) *JobSetWrapper {
+	for i, replicatedJob := range j.Spec.ReplicatedJobs {
+		if replicatedJob.Name == replicatedJobName {
+			j.Spec.ReplicatedJobs[i].Template.Spec.Template.Spec.Containers[0].Resources.Requests[r] = resource.MustParse(v)
+		}
+	}
+	return j
+}
+
+// PriorityClass updates the priorityClassName of the replicated jobs.
+func (j *JobSetWrapper) PriorityClass(pc string) *JobSetWrapper {
+	for i := range j.Spec.ReplicatedJobs {
+		j.Spec.ReplicatedJobs[i].Template.Spec.Template.Spec.PriorityClassName = pc
+	}
+	return j
+}
+
+// OriginalNodeSelectorsAnnotation updates the original node selectors annotation.
+func (j *JobSetWrapper) OriginalNodeSelectorsAnnotation(content string) *JobSetWrapper {
+	j.Annotations[jobframework.OriginalNodeSelectorsAnnotation] = content
+	return j
+}
+
+// Suspend updates the suspend status of the JobSet.
+func (j *JobSetWrapper) Suspend(s bool) *JobSetWrapper {
+	j.Spec.Suspend = pointer.Bool(s)
+	return j
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+)
+
+// PodWrapper wraps a Pod.
+type PodWrapper struct{ corev1.Pod }
+
+// MakePod creates a wrapper for a pod with a single container.
+func MakePod(name, ns string) *PodWrapper {
+	return &PodWrapper{corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   ns,
+			UID:         types.UID(name),
+			Labels:      make(map[string]string, 1),
+			Annotations: make(map[string]string, 1),
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:      "c",
+					Image:     "pause",
+					Command:   []string{},
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{}},
+				},
+			},
+			NodeSelector: map[string]string{},
+		},
+	}}
+}
+
+// Obj returns the inner Pod.
+func (p *PodWrapper) Obj() *corev1.Pod {
+	return &p.Pod
+}
+
+// Clone returns a deep copy of the wrapper.
+func (p *PodWrapper) Clone() *PodWrapper {
+	return &PodWrapper{Pod: *p.Pod.DeepCopy()}
+}
+
+// Queue updates the queue name of the pod.
+func (p *PodWrapper) Queue(queue string) *PodWrapper {
+	return p.Label(jobframework.QueueLabel, queue)
+}
+
+// Label sets the label with the key to the value.
+func (p *PodWrapper) Label(k, v string) *PodWrapper {
+	p.Labels[k] = v
+	return p
+}
+
+// Annotation sets the annotation with the key to the value.
+func (p *PodWrapper) Annotation(k, v string) *PodWrapper {
+	p.Annotations[k] = v
+	return p
+}
+
+// Gate adds a scheduling gate to the pod.
+func (p *PodWrapper) Gate(name string) *PodWrapper {
+	p.Spec.SchedulingGates = append(p.Spec.SchedulingGates, corev1.PodSchedulingGate{Name: name})
+	return p
+}
+
+// PriorityClass updates the priority class of the pod.
+func (p *PodWrapper) PriorityClass(pc string) *PodWrapper {
+	p.Spec.PriorityClassName = pc
+	return p
+}
+
+// NodeSelector adds a node selector to the pod.
+func (p *PodWrapper) NodeSelector(k, v string) *PodWrapper {
+	p.Spec.NodeSelector[k] = v
+	return p
+}
+
+// Request adds a resource request to the default container.
+func (p *PodWrapper) Request(r corev1.ResourceName, v string) *PodWrapper {
+	p.Spec.Containers[0].Resources.Requests[r] = resource.MustParse(v)
+	return p
+}
+
+// Image sets the image of the default container.
+func (p *PodWrapper) Image(image string) *PodWrapper {
+	p.Spec.Containers[0].Image = image
+	return p
+}
+
+// OwnerReference adds an owner reference to the pod.
+func (p *PodWrapper) OwnerReference(ownerName string, ownerGVK schema.GroupVersionKind, controller bool) *PodWrapper {
+	p.OwnerReferences = append(p.OwnerReferences, metav1.OwnerReference{
+		APIVersion: ownerGVK.GroupVersion().String(),
+		Kind:       ownerGVK.Kind,
+		Name:       ownerName,
+		UID:        types.UID(ownerName),
+		Controller: &controller,
+	})
+	return p
+}
+
+// Finalizer adds a finalizer to the pod.
+func (p *PodWrapper) Finalizer(f string) *PodWrapper {
+	p.ObjectMeta.Finalizers = append(p.ObjectMeta.Finalizers, f)
+	return p
+}
+
+// Delete marks the pod for deletion.
+func (p *PodWrapper) Delete() *PodWrapper {
+	t := metav1.Now()
+	p.ObjectMeta.DeletionTimestamp = &t
+	return p
+}
+
+// NodeName sets the node the pod is bound to.
+func (p *PodWrapper) NodeName(name string) *PodWrapper {
+	p.Spec.NodeName = name
+	return p
+}
+
+// StatusPhase sets the phase of the pod.
+func (p *PodWrapper) StatusPhase(phase corev1.PodPhase) *PodWrapper {
+	p.Status.Phase = phase
+	return p
+}
+
+// Ready sets the Ready condition of the pod to true.
+func (p *PodWrapper) Ready() *PodWrapper {
+	p.Status.Conditions = append(p.Status.Conditions, corev1.PodCondition{
+		Type:   corev1.PodReady,
+		Status: corev1.ConditionTrue,
+	})
+	return p
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package visibility
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/authenticatorfactory"
+	"k8s.io/apiserver/pkg/authentication/request/headerrequest"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizerfactory"
+	"k8s.io/apiserver/pkg/authorization/union"
+	genericfilters "k8s.io/apiserver/pkg/endpoints/filters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	"k8s.io/apiserver/pkg/util/webhook"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// authenticationConfigMap holds the CA and the headers that the
+	// kube-apiserver uses to proxy the requests, it's published by the
+	// kube-apiserver in the kube-system namespace.
+	authenticationConfigMap = "extension-apiserver-authentication"
+
+	authCacheTTL = 10 * time.Second
+)
+
+// withDelegatedAuth wraps the handler with the authentication and the
+// authorization filters of the generic API server, delegating both to the
+// kube-apiserver, as extension API servers do: requests proxied by the
+// kube-apiserver are authenticated with the request headers, once its client
+// certificate is verified with the CA from the authentication ConfigMap, and
+// other requests with TokenReviews; the requests are authorized with
+// SubjectAccessReviews.
+//
+// The content of the ConfigMap is loaded before returning and kept up to date
+// until the context is done. The permissions required are granted by the
+// role bindings in config/components/visibility.
+func withDelegatedAuth(ctx context.Context, client kubernetes.Interface, handler http.Handler) (http.Handler, error) {
+	requestHeaderCA, err := dynamiccertificates.NewDynamicCAFromConfigMapController(
+		"requestheader-client-ca", metav1.NamespaceSystem, authenticationConfigMap, "requestheader-client-ca-file", client)
+	if err != nil {
+		return nil, fmt.Errorf("creating the request header CA controller: %w", err)
+	}
+	requestHeaders := headerrequest.NewRequestHeaderAuthRequestController(
+		authenticationConfigMap, metav1.NamespaceSystem, client,
+		"requestheader-username-headers",
+		"requestheader-group-headers",
+		"requestheader-extra-headers-prefix",
+		"requestheader-allowed-names",
+	)
+	if err := requestHeaderCA.RunOnce(ctx); err != nil {
+		return nil, fmt.Errorf("loading the request header CA: %w", err)
+	}
+	if err := requestHeaders.RunOnce(ctx); err != nil {
+		return nil, fmt.Errorf("loading the request headers: %w", err)
+	}
+	go requestHeaderCA.Run(ctx, 1)
+	go requestHeaders.Run(ctx, 1)
+
+	backoff := webhook.DefaultRetryBackoffWithInitialDelay(500 * time.Millisecond)
+	requestHeaderConfig := &authenticatorfactory.RequestHeaderConfig{
+		CAContentProvider:   requestHeaderCA,
+		UsernameHeaders:     headerrequest.StringSliceProviderFunc(requestHeaders.UsernameHeaders),
+		GroupHeaders:        headerrequest.StringSliceProviderFunc(requestHeaders.GroupHeaders),
+		ExtraHeaderPrefixes: headerrequest.StringSliceProviderFunc(requestHeaders.ExtraHeaderPrefixes),
+		AllowedClientNames:  headerrequest.StringSliceProviderFunc(requestHeaders.AllowedClientNames),
+	}
+	authenticator, _, err := authenticatorfactory.DelegatingAuthenticatorConfig{
+		TokenAccessReviewClient:  client.AuthenticationV1(),
+		TokenAccessReviewTimeout: authCacheTTL,
+		WebhookRetryBackoff:      &backoff,
+		CacheTTL:                 authCacheTTL,
+		RequestHeaderConfig:      requestHeaderConfig,
+	}.New()
+	if err != nil {
+		return nil, fmt.Errorf("creating the authenticator: %w", err)
+	}
+	delegatedAuthorizer, err := authorizerfactory.DelegatingAuthorizerConfig{
+		SubjectAccessReviewClient: client.AuthorizationV1(),
+		AllowCacheTTL:             authCacheTTL,
+		DenyCacheTTL:              authCacheTTL,
+		WebhookRetryBackoff:       &backoff,
+	}.New()
+	if err != nil {
+		return nil, fmt.Errorf("creating the authorizer: %w", err)
+	}
+	authorizer := union.New(authorizerfactory.NewPrivilegedGroups(user.SystemPrivilegedGroup), delegatedAuthorizer)
+
+	scheme := runtime.NewScheme()
+	metav1.AddToGroupVersion(scheme, schema.GroupVersion{Version: "v1"})
+	codecs := serializer.NewCodecFactory(scheme).WithoutConversion()
+
+	handler = genericfilters.WithAuthorization(handler, authorizer, codecs)
+	handler = genericfilters.WithAuthentication(handler, authenticator, genericfilters.Unauthorized(codecs), nil, requestHeaderConfig)
+	return genericfilters.WithRequestInfo(handler, &request.RequestInfoFactory{
+		APIPrefixes:          sets.NewString("apis"),
+		GrouplessAPIPrefixes: sets.NewString(),
+	}), nil
+}
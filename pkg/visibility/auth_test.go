@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package visibility
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func TestWithDelegatedAuth(t *testing.T) {
+	caPEM, _, err := certutil.GenerateSelfSignedCertKey("front-proxy-ca", nil, nil)
+	if err != nil {
+		t.Fatalf("Failed generating CA: %v", err)
+	}
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: authenticationConfigMap, Namespace: metav1.NamespaceSystem, ResourceVersion: "1"},
+		Data: map[string]string{
+			"requestheader-client-ca-file":       string(caPEM),
+			"requestheader-username-headers":     `["X-Remote-User"]`,
+			"requestheader-group-headers":        `["X-Remote-Group"]`,
+			"requestheader-extra-headers-prefix": `["X-Remote-Extra-"]`,
+		},
+	}
+	// The fake kube-apiserver authenticates the token "team-a" as the user
+	// team-a, that can get the pending workloads of the LocalQueues in its
+	// namespace.
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var obj interface{}
+		switch {
+		case r.URL.Path == "/api/v1/namespaces/kube-system/configmaps/"+authenticationConfigMap:
+			obj = configMap
+		case r.URL.Path == "/api/v1/namespaces/kube-system/configmaps" && r.URL.Query().Get("watch") == "true":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		case r.URL.Path == "/api/v1/namespaces/kube-system/configmaps":
+			obj = &corev1.ConfigMapList{
+				TypeMeta: metav1.TypeMeta{Kind: "ConfigMapList", APIVersion: "v1"},
+				ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+				Items:    []corev1.ConfigMap{*configMap},
+			}
+		case r.URL.Path == "/apis/authentication.k8s.io/v1/tokenreviews":
+			review := &authenticationv1.TokenReview{}
+			if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+				t.Errorf("Failed decoding TokenReview: %v", err)
+			}
+			if review.Spec.Token == "team-a" {
+				review.Status.Authenticated = true
+				review.Status.User.Username = "team-a"
+			}
+			obj = review
+		case r.URL.Path == "/apis/authorization.k8s.io/v1/subjectaccessreviews":
+			review := &authorizationv1.SubjectAccessReview{}
+			if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+				t.Errorf("Failed decoding SubjectAccessReview: %v", err)
+			}
+			if attrs := review.Spec.ResourceAttributes; attrs != nil {
+				review.Status.Allowed = review.Spec.User == "team-a" && attrs.Group == GroupName &&
+					attrs.Namespace == "team-a" && attrs.Resource == "localqueues" && attrs.Subresource == "pendingworkloads" && attrs.Verb == "get"
+			} else {
+				review.Status.Allowed = review.Spec.NonResourceAttributes.Verb == "get"
+			}
+			obj = review
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(obj); err != nil {
+			t.Errorf("Failed encoding response: %v", err)
+		}
+	}))
+	defer apiServer.Close()
+	client := kubernetes.NewForConfigOrDie(&rest.Config{Host: apiServer.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler, err := withDelegatedAuth(ctx, client, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	if err != nil {
+		t.Fatalf("Failed setting up the authentication: %v", err)
+	}
+
+	cases := map[string]struct {
+		path       string
+		token      string
+		wantStatus int
+	}{
+		"allowed": {
+			path:       Path + "namespaces/team-a/localqueues/foo/pendingworkloads",
+			token:      "team-a",
+			wantStatus: http.StatusOK,
+		},
+		"another namespace": {
+			path:       Path + "namespaces/team-b/localqueues/bar/pendingworkloads",
+			token:      "team-a",
+			wantStatus: http.StatusForbidden,
+		},
+		"clusterQueue": {
+			path:       Path + "clusterqueues/cq/pendingworkloads",
+			token:      "team-a",
+			wantStatus: http.StatusForbidden,
+		},
+		"discovery": {
+			path:       "/apis/" + GroupName,
+			token:      "team-a",
+			wantStatus: http.StatusOK,
+		},
+		"invalid token": {
+			path:       Path + "namespaces/team-a/localqueues/foo/pendingworkloads",
+			token:      "team-b",
+			wantStatus: http.StatusUnauthorized,
+		},
+		"request headers without a client certificate": {
+			path:       Path + "namespaces/team-a/localqueues/foo/pendingworkloads",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("X-Remote-User", "team-a")
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tc.wantStatus {
+				t.Errorf("Got status %d, want %d; body: %s", rec.Code, tc.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
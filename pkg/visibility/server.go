@@ -0,0 +1,288 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package visibility serves the pending workloads of the ClusterQueues and
+// LocalQueues, with their positions in the queues, as the aggregated API
+// visibility.kueue.x-k8s.io/v1alpha1.
+//
+// The endpoints are:
+//
+//	GET /apis/visibility.kueue.x-k8s.io/v1alpha1/clusterqueues/{name}/pendingworkloads
+//	GET /apis/visibility.kueue.x-k8s.io/v1alpha1/namespaces/{namespace}/localqueues/{name}/pendingworkloads
+//
+// Both accept the query parameters offset and limit.
+//
+// The kube-apiserver proxies the requests to the server, which delegates
+// their authentication and authorization back to the kube-apiserver. Reading
+// the pending workloads requires the get verb on the pendingworkloads
+// subresource of clusterqueues or localqueues in the visibility API group.
+package visibility
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"sigs.k8s.io/kueue/pkg/queue"
+	utilpriority "sigs.k8s.io/kueue/pkg/util/priority"
+)
+
+const (
+	// GroupName is the API group served by the visibility server.
+	GroupName = "visibility.kueue.x-k8s.io"
+	// Version is the version of the API served by the visibility server.
+	Version = "v1alpha1"
+
+	// Path is the prefix of the paths served by the visibility server.
+	Path = "/apis/" + GroupName + "/" + Version + "/"
+
+	// Port is the port of the visibility server, that the APIService
+	// reaches through its Service.
+	Port = 8082
+
+	defaultLimit = 1000
+)
+
+// Server is an http.Handler that serves the pending workloads.
+type Server struct {
+	queues *queue.Manager
+
+	config   *rest.Config
+	certPath string
+	keyPath  string
+}
+
+var _ manager.Runnable = (*Server)(nil)
+
+// NewServer returns a Server that reads the pending workloads from the queue
+// manager.
+func NewServer(queues *queue.Manager) *Server {
+	return &Server{queues: queues}
+}
+
+// SetupWithManager adds the server to the manager. The server uses the
+// serving certificate of the webhook server, so it must be called after the
+// webhooks are registered.
+func (s *Server) SetupWithManager(mgr ctrl.Manager) error {
+	wh := mgr.GetWebhookServer()
+	s.config = mgr.GetConfig()
+	s.certPath = filepath.Join(wh.CertDir, wh.CertName)
+	s.keyPath = filepath.Join(wh.CertDir, wh.KeyName)
+	return mgr.Add(s)
+}
+
+// Start serves the API until the context is done. Nothing else in the
+// manager depends on the visibility API, so a failure of the server is
+// logged, leaving the APIService unavailable, instead of stopping the
+// manager.
+func (s *Server) Start(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx).WithName("visibility-server")
+	if err := s.serve(ctx); err != nil {
+		log.Error(err, "Visibility server failed, the pending workloads are not served")
+	}
+	return nil
+}
+
+func (s *Server) serve(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx).WithName("visibility-server")
+	client, err := kubernetes.NewForConfig(s.config)
+	if err != nil {
+		return err
+	}
+	handler, err := withDelegatedAuth(ctx, client, s)
+	if err != nil {
+		return err
+	}
+
+	certWatcher, err := certwatcher.New(s.certPath, s.keyPath)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := certWatcher.Start(ctx); err != nil {
+			log.Error(err, "Certificate watcher failed")
+		}
+	}()
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", Port),
+		Handler: handler,
+		TLSConfig: &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certWatcher.GetCertificate,
+			// The client certificates are verified by the authenticator,
+			// against the CA that the kube-apiserver publishes.
+			ClientAuth: tls.RequestClientCert,
+		},
+		ReadHeaderTimeout: 32 * time.Second,
+	}
+	idleConnsClosed := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		log.Info("Shutting down visibility server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error(err, "Error shutting down the visibility server")
+		}
+		close(idleConnsClosed)
+	}()
+
+	log.Info("Serving visibility API", "port", Port)
+	if err := srv.ListenAndServeTLS("", ""); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-idleConnsClosed
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "/apis/" + GroupName:
+		writeJSON(w, r, apiGroup())
+		return
+	case "/apis/" + GroupName + "/" + Version:
+		writeJSON(w, r, apiResourceList())
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, Path) {
+		http.NotFound(w, r)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, Path), "/"), "/")
+	var resource, name string
+	var list func() ([]queue.PendingWorkload, bool)
+	switch {
+	case len(parts) == 3 && parts[0] == "clusterqueues" && parts[2] == "pendingworkloads":
+		resource, name = "clusterqueues", parts[1]
+		list = func() ([]queue.PendingWorkload, bool) {
+			return s.queues.PendingWorkloadsInClusterQueue(parts[1])
+		}
+	case len(parts) == 5 && parts[0] == "namespaces" && parts[2] == "localqueues" && parts[4] == "pendingworkloads":
+		resource, name = "localqueues", parts[3]
+		list = func() ([]queue.PendingWorkload, bool) {
+			return s.queues.PendingWorkloadsInLocalQueue(parts[1], parts[3])
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	offset, limit, err := pagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pending, found := list()
+	if !found {
+		http.Error(w, fmt.Sprintf("%s %q not found", resource, name), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, r, summary(pending, offset, limit))
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		ctrl.LoggerFrom(r.Context()).Error(err, "Failed to write response")
+	}
+}
+
+// apiGroup is the discovery document of the API group.
+func apiGroup() *metav1.APIGroup {
+	gv := metav1.GroupVersionForDiscovery{
+		GroupVersion: GroupName + "/" + Version,
+		Version:      Version,
+	}
+	return &metav1.APIGroup{
+		TypeMeta:         metav1.TypeMeta{Kind: "APIGroup", APIVersion: "v1"},
+		Name:             GroupName,
+		Versions:         []metav1.GroupVersionForDiscovery{gv},
+		PreferredVersion: gv,
+	}
+}
+
+// apiResourceList is the discovery document of the API version. The
+// pending workloads are served as subresources of the ClusterQueues and
+// LocalQueues, which can't be listed or read through this API.
+func apiResourceList() *metav1.APIResourceList {
+	return &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: GroupName + "/" + Version,
+		APIResources: []metav1.APIResource{
+			{Name: "clusterqueues", Kind: "ClusterQueue", Verbs: metav1.Verbs{}},
+			{Name: "clusterqueues/pendingworkloads", Kind: "PendingWorkloadsSummary", Verbs: metav1.Verbs{"get"}},
+			{Name: "localqueues", Namespaced: true, Kind: "LocalQueue", Verbs: metav1.Verbs{}},
+			{Name: "localqueues/pendingworkloads", Namespaced: true, Kind: "PendingWorkloadsSummary", Verbs: metav1.Verbs{"get"}},
+		},
+	}
+}
+
+func pagination(r *http.Request) (int, int, error) {
+	offset, limit := 0, defaultLimit
+	var err error
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %q", v)
+		}
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			return 0, 0, fmt.Errorf("invalid limit %q", v)
+		}
+	}
+	return offset, limit, nil
+}
+
+func summary(pending []queue.PendingWorkload, offset, limit int) *PendingWorkloadsSummary {
+	s := &PendingWorkloadsSummary{
+		TypeMeta: metav1.TypeMeta{Kind: "PendingWorkloadsSummary", APIVersion: GroupName + "/" + Version},
+		Items:    []PendingWorkload{},
+	}
+	for i := offset; i < len(pending) && i < offset+limit; i++ {
+		p := pending[i]
+		wl := p.Info.Obj
+		s.Items = append(s.Items, PendingWorkload{
+			Name:                   wl.Name,
+			Namespace:              wl.Namespace,
+			LocalQueueName:         wl.Spec.QueueName,
+			CreationTimestamp:      wl.CreationTimestamp,
+			Priority:               utilpriority.Priority(wl),
+			PositionInClusterQueue: int32(p.PositionInClusterQueue),
+			PositionInLocalQueue:   int32(p.PositionInLocalQueue),
+			Inadmissible:           p.Inadmissible,
+		})
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package visibility
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/queue"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+)
+
+var summaryTypeMeta = metav1.TypeMeta{Kind: "PendingWorkloadsSummary", APIVersion: "visibility.kueue.x-k8s.io/v1alpha1"}
+
+func TestServeHTTP(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	cq := utiltesting.MakeClusterQueue("cq").Obj()
+	queues := []*kueue.LocalQueue{
+		utiltesting.MakeLocalQueue("foo", "team-a").ClusterQueue("cq").Obj(),
+		utiltesting.MakeLocalQueue("bar", "team-b").ClusterQueue("cq").Obj(),
+	}
+	workloads := []*kueue.Workload{
+		utiltesting.MakeWorkload("a", "team-a").Queue("foo").Creation(now).Obj(),
+		utiltesting.MakeWorkload("b", "team-b").Queue("bar").Creation(now.Add(time.Second)).Obj(),
+		utiltesting.MakeWorkload("c", "team-a").Queue("foo").Priority(10).Creation(now.Add(2 * time.Second)).Obj(),
+	}
+	ctx := context.Background()
+	qManager := queue.NewManager(utiltesting.NewFakeClient(), nil)
+	if err := qManager.AddClusterQueue(ctx, cq); err != nil {
+		t.Fatalf("Failed adding clusterQueue %s: %v", cq.Name, err)
+	}
+	for _, q := range queues {
+		if err := qManager.AddLocalQueue(ctx, q); err != nil {
+			t.Fatalf("Failed adding queue %s: %v", q.Name, err)
+		}
+	}
+	for _, w := range workloads {
+		qManager.AddOrUpdateWorkload(w)
+	}
+	server := NewServer(qManager)
+
+	cases := map[string]struct {
+		path        string
+		wantStatus  int
+		wantSummary *PendingWorkloadsSummary
+	}{
+		"clusterQueue": {
+			path:       Path + "clusterqueues/cq/pendingworkloads",
+			wantStatus: http.StatusOK,
+			wantSummary: &PendingWorkloadsSummary{
+				TypeMeta: summaryTypeMeta,
+				Items: []PendingWorkload{
+					{Name: "c", Namespace: "team-a", LocalQueueName: "foo", CreationTimestamp: metav1.NewTime(now.Add(2 * time.Second)), Priority: 10},
+					{Name: "a", Namespace: "team-a", LocalQueueName: "foo", CreationTimestamp: metav1.NewTime(now), PositionInClusterQueue: 1, PositionInLocalQueue: 1},
+					{Name: "b", Namespace: "team-b", LocalQueueName: "bar", CreationTimestamp: metav1.NewTime(now.Add(time.Second)), PositionInClusterQueue: 2},
+				},
+			},
+		},
+		"clusterQueue with offset and limit": {
+			path:       Path + "clusterqueues/cq/pendingworkloads?offset=1&limit=1",
+			wantStatus: http.StatusOK,
+			wantSummary: &PendingWorkloadsSummary{
+				TypeMeta: summaryTypeMeta,
+				Items: []PendingWorkload{
+					{Name: "a", Namespace: "team-a", LocalQueueName: "foo", CreationTimestamp: metav1.NewTime(now), PositionInClusterQueue: 1, PositionInLocalQueue: 1},
+				},
+			},
+		},
+		"localQueue": {
+			path:       Path + "namespaces/team-a/localqueues/foo/pendingworkloads",
+			wantStatus: http.StatusOK,
+			wantSummary: &PendingWorkloadsSummary{
+				TypeMeta: summaryTypeMeta,
+				Items: []PendingWorkload{
+					{Name: "c", Namespace: "team-a", LocalQueueName: "foo", CreationTimestamp: metav1.NewTime(now.Add(2 * time.Second)), Priority: 10},
+					{Name: "a", Namespace: "team-a", LocalQueueName: "foo", CreationTimestamp: metav1.NewTime(now), PositionInClusterQueue: 1, PositionInLocalQueue: 1},
+				},
+			},
+		},
+		"localQueue in another namespace": {
+			path:       Path + "namespaces/team-b/localqueues/foo/pendingworkloads",
+			wantStatus: http.StatusNotFound,
+		},
+		"missing clusterQueue": {
+			path:       Path + "clusterqueues/missing/pendingworkloads",
+			wantStatus: http.StatusNotFound,
+		},
+		"unknown path": {
+			path:       Path + "clusterqueues/cq",
+			wantStatus: http.StatusNotFound,
+		},
+		"API group discovery": {
+			path:       "/apis/visibility.kueue.x-k8s.io",
+			wantStatus: http.StatusOK,
+		},
+		"API version discovery": {
+			path:       "/apis/visibility.kueue.x-k8s.io/v1alpha1",
+			wantStatus: http.StatusOK,
+		},
+		"other API group": {
+			path:       "/apis/kueue.x-k8s.io/v1beta1/clusterqueues",
+			wantStatus: http.StatusNotFound,
+		},
+		"invalid limit": {
+			path:       Path + "clusterqueues/cq/pendingworkloads?limit=-1",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("Got status %d, want %d; body: %s", rec.Code, tc.wantStatus, rec.Body.String())
+			}
+			if tc.wantSummary == nil {
+				return
+			}
+			var got PendingWorkloadsSummary
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed decoding response: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantSummary, &got); diff != "" {
+				t.Errorf("Unexpected summary (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package visibility
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PendingWorkload is a workload waiting to be admitted.
+type PendingWorkload struct {
+	Name              string      `json:"name"`
+	Namespace         string      `json:"namespace"`
+	LocalQueueName    string      `json:"localQueueName"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+
+	// Priority of the workload.
+	Priority int32 `json:"priority"`
+
+	// PositionInClusterQueue is the 0-based position of the workload among
+	// the pending workloads of its ClusterQueue.
+	PositionInClusterQueue int32 `json:"positionInClusterQueue"`
+
+	// PositionInLocalQueue is the 0-based position of the workload among the
+	// pending workloads of its LocalQueue.
+	PositionInLocalQueue int32 `json:"positionInLocalQueue"`
+
+	// Inadmissible indicates that the workload was already tried and that it's
+	// waiting for cluster conditions to change to be tried again. Inadmissible
+	// workloads go after the active ones.
+	Inadmissible bool `json:"inadmissible"`
+}
+
+// PendingWorkloadsSummary lists the pending workloads of a ClusterQueue or a
+// LocalQueue, in the order in which they would be tried for admission.
+type PendingWorkloadsSummary struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Items []PendingWorkload `json:"items"`
+}
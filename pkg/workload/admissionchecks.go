@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workload
+
+import (
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
+)
+
+// forceOwnership forces the ownership of the fields of a Server-Side-Apply
+// patch to the status.
+var forceOwnership = &client.SubResourcePatchOptions{PatchOptions: client.PatchOptions{Force: pointer.Bool(true)}}
+
+// HasQuotaReservation returns whether the workload reserved quota in a
+// ClusterQueue. Workloads admitted by versions that didn't set the
+// QuotaReserved condition are considered to have reserved quota if they are
+// admitted.
+func HasQuotaReservation(w *kueue.Workload) bool {
+	cond := apimeta.FindStatusCondition(w.Status.Conditions, kueue.WorkloadQuotaReserved)
+	if cond == nil {
+		return IsAdmitted(w)
+	}
+	return cond.Status == metav1.ConditionTrue
+}
+
+// IsAdmitted returns whether the workload reserved quota and passed all the
+// admission checks of its ClusterQueue.
+func IsAdmitted(w *kueue.Workload) bool {
+	return apimeta.IsStatusConditionTrue(w.Status.Conditions, kueue.WorkloadAdmitted)
+}
+
+// FindAdmissionCheck returns the state of the admission check with the given
+// name, or nil if it's not in the list.
+func FindAdmissionCheck(checks []kueue.AdmissionCheckState, name string) *kueue.AdmissionCheckState {
+	for i := range checks {
+		if checks[i].Name == name {
+			return &checks[i]
+		}
+	}
+	return nil
+}
+
+// SetAdmissionCheckState adds or updates the state of an admission check.
+// The LastTransitionTime is only updated if the state changes.
+func SetAdmissionCheckState(checks *[]kueue.AdmissionCheckState, newCheck kueue.AdmissionCheckState) {
+	if newCheck.LastTransitionTime.IsZero() {
+		newCheck.LastTransitionTime = metav1.Now()
+	}
+	existing := FindAdmissionCheck(*checks, newCheck.Name)
+	if existing == nil {
+		*checks = append(*checks, newCheck)
+		return
+	}
+	if existing.State != newCheck.State {
+		existing.State = newCheck.State
+		existing.LastTransitionTime = newCheck.LastTransitionTime
+	}
+	existing.Message = newCheck.Message
+}
+
+// SyncAdmissionChecks makes the admission checks of the workload match the
+// given ones: missing checks are added as Pending and the checks that are
+// not in the list are dropped. Checks that transitioned before the quota
+// was reserved are stale and set back to Pending. It returns whether the
+// list changed.
+func SyncAdmissionChecks(w *kueue.Workload, names []string, now metav1.Time) bool {
+	var reservedAt metav1.Time
+	if cond := apimeta.FindStatusCondition(w.Status.Conditions, kueue.WorkloadQuotaReserved); cond != nil {
+		reservedAt = cond.LastTransitionTime
+	}
+	changed := len(w.Status.AdmissionChecks) != len(names)
+	var checks []kueue.AdmissionCheckState
+	for _, name := range names {
+		existing := FindAdmissionCheck(w.Status.AdmissionChecks, name)
+		if existing == nil {
+			changed = true
+			checks = append(checks, kueue.AdmissionCheckState{
+				Name:               name,
+				State:              kueue.CheckStatePending,
+				LastTransitionTime: now,
+			})
+			continue
+		}
+		check := *existing
+		if check.State != kueue.CheckStatePending && check.LastTransitionTime.Before(&reservedAt) {
+			changed = true
+			check.State = kueue.CheckStatePending
+			check.LastTransitionTime = now
+			check.Message = ""
+		}
+		checks = append(checks, check)
+	}
+	w.Status.AdmissionChecks = checks
+	return changed
+}
+
+// HasAllChecksReady returns whether all the admission checks of the
+// workload are Ready.
+func HasAllChecksReady(w *kueue.Workload) bool {
+	for i := range w.Status.AdmissionChecks {
+		if w.Status.AdmissionChecks[i].State != kueue.CheckStateReady {
+			return false
+		}
+	}
+	return true
+}
+
+// FirstCheckInState returns the first admission check of the workload that
+// is in the given state, or nil if there isn't any.
+func FirstCheckInState(w *kueue.Workload, state kueue.CheckState) *kueue.AdmissionCheckState {
+	for i := range w.Status.AdmissionChecks {
+		if w.Status.AdmissionChecks[i].State == state {
+			return &w.Status.AdmissionChecks[i]
+		}
+	}
+	return nil
+}
+
+func resetAdmissionChecks(checks []kueue.AdmissionCheckState, now metav1.Time) []kueue.AdmissionCheckState {
+	if len(checks) == 0 {
+		return nil
+	}
+	reset := make([]kueue.AdmissionCheckState, len(checks))
+	for i := range checks {
+		reset[i] = kueue.AdmissionCheckState{
+			Name:               checks[i].Name,
+			State:              kueue.CheckStatePending,
+			LastTransitionTime: checks[i].LastTransitionTime,
+		}
+		if checks[i].State != kueue.CheckStatePending {
+			reset[i].LastTransitionTime = now
+		}
+	}
+	return reset
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workload
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+)
+
+func TestHasQuotaReservation(t *testing.T) {
+	cases := map[string]struct {
+		workload *kueue.Workload
+		want     bool
+	}{
+		"no conditions": {
+			workload: utiltesting.MakeWorkload("wl", "ns").Obj(),
+		},
+		"quota reserved": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				Condition(metav1.Condition{Type: kueue.WorkloadQuotaReserved, Status: metav1.ConditionTrue}).
+				Obj(),
+			want: true,
+		},
+		"quota released": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				Condition(metav1.Condition{Type: kueue.WorkloadQuotaReserved, Status: metav1.ConditionFalse}).
+				Condition(metav1.Condition{Type: kueue.WorkloadAdmitted, Status: metav1.ConditionTrue}).
+				Obj(),
+		},
+		"admitted without QuotaReserved condition": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				Condition(metav1.Condition{Type: kueue.WorkloadAdmitted, Status: metav1.ConditionTrue}).
+				Obj(),
+			want: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := HasQuotaReservation(tc.workload); got != tc.want {
+				t.Errorf("HasQuotaReservation() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSyncAdmissionChecks(t *testing.T) {
+	reservedAt := metav1.NewTime(time.Date(2023, time.June, 14, 10, 0, 0, 0, time.UTC))
+	before := metav1.NewTime(reservedAt.Add(-time.Minute))
+	after := metav1.NewTime(reservedAt.Add(time.Minute))
+	now := metav1.NewTime(reservedAt.Add(time.Hour))
+	reserved := metav1.Condition{
+		Type:               kueue.WorkloadQuotaReserved,
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: reservedAt,
+	}
+	cases := map[string]struct {
+		checks      []kueue.AdmissionCheckState
+		names       []string
+		wantChecks  []kueue.AdmissionCheckState
+		wantChanged bool
+	}{
+		"no checks": {},
+		"missing checks are added as pending": {
+			checks: []kueue.AdmissionCheckState{
+				{Name: "check1", State: kueue.CheckStateReady, LastTransitionTime: after},
+			},
+			names: []string{"check1", "check2"},
+			wantChecks: []kueue.AdmissionCheckState{
+				{Name: "check1", State: kueue.CheckStateReady, LastTransitionTime: after},
+				{Name: "check2", State: kueue.CheckStatePending, LastTransitionTime: now},
+			},
+			wantChanged: true,
+		},
+		"extra checks are dropped": {
+			checks: []kueue.AdmissionCheckState{
+				{Name: "check1", State: kueue.CheckStateReady, LastTransitionTime: after},
+				{Name: "check2", State: kueue.CheckStatePending, LastTransitionTime: after},
+			},
+			names: []string{"check1"},
+			wantChecks: []kueue.AdmissionCheckState{
+				{Name: "check1", State: kueue.CheckStateReady, LastTransitionTime: after},
+			},
+			wantChanged: true,
+		},
+		"stale checks are reset": {
+			checks: []kueue.AdmissionCheckState{
+				{Name: "check1", State: kueue.CheckStateReady, LastTransitionTime: before, Message: "old"},
+				{Name: "check2", State: kueue.CheckStateRetry, LastTransitionTime: after},
+			},
+			names: []string{"check1", "check2"},
+			wantChecks: []kueue.AdmissionCheckState{
+				{Name: "check1", State: kueue.CheckStatePending, LastTransitionTime: now},
+				{Name: "check2", State: kueue.CheckStateRetry, LastTransitionTime: after},
+			},
+			wantChanged: true,
+		},
+		"unchanged": {
+			checks: []kueue.AdmissionCheckState{
+				{Name: "check1", State: kueue.CheckStatePending, LastTransitionTime: before},
+				{Name: "check2", State: kueue.CheckStateReady, LastTransitionTime: after},
+			},
+			names: []string{"check1", "check2"},
+			wantChecks: []kueue.AdmissionCheckState{
+				{Name: "check1", State: kueue.CheckStatePending, LastTransitionTime: before},
+				{Name: "check2", State: kueue.CheckStateReady, LastTransitionTime: after},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			wl := utiltesting.MakeWorkload("wl", "ns").Condition(reserved).Obj()
+			wl.Status.AdmissionChecks = tc.checks
+			changed := SyncAdmissionChecks(wl, tc.names, now)
+			if changed != tc.wantChanged {
+				t.Errorf("SyncAdmissionChecks() returned %t, want %t", changed, tc.wantChanged)
+			}
+			if diff := cmp.Diff(tc.wantChecks, wl.Status.AdmissionChecks); diff != "" {
+				t.Errorf("Unexpected admission checks (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnsetAdmissionPatch(t *testing.T) {
+	now := metav1.NewTime(time.Date(2023, time.June, 14, 10, 0, 0, 0, time.UTC))
+	wl := utiltesting.MakeWorkload("wl", "ns").
+		Admit(utiltesting.MakeAdmission("cq").Obj()).
+		AdmissionCheck("check1", kueue.CheckStateReady).
+		AdmissionCheck("check2", kueue.CheckStatePending).
+		RequeueState(1, now.Time).
+		Obj()
+	wl.Status.AdmissionChecks[1].LastTransitionTime = now
+	patch := UnsetAdmissionPatch(wl, "Preempted", "Preempted to accommodate a higher priority Workload")
+	if patch.Status.Admission != nil {
+		t.Errorf("Patch kept the admission: %v", patch.Status.Admission)
+	}
+	for _, condType := range []string{kueue.WorkloadQuotaReserved, kueue.WorkloadAdmitted} {
+		if !hasConditionStatus(patch.Status.Conditions, condType, metav1.ConditionFalse) {
+			t.Errorf("Patch doesn't set the %s condition to False", condType)
+		}
+	}
+	for _, check := range patch.Status.AdmissionChecks {
+		if check.State != kueue.CheckStatePending {
+			t.Errorf("Admission check %s in state %s, want Pending", check.Name, check.State)
+		}
+	}
+	if got := patch.Status.AdmissionChecks[1].LastTransitionTime; !got.Equal(&now) {
+		t.Errorf("Pending check changed its transition time to %v", got)
+	}
+	if diff := cmp.Diff(wl.Status.RequeueState, patch.Status.RequeueState); diff != "" {
+		t.Errorf("Patch didn't keep the requeue state (-want,+got):\n%s", diff)
+	}
+}
+
+func TestAdmissionStatusPatch(t *testing.T) {
+	wl := utiltesting.MakeWorkload("wl", "ns").
+		Admit(utiltesting.MakeAdmission("cq").Obj()).
+		AdmissionCheck("check1", kueue.CheckStateReady).
+		Condition(metav1.Condition{Type: kueue.WorkloadQuotaReserved, Status: metav1.ConditionTrue}).
+		Condition(metav1.Condition{Type: kueue.WorkloadAdmitted, Status: metav1.ConditionTrue}).
+		Condition(metav1.Condition{Type: kueue.WorkloadPodsReady, Status: metav1.ConditionTrue}).
+		Obj()
+	patch := AdmissionStatusPatch(wl)
+	if diff := cmp.Diff(wl.Status.Admission, patch.Status.Admission); diff != "" {
+		t.Errorf("Unexpected admission in the patch (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wl.Status.AdmissionChecks, patch.Status.AdmissionChecks); diff != "" {
+		t.Errorf("Unexpected admission checks in the patch (-want,+got):\n%s", diff)
+	}
+	for _, condType := range []string{kueue.WorkloadQuotaReserved, kueue.WorkloadAdmitted} {
+		if !hasConditionStatus(patch.Status.Conditions, condType, metav1.ConditionTrue) {
+			t.Errorf("Patch doesn't keep the %s condition", condType)
+		}
+	}
+	if len(patch.Status.Conditions) != 2 {
+		t.Errorf("Patch has %d conditions, want only QuotaReserved and Admitted", len(patch.Status.Conditions))
+	}
+}
+
+func TestEvictionPatch(t *testing.T) {
+	wl := utiltesting.MakeWorkload("wl", "ns").
+		Admit(utiltesting.MakeAdmission("cq").Obj()).
+		Obj()
+	patch := EvictionPatch(wl, kueue.WorkloadEvictedByPodsReadyTimeout, "Exceeded the PodsReady timeout ns/wl")
+	if patch.Status.Admission != nil {
+		t.Errorf("Patch kept the admission: %v", patch.Status.Admission)
+	}
+	for _, condType := range []string{kueue.WorkloadQuotaReserved, kueue.WorkloadAdmitted} {
+		if !hasConditionStatus(patch.Status.Conditions, condType, metav1.ConditionFalse) {
+			t.Errorf("Patch doesn't set the %s condition to False", condType)
+		}
+	}
+	evictedCond := apimeta.FindStatusCondition(patch.Status.Conditions, kueue.WorkloadEvicted)
+	if evictedCond == nil || evictedCond.Status != metav1.ConditionTrue || evictedCond.Reason != kueue.WorkloadEvictedByPodsReadyTimeout {
+		t.Errorf("Patch doesn't set the Evicted condition to True with reason %s, got %v", kueue.WorkloadEvictedByPodsReadyTimeout, evictedCond)
+	}
+
+	// Releasing the quota reservation for other reasons keeps the Evicted
+	// condition, which is owned by the same field manager.
+	wl.Status.Conditions = patch.Status.Conditions
+	patch = UnsetAdmissionPatch(wl, "Inadmissible", "LocalQueue lq doesn't exist")
+	if diff := cmp.Diff(evictedCond, apimeta.FindStatusCondition(patch.Status.Conditions, kueue.WorkloadEvicted)); diff != "" {
+		t.Errorf("Patch didn't keep the Evicted condition (-want,+got):\n%s", diff)
+	}
+}
+
+func hasConditionStatus(conds []metav1.Condition, condType string, status metav1.ConditionStatus) bool {
+	for _, c := range conds {
+		if c.Type == condType {
+			return c.Status == status
+		}
+	}
+	return false
+}
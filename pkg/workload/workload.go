@@ -20,8 +20,10 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -30,6 +32,7 @@ import (
 	"sigs.k8s.io/kueue/pkg/constants"
 	"sigs.k8s.io/kueue/pkg/util/api"
 	"sigs.k8s.io/kueue/pkg/util/limitrange"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
 )
 
 // Info holds a Workload object and some pre-processing.
@@ -45,9 +48,34 @@ type Info struct {
 type PodSetResources struct {
 	Name     string
 	Requests Requests
-	Flavors  map[corev1.ResourceName]kueue.ResourceFlavorReference
+	// Count is the number of pods the requests account for.
+	Count   int32
+	Flavors map[corev1.ResourceName]kueue.ResourceFlavorReference
 }
 
+// ScaledTo returns the resources of the podSet for the given number of pods.
+// The workload count is not scaled, as it doesn't depend on the pods.
+func (psr *PodSetResources) ScaledTo(newCount int32) PodSetResources {
+	ret := PodSetResources{
+		Name:     psr.Name,
+		Requests: make(Requests, len(psr.Requests)),
+		Count:    newCount,
+		Flavors:  psr.Flavors,
+	}
+	for name, v := range psr.Requests {
+		if name == kueue.ResourceWorkloads || psr.Count == 0 {
+			ret.Requests[name] = v
+			continue
+		}
+		ret.Requests[name] = v / int64(psr.Count) * int64(newCount)
+	}
+	return ret
+}
+
+// reclaimablePodsFieldManager is the field manager used to update the
+// reclaimable pods of the workloads.
+const reclaimablePodsFieldManager = constants.JobControllerName + "-reclaimable-pods"
+
 func NewInfo(w *kueue.Workload) *Info {
 	info := &Info{
 		Obj: w,
@@ -79,12 +107,18 @@ func totalRequestsFromPodSets(wl *kueue.Workload) []PodSetResources {
 	}
 	res := make([]PodSetResources, 0, len(wl.Spec.PodSets))
 
-	for _, ps := range wl.Spec.PodSets {
+	for i, ps := range wl.Spec.PodSets {
 		setRes := PodSetResources{
-			Name: ps.Name,
+			Name:  ps.Name,
+			Count: ps.Count,
 		}
-		setRes.Requests = newRequests(limitrange.TotalRequests(&ps.Template.Spec))
+		setRes.Requests = NewRequests(limitrange.TotalRequests(&ps.Template.Spec))
 		setRes.Requests.scale(int64(ps.Count))
+		setRes.Requests[kueue.ResourcePods] = int64(ps.Count)
+		if i == 0 {
+			// The workload is accounted for in its first pod set.
+			setRes.Requests[kueue.ResourceWorkloads] = 1
+		}
 		res = append(res, setRes)
 	}
 	return res
@@ -97,22 +131,68 @@ func totalRequestsFromAdmission(wl *kueue.Workload) []PodSetResources {
 	res := make([]PodSetResources, 0, len(wl.Spec.PodSets))
 	for _, ps := range wl.Status.Admission.PodSetAssignments {
 		setRes := PodSetResources{
-			Name: ps.Name,
+			Name:  ps.Name,
+			Count: AdmittedCount(wl, &ps),
 		}
 		setRes.Flavors = ps.Flavors
-		setRes.Requests = newRequests(ps.ResourceUsage)
+		setRes.Requests = NewRequests(ps.ResourceUsage)
+		// The quota of the reclaimable pods is released.
+		if reclaimable := reclaimableCount(wl, ps.Name); reclaimable > 0 && setRes.Count > 0 {
+			newCount := setRes.Count - reclaimable
+			if newCount < 0 {
+				newCount = 0
+			}
+			setRes = setRes.ScaledTo(newCount)
+		}
 		res = append(res, setRes)
 	}
 	return res
 }
 
+// reclaimableCount returns the number of pods of the podSet whose quota is
+// no longer needed.
+func reclaimableCount(wl *kueue.Workload, podSetName string) int32 {
+	for _, rp := range wl.Status.ReclaimablePods {
+		if rp.Name == podSetName {
+			return rp.Count
+		}
+	}
+	return 0
+}
+
+// AdmittedCount returns the number of pods admitted for the podSet.
+func AdmittedCount(wl *kueue.Workload, psa *kueue.PodSetAssignment) int32 {
+	if psa.Count != nil {
+		return *psa.Count
+	}
+	for i := range wl.Spec.PodSets {
+		if wl.Spec.PodSets[i].Name == psa.Name {
+			return wl.Spec.PodSets[i].Count
+		}
+	}
+	return 0
+}
+
+// CanBePartiallyAdmitted returns whether the workload can be admitted with
+// fewer pods than requested by any of its podSets.
+func CanBePartiallyAdmitted(wl *kueue.Workload) bool {
+	for i := range wl.Spec.PodSets {
+		ps := &wl.Spec.PodSets[i]
+		if ps.MinCount != nil && *ps.MinCount < ps.Count {
+			return true
+		}
+	}
+	return false
+}
+
 // The following resources calculations are inspired on
 // https://github.com/kubernetes/kubernetes/blob/master/pkg/scheduler/framework/types.go
 
 // Requests maps ResourceName to flavor to value; for CPU it is tracked in MilliCPU.
 type Requests map[corev1.ResourceName]int64
 
-func newRequests(rl corev1.ResourceList) Requests {
+// NewRequests returns the integer values of the resources in the list.
+func NewRequests(rl corev1.ResourceList) Requests {
 	r := Requests{}
 	for name, quant := range rl {
 		r[name] = ResourceValue(name, quant)
@@ -194,24 +274,149 @@ func UpdateStatus(ctx context.Context,
 	return c.Status().Patch(ctx, newWl, client.Apply, client.FieldOwner(managerPrefix+"-"+condition.Type))
 }
 
+// UnsetAdmissionWithCondition releases the quota reservation of the
+// workload, if any, and sets its QuotaReserved and Admitted conditions to
+// False with the given reason and message.
 func UnsetAdmissionWithCondition(
 	ctx context.Context,
 	c client.Client,
 	wl *kueue.Workload,
 	reason, message string) error {
-	condition := metav1.Condition{
-		Type:               kueue.WorkloadAdmitted,
-		Status:             metav1.ConditionFalse,
-		LastTransitionTime: metav1.Now(),
-		Reason:             reason,
-		Message:            api.TruncateConditionMessage(message),
+	newWl := UnsetAdmissionPatch(wl, reason, message)
+	// Use resourceVersion to avoid overriding admissions by the scheduler that
+	// happen in a different routine.
+	newWl.ResourceVersion = wl.ResourceVersion
+	return ApplyUnsetAdmission(ctx, c, newWl)
+}
+
+// UnsetAdmissionPatch creates a new object based on the input workload that
+// releases its quota reservation: the QuotaReserved and Admitted conditions
+// are False and the admission checks are Pending. The Evicted condition and
+// the requeue state, owned by the same field manager, are carried over. The
+// object can be used in Server-Side-Apply with ApplyUnsetAdmission.
+func UnsetAdmissionPatch(w *kueue.Workload, reason, message string) *kueue.Workload {
+	now := metav1.Now()
+	message = api.TruncateConditionMessage(message)
+	newWl := BaseSSAWorkload(w)
+	newWl.Status.Conditions = []metav1.Condition{
+		{
+			Type:               kueue.WorkloadQuotaReserved,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: now,
+			Reason:             reason,
+			Message:            message,
+		},
+		{
+			Type:               kueue.WorkloadAdmitted,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: now,
+			Reason:             reason,
+			Message:            message,
+		},
 	}
-	newWl := BaseSSAWorkload(wl)
+	if evictedCond := apimeta.FindStatusCondition(w.Status.Conditions, kueue.WorkloadEvicted); evictedCond != nil {
+		newWl.Status.Conditions = append(newWl.Status.Conditions, *evictedCond)
+	}
+	newWl.Status.AdmissionChecks = resetAdmissionChecks(w.Status.AdmissionChecks, now)
+	newWl.Status.RequeueState = w.Status.RequeueState.DeepCopy()
+	return newWl
+}
+
+// EvictionPatch creates a new object based on the input workload that
+// releases its quota reservation, like UnsetAdmissionPatch, and sets the
+// Evicted condition to True with the given reason, one of the
+// WorkloadEvictedBy* reasons. The object can be used in Server-Side-Apply
+// with ApplyUnsetAdmission.
+func EvictionPatch(w *kueue.Workload, reason, message string) *kueue.Workload {
+	newWl := UnsetAdmissionPatch(w, "Evicted", message)
+	SetEvictedCondition(newWl, metav1.ConditionTrue, reason, message)
+	return newWl
+}
+
+// SetEvictedCondition sets the Evicted condition of the workload. Its
+// transition time is only updated when the status changes. The condition is
+// owned by the same field manager as the admission, so it's carried by
+// UnsetAdmissionPatch and AdmissionStatusPatch.
+func SetEvictedCondition(w *kueue.Workload, status metav1.ConditionStatus, reason, message string) {
+	apimeta.SetStatusCondition(&w.Status.Conditions, metav1.Condition{
+		Type:    kueue.WorkloadEvicted,
+		Status:  status,
+		Reason:  reason,
+		Message: api.TruncateConditionMessage(message),
+	})
+}
+
+// Evict releases the quota reservation of the workload and sets its Evicted
+// condition to True with the given reason and message.
+func Evict(ctx context.Context, c client.Client, wl *kueue.Workload, reason, message string) error {
+	newWl := EvictionPatch(wl, reason, message)
 	// Use resourceVersion to avoid overriding admissions by the scheduler that
 	// happen in a different routine.
 	newWl.ResourceVersion = wl.ResourceVersion
-	newWl.Status.Conditions = []metav1.Condition{condition}
-	return c.Status().Patch(ctx, newWl, client.Apply, client.FieldOwner(constants.AdmissionName))
+	return ApplyUnsetAdmission(ctx, c, newWl)
+}
+
+// ApplyUnsetAdmission applies a patch created with UnsetAdmissionPatch. The
+// admission field is cleared because it is owned by the same field manager.
+// The ownership of the admission checks, which are usually set by their
+// controllers, is forced.
+func ApplyUnsetAdmission(ctx context.Context, c client.Client, patch *kueue.Workload) error {
+	return c.Status().Patch(ctx, patch, client.Apply, client.FieldOwner(constants.AdmissionName), forceOwnership)
+}
+
+// AdmissionStatusPatch creates a new object based on the input workload that
+// contains its admission status: the admission, the QuotaReserved, Admitted
+// and Evicted conditions, the admission checks and the requeue state. The
+// object can be used in Server-Side-Apply with ApplyAdmissionStatus.
+func AdmissionStatusPatch(w *kueue.Workload) *kueue.Workload {
+	patch := BaseSSAWorkload(w)
+	patch.Status.Admission = w.Status.Admission.DeepCopy()
+	for _, condType := range []string{kueue.WorkloadQuotaReserved, kueue.WorkloadAdmitted, kueue.WorkloadEvicted} {
+		if cond := apimeta.FindStatusCondition(w.Status.Conditions, condType); cond != nil {
+			patch.Status.Conditions = append(patch.Status.Conditions, *cond)
+		}
+	}
+	patch.Status.AdmissionChecks = w.Status.AdmissionChecks
+	patch.Status.RequeueState = w.Status.RequeueState.DeepCopy()
+	return patch
+}
+
+// ApplyAdmissionStatus applies the admission status of the workload. The
+// admission status is owned by a single field manager, so the patch has to
+// contain all of it, see AdmissionStatusPatch. The resourceVersion of the
+// workload is used to avoid overriding a concurrent change of the quota
+// reservation, and the ownership of the admission checks, which are usually
+// set by their controllers, is forced.
+func ApplyAdmissionStatus(ctx context.Context, c client.Client, w *kueue.Workload) error {
+	patch := AdmissionStatusPatch(w)
+	patch.ResourceVersion = w.ResourceVersion
+	return c.Status().Patch(ctx, patch, client.Apply, client.FieldOwner(constants.AdmissionName), forceOwnership)
+}
+
+// ReclaimablePodsAreEqual returns whether the lists have the same counts
+// for the same podSets, in any order.
+func ReclaimablePodsAreEqual(a, b []kueue.ReclaimablePod) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int32, len(a))
+	for _, rp := range a {
+		counts[rp.Name] = rp.Count
+	}
+	for _, rp := range b {
+		if count, found := counts[rp.Name]; !found || count != rp.Count {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateReclaimablePods updates the reclaimable pods of the workload,
+// releasing the quota of the pods that won't run again.
+func UpdateReclaimablePods(ctx context.Context, c client.Client, w *kueue.Workload, reclaimablePods []kueue.ReclaimablePod) error {
+	patch := BaseSSAWorkload(w)
+	patch.Status.ReclaimablePods = reclaimablePods
+	return c.Status().Patch(ctx, patch, client.Apply, client.FieldOwner(reclaimablePodsFieldManager))
 }
 
 // BaseSSAWorkload creates a new object based on the input workload that
@@ -236,10 +441,42 @@ func BaseSSAWorkload(w *kueue.Workload) *kueue.Workload {
 	return wlCopy
 }
 
-// AdmissionPatch creates a new object based on the input workload that
-// contains the admission. The object can be used in Server-Side-Apply.
-func AdmissionPatch(w *kueue.Workload) *kueue.Workload {
-	wlCopy := BaseSSAWorkload(w)
-	wlCopy.Status.Admission = w.Status.Admission.DeepCopy()
-	return wlCopy
+// IsActive returns whether the workload can be queued for admission, that
+// is, .spec.active is not set to false.
+func IsActive(w *kueue.Workload) bool {
+	return pointer.BoolDeref(w.Spec.Active, true)
+}
+
+// Deactivate sets .spec.active of the workload to false, with a patch that
+// only carries that field, and its Deactivated condition to True with the
+// given reason and message. The admission is cancelled when the inactive
+// workload is reconciled.
+func Deactivate(ctx context.Context, c client.Client, w *kueue.Workload, reason, message string) error {
+	patch := client.MergeFrom(w.DeepCopy())
+	w.Spec.Active = pointer.Bool(false)
+	if err := c.Patch(ctx, w, patch); err != nil {
+		return err
+	}
+	return UpdateStatus(ctx, c, w, kueue.WorkloadDeactivated, metav1.ConditionTrue, reason, message, constants.AdmissionName)
+}
+
+// RequeueCount returns the number of times the workload was evicted and
+// requeued because its pods didn't become ready in time.
+func RequeueCount(w *kueue.Workload) int32 {
+	if w.Status.RequeueState == nil || w.Status.RequeueState.Count == nil {
+		return 0
+	}
+	return *w.Status.RequeueState.Count
+}
+
+// RemainingRequeueBackoff returns how long the workload, after being
+// evicted, still has to wait before it can be queued for admission again.
+func RemainingRequeueBackoff(w *kueue.Workload, now time.Time) time.Duration {
+	if w.Status.RequeueState == nil || w.Status.RequeueState.RequeueAt == nil {
+		return 0
+	}
+	if remaining := w.Status.RequeueState.RequeueAt.Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
 }
@@ -44,10 +44,46 @@ func TestNewInfo(t *testing.T) {
 			wantInfo: Info{
 				TotalRequests: []PodSetResources{
 					{
-						Name: "main",
+						Name:  "main",
+						Count: 1,
 						Requests: Requests{
-							corev1.ResourceCPU:    10,
-							corev1.ResourceMemory: 512 * 1024,
+							corev1.ResourceCPU:      10,
+							corev1.ResourceMemory:   512 * 1024,
+							kueue.ResourcePods:      1,
+							kueue.ResourceWorkloads: 1,
+						},
+					},
+				},
+			},
+		},
+		"pending with multiple pod sets": {
+			workload: *utiltesting.MakeWorkload("", "").
+				PodSets(
+					*utiltesting.MakePodSet("driver", 1).
+						Request(corev1.ResourceCPU, "10m").
+						Obj(),
+					*utiltesting.MakePodSet("workers", 3).
+						Request(corev1.ResourceCPU, "5m").
+						Obj(),
+				).
+				Obj(),
+			wantInfo: Info{
+				TotalRequests: []PodSetResources{
+					{
+						Name:  "driver",
+						Count: 1,
+						Requests: Requests{
+							corev1.ResourceCPU:      10,
+							kueue.ResourcePods:      1,
+							kueue.ResourceWorkloads: 1,
+						},
+					},
+					{
+						Name:  "workers",
+						Count: 3,
+						Requests: Requests{
+							corev1.ResourceCPU: 15,
+							kueue.ResourcePods: 3,
 						},
 					},
 				},
@@ -93,7 +129,8 @@ func TestNewInfo(t *testing.T) {
 				ClusterQueue: "foo",
 				TotalRequests: []PodSetResources{
 					{
-						Name: "driver",
+						Name:  "driver",
+						Count: 1,
 						Requests: Requests{
 							corev1.ResourceCPU:    10,
 							corev1.ResourceMemory: 512 * 1024,
@@ -103,7 +140,8 @@ func TestNewInfo(t *testing.T) {
 						},
 					},
 					{
-						Name: "workers",
+						Name:  "workers",
+						Count: 3,
 						Requests: Requests{
 							corev1.ResourceCPU:    15,
 							corev1.ResourceMemory: 3 * 1024 * 1024,
@@ -113,6 +151,46 @@ func TestNewInfo(t *testing.T) {
 				},
 			},
 		},
+		"admitted with reclaimable pods": {
+			workload: *utiltesting.MakeWorkload("", "").
+				PodSets(
+					*utiltesting.MakePodSet("workers", 4).
+						Request(corev1.ResourceCPU, "1").
+						Obj(),
+				).
+				Admit(utiltesting.MakeAdmission("foo").
+					PodSets(
+						kueue.PodSetAssignment{
+							Name: "workers",
+							Flavors: map[corev1.ResourceName]kueue.ResourceFlavorReference{
+								corev1.ResourceCPU: "on-demand",
+							},
+							ResourceUsage: corev1.ResourceList{
+								corev1.ResourceCPU:      resource.MustParse("4"),
+								kueue.ResourceWorkloads: resource.MustParse("1"),
+							},
+						},
+					).
+					Obj()).
+				ReclaimablePods(kueue.ReclaimablePod{Name: "workers", Count: 3}).
+				Obj(),
+			wantInfo: Info{
+				ClusterQueue: "foo",
+				TotalRequests: []PodSetResources{
+					{
+						Name:  "workers",
+						Count: 1,
+						Requests: Requests{
+							corev1.ResourceCPU:      1000,
+							kueue.ResourceWorkloads: 1,
+						},
+						Flavors: map[corev1.ResourceName]kueue.ResourceFlavorReference{
+							corev1.ResourceCPU: "on-demand",
+						},
+					},
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -124,6 +202,30 @@ func TestNewInfo(t *testing.T) {
 	}
 }
 
+func TestPodSetResourcesScaledTo(t *testing.T) {
+	psr := PodSetResources{
+		Name: "main",
+		Requests: Requests{
+			corev1.ResourceCPU:      4000,
+			kueue.ResourcePods:      4,
+			kueue.ResourceWorkloads: 1,
+		},
+		Count: 4,
+	}
+	want := PodSetResources{
+		Name: "main",
+		Requests: Requests{
+			corev1.ResourceCPU:      3000,
+			kueue.ResourcePods:      3,
+			kueue.ResourceWorkloads: 1,
+		},
+		Count: 3,
+	}
+	if diff := cmp.Diff(want, psr.ScaledTo(3)); diff != "" {
+		t.Errorf("ScaledTo(3) = (-want,+got):\n%s", diff)
+	}
+}
+
 var ignoreConditionTimestamps = cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")
 
 func TestUpdateWorkloadStatus(t *testing.T) {
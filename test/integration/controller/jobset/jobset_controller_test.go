@@ -0,0 +1,321 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobset
+
+import (
+	"fmt"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	jobsetapi "sigs.k8s.io/jobset/api/v1alpha1"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/controller/jobframework"
+	workloadjobset "sigs.k8s.io/kueue/pkg/controller/jobs/jobset"
+	"sigs.k8s.io/kueue/pkg/util/testing"
+	testingjobset "sigs.k8s.io/kueue/pkg/util/testingjobs/jobset"
+	"sigs.k8s.io/kueue/test/integration/framework"
+	"sigs.k8s.io/kueue/test/util"
+)
+
+const (
+	jobSetName        = "test-jobset"
+	jobSetNamespace   = "default"
+	priorityClassName = "test-priority-class"
+	priorityValue     = 10
+)
+
+var (
+	wlLookupKey = types.NamespacedName{Name: workloadjobset.GetWorkloadNameForJobSet(jobSetName), Namespace: jobSetNamespace}
+)
+
+// +kubebuilder:docs-gen:collapse=Imports
+
+var _ = ginkgo.Describe("JobSet controller", func() {
+
+	ginkgo.BeforeEach(func() {
+		fwk = &framework.Framework{
+			ManagerSetup: managerSetup(jobframework.WithManageJobsWithoutQueueName(true)),
+			CRDPath:      crdPath,
+			DepCRDPaths:  []string{jobsetCrdPath},
+		}
+
+		ctx, cfg, k8sClient = fwk.Setup()
+	})
+	ginkgo.AfterEach(func() {
+		fwk.Teardown()
+	})
+
+	ginkgo.It("Should reconcile JobSets", func() {
+		ginkgo.By("checking the JobSet gets suspended when created unsuspended")
+		priorityClass := testing.MakePriorityClass(priorityClassName).
+			PriorityValue(int32(priorityValue)).Obj()
+		gomega.Expect(k8sClient.Create(ctx, priorityClass)).Should(gomega.Succeed())
+
+		jobSet := testingjobset.MakeJobSet(jobSetName, jobSetNamespace).
+			ReplicatedJobs(
+				testingjobset.ReplicatedJobRequirements{Name: "leader", Replicas: 1, Parallelism: 1},
+				testingjobset.ReplicatedJobRequirements{Name: "workers", Replicas: 2, Parallelism: 3},
+			).
+			PriorityClass(priorityClassName).
+			Suspend(false).
+			Obj()
+		gomega.Expect(k8sClient.Create(ctx, jobSet)).Should(gomega.Succeed())
+		lookupKey := types.NamespacedName{Name: jobSetName, Namespace: jobSetNamespace}
+		createdJobSet := &jobsetapi.JobSet{}
+		gomega.Eventually(func() *bool {
+			if err := k8sClient.Get(ctx, lookupKey, createdJobSet); err != nil {
+				return nil
+			}
+			return createdJobSet.Spec.Suspend
+		}, util.Timeout, util.Interval).Should(gomega.Equal(pointer.Bool(true)))
+
+		ginkgo.By("checking the workload is created with a podSet per replicated job")
+		createdWorkload := &kueue.Workload{}
+		gomega.Eventually(func() error {
+			return k8sClient.Get(ctx, wlLookupKey, createdWorkload)
+		}, util.Timeout, util.Interval).Should(gomega.Succeed())
+		gomega.Expect(createdWorkload.Spec.QueueName).Should(gomega.Equal(""), "The Workload shouldn't have .spec.queueName set")
+		gomega.Expect(metav1.IsControlledBy(createdWorkload, createdJobSet)).To(gomega.BeTrue(), "The Workload should be owned by the JobSet")
+		gomega.Expect(createdWorkload.Spec.PodSets).Should(gomega.HaveLen(2))
+		gomega.Expect(createdWorkload.Spec.PodSets[0].Name).Should(gomega.Equal("leader"))
+		gomega.Expect(createdWorkload.Spec.PodSets[0].Count).Should(gomega.Equal(int32(1)))
+		gomega.Expect(createdWorkload.Spec.PodSets[1].Name).Should(gomega.Equal("workers"))
+		gomega.Expect(createdWorkload.Spec.PodSets[1].Count).Should(gomega.Equal(int32(6)))
+
+		ginkgo.By("checking the workload is created with priority and priorityName")
+		gomega.Expect(createdWorkload.Spec.PriorityClassName).Should(gomega.Equal(priorityClassName))
+		gomega.Expect(*createdWorkload.Spec.Priority).Should(gomega.Equal(int32(priorityValue)))
+
+		ginkgo.By("checking the workload is updated with queue name when the JobSet does")
+		jobSetQueueName := "test-queue"
+		createdJobSet.Labels = map[string]string{jobframework.QueueLabel: jobSetQueueName}
+		gomega.Expect(k8sClient.Update(ctx, createdJobSet)).Should(gomega.Succeed())
+		gomega.Eventually(func() string {
+			if err := k8sClient.Get(ctx, wlLookupKey, createdWorkload); err != nil {
+				return ""
+			}
+			return createdWorkload.Spec.QueueName
+		}, util.Timeout, util.Interval).Should(gomega.Equal(jobSetQueueName))
+
+		ginkgo.By("checking the JobSet is unsuspended when the workload is admitted")
+		defaultFlavor := testing.MakeResourceFlavor("default").Obj()
+		gomega.Expect(k8sClient.Create(ctx, defaultFlavor)).Should(gomega.Succeed())
+		clusterQueue := testing.MakeClusterQueue("cluster-queue").
+			ResourceGroup(
+				*testing.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "10").Obj(),
+			).Obj()
+		createdWorkload.Status.Admission = &kueue.Admission{
+			ClusterQueue: kueue.ClusterQueueReference(clusterQueue.Name),
+			PodSetAssignments: []kueue.PodSetAssignment{{
+				Name: "leader",
+				Flavors: map[corev1.ResourceName]kueue.ResourceFlavorReference{
+					corev1.ResourceCPU: "default",
+				},
+			}, {
+				Name: "workers",
+				Flavors: map[corev1.ResourceName]kueue.ResourceFlavorReference{
+					corev1.ResourceCPU: "default",
+				},
+			}},
+		}
+		gomega.Expect(k8sClient.Status().Update(ctx, createdWorkload)).Should(gomega.Succeed())
+		gomega.Eventually(func() *bool {
+			if err := k8sClient.Get(ctx, lookupKey, createdJobSet); err != nil {
+				return nil
+			}
+			return createdJobSet.Spec.Suspend
+		}, util.Timeout, util.Interval).Should(gomega.Equal(pointer.Bool(false)))
+		gomega.Eventually(func() bool {
+			ok, _ := testing.CheckLatestEvent(ctx, k8sClient, "Started", corev1.EventTypeNormal, fmt.Sprintf("Admitted by clusterQueue %v", clusterQueue.Name))
+			return ok
+		}, util.Timeout, util.Interval).Should(gomega.BeTrue())
+
+		ginkgo.By("checking the JobSet is suspended when the workload is evicted")
+		gomega.Eventually(func() error {
+			if err := k8sClient.Get(ctx, wlLookupKey, createdWorkload); err != nil {
+				return err
+			}
+			createdWorkload.Status.Admission = nil
+			return k8sClient.Status().Update(ctx, createdWorkload)
+		}, util.Timeout, util.Interval).Should(gomega.Succeed())
+		gomega.Eventually(func() *bool {
+			if err := k8sClient.Get(ctx, lookupKey, createdJobSet); err != nil {
+				return nil
+			}
+			return createdJobSet.Spec.Suspend
+		}, util.Timeout, util.Interval).Should(gomega.Equal(pointer.Bool(true)))
+
+		ginkgo.By("checking the workload is finished when the JobSet is completed")
+		apimeta.SetStatusCondition(&createdJobSet.Status.Conditions, metav1.Condition{
+			Type:    string(jobsetapi.JobSetCompleted),
+			Status:  metav1.ConditionTrue,
+			Reason:  "AllJobsCompleted",
+			Message: "jobset completed successfully",
+		})
+		gomega.Expect(k8sClient.Status().Update(ctx, createdJobSet)).Should(gomega.Succeed())
+		gomega.Eventually(func() bool {
+			if err := k8sClient.Get(ctx, wlLookupKey, createdWorkload); err != nil {
+				return false
+			}
+			return apimeta.IsStatusConditionTrue(createdWorkload.Status.Conditions, kueue.WorkloadFinished)
+		}, util.Timeout, util.Interval).Should(gomega.BeTrue())
+	})
+})
+
+var _ = ginkgo.Describe("JobSet controller for workloads when only jobs with queue are managed", func() {
+	ginkgo.BeforeEach(func() {
+		fwk = &framework.Framework{
+			ManagerSetup: managerSetup(),
+			CRDPath:      crdPath,
+			DepCRDPaths:  []string{jobsetCrdPath},
+		}
+		ctx, cfg, k8sClient = fwk.Setup()
+	})
+	ginkgo.AfterEach(func() {
+		fwk.Teardown()
+	})
+
+	ginkgo.It("Should reconcile JobSets only when queue is set", func() {
+		ginkgo.By("checking the workload is not created when queue name is not set")
+		jobSet := testingjobset.MakeJobSet(jobSetName, jobSetNamespace).
+			ReplicatedJobs(testingjobset.ReplicatedJobRequirements{Name: "workers", Replicas: 1, Parallelism: 1}).
+			Obj()
+		gomega.Expect(k8sClient.Create(ctx, jobSet)).Should(gomega.Succeed())
+		lookupKey := types.NamespacedName{Name: jobSetName, Namespace: jobSetNamespace}
+		createdJobSet := &jobsetapi.JobSet{}
+		gomega.Expect(k8sClient.Get(ctx, lookupKey, createdJobSet)).Should(gomega.Succeed())
+
+		createdWorkload := &kueue.Workload{}
+		gomega.Consistently(func() bool {
+			return apierrors.IsNotFound(k8sClient.Get(ctx, wlLookupKey, createdWorkload))
+		}, util.ConsistentDuration, util.Interval).Should(gomega.BeTrue())
+
+		ginkgo.By("checking the workload is created when queue name is set")
+		createdJobSet.Labels = map[string]string{jobframework.QueueLabel: "test-queue"}
+		gomega.Expect(k8sClient.Update(ctx, createdJobSet)).Should(gomega.Succeed())
+		gomega.Eventually(func() error {
+			return k8sClient.Get(ctx, wlLookupKey, createdWorkload)
+		}, util.Timeout, util.Interval).Should(gomega.Succeed())
+	})
+})
+
+var _ = ginkgo.Describe("JobSet controller interacting with scheduler", func() {
+	var (
+		ns            *corev1.Namespace
+		defaultFlavor *kueue.ResourceFlavor
+		clusterQueue  *kueue.ClusterQueue
+		localQueue    *kueue.LocalQueue
+	)
+
+	ginkgo.BeforeEach(func() {
+		fwk = &framework.Framework{
+			ManagerSetup: managerAndSchedulerSetup(),
+			CRDPath:      crdPath,
+			DepCRDPaths:  []string{jobsetCrdPath},
+		}
+		ctx, cfg, k8sClient = fwk.Setup()
+
+		ns = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "core-",
+			},
+		}
+		gomega.Expect(k8sClient.Create(ctx, ns)).To(gomega.Succeed())
+
+		defaultFlavor = testing.MakeResourceFlavor("default").Obj()
+		gomega.Expect(k8sClient.Create(ctx, defaultFlavor)).Should(gomega.Succeed())
+
+		clusterQueue = testing.MakeClusterQueue("dev-clusterqueue").
+			ResourceGroup(
+				*testing.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "5").Obj(),
+			).Obj()
+		gomega.Expect(k8sClient.Create(ctx, clusterQueue)).Should(gomega.Succeed())
+
+		localQueue = testing.MakeLocalQueue("local-queue", ns.Name).ClusterQueue(clusterQueue.Name).Obj()
+		gomega.Expect(k8sClient.Create(ctx, localQueue)).Should(gomega.Succeed())
+	})
+
+	ginkgo.AfterEach(func() {
+		gomega.Expect(util.DeleteNamespace(ctx, k8sClient, ns)).To(gomega.Succeed())
+		util.ExpectClusterQueueToBeDeleted(ctx, k8sClient, clusterQueue, true)
+		util.ExpectResourceFlavorToBeDeleted(ctx, k8sClient, defaultFlavor, true)
+
+		fwk.Teardown()
+	})
+
+	ginkgo.It("Should admit JobSets as they fit in their ClusterQueue", func() {
+		ginkgo.By("checking the first JobSet starts")
+		jobSet1 := testingjobset.MakeJobSet("dev-jobset1", ns.Name).Queue(localQueue.Name).
+			ReplicatedJobs(
+				testingjobset.ReplicatedJobRequirements{Name: "leader", Replicas: 1, Parallelism: 1},
+				testingjobset.ReplicatedJobRequirements{Name: "workers", Replicas: 1, Parallelism: 2},
+			).
+			Request("leader", corev1.ResourceCPU, "1").
+			Request("workers", corev1.ResourceCPU, "1").
+			Obj()
+		gomega.Expect(k8sClient.Create(ctx, jobSet1)).Should(gomega.Succeed())
+		createdJobSet1 := &jobsetapi.JobSet{}
+		gomega.Eventually(func() *bool {
+			gomega.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: jobSet1.Name, Namespace: ns.Name}, createdJobSet1)).
+				Should(gomega.Succeed())
+			return createdJobSet1.Spec.Suspend
+		}, util.Timeout, util.Interval).Should(gomega.Equal(pointer.Bool(false)))
+		util.ExpectPendingWorkloadsMetric(clusterQueue, 0, 0)
+		util.ExpectAdmittedActiveWorkloadsMetric(clusterQueue, 1)
+
+		ginkgo.By("checking a second JobSet that doesn't fit stays suspended")
+		jobSet2 := testingjobset.MakeJobSet("dev-jobset2", ns.Name).Queue(localQueue.Name).
+			ReplicatedJobs(
+				testingjobset.ReplicatedJobRequirements{Name: "leader", Replicas: 1, Parallelism: 1},
+				testingjobset.ReplicatedJobRequirements{Name: "workers", Replicas: 1, Parallelism: 2},
+			).
+			Request("leader", corev1.ResourceCPU, "1").
+			Request("workers", corev1.ResourceCPU, "1").
+			Obj()
+		gomega.Expect(k8sClient.Create(ctx, jobSet2)).Should(gomega.Succeed())
+		createdJobSet2 := &jobsetapi.JobSet{}
+		gomega.Consistently(func() *bool {
+			gomega.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: jobSet2.Name, Namespace: ns.Name}, createdJobSet2)).
+				Should(gomega.Succeed())
+			return createdJobSet2.Spec.Suspend
+		}, util.ConsistentDuration, util.Interval).Should(gomega.Equal(pointer.Bool(true)))
+		util.ExpectPendingWorkloadsMetric(clusterQueue, 0, 1)
+
+		ginkgo.By("checking the second JobSet starts when the first one finishes")
+		apimeta.SetStatusCondition(&createdJobSet1.Status.Conditions, metav1.Condition{
+			Type:    string(jobsetapi.JobSetCompleted),
+			Status:  metav1.ConditionTrue,
+			Reason:  "AllJobsCompleted",
+			Message: "jobset completed successfully",
+		})
+		gomega.Expect(k8sClient.Status().Update(ctx, createdJobSet1)).Should(gomega.Succeed())
+		gomega.Eventually(func() *bool {
+			gomega.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: jobSet2.Name, Namespace: ns.Name}, createdJobSet2)).
+				Should(gomega.Succeed())
+			return createdJobSet2.Spec.Suspend
+		}, util.Timeout, util.Interval).Should(gomega.Equal(pointer.Bool(false)))
+		util.ExpectPendingWorkloadsMetric(clusterQueue, 0, 0)
+		util.ExpectAdmittedActiveWorkloadsMetric(clusterQueue, 1)
+	})
+})
@@ -126,6 +126,16 @@ func (f *Framework) Setup() (context.Context, *rest.Config, client.Client) {
 	return ctx, cfg, k8sClient
 }
 
+// Kubeconfig returns a kubeconfig granting admin access to the API server of
+// the test environment.
+func (f *Framework) Kubeconfig() []byte {
+	user, err := f.testEnv.AddUser(envtest.User{Name: "kueue-admin", Groups: []string{"system:masters"}}, nil)
+	gomega.ExpectWithOffset(1, err).NotTo(gomega.HaveOccurred())
+	kubeconfig, err := user.KubeConfig()
+	gomega.ExpectWithOffset(1, err).NotTo(gomega.HaveOccurred())
+	return kubeconfig
+}
+
 func (f *Framework) Teardown() {
 	ginkgo.By("tearing down the test environment")
 	f.cancel()
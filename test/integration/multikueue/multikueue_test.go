@@ -0,0 +1,218 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multikueue
+
+import (
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	workloadjob "sigs.k8s.io/kueue/pkg/controller/jobs/job"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+	testingjob "sigs.k8s.io/kueue/pkg/util/testingjobs/job"
+	"sigs.k8s.io/kueue/pkg/workload"
+	"sigs.k8s.io/kueue/test/util"
+)
+
+var _ = ginkgo.Describe("MultiKueue", func() {
+	var (
+		managerNs *corev1.Namespace
+		worker1Ns *corev1.Namespace
+		worker2Ns *corev1.Namespace
+
+		managerFlavor *kueue.ResourceFlavor
+		managerCq     *kueue.ClusterQueue
+		managerLq     *kueue.LocalQueue
+		config        *kueue.MultiKueueConfig
+		ac            *kueue.AdmissionCheck
+		secrets       []*corev1.Secret
+
+		workerFlavors []*kueue.ResourceFlavor
+		workerCqs     []*kueue.ClusterQueue
+		workerLqs     []*kueue.LocalQueue
+	)
+
+	ginkgo.BeforeEach(func() {
+		managerNs = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "multikueue-",
+			},
+		}
+		gomega.Expect(managerCluster.client.Create(managerCluster.ctx, managerNs)).To(gomega.Succeed())
+
+		// The jobs are copied to the namespace with the same name in the
+		// worker clusters.
+		worker1Ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: managerNs.Name}}
+		gomega.Expect(worker1Cluster.client.Create(worker1Cluster.ctx, worker1Ns)).To(gomega.Succeed())
+		worker2Ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: managerNs.Name}}
+		gomega.Expect(worker2Cluster.client.Create(worker2Cluster.ctx, worker2Ns)).To(gomega.Succeed())
+
+		secrets = nil
+		for name, worker := range map[string]cluster{"worker1": worker1Cluster, "worker2": worker2Cluster} {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      managerNs.Name + "-" + name,
+					Namespace: managerNamespace,
+				},
+				Data: map[string][]byte{"kubeconfig": worker.fwk.Kubeconfig()},
+			}
+			gomega.Expect(managerCluster.client.Create(managerCluster.ctx, secret)).To(gomega.Succeed())
+			secrets = append(secrets, secret)
+		}
+
+		config = utiltesting.MakeMultiKueueConfig(managerNs.Name).
+			Cluster("worker1", managerNs.Name+"-worker1").
+			Cluster("worker2", managerNs.Name+"-worker2").
+			Obj()
+		gomega.Expect(managerCluster.client.Create(managerCluster.ctx, config)).To(gomega.Succeed())
+
+		ac = utiltesting.MakeAdmissionCheck(managerNs.Name, kueue.MultiKueueControllerName).
+			Parameters(kueue.GroupVersion.Group, "MultiKueueConfig", config.Name).
+			Obj()
+		gomega.Expect(managerCluster.client.Create(managerCluster.ctx, ac)).To(gomega.Succeed())
+
+		ginkgo.By("waiting for the admission check to be active", func() {
+			gomega.Eventually(func(g gomega.Gomega) {
+				var updatedAc kueue.AdmissionCheck
+				g.Expect(managerCluster.client.Get(managerCluster.ctx, client.ObjectKeyFromObject(ac), &updatedAc)).To(gomega.Succeed())
+				g.Expect(apimeta.IsStatusConditionTrue(updatedAc.Status.Conditions, kueue.AdmissionCheckActive)).To(gomega.BeTrue())
+			}, util.Timeout, util.Interval).Should(gomega.Succeed())
+		})
+
+		managerFlavor = utiltesting.MakeResourceFlavor("default").Obj()
+		gomega.Expect(managerCluster.client.Create(managerCluster.ctx, managerFlavor)).To(gomega.Succeed())
+		managerCq = utiltesting.MakeClusterQueue("q1").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "5").Obj()).
+			AdmissionChecks(ac.Name).
+			Obj()
+		gomega.Expect(managerCluster.client.Create(managerCluster.ctx, managerCq)).To(gomega.Succeed())
+		managerLq = utiltesting.MakeLocalQueue("q1", managerNs.Name).ClusterQueue(managerCq.Name).Obj()
+		gomega.Expect(managerCluster.client.Create(managerCluster.ctx, managerLq)).To(gomega.Succeed())
+
+		workerFlavors, workerCqs, workerLqs = nil, nil, nil
+		for _, worker := range []cluster{worker1Cluster, worker2Cluster} {
+			flavor := utiltesting.MakeResourceFlavor("default").Obj()
+			gomega.Expect(worker.client.Create(worker.ctx, flavor)).To(gomega.Succeed())
+			workerFlavors = append(workerFlavors, flavor)
+			cq := utiltesting.MakeClusterQueue("q1").
+				ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "5").Obj()).
+				Obj()
+			gomega.Expect(worker.client.Create(worker.ctx, cq)).To(gomega.Succeed())
+			workerCqs = append(workerCqs, cq)
+			lq := utiltesting.MakeLocalQueue("q1", managerNs.Name).ClusterQueue(cq.Name).Obj()
+			gomega.Expect(worker.client.Create(worker.ctx, lq)).To(gomega.Succeed())
+			workerLqs = append(workerLqs, lq)
+		}
+	})
+
+	ginkgo.AfterEach(func() {
+		gomega.Expect(util.DeleteNamespace(managerCluster.ctx, managerCluster.client, managerNs)).To(gomega.Succeed())
+		gomega.Expect(util.DeleteNamespace(worker1Cluster.ctx, worker1Cluster.client, worker1Ns)).To(gomega.Succeed())
+		gomega.Expect(util.DeleteNamespace(worker2Cluster.ctx, worker2Cluster.client, worker2Ns)).To(gomega.Succeed())
+
+		util.ExpectClusterQueueToBeDeleted(managerCluster.ctx, managerCluster.client, managerCq, true)
+		util.ExpectResourceFlavorToBeDeleted(managerCluster.ctx, managerCluster.client, managerFlavor, true)
+		gomega.Expect(managerCluster.client.Delete(managerCluster.ctx, ac)).To(gomega.Succeed())
+		gomega.Expect(managerCluster.client.Delete(managerCluster.ctx, config)).To(gomega.Succeed())
+		for _, secret := range secrets {
+			gomega.Expect(managerCluster.client.Delete(managerCluster.ctx, secret)).To(gomega.Succeed())
+		}
+		for i, worker := range []cluster{worker1Cluster, worker2Cluster} {
+			util.ExpectClusterQueueToBeDeleted(worker.ctx, worker.client, workerCqs[i], true)
+			util.ExpectResourceFlavorToBeDeleted(worker.ctx, worker.client, workerFlavors[i], true)
+		}
+	})
+
+	ginkgo.It("Should run a job in one of the worker clusters", func() {
+		job := testingjob.MakeJob("job", managerNs.Name).
+			Queue(managerLq.Name).
+			Request(corev1.ResourceCPU, "1").
+			Obj()
+		gomega.Expect(managerCluster.client.Create(managerCluster.ctx, job)).To(gomega.Succeed())
+
+		wlKey := types.NamespacedName{Name: workloadjob.GetWorkloadNameForJob(job.Name), Namespace: managerNs.Name}
+		jobKey := client.ObjectKeyFromObject(job)
+
+		var runningIn cluster
+		ginkgo.By("waiting for the workload to be admitted through the worker clusters", func() {
+			gomega.Eventually(func(g gomega.Gomega) {
+				var wl kueue.Workload
+				g.Expect(managerCluster.client.Get(managerCluster.ctx, wlKey, &wl)).To(gomega.Succeed())
+				g.Expect(workload.IsAdmitted(&wl)).To(gomega.BeTrue())
+			}, util.Timeout, util.Interval).Should(gomega.Succeed())
+
+			gomega.Eventually(func(g gomega.Gomega) {
+				found := 0
+				for _, worker := range []cluster{worker1Cluster, worker2Cluster} {
+					var remoteJob batchv1.Job
+					err := worker.client.Get(worker.ctx, jobKey, &remoteJob)
+					if client.IgnoreNotFound(err) != nil {
+						g.Expect(err).NotTo(gomega.HaveOccurred())
+					}
+					if err == nil {
+						found++
+						runningIn = worker
+					}
+				}
+				g.Expect(found).To(gomega.Equal(1))
+			}, util.Timeout, util.Interval).Should(gomega.Succeed())
+		})
+
+		ginkgo.By("checking that the local job stays suspended", func() {
+			gomega.Consistently(func(g gomega.Gomega) {
+				var localJob batchv1.Job
+				g.Expect(managerCluster.client.Get(managerCluster.ctx, jobKey, &localJob)).To(gomega.Succeed())
+				g.Expect(localJob.Spec.Suspend).To(gomega.Equal(pointer.Bool(true)))
+			}, util.ConsistentDuration, util.Interval).Should(gomega.Succeed())
+		})
+
+		ginkgo.By("finishing the remote job", func() {
+			gomega.Eventually(func(g gomega.Gomega) {
+				var remoteJob batchv1.Job
+				g.Expect(runningIn.client.Get(runningIn.ctx, jobKey, &remoteJob)).To(gomega.Succeed())
+				remoteJob.Status.Conditions = append(remoteJob.Status.Conditions, batchv1.JobCondition{
+					Type:               batchv1.JobComplete,
+					Status:             corev1.ConditionTrue,
+					LastProbeTime:      metav1.Now(),
+					LastTransitionTime: metav1.Now(),
+				})
+				g.Expect(runningIn.client.Status().Update(runningIn.ctx, &remoteJob)).To(gomega.Succeed())
+			}, util.Timeout, util.Interval).Should(gomega.Succeed())
+		})
+
+		ginkgo.By("checking that the local workload finishes and the remote objects are removed", func() {
+			gomega.Eventually(func(g gomega.Gomega) {
+				var wl kueue.Workload
+				g.Expect(managerCluster.client.Get(managerCluster.ctx, wlKey, &wl)).To(gomega.Succeed())
+				g.Expect(apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadFinished)).To(gomega.BeTrue())
+			}, util.Timeout, util.Interval).Should(gomega.Succeed())
+
+			gomega.Eventually(func(g gomega.Gomega) {
+				var remoteWl kueue.Workload
+				err := runningIn.client.Get(runningIn.ctx, wlKey, &remoteWl)
+				g.Expect(err).To(utiltesting.BeNotFoundError())
+			}, util.Timeout, util.Interval).Should(gomega.Succeed())
+		})
+	})
+})
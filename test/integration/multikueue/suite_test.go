@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multikueue
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	config "sigs.k8s.io/kueue/apis/config/v1beta1"
+	"sigs.k8s.io/kueue/pkg/cache"
+	"sigs.k8s.io/kueue/pkg/constants"
+	"sigs.k8s.io/kueue/pkg/controller/admissionchecks/multikueue"
+	"sigs.k8s.io/kueue/pkg/controller/core"
+	"sigs.k8s.io/kueue/pkg/controller/core/indexer"
+	workloadjob "sigs.k8s.io/kueue/pkg/controller/jobs/job"
+	"sigs.k8s.io/kueue/pkg/queue"
+	"sigs.k8s.io/kueue/pkg/scheduler"
+	"sigs.k8s.io/kueue/test/integration/framework"
+	//+kubebuilder:scaffold:imports
+)
+
+// managerNamespace is the namespace of the Secrets with the kubeconfigs of
+// the worker clusters.
+const managerNamespace = "default"
+
+type cluster struct {
+	fwk    *framework.Framework
+	ctx    context.Context
+	client client.Client
+}
+
+var (
+	managerCluster cluster
+	worker1Cluster cluster
+	worker2Cluster cluster
+)
+
+func TestMultiKueue(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	ginkgo.RunSpecs(t,
+		"MultiKueue Suite",
+	)
+}
+
+func createCluster(setup framework.ManagerSetup) cluster {
+	c := cluster{
+		fwk: &framework.Framework{
+			ManagerSetup: setup,
+			CRDPath:      filepath.Join("..", "..", "..", "config", "components", "crd", "bases"),
+		},
+	}
+	c.ctx, _, c.client = c.fwk.Setup()
+	return c
+}
+
+var _ = ginkgo.BeforeSuite(func() {
+	managerCluster = createCluster(managerSetup)
+	worker1Cluster = createCluster(workerSetup)
+	worker2Cluster = createCluster(workerSetup)
+})
+
+var _ = ginkgo.AfterSuite(func() {
+	managerCluster.fwk.Teardown()
+	worker1Cluster.fwk.Teardown()
+	worker2Cluster.fwk.Teardown()
+})
+
+// workerSetup runs Kueue with the Job integration.
+func workerSetup(mgr manager.Manager, ctx context.Context) {
+	err := indexer.Setup(ctx, mgr.GetFieldIndexer())
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	cCache := cache.New(mgr.GetClient())
+	queues := queue.NewManager(mgr.GetClient(), cCache)
+
+	failedCtrl, err := core.SetupControllers(mgr, queues, cCache, &config.Configuration{})
+	gomega.Expect(err).ToNot(gomega.HaveOccurred(), "controller", failedCtrl)
+
+	err = workloadjob.SetupIndexes(ctx, mgr.GetFieldIndexer())
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	err = workloadjob.NewReconciler(mgr.GetScheme(), mgr.GetClient(),
+		mgr.GetEventRecorderFor(constants.JobControllerName)).SetupWithManager(mgr)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	sched := scheduler.New(queues, cCache, mgr.GetClient(), mgr.GetEventRecorderFor(constants.AdmissionName))
+	err = sched.Start(ctx)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+// managerSetup runs Kueue with the Job integration and MultiKueue.
+func managerSetup(mgr manager.Manager, ctx context.Context) {
+	workerSetup(mgr, ctx)
+
+	err := multikueue.SetupControllers(mgr, managerNamespace)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+}
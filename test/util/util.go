@@ -25,6 +25,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	nodev1 "k8s.io/api/node/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/component-base/metrics/testutil"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -138,6 +139,17 @@ func SetAdmission(ctx context.Context, k8sClient client.Client, wl *kueue.Worklo
 		var newWL kueue.Workload
 		gomega.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(wl), &newWL)).To(gomega.Succeed())
 		newWL.Status.Admission = admission
+		status, reason := metav1.ConditionTrue, "AdmittedByTest"
+		if admission == nil {
+			status, reason = metav1.ConditionFalse, "PendingByTest"
+		}
+		for _, condType := range []string{kueue.WorkloadQuotaReserved, kueue.WorkloadAdmitted} {
+			apimeta.SetStatusCondition(&newWL.Status.Conditions, metav1.Condition{
+				Type:   condType,
+				Status: status,
+				Reason: reason,
+			})
+		}
 		return k8sClient.Status().Update(ctx, &newWL)
 	}, Timeout, Interval).Should(gomega.Succeed())
 }
@@ -298,6 +310,12 @@ func AdmitWorkload(ctx context.Context, k8sClient client.Client, wl *kueue.Workl
 	}
 	wl.Status.Admission = admission
 	wl.Status.Conditions = []metav1.Condition{{
+		Type:               kueue.WorkloadQuotaReserved,
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "AdmittedByTest",
+		Message:            fmt.Sprintf("Quota reserved in ClusterQueue %s", wl.Status.Admission.ClusterQueue),
+	}, {
 		Type:               kueue.WorkloadAdmitted,
 		Status:             metav1.ConditionTrue,
 		LastTransitionTime: metav1.Now(),